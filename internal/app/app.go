@@ -7,14 +7,19 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 
 	"github.com/AGENT3128/shortener-url/internal/config"
+	"github.com/AGENT3128/shortener-url/internal/controller/grpcapi"
 	"github.com/AGENT3128/shortener-url/internal/controller/httpapi"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
 	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/infrastructure/grpcserver"
 	"github.com/AGENT3128/shortener-url/internal/infrastructure/httpserver"
 	"github.com/AGENT3128/shortener-url/internal/logger"
 	"github.com/AGENT3128/shortener-url/internal/repository/file"
@@ -23,16 +28,17 @@ import (
 	"github.com/AGENT3128/shortener-url/internal/usecase"
 	"github.com/AGENT3128/shortener-url/internal/worker"
 	"github.com/AGENT3128/shortener-url/pkg/database"
+	"github.com/AGENT3128/shortener-url/pkg/shorneter"
 )
 
 // URLSaver is an interface that defines the methods for saving a URL.
 type URLSaver interface {
-	Add(ctx context.Context, userID, shortURL, originalURL string) (string, error)
+	Add(ctx context.Context, userID string, url entity.URL) (string, error)
 }
 
 // URLGetter is an interface that defines the methods for getting a URL.
 type URLGetter interface {
-	GetByOriginalURL(ctx context.Context, originalURL string) (string, error)
+	GetByOriginalURL(ctx context.Context, userID, originalURL string) (string, error)
 	GetByShortURL(ctx context.Context, shortURL string) (string, error)
 }
 
@@ -53,7 +59,64 @@ type UserURLGetter interface {
 
 // URLDeleter is an interface that defines the method for deleting a URL.
 type URLDeleter interface {
-	MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) error
+	MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error)
+}
+
+// URLRestorer is an interface that defines the method for restoring deleted URLs.
+type URLRestorer interface {
+	MarkRestoredBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error)
+}
+
+// URLUpdater is an interface that defines the method for changing the destination of a URL.
+// It returns the previous destinations of the short URL, the oldest first.
+type URLUpdater interface {
+	UpdateOriginalURL(ctx context.Context, userID, shortURL, originalURL string) ([]entity.URLChange, error)
+}
+
+// URLVisitor is an interface that defines the method for following a short URL.
+type URLVisitor interface {
+	Visit(ctx context.Context, shortURL string) (string, error)
+}
+
+// URLExpirer is an interface that defines the method for deleting expired URLs.
+type URLExpirer interface {
+	MarkExpiredDeleted(ctx context.Context, now time.Time) (int64, error)
+}
+
+// URLPurger is an interface that defines the method for permanently removing deleted URLs.
+type URLPurger interface {
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int64, error)
+}
+
+// ClickSaver is an interface that defines the method for saving click events.
+type ClickSaver interface {
+	AddClicks(ctx context.Context, clicks []entity.Click) error
+}
+
+// ClickStatsGetter is an interface that defines the method for getting the click statistics of a URL.
+type ClickStatsGetter interface {
+	GetClickStats(ctx context.Context, userID, shortURL string) (entity.URLStats, error)
+}
+
+// ServiceStatsGetter is an interface that defines the method for counting the URLs and users of the service.
+type ServiceStatsGetter interface {
+	GetServiceStats(ctx context.Context) (entity.ServiceStats, error)
+}
+
+// APIKeySaver is an interface that defines the method for storing an API key.
+type APIKeySaver interface {
+	AddAPIKey(ctx context.Context, key entity.APIKey) error
+}
+
+// APIKeyGetter is an interface that defines the methods for getting the stored API keys.
+type APIKeyGetter interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID string) ([]entity.APIKey, error)
+}
+
+// APIKeyDeleter is an interface that defines the method for deleting the API keys of a user.
+type APIKeyDeleter interface {
+	DeleteAPIKeys(ctx context.Context, userID string, ids []string) ([]string, error)
 }
 
 // Closer is an interface that defines the method for closing the repository.
@@ -61,6 +124,12 @@ type Closer interface {
 	Close() error
 }
 
+// URLSequenceGetter is an interface that defines the method for getting the number of URLs ever added.
+// The counter based short URL generators start from it, so they do not reissue stored short URLs after a restart.
+type URLSequenceGetter interface {
+	GetURLSequence(ctx context.Context) (int64, error)
+}
+
 // Repository is an interface that defines the methods for the repository.
 type Repository interface {
 	URLSaver
@@ -69,7 +138,176 @@ type Repository interface {
 	BatchURLSaver
 	UserURLGetter
 	URLDeleter
+	URLRestorer
+	URLUpdater
+	URLVisitor
+	URLExpirer
+	URLPurger
+	ClickSaver
+	ClickStatsGetter
+	ServiceStatsGetter
+	APIKeySaver
+	APIKeyGetter
+	APIKeyDeleter
 	Closer
+	URLSequenceGetter
+}
+
+// newGenerator creates the short URL generator configured by cfg.
+// The counter based generators continue from the URL sequence of the repository,
+// so they do not reissue the stored short URLs after a restart.
+func newGenerator(ctx context.Context, cfg *config.Config, repository URLSequenceGetter) (shorneter.Generator, error) {
+	sequence, err := repository.GetURLSequence(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get url sequence: %w", err)
+	}
+	opts := []shorneter.Option{
+		shorneter.WithSalt(cfg.ShortURLSalt),
+		shorneter.WithCounterStart(uint64(max(sequence, 0))),
+	}
+	if cfg.ShortURLAlphabet != "" {
+		opts = append(opts, shorneter.WithAlphabet(cfg.ShortURLAlphabet))
+	}
+	return shorneter.NewGenerator(shorneter.Strategy(cfg.ShortURLGenerator), opts...)
+}
+
+// splitList splits a comma separated list dropping blank items.
+func splitList(list string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// startWorkers runs the background job types on a new worker manager.
+func startWorkers(
+	cfg *config.Config,
+	logger *zap.Logger,
+	repository Repository,
+	deleteWorker *worker.DeleteWorker,
+	clickWorker *worker.ClickWorker,
+) (*worker.Manager, error) {
+	type jobType struct {
+		handler worker.Handler
+		name    string
+		opts    []worker.TypeOption
+	}
+	jobTypes := []jobType{
+		{
+			name:    worker.DeleteJobType,
+			handler: deleteWorker,
+			opts: []worker.TypeOption{
+				worker.WithBatchSize(cfg.DeleteBatchSize),
+				worker.WithFlushInterval(cfg.DeleteFlushInterval),
+				worker.WithDrainOnShutdown(),
+			},
+		},
+		{
+			name:    worker.ClickJobType,
+			handler: clickWorker,
+			opts: []worker.TypeOption{
+				worker.WithBatchSize(cfg.ClickBatchSize),
+				worker.WithFlushInterval(cfg.ClickFlushInterval),
+				worker.WithDrainOnShutdown(),
+			},
+		},
+		{
+			name:    worker.ExpiryJobType,
+			handler: worker.NewExpirySweeper(repository, logger),
+			opts:    []worker.TypeOption{worker.WithFlushInterval(cfg.ExpiredURLSweepInterval)},
+		},
+	}
+	if cfg.DeletedURLRetention > 0 {
+		jobTypes = append(jobTypes, jobType{
+			name:    worker.PurgeJobType,
+			handler: worker.NewRetentionPurger(repository, logger, cfg.DeletedURLRetention),
+			opts: []worker.TypeOption{
+				worker.WithBatchSize(cfg.DeletedURLPurgeBatchSize),
+				worker.WithFlushInterval(cfg.DeletedURLPurgeInterval),
+			},
+		})
+	}
+	if cfg.HealthCheckInterval > 0 {
+		jobTypes = append(jobTypes, jobType{
+			name:    worker.HealthJobType,
+			handler: worker.NewHealthChecker(repository, logger),
+			opts:    []worker.TypeOption{worker.WithFlushInterval(cfg.HealthCheckInterval)},
+		})
+	}
+
+	workers := worker.NewManager(logger, worker.WithPoolSize(cfg.WorkerPoolSize))
+	for _, t := range jobTypes {
+		if err := workers.Register(t.name, t.handler, t.opts...); err != nil {
+			if errShutdown := workers.Shutdown(context.Background()); errShutdown != nil {
+				logger.Error("failed to shut down workers", zap.Error(errShutdown))
+			}
+			return nil, err
+		}
+	}
+	return workers, nil
+}
+
+// newTokenManager creates the manager of the user tokens configured by cfg.
+// The keys file, when set, replaces the listed keys.
+// Without keys it fails unless the ephemeral key is enabled for development.
+func newTokenManager(cfg *config.Config, logger *zap.Logger) (*middleware.TokenManager, error) {
+	list := cfg.JWTKeys
+	if cfg.JWTKeysFile != "" {
+		content, err := os.ReadFile(cfg.JWTKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwt keys file: %w", err)
+		}
+		list = string(content)
+	}
+	keys, err := middleware.ParseSigningKeys(list)
+	if err != nil {
+		return nil, err
+	}
+	opts := []middleware.OptionTokenManager{
+		middleware.WithTokenKeys(keys...),
+		middleware.WithTokenTTL(cfg.JWTTTL),
+		middleware.WithTokenIssuer(cfg.JWTIssuer),
+		middleware.WithTokenAudience(cfg.JWTAudience),
+	}
+	if len(keys) == 0 {
+		if !cfg.JWTEphemeralKey {
+			return nil, errors.New("no JWT keys configured: set the JWT keys or the JWT keys file")
+		}
+		logger.Warn("JWT ephemeral key enabled, user tokens are signed with a random key and expire on restart")
+		opts = append(opts, middleware.WithEphemeralTokenKey())
+	}
+	return middleware.NewTokenManager(opts...)
+}
+
+// newGRPCServer creates the gRPC server of the API on the URL usecase.
+// It serves TLS with the certificate of the HTTP server when HTTPS is enabled.
+func newGRPCServer(
+	cfg *config.Config,
+	logger *zap.Logger,
+	tokens *middleware.TokenManager,
+	urlUsecase *usecase.URLUsecase,
+) (*grpcserver.Server, error) {
+	opts := []grpcapi.Option{
+		grpcapi.WithLogger(logger),
+		grpcapi.WithTokenManager(tokens),
+		grpcapi.WithBaseURL(cfg.BaseURLAddress),
+		grpcapi.WithURLUsecase(urlUsecase),
+		grpcapi.WithTrustedSubnet(cfg.TrustedSubnet),
+	}
+	if cfg.EnableHTTPS {
+		opts = append(opts, grpcapi.WithTLSCertPath(cfg.TLSCertPath), grpcapi.WithTLSKeyPath(cfg.TLSKeyPath))
+	}
+	server, err := grpcapi.NewServer(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return grpcserver.New(
+		grpcserver.WithAddress(cfg.GRPCServerAddress),
+		grpcserver.WithServer(server),
+	)
 }
 
 // Run is the main function for running the application.
@@ -108,39 +346,106 @@ func Run(cfg *config.Config) error {
 
 	// repositories
 	var urlRepository Repository
+	var deleteQueue worker.DeleteQueue
 
+	dedupScope, err := entity.ParseDedupScope(cfg.DedupScope)
+	if err != nil {
+		return fmt.Errorf("failed to parse dedup scope: %w", err)
+	}
 	switch {
 	case cfg.DatabaseDSN != "":
-		urlRepository = postgres.NewURLRepository(db, logger)
+		urlRepository = postgres.NewURLRepository(db, logger, postgres.WithDedupScope(dedupScope))
+		deleteQueue = postgres.NewDeleteQueue(db, logger)
 	case cfg.FileStoragePath != "":
-		urlRepository, err = file.NewFileStorage(cfg.FileStoragePath, logger)
+		syncPolicy, errPolicy := file.ParseSyncPolicy(cfg.FileStorageSyncPolicy)
+		if errPolicy != nil {
+			return fmt.Errorf("failed to parse file storage sync policy: %w", errPolicy)
+		}
+		corruptionPolicy, errPolicy := file.ParseCorruptionPolicy(cfg.FileStorageOnCorruption)
+		if errPolicy != nil {
+			return fmt.Errorf("failed to parse file storage corruption policy: %w", errPolicy)
+		}
+		urlRepository, err = file.NewFileStorage(
+			cfg.FileStoragePath,
+			logger,
+			file.WithSyncPolicy(syncPolicy),
+			file.WithSyncInterval(cfg.FileStorageSyncInterval),
+			file.WithSaveTicker(cfg.FileStorageCompactInterval),
+			file.WithCorruptionPolicy(corruptionPolicy),
+			file.WithDedupScope(dedupScope),
+		)
 		if err != nil {
 			return fmt.Errorf("failed to create file storage: %w", err)
 		}
+		deleteQueue, err = file.NewDeleteSpool(cfg.FileStoragePath, logger)
+		if err != nil {
+			return fmt.Errorf("failed to create delete spool: %w", err)
+		}
+	case cfg.MemoryStorageShards > 1:
+		urlRepository, err = memory.NewShardedMemStorage(
+			logger,
+			memory.WithShardCount(cfg.MemoryStorageShards),
+			memory.WithShardedDedupScope(dedupScope),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create sharded memory storage: %w", err)
+		}
+		deleteQueue = worker.NewMemoryDeleteQueue()
 	default:
-		urlRepository = memory.NewMemStorage(logger)
+		urlRepository = memory.NewMemStorage(logger, memory.WithDedupScope(dedupScope))
+		deleteQueue = worker.NewMemoryDeleteQueue()
 	}
 
-	// worker
+	// workers
 	deleteWorker := worker.NewDeleteWorker(
 		urlRepository,
+		deleteQueue,
 		logger,
+		worker.WithJobRetention(cfg.DeleteJobRetention),
+		worker.WithDeadLetterRetention(cfg.DeleteDeadLetterRetention),
+		worker.WithConcurrency(cfg.DeleteConcurrency),
+		worker.WithMaxAttempts(cfg.DeleteMaxAttempts),
+		worker.WithRetryBackoff(cfg.DeleteRetryBackoff, cfg.DeleteRetryMaxBackoff),
 	)
+	clickWorker := worker.NewClickWorker(urlRepository, logger)
+	workers, err := startWorkers(cfg, logger, urlRepository, deleteWorker, clickWorker)
+	if err != nil {
+		return fmt.Errorf("failed to start workers: %w", err)
+	}
+
+	generator, err := newGenerator(ctx, cfg, urlRepository)
+	if err != nil {
+		return fmt.Errorf("failed to create short url generator: %w", err)
+	}
 
 	// usecases
 	urlUsecase, err := usecase.NewURLUsecase(
 		usecase.WithURLUsecaseLogger(logger),
 		usecase.WithURLUsecaseRepository(urlRepository),
 		usecase.WithDeleteWorker(deleteWorker),
+		usecase.WithClickWorker(clickWorker),
+		usecase.WithWorkers(workers),
+		usecase.WithGenerator(generator),
+		usecase.WithShortURLLength(cfg.ShortURLLength),
+		usecase.WithShortURLAttempts(cfg.ShortURLAttempts),
+		usecase.WithAliasPattern(cfg.ShortURLAliasPattern),
+		usecase.WithReservedAliases(splitList(cfg.ShortURLReservedAliases)),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create url usecase: %w", err)
 	}
 
+	tokens, err := newTokenManager(cfg, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create token manager: %w", err)
+	}
+
 	router, err := httpapi.NewRouter(
 		httpapi.WithLogger(logger),
+		httpapi.WithTokenManager(tokens),
 		httpapi.WithBaseURL(cfg.BaseURLAddress),
 		httpapi.WithURLUsecase(urlUsecase),
+		httpapi.WithTrustedSubnet(cfg.TrustedSubnet),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create router: %w", err)
@@ -161,8 +466,34 @@ func Run(cfg *config.Config) error {
 		return fmt.Errorf("failed to create server: %w", err)
 	}
 
+	// the gRPC server runs only when its address is configured
+	var grpcServer *grpcserver.Server
+	if cfg.GRPCServerAddress != "" {
+		grpcServer, err = newGRPCServer(cfg, logger, tokens, urlUsecase)
+		if err != nil {
+			return fmt.Errorf("failed to create grpc server: %w", err)
+		}
+	}
+
 	serverCtx, serverCancel := context.WithCancel(context.Background())
-	gracefulShutdown(serverCtx, serverCancel, logger, httpserver, cfg.GracefulShutdownTimeout, urlUsecase)
+	gracefulShutdown(
+		serverCtx,
+		serverCancel,
+		logger,
+		httpserver,
+		grpcServer,
+		cfg.GracefulShutdownTimeout,
+		urlUsecase,
+	)
+
+	if grpcServer != nil {
+		go func() {
+			logger.Info("Starting gRPC server", zap.String("address", grpcServer.Address()))
+			if errRun := grpcServer.Start(); errRun != nil && !errors.Is(errRun, grpc.ErrServerStopped) {
+				logger.Fatal("Error starting gRPC server", zap.Error(errRun))
+			}
+		}()
+	}
 
 	// TODO: may be rework to receive from a channel and blocking select to run multiple servers
 	logger.Info("Starting server", zap.String("address", httpserver.Address()))
@@ -181,6 +512,7 @@ func gracefulShutdown(
 	serverCancel context.CancelFunc,
 	logger *zap.Logger,
 	server *httpserver.Server,
+	grpcServer *grpcserver.Server,
 	gracefulShutdownTimeout time.Duration,
 	urlUsecase *usecase.URLUsecase,
 ) {
@@ -209,14 +541,23 @@ func gracefulShutdown(
 			}
 		}()
 
-		logger.Info("Shutting down worker...")
-		urlUsecase.Shutdown()
-
 		// call graceful shutdown server
 		logger.Info("Shutting down server...")
 		if err := server.Shutdown(shutdownCtx); err != nil {
 			logger.Fatal("Server forced to shutdown", zap.Error(err))
 		}
+		if grpcServer != nil {
+			logger.Info("Shutting down gRPC server...")
+			if err := grpcServer.Shutdown(shutdownCtx); err != nil {
+				logger.Fatal("gRPC server forced to shutdown", zap.Error(err))
+			}
+		}
+
+		// drain the workers once no request can queue more work
+		logger.Info("Shutting down workers...")
+		if err := urlUsecase.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Workers forced to shutdown", zap.Error(err))
+		}
 
 		serverCancel()
 	}()
@@ -21,10 +21,33 @@ type Config struct {
 	ReleaseMode                 string        `json:"release_mode,omitempty"                    env:"RELEASE_MODE"                    envDefault:"debug"`                 // release mode. Available options: debug, release, test
 	LogLevel                    string        `json:"log_level,omitempty"                       env:"LOG_LEVEL"                       envDefault:"info"`                  // log level
 	FileStoragePath             string        `json:"file_storage_path,omitempty"               env:"FILE_STORAGE_PATH"               envDefault:""`                      // file storage path
+	FileStorageSyncPolicy       string        `json:"file_storage_sync_policy,omitempty"        env:"FILE_STORAGE_SYNC_POLICY"        envDefault:"interval"`              // file storage write-ahead log sync policy. Available options: always, interval, never
+	FileStorageOnCorruption     string        `json:"file_storage_on_corruption,omitempty"      env:"FILE_STORAGE_ON_CORRUPTION"      envDefault:"fail"`                  // file storage reaction to a corrupted snapshot. Available options: fail, fallback
 	DatabaseDSN                 string        `json:"database_dsn,omitempty"                    env:"DATABASE_DSN"                    envDefault:""`                      // database dsn
+	TrustedSubnet               string        `json:"trusted_subnet,omitempty"                  env:"TRUSTED_SUBNET"                  envDefault:""`                      // subnet in CIDR notation allowed to read the internal stats and dead letters. Empty forbids everyone
+	ShortURLGenerator           string        `json:"short_url_generator,omitempty"             env:"SHORT_URL_GENERATOR"             envDefault:"random"`                // short url generator. Available options: random, counter, hashids, human
+	ShortURLAlphabet            string        `json:"short_url_alphabet,omitempty"              env:"SHORT_URL_ALPHABET"              envDefault:""`                      // short url alphabet. Empty means the generator default
+	ShortURLAliasPattern        string        `json:"short_url_alias_pattern,omitempty"         env:"SHORT_URL_ALIAS_PATTERN"         envDefault:""`                      // pattern of custom aliases. Empty means 3 to 64 letters, digits, dashes and underscores
+	ShortURLReservedAliases     string        `json:"short_url_reserved_aliases,omitempty"      env:"SHORT_URL_RESERVED_ALIASES"      envDefault:"api,ping,debug"`        // comma separated aliases that cannot be used
+	DedupScope                  string        `json:"dedup_scope,omitempty"                     env:"DEDUP_SCOPE"                     envDefault:"global"`                // scope within which an original url is shortened only once. Available options: global, user, none
+	ShortURLSalt                string        `json:"short_url_salt,omitempty"                  env:"SHORT_URL_SALT"                  envDefault:""`                      // short url salt of the hashids generator
+	JWTKeys                     string        `json:"jwt_keys,omitempty"                        env:"JWT_KEYS"                        envDefault:""`                      // comma separated kid:secret keys of the user tokens, the first one signs. Required unless the jwt ephemeral key is enabled
+	JWTKeysFile                 string        `json:"jwt_keys_file,omitempty"                   env:"JWT_KEYS_FILE"                   envDefault:""`                      // file of kid:secret keys of the user tokens, one per line, used instead of jwt keys
+	JWTIssuer                   string        `json:"jwt_issuer,omitempty"                      env:"JWT_ISSUER"                      envDefault:"shortener-url"`         // issuer of the user tokens
+	JWTAudience                 string        `json:"jwt_audience,omitempty"                    env:"JWT_AUDIENCE"                    envDefault:"shortener-url"`         // audience of the user tokens
 	HTTPServerAddress           string        `json:"http_server_address,omitempty"             env:"HTTP_SERVER_ADDRESS"             envDefault:"localhost:8080"`        // http server address
+	GRPCServerAddress           string        `json:"grpc_server_address,omitempty"             env:"GRPC_SERVER_ADDRESS"             envDefault:""`                      // grpc server address. Empty disables the grpc server
 	TLSCertPath                 string        `json:"tls_cert_path,omitempty"                   env:"TLS_CERT_PATH"                   envDefault:""`                      // tls cert path
 	TLSKeyPath                  string        `json:"tls_key_path,omitempty"                    env:"TLS_KEY_PATH"                    envDefault:""`                      // tls key path
+	MemoryStorageShards         int           `json:"memory_storage_shards,omitempty"           env:"MEMORY_STORAGE_SHARDS"           envDefault:"1"`                     // memory storage shards. More than one enables the lock-striped storage
+	ShortURLLength              int           `json:"short_url_length,omitempty"                env:"SHORT_URL_LENGTH"                envDefault:"8"`                     // initial short url length
+	ShortURLAttempts            int           `json:"short_url_attempts,omitempty"              env:"SHORT_URL_ATTEMPTS"              envDefault:"3"`                     // attempts to generate a free short url before its length grows
+	ClickBatchSize              int           `json:"click_batch_size,omitempty"                env:"CLICK_BATCH_SIZE"                envDefault:"100"`                   // clicks written to the storage at once
+	DeletedURLPurgeBatchSize    int           `json:"deleted_url_purge_batch_size,omitempty"    env:"DELETED_URL_PURGE_BATCH_SIZE"    envDefault:"1000"`                  // deleted urls purged at once
+	DeleteConcurrency           int           `json:"delete_concurrency,omitempty"              env:"DELETE_CONCURRENCY"              envDefault:"4"`                     // users whose delete and restore jobs are processed at once
+	DeleteMaxAttempts           int           `json:"delete_max_attempts,omitempty"             env:"DELETE_MAX_ATTEMPTS"             envDefault:"5"`                     // attempts of a delete or restore job before it is moved to the dead letters
+	DeleteBatchSize             int           `json:"delete_batch_size,omitempty"               env:"DELETE_BATCH_SIZE"               envDefault:"50"`                    // delete and restore jobs claimed at once
+	WorkerPoolSize              int           `json:"worker_pool_size,omitempty"                env:"WORKER_POOL_SIZE"                envDefault:"4"`                     // background job runs going on at once across all job types
 	DatabaseMaxConns            int           `json:"database_max_conns,omitempty"              env:"DATABASE_MAX_CONNS"              envDefault:"10"`                    // database max conns
 	DatabaseMinConns            int           `json:"database_min_conns,omitempty"              env:"DATABASE_MIN_CONNS"              envDefault:"2"`                     // database min conns
 	DatabaseConnMaxLifetime     time.Duration `json:"database_conn_max_lifetime,omitempty"      env:"DATABASE_CONN_MAX_LIFETIME"      envDefault:"10s"`                   // database connection max lifetime
@@ -35,7 +58,21 @@ type Config struct {
 	HTTPServerReadHeaderTimeout time.Duration `json:"http_server_read_header_timeout,omitempty" env:"HTTP_SERVER_READ_HEADER_TIMEOUT" envDefault:"15s"`                   // http server read header timeout
 	HTTPServerWriteTimeout      time.Duration `json:"http_server_write_timeout,omitempty"       env:"HTTP_SERVER_WRITE_TIMEOUT"       envDefault:"10s"`                   // http server write timeout
 	GracefulShutdownTimeout     time.Duration `json:"graceful_shutdown_timeout,omitempty"       env:"GRACEFUL_SHUTDOWN_TIMEOUT"       envDefault:"20s"`                   // graceful shutdown timeout
+	FileStorageSyncInterval     time.Duration `json:"file_storage_sync_interval,omitempty"      env:"FILE_STORAGE_SYNC_INTERVAL"      envDefault:"1s"`                    // file storage write-ahead log sync interval
+	FileStorageCompactInterval  time.Duration `json:"file_storage_compact_interval,omitempty"   env:"FILE_STORAGE_COMPACT_INTERVAL"   envDefault:"10s"`                   // file storage write-ahead log compaction interval
+	ExpiredURLSweepInterval     time.Duration `json:"expired_url_sweep_interval,omitempty"      env:"EXPIRED_URL_SWEEP_INTERVAL"      envDefault:"1m"`                    // interval between sweeps marking expired urls deleted
+	ClickFlushInterval          time.Duration `json:"click_flush_interval,omitempty"            env:"CLICK_FLUSH_INTERVAL"            envDefault:"1s"`                    // longest time a click waits for its batch to fill up
+	DeletedURLRetention         time.Duration `json:"deleted_url_retention,omitempty"           env:"DELETED_URL_RETENTION"           envDefault:"720h"`                  // time a deleted url is kept before it is purged. Zero disables purging
+	DeletedURLPurgeInterval     time.Duration `json:"deleted_url_purge_interval,omitempty"      env:"DELETED_URL_PURGE_INTERVAL"      envDefault:"1h"`                    // interval between purges of deleted urls
+	DeleteJobRetention          time.Duration `json:"delete_job_retention,omitempty"            env:"DELETE_JOB_RETENTION"            envDefault:"24h"`                   // time a finished delete or restore job can be looked up
+	DeleteDeadLetterRetention   time.Duration `json:"delete_dead_letter_retention,omitempty"    env:"DELETE_DEAD_LETTER_RETENTION"    envDefault:"168h"`                  // time a delete or restore job that ran out of attempts is kept in the dead letters. Zero keeps them forever
+	DeleteRetryBackoff          time.Duration `json:"delete_retry_backoff,omitempty"            env:"DELETE_RETRY_BACKOFF"            envDefault:"1s"`                    // delay before the first retry of a failed delete or restore job, doubled on every attempt
+	DeleteRetryMaxBackoff       time.Duration `json:"delete_retry_max_backoff,omitempty"        env:"DELETE_RETRY_MAX_BACKOFF"        envDefault:"5m"`                    // longest delay between two attempts of a delete or restore job
+	DeleteFlushInterval         time.Duration `json:"delete_flush_interval,omitempty"           env:"DELETE_FLUSH_INTERVAL"           envDefault:"500ms"`                 // interval between two claims of the due delete and restore jobs
+	HealthCheckInterval         time.Duration `json:"health_check_interval,omitempty"           env:"HEALTH_CHECK_INTERVAL"           envDefault:"30s"`                   // interval between two pings of the storage. Zero disables the health checks
+	JWTTTL                      time.Duration `json:"jwt_ttl,omitempty"                         env:"JWT_TTL"                         envDefault:"720h"`                  // lifetime of the user tokens
 	EnableHTTPS                 bool          `json:"enable_https,omitempty"                    env:"ENABLE_HTTPS"                    envDefault:""`                      // enable https
+	JWTEphemeralKey             bool          `json:"jwt_ephemeral_key,omitempty"               env:"JWT_EPHEMERAL_KEY"               envDefault:""`                      // sign the user tokens with a random key per start when no jwt keys are configured. For development only
 }
 
 // NewConfig creates a new Config instance.
@@ -71,11 +108,139 @@ func NewConfig() (*Config, error) {
 func parseFlags(cfg *Config) {
 	// Define and parse command line flags
 	flag.StringVar(&cfg.HTTPServerAddress, "a", cfg.HTTPServerAddress, "HTTP server address")
+	flag.StringVar(&cfg.GRPCServerAddress, "grpc-server-address", cfg.GRPCServerAddress, "gRPC server address. Empty disables the gRPC server")
 	flag.StringVar(&cfg.BaseURLAddress, "b", cfg.BaseURLAddress, "Base URL for shortened URLs")
 	flag.StringVar(&cfg.ReleaseMode, "r", cfg.ReleaseMode, "Release mode. Available options: debug, release, test")
 	flag.StringVar(&cfg.LogLevel, "l", cfg.LogLevel, "Log level")
 	flag.StringVar(&cfg.FileStoragePath, "f", cfg.FileStoragePath, "File storage path")
 	flag.StringVar(&cfg.DatabaseDSN, "d", cfg.DatabaseDSN, "Database DSN")
+	flag.StringVar(&cfg.TrustedSubnet, "t", cfg.TrustedSubnet, "Trusted subnet in CIDR notation for the internal stats and dead letters")
+	flag.StringVar(
+		&cfg.DedupScope,
+		"dedup-scope",
+		cfg.DedupScope,
+		"Scope within which an original URL is shortened only once. Available options: global, user, none",
+	)
+	flag.StringVar(
+		&cfg.FileStorageSyncPolicy,
+		"file-storage-sync-policy",
+		cfg.FileStorageSyncPolicy,
+		"File storage write-ahead log sync policy. Available options: always, interval, never",
+	)
+	flag.StringVar(
+		&cfg.FileStorageOnCorruption,
+		"file-storage-on-corruption",
+		cfg.FileStorageOnCorruption,
+		"File storage reaction to a corrupted snapshot. Available options: fail, fallback",
+	)
+	flag.DurationVar(
+		&cfg.FileStorageSyncInterval,
+		"file-storage-sync-interval",
+		cfg.FileStorageSyncInterval,
+		"File storage write-ahead log sync interval",
+	)
+	flag.DurationVar(
+		&cfg.FileStorageCompactInterval,
+		"file-storage-compact-interval",
+		cfg.FileStorageCompactInterval,
+		"File storage write-ahead log compaction interval",
+	)
+	flag.DurationVar(
+		&cfg.ExpiredURLSweepInterval,
+		"expired-url-sweep-interval",
+		cfg.ExpiredURLSweepInterval,
+		"Interval between sweeps marking expired URLs deleted",
+	)
+	flag.DurationVar(
+		&cfg.DeletedURLRetention,
+		"deleted-url-retention",
+		cfg.DeletedURLRetention,
+		"Time a deleted URL is kept before it is purged. Zero disables purging",
+	)
+	flag.DurationVar(
+		&cfg.DeletedURLPurgeInterval,
+		"deleted-url-purge-interval",
+		cfg.DeletedURLPurgeInterval,
+		"Interval between purges of deleted URLs",
+	)
+	flag.DurationVar(
+		&cfg.DeleteJobRetention,
+		"delete-job-retention",
+		cfg.DeleteJobRetention,
+		"Time a finished delete or restore job can be looked up",
+	)
+	flag.DurationVar(
+		&cfg.DeleteDeadLetterRetention,
+		"delete-dead-letter-retention",
+		cfg.DeleteDeadLetterRetention,
+		"Time a delete or restore job that ran out of attempts is kept in the dead letters. Zero keeps them forever",
+	)
+	flag.IntVar(
+		&cfg.DeleteConcurrency,
+		"delete-concurrency",
+		cfg.DeleteConcurrency,
+		"Number of users whose delete and restore jobs are processed at once",
+	)
+	flag.IntVar(
+		&cfg.DeleteMaxAttempts,
+		"delete-max-attempts",
+		cfg.DeleteMaxAttempts,
+		"Attempts of a delete or restore job before it is moved to the dead letters",
+	)
+	flag.DurationVar(
+		&cfg.DeleteRetryBackoff,
+		"delete-retry-backoff",
+		cfg.DeleteRetryBackoff,
+		"Delay before the first retry of a failed delete or restore job, doubled on every attempt",
+	)
+	flag.DurationVar(
+		&cfg.DeleteRetryMaxBackoff,
+		"delete-retry-max-backoff",
+		cfg.DeleteRetryMaxBackoff,
+		"Longest delay between two attempts of a delete or restore job",
+	)
+	flag.IntVar(
+		&cfg.DeleteBatchSize,
+		"delete-batch-size",
+		cfg.DeleteBatchSize,
+		"Number of delete and restore jobs claimed at once",
+	)
+	flag.DurationVar(
+		&cfg.DeleteFlushInterval,
+		"delete-flush-interval",
+		cfg.DeleteFlushInterval,
+		"Interval between two claims of the due delete and restore jobs",
+	)
+	flag.IntVar(
+		&cfg.WorkerPoolSize,
+		"worker-pool-size",
+		cfg.WorkerPoolSize,
+		"Number of background job runs going on at once across all job types",
+	)
+	flag.DurationVar(
+		&cfg.HealthCheckInterval,
+		"health-check-interval",
+		cfg.HealthCheckInterval,
+		"Interval between two pings of the storage. Zero disables the health checks",
+	)
+	flag.IntVar(
+		&cfg.DeletedURLPurgeBatchSize,
+		"deleted-url-purge-batch-size",
+		cfg.DeletedURLPurgeBatchSize,
+		"Number of deleted URLs purged at once",
+	)
+	flag.IntVar(
+		&cfg.ClickBatchSize,
+		"click-batch-size",
+		cfg.ClickBatchSize,
+		"Number of clicks written to the storage at once",
+	)
+	flag.DurationVar(
+		&cfg.ClickFlushInterval,
+		"click-flush-interval",
+		cfg.ClickFlushInterval,
+		"Longest time a click waits for its batch to fill up",
+	)
 	flag.DurationVar(
 		&cfg.HTTPServerIdleTimeout,
 		"http-server-idle-timeout",
@@ -106,6 +271,65 @@ func parseFlags(cfg *Config) {
 		cfg.GracefulShutdownTimeout,
 		"HTTP graceful shutdown timeout",
 	)
+	flag.StringVar(
+		&cfg.ShortURLGenerator,
+		"short-url-generator",
+		cfg.ShortURLGenerator,
+		"Short URL generator. Available options: random, counter, hashids, human",
+	)
+	flag.StringVar(
+		&cfg.ShortURLAlphabet,
+		"short-url-alphabet",
+		cfg.ShortURLAlphabet,
+		"Short URL alphabet. Empty means the generator default",
+	)
+	flag.StringVar(
+		&cfg.ShortURLAliasPattern,
+		"short-url-alias-pattern",
+		cfg.ShortURLAliasPattern,
+		"Pattern of custom aliases. Empty means 3 to 64 letters, digits, dashes and underscores",
+	)
+	flag.StringVar(
+		&cfg.ShortURLReservedAliases,
+		"short-url-reserved-aliases",
+		cfg.ShortURLReservedAliases,
+		"Comma separated aliases that cannot be used",
+	)
+	flag.StringVar(&cfg.ShortURLSalt, "short-url-salt", cfg.ShortURLSalt, "Short URL salt of the hashids generator")
+	flag.StringVar(
+		&cfg.JWTKeys,
+		"jwt-keys",
+		cfg.JWTKeys,
+		"Comma separated kid:secret keys of the user tokens, the first one signs. Required unless -jwt-ephemeral-key is set",
+	)
+	flag.StringVar(
+		&cfg.JWTKeysFile,
+		"jwt-keys-file",
+		cfg.JWTKeysFile,
+		"File of kid:secret keys of the user tokens, one per line, used instead of -jwt-keys",
+	)
+	flag.StringVar(&cfg.JWTIssuer, "jwt-issuer", cfg.JWTIssuer, "Issuer of the user tokens")
+	flag.StringVar(&cfg.JWTAudience, "jwt-audience", cfg.JWTAudience, "Audience of the user tokens")
+	flag.DurationVar(&cfg.JWTTTL, "jwt-ttl", cfg.JWTTTL, "Lifetime of the user tokens")
+	flag.BoolVar(
+		&cfg.JWTEphemeralKey,
+		"jwt-ephemeral-key",
+		cfg.JWTEphemeralKey,
+		"Sign the user tokens with a random key per start when no JWT keys are configured. For development only",
+	)
+	flag.IntVar(&cfg.ShortURLLength, "short-url-length", cfg.ShortURLLength, "Initial short URL length")
+	flag.IntVar(
+		&cfg.ShortURLAttempts,
+		"short-url-attempts",
+		cfg.ShortURLAttempts,
+		"Attempts to generate a free short URL before its length grows",
+	)
+	flag.IntVar(
+		&cfg.MemoryStorageShards,
+		"memory-storage-shards",
+		cfg.MemoryStorageShards,
+		"Memory storage shards. More than one enables the lock-striped storage",
+	)
 	flag.IntVar(&cfg.DatabaseMaxConns, "database-max-conns", cfg.DatabaseMaxConns, "Database max conns")
 	flag.IntVar(&cfg.DatabaseMinConns, "database-min-conns", cfg.DatabaseMinConns, "Database min conns")
 	flag.DurationVar(
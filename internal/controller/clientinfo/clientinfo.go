@@ -0,0 +1,82 @@
+// Package clientinfo holds what the HTTP and gRPC transports know about the client of a call:
+// the network of its address kept in a click, the bounded fields of the click and its trust.
+package clientinfo
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"unicode/utf8"
+)
+
+// Constants for the click of a client.
+const (
+	IPv4PrefixBits      = 24  // bits of the IPv4 client address kept in a click
+	IPv6PrefixBits      = 48  // bits of the IPv6 client address kept in a click
+	MaxClickFieldLength = 512 // bytes of the client supplied referrer and user agent kept in a click
+)
+
+// IPPrefix returns the /24 (IPv4) or /48 (IPv6) network of the client address.
+// The address is the real IP set by the proxy or, when it is empty, the host of the remote address.
+// It is empty if the address is not parsable.
+func IPPrefix(realIP, remoteAddr string) string {
+	host := realIP
+	if host == "" {
+		var err error
+		host, _, err = net.SplitHostPort(remoteAddr)
+		if err != nil {
+			host = remoteAddr
+		}
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(host))
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	bits := IPv6PrefixBits
+	if addr.Is4() {
+		bits = IPv4PrefixBits
+	}
+	prefix, err := addr.WithZone("").Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
+
+// Truncate cuts s to at most n bytes without splitting a UTF-8 rune.
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// ParseTrustedSubnet parses the subnet in CIDR notation allowed to call the internal endpoints.
+// The empty subnet is the invalid prefix that trusts nobody.
+func ParseTrustedSubnet(subnet string) (netip.Prefix, error) {
+	if subnet == "" {
+		return netip.Prefix{}, nil
+	}
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(subnet))
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid trusted subnet: %w", err)
+	}
+	return prefix.Masked(), nil
+}
+
+// Trusted reports whether the real IP set by the proxy belongs to the trusted subnet.
+func Trusted(subnet netip.Prefix, realIP string) bool {
+	if !subnet.IsValid() {
+		return false
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(realIP))
+	if err != nil {
+		return false
+	}
+	return subnet.Contains(addr.Unmap())
+}
@@ -0,0 +1,55 @@
+package clientinfo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/clientinfo"
+)
+
+func TestIPPrefix(t *testing.T) {
+	tests := []struct {
+		name       string
+		realIP     string
+		remoteAddr string
+		want       string
+	}{
+		{name: "real ip first", realIP: "10.1.2.3", remoteAddr: "192.168.0.1:1234", want: "10.1.2.0/24"},
+		{name: "remote address", remoteAddr: "192.168.0.1:1234", want: "192.168.0.0/24"},
+		{name: "remote address without port", remoteAddr: "192.168.0.1", want: "192.168.0.0/24"},
+		{name: "ipv6", remoteAddr: "[2001:db8:1:2::1]:1234", want: "2001:db8:1::/48"},
+		{name: "ipv4 mapped", realIP: "::ffff:10.1.2.3", want: "10.1.2.0/24"},
+		{name: "unparsable", realIP: "unknown", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, clientinfo.IPPrefix(tt.realIP, tt.remoteAddr))
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", clientinfo.Truncate("short", 10))
+	assert.Equal(t, "abc", clientinfo.Truncate("abcdef", 3))
+	// the two bytes rune is not split
+	assert.Equal(t, "a", clientinfo.Truncate("aéb", 2))
+}
+
+func TestTrusted(t *testing.T) {
+	subnet, err := clientinfo.ParseTrustedSubnet(" 10.1.2.3/8 ")
+	require.NoError(t, err)
+	assert.True(t, clientinfo.Trusted(subnet, "10.200.0.1"))
+	assert.True(t, clientinfo.Trusted(subnet, "::ffff:10.200.0.1"))
+	assert.False(t, clientinfo.Trusted(subnet, "192.168.0.1"))
+	assert.False(t, clientinfo.Trusted(subnet, ""))
+
+	// the empty subnet trusts nobody
+	subnet, err = clientinfo.ParseTrustedSubnet("")
+	require.NoError(t, err)
+	assert.False(t, clientinfo.Trusted(subnet, "10.200.0.1"))
+
+	_, err = clientinfo.ParseTrustedSubnet("10.0.0.0")
+	require.Error(t, err)
+}
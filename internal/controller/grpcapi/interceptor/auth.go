@@ -0,0 +1,157 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+)
+
+const (
+	// AuthMetadataKey is the metadata key carrying the token of the user, both in calls and in response headers.
+	AuthMetadataKey = "authorization"
+	bearerPrefix    = "Bearer "
+)
+
+type optionsAuthInterceptor struct {
+	logger *zap.Logger
+	tokens *middleware.TokenManager
+}
+
+// OptionAuthInterceptor is the option for the auth interceptor.
+type OptionAuthInterceptor func(options *optionsAuthInterceptor) error
+
+// AuthInterceptor authenticates the calls with the same token as the auth middleware.
+// A call without a token gets a new user whose token is sent back in the response header.
+type AuthInterceptor struct {
+	logger *zap.Logger
+	tokens *middleware.TokenManager
+}
+
+// WithAuthInterceptorLogger is the option for the auth interceptor to set the logger.
+func WithAuthInterceptorLogger(logger *zap.Logger) OptionAuthInterceptor {
+	return func(options *optionsAuthInterceptor) error {
+		options.logger = logger.With(zap.String("interceptor", "auth"))
+		return nil
+	}
+}
+
+// WithAuthInterceptorTokens is the option for the auth interceptor to set the token manager.
+func WithAuthInterceptorTokens(tokens *middleware.TokenManager) OptionAuthInterceptor {
+	return func(options *optionsAuthInterceptor) error {
+		options.tokens = tokens
+		return nil
+	}
+}
+
+// NewAuthInterceptor creates a new auth interceptor.
+// Without a token manager it signs with a random key, so the tokens do not survive a restart.
+func NewAuthInterceptor(opts ...OptionAuthInterceptor) (*AuthInterceptor, error) {
+	options := &optionsAuthInterceptor{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if options.tokens == nil {
+		tokens, err := middleware.NewTokenManager(middleware.WithEphemeralTokenKey())
+		if err != nil {
+			return nil, err
+		}
+		options.tokens = tokens
+	}
+	return &AuthInterceptor{logger: options.logger, tokens: options.tokens}, nil
+}
+
+// Unary returns the unary server interceptor for authentication.
+func (i *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		authCtx, err := i.authenticate(ctx, func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return handler(authCtx, req)
+	}
+}
+
+// Stream returns the stream server interceptor for authentication.
+func (i *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authenticate(ss.Context(), ss.SetHeader)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate returns the context with the user of the call, setHeader sends the token of a new user.
+func (i *AuthInterceptor) authenticate(
+	ctx context.Context,
+	setHeader func(md metadata.MD) error,
+) (context.Context, error) {
+	tokenString := bearerToken(ctx)
+	if tokenString == "" {
+		i.logger.Info("No token found, generating new token")
+		userID := uuid.New().String()
+		newToken, errGenerate := i.tokens.Generate(userID)
+		if errGenerate != nil {
+			i.logger.Error("Failed to generate token", zap.Error(errGenerate))
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+		if errHeader := setHeader(metadata.Pairs(AuthMetadataKey, bearerPrefix+newToken)); errHeader != nil {
+			i.logger.Error("Failed to send token", zap.Error(errHeader))
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+		return context.WithValue(ctx, middleware.UserIDKey, userID), nil
+	}
+
+	userID, err := i.tokens.Parse(tokenString)
+	if err != nil {
+		i.logger.Error("Failed to get userID", zap.Error(err))
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	return context.WithValue(ctx, middleware.UserIDKey, userID), nil
+}
+
+// bearerToken returns the token of the call, the Bearer scheme is optional.
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(AuthMetadataKey)
+	if len(values) == 0 {
+		return ""
+	}
+	token := strings.TrimSpace(values[0])
+	if len(token) >= len(bearerPrefix) && strings.EqualFold(token[:len(bearerPrefix)], bearerPrefix) {
+		token = strings.TrimSpace(token[len(bearerPrefix):])
+	}
+	return token
+}
+
+// serverStream overrides the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+// Context returns the context of the stream.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
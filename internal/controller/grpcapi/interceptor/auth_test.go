@@ -0,0 +1,162 @@
+package interceptor_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/grpcapi/interceptor"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+)
+
+// headerRecorder records the response headers of a call.
+type headerRecorder struct {
+	header metadata.MD
+}
+
+func (r *headerRecorder) Method() string { return "/shortener.v1.Shortener/Shorten" }
+
+func (r *headerRecorder) SetHeader(md metadata.MD) error {
+	r.header = metadata.Join(r.header, md)
+	return nil
+}
+
+func (r *headerRecorder) SendHeader(md metadata.MD) error { return r.SetHeader(md) }
+
+func (r *headerRecorder) SetTrailer(metadata.MD) error { return nil }
+
+// recordingStream is the server stream of a call recording its response headers.
+type recordingStream struct {
+	grpc.ServerStream
+
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *recordingStream) Context() context.Context { return s.ctx }
+
+func (s *recordingStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	_, err := interceptor.NewAuthInterceptor()
+	require.Error(t, err)
+
+	tokens, err := middleware.NewTokenManager(middleware.WithEphemeralTokenKey())
+	require.NoError(t, err)
+	auth, err := interceptor.NewAuthInterceptor(
+		interceptor.WithAuthInterceptorLogger(zap.NewNop()),
+		interceptor.WithAuthInterceptorTokens(tokens),
+	)
+	require.NoError(t, err)
+
+	token, err := tokens.Generate("user1")
+	require.NoError(t, err)
+
+	type test struct {
+		name          string
+		authorization string
+		wantUserID    string
+		wantCode      codes.Code
+		wantIssued    bool
+	}
+	tests := []test{
+		{
+			name:       "no token",
+			wantCode:   codes.OK,
+			wantIssued: true,
+		},
+		{
+			name:          "bearer token",
+			authorization: "Bearer " + token,
+			wantUserID:    "user1",
+			wantCode:      codes.OK,
+		},
+		{
+			name:          "token without scheme",
+			authorization: token,
+			wantUserID:    "user1",
+			wantCode:      codes.OK,
+		},
+		{
+			name:          "invalid token",
+			authorization: "Bearer invalid",
+			wantCode:      codes.Unauthenticated,
+		},
+	}
+
+	// checkCall checks the user the handler was called with and the issued token.
+	checkCall := func(t *testing.T, test test, called bool, userID string, errCall error, header metadata.MD) {
+		t.Helper()
+		require.Equal(t, test.wantCode, status.Code(errCall))
+		issued := header.Get(interceptor.AuthMetadataKey)
+		if test.wantCode != codes.OK {
+			require.False(t, called, "handler must not be called")
+			require.Empty(t, issued)
+			return
+		}
+		require.True(t, called)
+		if !test.wantIssued {
+			require.Empty(t, issued)
+			require.Equal(t, test.wantUserID, userID)
+			return
+		}
+		require.Len(t, issued, 1)
+		require.True(t, strings.HasPrefix(issued[0], "Bearer "))
+		issuedUserID, errParse := tokens.Parse(strings.TrimPrefix(issued[0], "Bearer "))
+		require.NoError(t, errParse)
+		require.NotEmpty(t, userID)
+		require.Equal(t, issuedUserID, userID)
+	}
+
+	incoming := func(ctx context.Context, authorization string) context.Context {
+		if authorization == "" {
+			return ctx
+		}
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(interceptor.AuthMetadataKey, authorization))
+	}
+
+	for _, test := range tests {
+		t.Run("unary/"+test.name, func(t *testing.T) {
+			recorder := &headerRecorder{}
+			ctx := grpc.NewContextWithServerTransportStream(incoming(t.Context(), test.authorization), recorder)
+
+			var (
+				called bool
+				userID string
+			)
+			info := &grpc.UnaryServerInfo{FullMethod: recorder.Method()}
+			_, errCall := auth.Unary()(ctx, nil, info, func(ctx context.Context, _ any) (any, error) {
+				called = true
+				userID, _ = ctx.Value(middleware.UserIDKey).(string)
+				return nil, nil
+			})
+			checkCall(t, test, called, userID, errCall, recorder.header)
+		})
+
+		t.Run("stream/"+test.name, func(t *testing.T) {
+			stream := &recordingStream{ctx: incoming(t.Context(), test.authorization)}
+
+			var (
+				called bool
+				userID string
+			)
+			info := &grpc.StreamServerInfo{FullMethod: "/shortener.v1.Shortener/Watch"}
+			errCall := auth.Stream()(nil, stream, info, func(_ any, ss grpc.ServerStream) error {
+				called = true
+				userID, _ = ss.Context().Value(middleware.UserIDKey).(string)
+				return nil
+			})
+			checkCall(t, test, called, userID, errCall, stream.header)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Call kinds logged as the method of the request.
+const (
+	unaryMethod  = "unary"
+	streamMethod = "stream"
+)
+
+type optionsLoggerInterceptor struct {
+	logger *zap.Logger
+}
+
+// OptionLoggerInterceptor is the option for the logger interceptor.
+type OptionLoggerInterceptor func(options *optionsLoggerInterceptor) error
+
+// LoggerInterceptor logs the statistics of every call like the logger middleware.
+// The uri is the full gRPC method, the status is the gRPC code and the size counts the bytes of the responses.
+type LoggerInterceptor struct {
+	logger *zap.Logger
+}
+
+// WithLoggerInterceptorLogger is the option for the logger interceptor to set the logger.
+func WithLoggerInterceptorLogger(logger *zap.Logger) OptionLoggerInterceptor {
+	return func(options *optionsLoggerInterceptor) error {
+		options.logger = logger.With(zap.String("interceptor", "logger"))
+		return nil
+	}
+}
+
+// NewLoggerInterceptor creates a new logger interceptor.
+func NewLoggerInterceptor(opts ...OptionLoggerInterceptor) (*LoggerInterceptor, error) {
+	options := &optionsLoggerInterceptor{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	return &LoggerInterceptor{logger: options.logger}, nil
+}
+
+// Unary returns the unary server interceptor for logging.
+func (i *LoggerInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		i.log(info.FullMethod, unaryMethod, time.Since(start), err, messageSize(resp))
+		return resp, err
+	}
+}
+
+// Stream returns the stream server interceptor for logging.
+func (i *LoggerInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		// Wrap the stream to count the size of the sent messages
+		ws := &sizeStream{ServerStream: ss}
+		err := handler(srv, ws)
+
+		i.log(info.FullMethod, streamMethod, time.Since(start), err, ws.size)
+		return err
+	}
+}
+
+func (i *LoggerInterceptor) log(fullMethod, method string, duration time.Duration, err error, size int) {
+	i.logger.Info("statistics",
+		zap.Dict("request",
+			zap.String("uri", fullMethod),
+			zap.String("method", method),
+			zap.Duration("duration", duration),
+		),
+		zap.Dict("response",
+			zap.Int("status", int(status.Code(err))),
+			zap.Int("size", size),
+		),
+	)
+}
+
+// messageSize returns the encoded size of a protobuf message, zero for anything else.
+func messageSize(message any) int {
+	if m, ok := message.(proto.Message); ok {
+		return proto.Size(m)
+	}
+	return 0
+}
+
+type sizeStream struct {
+	grpc.ServerStream
+
+	size int
+}
+
+// SendMsg sends the message to the client.
+func (s *sizeStream) SendMsg(m any) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.size += messageSize(m)
+	return nil
+}
@@ -0,0 +1,57 @@
+package interceptor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/grpcapi/interceptor"
+	"github.com/AGENT3128/shortener-url/internal/controller/grpcapi/pb"
+)
+
+func TestLoggerInterceptor(t *testing.T) {
+	_, err := interceptor.NewLoggerInterceptor()
+	require.Error(t, err)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger, err := interceptor.NewLoggerInterceptor(interceptor.WithLoggerInterceptorLogger(zap.New(core)))
+	require.NoError(t, err)
+
+	response := &pb.ShortenResponse{Result: "http://localhost:8080/abc123"}
+	info := &grpc.UnaryServerInfo{FullMethod: "/shortener.v1.Shortener/Shorten"}
+	_, err = logger.Unary()(t.Context(), nil, info, func(_ context.Context, _ any) (any, error) {
+		return response, nil
+	})
+	require.NoError(t, err)
+	_, err = logger.Unary()(t.Context(), nil, info, func(_ context.Context, _ any) (any, error) {
+		return nil, status.Error(codes.InvalidArgument, "URL is required")
+	})
+	require.Error(t, err)
+
+	entries := logs.FilterMessage("statistics").AllUntimed()
+	require.Len(t, entries, 2)
+
+	fields := entries[0].ContextMap()
+	request, ok := fields["request"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, "/shortener.v1.Shortener/Shorten", request["uri"])
+	require.Equal(t, "unary", request["method"])
+	require.Contains(t, request, "duration")
+	responseFields, ok := fields["response"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, int64(codes.OK), responseFields["status"])
+	require.Equal(t, int64(proto.Size(response)), responseFields["size"])
+
+	responseFields, ok = entries[1].ContextMap()["response"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, int64(codes.InvalidArgument), responseFields["status"])
+	require.Equal(t, int64(0), responseFields["size"])
+}
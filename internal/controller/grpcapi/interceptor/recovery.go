@@ -0,0 +1,89 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type optionsRecoveryInterceptor struct {
+	logger *zap.Logger
+}
+
+// OptionRecoveryInterceptor is the option for the recovery interceptor.
+type OptionRecoveryInterceptor func(options *optionsRecoveryInterceptor) error
+
+// RecoveryInterceptor turns the panics of the calls into codes.Internal errors.
+type RecoveryInterceptor struct {
+	logger *zap.Logger
+}
+
+// WithRecoveryInterceptorLogger is the option for the recovery interceptor to set the logger.
+func WithRecoveryInterceptorLogger(logger *zap.Logger) OptionRecoveryInterceptor {
+	return func(options *optionsRecoveryInterceptor) error {
+		options.logger = logger.With(zap.String("interceptor", "recovery"))
+		return nil
+	}
+}
+
+// NewRecoveryInterceptor creates a new recovery interceptor.
+func NewRecoveryInterceptor(opts ...OptionRecoveryInterceptor) (*RecoveryInterceptor, error) {
+	options := &optionsRecoveryInterceptor{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	return &RecoveryInterceptor{logger: options.logger}, nil
+}
+
+// Unary returns the unary server interceptor for panic recovery.
+func (i *RecoveryInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var (
+			resp any
+			err  error
+		)
+		func() {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					resp, err = nil, i.recovered(info.FullMethod, recovered)
+				}
+			}()
+			resp, err = handler(ctx, req)
+		}()
+		return resp, err
+	}
+}
+
+// Stream returns the stream server interceptor for panic recovery.
+func (i *RecoveryInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		var err error
+		func() {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					err = i.recovered(info.FullMethod, recovered)
+				}
+			}()
+			err = handler(srv, ss)
+		}()
+		return err
+	}
+}
+
+func (i *RecoveryInterceptor) recovered(fullMethod string, recovered any) error {
+	i.logger.Error("panic recovered",
+		zap.String("method", fullMethod),
+		zap.Any("panic", recovered),
+		zap.Stack("stack"),
+	)
+	return status.Error(codes.Internal, "internal server error")
+}
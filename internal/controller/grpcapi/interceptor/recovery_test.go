@@ -0,0 +1,45 @@
+package interceptor_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/grpcapi/interceptor"
+)
+
+func TestRecoveryInterceptor(t *testing.T) {
+	_, err := interceptor.NewRecoveryInterceptor()
+	require.Error(t, err)
+
+	recovery, err := interceptor.NewRecoveryInterceptor(interceptor.WithRecoveryInterceptorLogger(zap.NewNop()))
+	require.NoError(t, err)
+
+	t.Run("unary", func(t *testing.T) {
+		info := &grpc.UnaryServerInfo{FullMethod: "/shortener.v1.Shortener/Ping"}
+		resp, errCall := recovery.Unary()(t.Context(), nil, info, func(_ context.Context, _ any) (any, error) {
+			panic("boom")
+		})
+		require.Nil(t, resp)
+		require.Equal(t, codes.Internal, status.Code(errCall))
+
+		resp, errCall = recovery.Unary()(t.Context(), nil, info, func(_ context.Context, _ any) (any, error) {
+			return "ok", nil
+		})
+		require.NoError(t, errCall)
+		require.Equal(t, "ok", resp)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		info := &grpc.StreamServerInfo{FullMethod: "/shortener.v1.Shortener/Watch"}
+		errCall := recovery.Stream()(nil, nil, info, func(_ any, _ grpc.ServerStream) error {
+			panic("boom")
+		})
+		require.Equal(t, codes.Internal, status.Code(errCall))
+	})
+}
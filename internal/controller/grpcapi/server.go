@@ -0,0 +1,162 @@
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative pb/shortener.proto
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/grpcapi/interceptor"
+	"github.com/AGENT3128/shortener-url/internal/controller/grpcapi/pb"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+)
+
+type options struct {
+	URLusecase    httpapi.URLusecase
+	logger        *zap.Logger
+	tokens        *middleware.TokenManager
+	baseURL       string
+	trustedSubnet string
+	tlsCertPath   string
+	tlsKeyPath    string
+}
+
+// Option is the option for the gRPC server.
+type Option func(options *options) error
+
+// WithLogger is the option for the gRPC server to set the logger.
+func WithLogger(logger *zap.Logger) Option {
+	return func(options *options) error {
+		options.logger = logger
+		return nil
+	}
+}
+
+// WithBaseURL is the option for the gRPC server to set the base URL of the short URLs.
+func WithBaseURL(baseURL string) Option {
+	return func(options *options) error {
+		options.baseURL = baseURL
+		return nil
+	}
+}
+
+// WithTrustedSubnet is the option for the gRPC server to set the subnet, in CIDR notation,
+// allowed to read the service statistics.
+func WithTrustedSubnet(subnet string) Option {
+	return func(options *options) error {
+		options.trustedSubnet = subnet
+		return nil
+	}
+}
+
+// WithTLSCertPath is the option for the gRPC server to set the TLS cert path.
+// The server serves TLS only when the cert path is set.
+func WithTLSCertPath(tlsCertPath string) Option {
+	return func(options *options) error {
+		options.tlsCertPath = tlsCertPath
+		return nil
+	}
+}
+
+// WithTLSKeyPath is the option for the gRPC server to set the TLS key path.
+func WithTLSKeyPath(tlsKeyPath string) Option {
+	return func(options *options) error {
+		options.tlsKeyPath = tlsKeyPath
+		return nil
+	}
+}
+
+// WithTokenManager is the option for the gRPC server to set the token manager of the user tokens.
+func WithTokenManager(tokens *middleware.TokenManager) Option {
+	return func(options *options) error {
+		options.tokens = tokens
+		return nil
+	}
+}
+
+// WithURLUsecase is the option for the gRPC server to set the URL usecase.
+func WithURLUsecase(usecase httpapi.URLusecase) Option {
+	return func(options *options) error {
+		options.URLusecase = usecase
+		return nil
+	}
+}
+
+// NewServer creates a new gRPC server serving the Shortener service.
+func NewServer(opts ...Option) (*grpc.Server, error) {
+	options := &options{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.tokens == nil {
+		return nil, errors.New("token manager is required")
+	}
+
+	service, err := NewShortenerService(
+		WithServiceUsecase(options.URLusecase),
+		WithServiceLogger(options.logger),
+		WithServiceBaseURL(options.baseURL),
+		WithServiceTrustedSubnet(options.trustedSubnet),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// interceptors in the order of the middlewares of the HTTP router
+	loggerInterceptor, err := interceptor.NewLoggerInterceptor(
+		interceptor.WithLoggerInterceptorLogger(options.logger),
+	)
+	if err != nil {
+		return nil, err
+	}
+	recoveryInterceptor, err := interceptor.NewRecoveryInterceptor(
+		interceptor.WithRecoveryInterceptorLogger(options.logger),
+	)
+	if err != nil {
+		return nil, err
+	}
+	authInterceptor, err := interceptor.NewAuthInterceptor(
+		interceptor.WithAuthInterceptorLogger(options.logger),
+		interceptor.WithAuthInterceptorTokens(options.tokens),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	serverOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			loggerInterceptor.Unary(),
+			recoveryInterceptor.Unary(),
+			authInterceptor.Unary(),
+		),
+		grpc.ChainStreamInterceptor(
+			loggerInterceptor.Stream(),
+			recoveryInterceptor.Stream(),
+			authInterceptor.Stream(),
+		),
+	}
+	if options.tlsCertPath != "" {
+		cert, errLoad := tls.LoadX509KeyPair(options.tlsCertPath, options.tlsKeyPath)
+		if errLoad != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", errLoad)
+		}
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(&tls.Config{
+			MinVersion: tls.VersionTLS13,
+			Certificates: []tls.Certificate{
+				cert,
+			},
+		})))
+	}
+
+	server := grpc.NewServer(serverOptions...)
+	pb.RegisterShortenerServer(server, service)
+	return server, nil
+}
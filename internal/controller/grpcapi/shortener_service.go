@@ -0,0 +1,381 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/clientinfo"
+	"github.com/AGENT3128/shortener-url/internal/controller/grpcapi/pb"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+// realIPKey is the metadata key of the client address set by the proxy, the gRPC counterpart of X-Real-IP.
+const realIPKey = "x-real-ip"
+
+type serviceOptions struct {
+	usecase       httpapi.URLusecase
+	logger        *zap.Logger
+	baseURL       string
+	trustedSubnet netip.Prefix
+}
+
+// ServiceOption is the option for the Shortener service.
+type ServiceOption func(options *serviceOptions) error
+
+// ShortenerService implements the Shortener gRPC service on the URL usecase.
+// The user is taken from the context, where the auth interceptor puts it.
+type ShortenerService struct {
+	pb.UnimplementedShortenerServer
+
+	usecase       httpapi.URLusecase
+	logger        *zap.Logger
+	baseURL       string
+	trustedSubnet netip.Prefix
+}
+
+// WithServiceUsecase is the option for the Shortener service to set the usecase.
+func WithServiceUsecase(usecase httpapi.URLusecase) ServiceOption {
+	return func(options *serviceOptions) error {
+		options.usecase = usecase
+		return nil
+	}
+}
+
+// WithServiceLogger is the option for the Shortener service to set the logger.
+func WithServiceLogger(logger *zap.Logger) ServiceOption {
+	return func(options *serviceOptions) error {
+		if logger != nil {
+			options.logger = logger.With(zap.String("service", "ShortenerService"))
+		}
+		return nil
+	}
+}
+
+// WithServiceBaseURL is the option for the Shortener service to set the base URL of the short URLs.
+func WithServiceBaseURL(baseURL string) ServiceOption {
+	return func(options *serviceOptions) error {
+		options.baseURL = baseURL
+		return nil
+	}
+}
+
+// WithServiceTrustedSubnet is the option for the Shortener service to set the subnet, in CIDR notation,
+// allowed to read the service statistics. Every Stats call is denied while the subnet is empty.
+func WithServiceTrustedSubnet(subnet string) ServiceOption {
+	return func(options *serviceOptions) error {
+		prefix, err := clientinfo.ParseTrustedSubnet(subnet)
+		if err != nil {
+			return err
+		}
+		options.trustedSubnet = prefix
+		return nil
+	}
+}
+
+// NewShortenerService creates a new Shortener service.
+func NewShortenerService(opts ...ServiceOption) (*ShortenerService, error) {
+	options := &serviceOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.usecase == nil {
+		return nil, errors.New("usecase is required")
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if options.baseURL == "" {
+		return nil, errors.New("baseURL is required")
+	}
+	return &ShortenerService{
+		usecase:       options.usecase,
+		logger:        options.logger,
+		baseURL:       options.baseURL,
+		trustedSubnet: options.trustedSubnet,
+	}, nil
+}
+
+// Shorten shortens one URL.
+func (s *ShortenerService) Shorten(ctx context.Context, request *pb.ShortenRequest) (*pb.ShortenResponse, error) {
+	userID, err := s.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if request.GetUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "URL is required")
+	}
+
+	shortURL, err := s.usecase.Add(ctx, userID, entity.URL{
+		ExpiresAt:   toTime(request.GetExpiresAt()),
+		ShortURL:    request.GetAlias(),
+		OriginalURL: request.GetUrl(),
+		MaxClicks:   request.GetMaxClicks(),
+	})
+	if err != nil {
+		if errors.Is(err, entity.ErrURLExists) {
+			return nil, s.urlExists(shortURL)
+		}
+		return nil, s.shortenError(err)
+	}
+	return &pb.ShortenResponse{Result: s.fullURL(shortURL)}, nil
+}
+
+// ShortenBatch shortens several URLs at once, the items without a URL or a correlation ID are skipped.
+func (s *ShortenerService) ShortenBatch(
+	ctx context.Context,
+	request *pb.ShortenBatchRequest,
+) (*pb.ShortenBatchResponse, error) {
+	userID, err := s.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(request.GetUrls()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no URLs provided")
+	}
+
+	urls := make([]entity.URL, 0, len(request.GetUrls()))
+	correlationMap := make(map[string]string)
+	for _, item := range request.GetUrls() {
+		if item.GetOriginalUrl() == "" || item.GetCorrelationId() == "" {
+			continue
+		}
+		urls = append(urls, entity.URL{
+			ExpiresAt:   toTime(item.GetExpiresAt()),
+			OriginalURL: item.GetOriginalUrl(),
+			ShortURL:    item.GetAlias(),
+			MaxClicks:   item.GetMaxClicks(),
+		})
+		correlationMap[item.GetOriginalUrl()] = item.GetCorrelationId()
+	}
+
+	shortened, err := s.usecase.AddBatch(ctx, userID, urls)
+	if err != nil {
+		return nil, s.shortenError(err)
+	}
+	response := &pb.ShortenBatchResponse{Urls: make([]*pb.ShortenBatchResponse_URL, 0, len(shortened))}
+	for _, url := range shortened {
+		response.Urls = append(response.Urls, &pb.ShortenBatchResponse_URL{
+			CorrelationId: correlationMap[url.OriginalURL],
+			ShortUrl:      s.fullURL(url.ShortURL),
+		})
+	}
+	return response, nil
+}
+
+// GetOriginal resolves a short URL and records the visit like a redirect.
+func (s *ShortenerService) GetOriginal(
+	ctx context.Context,
+	request *pb.GetOriginalRequest,
+) (*pb.GetOriginalResponse, error) {
+	shortURL := strings.TrimPrefix(request.GetShortUrl(), s.baseURL+"/")
+	if shortURL == "" {
+		return nil, status.Error(codes.InvalidArgument, "short URL is required")
+	}
+
+	originalURL, err := s.usecase.GetByShortURL(ctx, shortURL)
+	if err != nil {
+		s.logger.Error("failed to get original URL", zap.Error(err))
+		switch {
+		case errors.Is(err, entity.ErrURLDeleted):
+			return nil, status.Error(codes.NotFound, "URL has been deleted")
+		case errors.Is(err, entity.ErrURLExpired):
+			return nil, status.Error(codes.NotFound, "URL has expired")
+		case errors.Is(err, entity.ErrURLNotFound):
+			return nil, status.Error(codes.NotFound, "URL not found")
+		default:
+			return nil, status.Error(codes.Internal, "failed to get URL")
+		}
+	}
+	s.usecase.RecordClick(ctx, newClick(ctx, shortURL))
+	return &pb.GetOriginalResponse{OriginalUrl: originalURL}, nil
+}
+
+// ListUserURLs lists the URLs of the user.
+func (s *ShortenerService) ListUserURLs(
+	ctx context.Context,
+	request *pb.ListUserURLsRequest,
+) (*pb.ListUserURLsResponse, error) {
+	userID, err := s.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	urls, err := s.usecase.GetUserURLs(ctx, userID, request.GetIncludeDeleted())
+	if err != nil {
+		s.logger.Error("failed to get user URLs", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to get user URLs")
+	}
+	response := &pb.ListUserURLsResponse{Urls: make([]*pb.ListUserURLsResponse_URL, 0, len(urls))}
+	for _, url := range urls {
+		response.Urls = append(response.Urls, &pb.ListUserURLsResponse_URL{
+			ShortUrl:    s.fullURL(url.ShortURL),
+			OriginalUrl: url.OriginalURL,
+			IsDeleted:   url.DeletedFlag,
+		})
+	}
+	return response, nil
+}
+
+// DeleteUserURLs queues the deletion of the user's URLs.
+func (s *ShortenerService) DeleteUserURLs(
+	ctx context.Context,
+	request *pb.DeleteUserURLsRequest,
+) (*pb.DeleteUserURLsResponse, error) {
+	userID, err := s.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(request.GetShortUrls()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no URLs provided for deletion")
+	}
+
+	job, err := s.usecase.DeleteUserURLs(ctx, userID, request.GetShortUrls())
+	if err != nil {
+		s.logger.Error("failed to delete user URLs", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to delete URLs")
+	}
+	return &pb.DeleteUserURLsResponse{Job: toJob(job)}, nil
+}
+
+// Ping checks the storage.
+func (s *ShortenerService) Ping(ctx context.Context, _ *pb.PingRequest) (*pb.PingResponse, error) {
+	if err := s.usecase.Ping(ctx); err != nil {
+		s.logger.Error("failed to ping storage", zap.Error(err))
+		return nil, status.Error(codes.Unavailable, "storage is unavailable")
+	}
+	return &pb.PingResponse{}, nil
+}
+
+// Stats counts the URLs and users of the service for the clients of the trusted subnet.
+func (s *ShortenerService) Stats(ctx context.Context, _ *pb.StatsRequest) (*pb.StatsResponse, error) {
+	if !s.trustedClient(ctx) {
+		s.logger.Warn("untrusted client", zap.String(realIPKey, realIP(ctx)))
+		return nil, status.Error(codes.PermissionDenied, "forbidden")
+	}
+
+	stats, err := s.usecase.GetServiceStats(ctx)
+	if err != nil {
+		s.logger.Error("failed to get service stats", zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to get service stats")
+	}
+	return &pb.StatsResponse{Urls: stats.URLs, Users: stats.Users}, nil
+}
+
+func (s *ShortenerService) userID(ctx context.Context) (string, error) {
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok {
+		s.logger.Error("userID not found in context")
+		return "", status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	return userID, nil
+}
+
+func (s *ShortenerService) fullURL(shortURL string) string {
+	return s.baseURL + "/" + shortURL
+}
+
+// urlExists reports the URL shortened before with its short URL in the status details.
+func (s *ShortenerService) urlExists(shortURL string) error {
+	st, err := status.New(codes.AlreadyExists, "URL already exists").
+		WithDetails(&pb.ShortenResponse{Result: s.fullURL(shortURL)})
+	if err != nil {
+		s.logger.Error("failed to add status details", zap.Error(err))
+		return status.Error(codes.AlreadyExists, "URL already exists")
+	}
+	return st.Err()
+}
+
+func (s *ShortenerService) shortenError(err error) error {
+	switch {
+	case errors.Is(err, entity.ErrAliasTaken):
+		return status.Error(codes.AlreadyExists, "alias already taken")
+	case errors.Is(err, entity.ErrInvalidAlias), errors.Is(err, entity.ErrInvalidLimits):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		s.logger.Error("failed to shorten URL", zap.Error(err))
+		return status.Error(codes.Internal, "failed to shorten URL")
+	}
+}
+
+// trustedClient reports whether the x-real-ip metadata of the call belongs to the trusted subnet.
+func (s *ShortenerService) trustedClient(ctx context.Context) bool {
+	return clientinfo.Trusted(s.trustedSubnet, realIP(ctx))
+}
+
+// realIP returns the x-real-ip metadata of the call.
+func realIP(ctx context.Context) string {
+	return firstMetadata(ctx, realIPKey)
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// newClick describes the visit of the short URL by the caller.
+func newClick(ctx context.Context, shortURL string) entity.Click {
+	return entity.Click{
+		Time:      time.Now().UTC(),
+		ShortURL:  shortURL,
+		UserAgent: clientinfo.Truncate(firstMetadata(ctx, "user-agent"), clientinfo.MaxClickFieldLength),
+		IPPrefix:  clientIPPrefix(ctx),
+	}
+}
+
+// clientIPPrefix returns the network of the caller address taken from the x-real-ip metadata or the peer.
+func clientIPPrefix(ctx context.Context) string {
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	return clientinfo.IPPrefix(realIP(ctx), remoteAddr)
+}
+
+// toTime converts an optional timestamp, nil is the zero time.
+func toTime(timestamp *timestamppb.Timestamp) time.Time {
+	if timestamp == nil {
+		return time.Time{}
+	}
+	return timestamp.AsTime()
+}
+
+// toTimestamp converts an optional time, the zero time is nil.
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func toJob(job entity.Job) *pb.Job {
+	return &pb.Job{
+		JobId:      job.ID,
+		Operation:  string(job.Operation),
+		Status:     string(job.Status),
+		ShortUrls:  job.ShortURLs,
+		FailedUrls: job.FailedURLs,
+		CreatedAt:  toTimestamp(job.CreatedAt),
+		FinishedAt: toTimestamp(job.FinishedAt),
+	}
+}
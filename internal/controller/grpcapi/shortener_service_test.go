@@ -0,0 +1,351 @@
+package grpcapi_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/grpcapi"
+	"github.com/AGENT3128/shortener-url/internal/controller/grpcapi/interceptor"
+	"github.com/AGENT3128/shortener-url/internal/controller/grpcapi/pb"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/repository/memory"
+	"github.com/AGENT3128/shortener-url/internal/usecase"
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+const baseURL = "http://localhost:8080"
+
+// newURLUsecase creates a URL usecase on the memory storage whose workers only run on shutdown.
+func newURLUsecase(t *testing.T) *usecase.URLUsecase {
+	t.Helper()
+	logger := zap.NewNop()
+	repository := memory.NewMemStorage(logger)
+	deleteWorker := worker.NewDeleteWorker(repository, worker.NewMemoryDeleteQueue(), logger)
+	clickWorker := worker.NewClickWorker(repository, logger)
+	workers := worker.NewManager(logger)
+	for name, handler := range map[string]worker.Handler{
+		worker.DeleteJobType: deleteWorker,
+		worker.ClickJobType:  clickWorker,
+	} {
+		require.NoError(t, workers.Register(
+			name,
+			handler,
+			worker.WithFlushInterval(time.Hour),
+			worker.WithDrainOnShutdown(),
+		))
+	}
+
+	urlUsecase, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(repository),
+		usecase.WithURLUsecaseLogger(logger),
+		usecase.WithDeleteWorker(deleteWorker),
+		usecase.WithClickWorker(clickWorker),
+		usecase.WithWorkers(workers),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, urlUsecase.Shutdown(context.Background()))
+	})
+	return urlUsecase
+}
+
+func requireCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	require.Error(t, err)
+	require.Equal(t, code, status.Code(err), err.Error())
+}
+
+func TestNewShortenerService(t *testing.T) {
+	urlUsecase := newURLUsecase(t)
+
+	tests := []struct {
+		name string
+		opts []grpcapi.ServiceOption
+	}{
+		{
+			name: "missing usecase",
+			opts: []grpcapi.ServiceOption{
+				grpcapi.WithServiceLogger(zap.NewNop()),
+				grpcapi.WithServiceBaseURL(baseURL),
+			},
+		},
+		{
+			name: "missing logger",
+			opts: []grpcapi.ServiceOption{
+				grpcapi.WithServiceUsecase(urlUsecase),
+				grpcapi.WithServiceBaseURL(baseURL),
+			},
+		},
+		{
+			name: "missing base URL",
+			opts: []grpcapi.ServiceOption{
+				grpcapi.WithServiceUsecase(urlUsecase),
+				grpcapi.WithServiceLogger(zap.NewNop()),
+			},
+		},
+		{
+			name: "invalid trusted subnet",
+			opts: []grpcapi.ServiceOption{
+				grpcapi.WithServiceUsecase(urlUsecase),
+				grpcapi.WithServiceLogger(zap.NewNop()),
+				grpcapi.WithServiceBaseURL(baseURL),
+				grpcapi.WithServiceTrustedSubnet("10.0.0.0/33"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := grpcapi.NewShortenerService(tt.opts...)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestShortenerService(t *testing.T) {
+	service, err := grpcapi.NewShortenerService(
+		grpcapi.WithServiceUsecase(newURLUsecase(t)),
+		grpcapi.WithServiceLogger(zap.NewNop()),
+		grpcapi.WithServiceBaseURL(baseURL),
+	)
+	require.NoError(t, err)
+	ctx := context.WithValue(t.Context(), middleware.UserIDKey, "user1")
+
+	// the calls of a user need the user in the context
+	_, err = service.Shorten(t.Context(), &pb.ShortenRequest{Url: "https://example.com"})
+	requireCode(t, err, codes.Unauthenticated)
+	_, err = service.Shorten(ctx, &pb.ShortenRequest{})
+	requireCode(t, err, codes.InvalidArgument)
+
+	shortened, err := service.Shorten(ctx, &pb.ShortenRequest{Url: "https://example.com"})
+	require.NoError(t, err)
+
+	// a URL shortened before carries its short URL in the details
+	_, err = service.Shorten(ctx, &pb.ShortenRequest{Url: "https://example.com"})
+	requireCode(t, err, codes.AlreadyExists)
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	existing, ok := details[0].(*pb.ShortenResponse)
+	require.True(t, ok)
+	require.Equal(t, shortened.GetResult(), existing.GetResult())
+
+	_, err = service.Shorten(ctx, &pb.ShortenRequest{Url: "https://example.org", Alias: "a!"})
+	requireCode(t, err, codes.InvalidArgument)
+
+	batch, err := service.ShortenBatch(ctx, &pb.ShortenBatchRequest{Urls: []*pb.ShortenBatchRequest_URL{
+		{CorrelationId: "1", OriginalUrl: "https://example.org", Alias: "example"},
+		{CorrelationId: "2", OriginalUrl: "https://example.net"},
+	}})
+	require.NoError(t, err)
+	require.Len(t, batch.GetUrls(), 2)
+	require.Equal(t, "1", batch.GetUrls()[0].GetCorrelationId())
+	require.Equal(t, baseURL+"/example", batch.GetUrls()[0].GetShortUrl())
+	_, err = service.ShortenBatch(ctx, &pb.ShortenBatchRequest{})
+	requireCode(t, err, codes.InvalidArgument)
+
+	// the short URL resolves with or without the base URL
+	original, err := service.GetOriginal(t.Context(), &pb.GetOriginalRequest{ShortUrl: shortened.GetResult()})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com", original.GetOriginalUrl())
+	original, err = service.GetOriginal(t.Context(), &pb.GetOriginalRequest{ShortUrl: "example"})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.org", original.GetOriginalUrl())
+	_, err = service.GetOriginal(t.Context(), &pb.GetOriginalRequest{ShortUrl: "unknown"})
+	requireCode(t, err, codes.NotFound)
+
+	list, err := service.ListUserURLs(ctx, &pb.ListUserURLsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetUrls(), 3)
+	list, err = service.ListUserURLs(context.WithValue(t.Context(), middleware.UserIDKey, "user2"),
+		&pb.ListUserURLsRequest{})
+	require.NoError(t, err)
+	require.Empty(t, list.GetUrls())
+
+	_, err = service.DeleteUserURLs(ctx, &pb.DeleteUserURLsRequest{})
+	requireCode(t, err, codes.InvalidArgument)
+	deleted, err := service.DeleteUserURLs(ctx, &pb.DeleteUserURLsRequest{ShortUrls: []string{"example"}})
+	require.NoError(t, err)
+	require.NotEmpty(t, deleted.GetJob().GetJobId())
+	require.Equal(t, string(entity.JobDelete), deleted.GetJob().GetOperation())
+	require.Equal(t, string(entity.JobPending), deleted.GetJob().GetStatus())
+	require.Equal(t, []string{"example"}, deleted.GetJob().GetShortUrls())
+	require.Nil(t, deleted.GetJob().GetFinishedAt())
+}
+
+func TestNewServer(t *testing.T) {
+	_, err := grpcapi.NewServer(
+		grpcapi.WithLogger(zap.NewNop()),
+		grpcapi.WithBaseURL(baseURL),
+		grpcapi.WithURLUsecase(newURLUsecase(t)),
+	)
+	require.Error(t, err)
+
+	tokens, err := middleware.NewTokenManager(middleware.WithEphemeralTokenKey())
+	require.NoError(t, err)
+	server, err := grpcapi.NewServer(
+		grpcapi.WithLogger(zap.NewNop()),
+		grpcapi.WithBaseURL(baseURL),
+		grpcapi.WithURLUsecase(newURLUsecase(t)),
+		grpcapi.WithTrustedSubnet("10.0.0.0/8"),
+		grpcapi.WithTokenManager(tokens),
+	)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewShortenerClient(conn)
+
+	_, err = client.Ping(t.Context(), &pb.PingRequest{})
+	require.NoError(t, err)
+
+	// the stats are only served to the trusted subnet
+	_, err = client.Stats(t.Context(), &pb.StatsRequest{})
+	requireCode(t, err, codes.PermissionDenied)
+	untrusted := metadata.AppendToOutgoingContext(t.Context(), "x-real-ip", "192.168.0.1")
+	_, err = client.Stats(untrusted, &pb.StatsRequest{})
+	requireCode(t, err, codes.PermissionDenied)
+	trusted := metadata.AppendToOutgoingContext(t.Context(), "x-real-ip", "10.1.2.3")
+	stats, err := client.Stats(trusted, &pb.StatsRequest{})
+	require.NoError(t, err)
+	require.Zero(t, stats.GetUrls())
+	require.Zero(t, stats.GetUsers())
+
+	// a call without a token gets a new user whose token identifies the next calls
+	var header metadata.MD
+	_, err = client.Shorten(t.Context(), &pb.ShortenRequest{Url: "https://example.com"}, grpc.Header(&header))
+	require.NoError(t, err)
+	token := header.Get(interceptor.AuthMetadataKey)
+	require.Len(t, token, 1)
+
+	authorized := metadata.AppendToOutgoingContext(t.Context(), interceptor.AuthMetadataKey, token[0])
+	header = nil
+	list, err := client.ListUserURLs(authorized, &pb.ListUserURLsRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Len(t, list.GetUrls(), 1)
+	require.Empty(t, header.Get(interceptor.AuthMetadataKey))
+
+	invalid := metadata.AppendToOutgoingContext(t.Context(), interceptor.AuthMetadataKey, "Bearer invalid")
+	_, err = client.ListUserURLs(invalid, &pb.ListUserURLsRequest{})
+	requireCode(t, err, codes.Unauthenticated)
+}
+
+// writeTestCertificate writes a self-signed certificate of localhost and its key to dir.
+func writeTestCertificate(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return cert, certPath, keyPath
+}
+
+func TestNewServer_TLS(t *testing.T) {
+	tokens, err := middleware.NewTokenManager(middleware.WithEphemeralTokenKey())
+	require.NoError(t, err)
+	dir := t.TempDir()
+	_, err = grpcapi.NewServer(
+		grpcapi.WithLogger(zap.NewNop()),
+		grpcapi.WithBaseURL(baseURL),
+		grpcapi.WithURLUsecase(newURLUsecase(t)),
+		grpcapi.WithTokenManager(tokens),
+		grpcapi.WithTLSCertPath(filepath.Join(dir, "missing.pem")),
+		grpcapi.WithTLSKeyPath(filepath.Join(dir, "missing.pem")),
+	)
+	require.Error(t, err)
+
+	cert, certPath, keyPath := writeTestCertificate(t, dir)
+	server, err := grpcapi.NewServer(
+		grpcapi.WithLogger(zap.NewNop()),
+		grpcapi.WithBaseURL(baseURL),
+		grpcapi.WithURLUsecase(newURLUsecase(t)),
+		grpcapi.WithTokenManager(tokens),
+		grpcapi.WithTLSCertPath(certPath),
+		grpcapi.WithTLSKeyPath(keyPath),
+	)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+	dialer := grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	})
+
+	// a plaintext client is refused
+	plain, err := grpc.NewClient("passthrough:///bufnet", dialer, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer plain.Close()
+	_, err = pb.NewShortenerClient(plain).Ping(t.Context(), &pb.PingRequest{})
+	requireCode(t, err, codes.Unavailable)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	secure, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		dialer,
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			MinVersion: tls.VersionTLS13,
+			RootCAs:    roots,
+			ServerName: "localhost",
+		})),
+	)
+	require.NoError(t, err)
+	defer secure.Close()
+	_, err = pb.NewShortenerClient(secure).Ping(t.Context(), &pb.PingRequest{})
+	require.NoError(t, err)
+}
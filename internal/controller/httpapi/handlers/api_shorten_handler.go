@@ -114,7 +114,12 @@ func (h *APIShortenHandler) HandlerFunc() http.HandlerFunc {
 			return
 		}
 
-		shortURL, err := h.usecase.Add(r.Context(), userID, request.URL)
+		shortURL, err := h.usecase.Add(r.Context(), userID, entity.URL{
+			ExpiresAt:   request.ExpiresAt,
+			ShortURL:    request.Alias,
+			OriginalURL: request.URL,
+			MaxClicks:   request.MaxClicks,
+		})
 		h.logger.Info("short URL", zap.String("short_url", shortURL))
 		if err != nil {
 			h.handleError(w, err)
@@ -132,10 +137,15 @@ func (h *APIShortenHandler) HandlerFunc() http.HandlerFunc {
 }
 
 func (h *APIShortenHandler) handleError(w http.ResponseWriter, err error) {
-	if errors.Is(err, entity.ErrURLExists) {
+	switch {
+	case errors.Is(err, entity.ErrURLExists):
 		JSONResponse(w, http.StatusConflict, "URL already exists")
-		return
+	case errors.Is(err, entity.ErrAliasTaken):
+		JSONResponse(w, http.StatusConflict, "Alias already taken")
+	case errors.Is(err, entity.ErrInvalidAlias), errors.Is(err, entity.ErrInvalidLimits):
+		JSONResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error("failed to shorten URL", zap.Error(err))
+		JSONResponse(w, http.StatusInternalServerError, "Failed to shorten URL")
 	}
-	h.logger.Error("failed to shorten URL", zap.Error(err))
-	JSONResponse(w, http.StatusInternalServerError, "Failed to shorten URL")
 }
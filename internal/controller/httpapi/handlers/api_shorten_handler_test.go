@@ -80,6 +80,65 @@ func TestAPIShortenHandler(t *testing.T) {
 					Return("exampleShortURL", nil)
 			},
 		},
+		{
+			name: "save url with alias",
+			request: request{
+				body:   `{"url": "https://example.com", "alias": "spring-sale"}`,
+				path:   "/api/shorten",
+				method: http.MethodPost,
+			},
+			want: want{
+				statusCode:  http.StatusCreated,
+				contentType: "application/json",
+				response:    dto.ShortenResponse{Result: "http://localhost:8080/spring-sale"},
+			},
+			setup: func() {
+				urlUsecaseMock.EXPECT().
+					Add(gomock.Any(), gomock.Any(), entity.URL{
+						ShortURL:    "spring-sale",
+						OriginalURL: "https://example.com",
+					}).
+					Return("spring-sale", nil)
+			},
+		},
+		{
+			name: "alias already taken",
+			request: request{
+				body:   `{"url": "https://example.com", "alias": "spring-sale"}`,
+				path:   "/api/shorten",
+				method: http.MethodPost,
+			},
+			want: want{
+				statusCode:  http.StatusConflict,
+				contentType: "application/json",
+				response:    handlers.Response{Status: http.StatusConflict, Message: "Alias already taken", Data: nil},
+			},
+			setup: func() {
+				urlUsecaseMock.EXPECT().
+					Add(gomock.Any(), gomock.Any(), entity.URL{
+						ShortURL:    "spring-sale",
+						OriginalURL: "https://example.com",
+					}).
+					Return("", entity.ErrAliasTaken)
+			},
+		},
+		{
+			name: "invalid alias",
+			request: request{
+				body:   `{"url": "https://example.com", "alias": "api"}`,
+				path:   "/api/shorten",
+				method: http.MethodPost,
+			},
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json",
+			},
+			setup: func() {
+				urlUsecaseMock.EXPECT().
+					Add(gomock.Any(), gomock.Any(), entity.URL{ShortURL: "api", OriginalURL: "https://example.com"}).
+					Return("", entity.ErrInvalidAlias)
+			},
+		},
 		{
 			name: "empty url in request",
 			request: request{
@@ -123,7 +123,10 @@ func (h *BatchShortenHandler) HandlerFunc() http.HandlerFunc {
 				continue
 			}
 			url := entity.URL{
+				ExpiresAt:   req.ExpiresAt,
 				OriginalURL: req.OriginalURL,
+				ShortURL:    req.Alias,
+				MaxClicks:   req.MaxClicks,
 			}
 			urls = append(urls, url)
 			correlationMap[req.OriginalURL] = req.CorrelationID
@@ -133,8 +136,7 @@ func (h *BatchShortenHandler) HandlerFunc() http.HandlerFunc {
 		shortenedURLs, err := h.usecase.AddBatch(r.Context(), userID, urls)
 		h.logger.Info("shortenedURLs", zap.Any("shortenedURLs", shortenedURLs), zap.Error(err))
 		if err != nil {
-			h.logger.Error("Failed to shorten URLs", zap.Error(err))
-			JSONResponse(w, http.StatusInternalServerError, "Failed to shorten URLs")
+			h.handleError(w, err)
 			return
 		}
 
@@ -148,6 +150,18 @@ func (h *BatchShortenHandler) HandlerFunc() http.HandlerFunc {
 	}
 }
 
+func (h *BatchShortenHandler) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, entity.ErrAliasTaken):
+		JSONResponse(w, http.StatusConflict, "Alias already taken")
+	case errors.Is(err, entity.ErrInvalidAlias), errors.Is(err, entity.ErrInvalidLimits):
+		JSONResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error("Failed to shorten URLs", zap.Error(err))
+		JSONResponse(w, http.StatusInternalServerError, "Failed to shorten URLs")
+	}
+}
+
 func (h *BatchShortenHandler) toResponse(
 	urls []entity.URL,
 	correlationMap map[string]string,
@@ -89,6 +89,32 @@ func TestBatchShortenHandler(t *testing.T) {
 					}, nil)
 			},
 		},
+		{
+			name: "alias already taken",
+			request: request{
+				body: []dto.ShortenBatchRequest{
+					{CorrelationID: "1", OriginalURL: "https://example1.com", Alias: "spring-sale"},
+				},
+				path:   "/api/shorten/batch",
+				method: http.MethodPost,
+			},
+			want: want{
+				statusCode:  http.StatusConflict,
+				contentType: "application/json",
+				response: handlers.Response{
+					Status:  http.StatusConflict,
+					Message: "Alias already taken",
+					Data:    nil,
+				},
+			},
+			setup: func() {
+				batchURLSaverMock.EXPECT().
+					AddBatch(gomock.Any(), gomock.Any(), []entity.URL{
+						{OriginalURL: "https://example1.com", ShortURL: "spring-sale"},
+					}).
+					Return(nil, entity.ErrAliasTaken)
+			},
+		},
 		{
 			name: "incorrect body",
 			request: request{
@@ -22,9 +22,11 @@ import (
 func Example_getUserURLs() {
 	// create logger
 	logger := zap.NewNop()
-	// create auth middleware
+	// create token manager and auth middleware
+	tokens, _ := customMiddleware.NewTokenManager(customMiddleware.WithEphemeralTokenKey())
 	authMiddleware, _ := customMiddleware.NewAuthMiddleware(
 		customMiddleware.WithAuthMiddlewareLogger(logger),
+		customMiddleware.WithAuthMiddlewareTokens(tokens),
 	)
 	// create router
 	r := chi.NewRouter()
@@ -54,7 +56,8 @@ func Example_getUserURLs() {
 
 	// Send request with user token
 	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/user/urls", nil)
-	req.Header.Set("Authorization", "Bearer user-token")
+	token, _ := tokens.Generate("user1")
+	req.Header.Set("Authorization", "Bearer "+token)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -77,9 +80,11 @@ func Example_getUserURLs() {
 func Example_deleteUserURLs() {
 	// create logger
 	logger := zap.NewNop()
-	// create auth middleware
+	// create token manager and auth middleware
+	tokens, _ := customMiddleware.NewTokenManager(customMiddleware.WithEphemeralTokenKey())
 	authMiddleware, _ := customMiddleware.NewAuthMiddleware(
 		customMiddleware.WithAuthMiddlewareLogger(logger),
+		customMiddleware.WithAuthMiddlewareTokens(tokens),
 	)
 	// create router
 	r := chi.NewRouter()
@@ -90,6 +95,7 @@ func Example_deleteUserURLs() {
 	// create delete worker
 	deleteWorker := worker.NewDeleteWorker(
 		urlRepository,
+		worker.NewMemoryDeleteQueue(),
 		logger,
 	)
 	// create url usecase
@@ -122,7 +128,8 @@ func Example_deleteUserURLs() {
 
 	// Send request
 	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/api/user/urls", bytes.NewBuffer(jsonData))
-	req.Header.Set("Authorization", "Bearer user-token")
+	token, _ := tokens.Generate("user1")
+	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := http.DefaultClient.Do(req)
@@ -142,9 +149,11 @@ func Example_deleteUserURLs() {
 func Example_pingDB() {
 	// create logger
 	logger := zap.NewNop()
-	// create auth middleware
+	// create token manager and auth middleware
+	tokens, _ := customMiddleware.NewTokenManager(customMiddleware.WithEphemeralTokenKey())
 	authMiddleware, _ := customMiddleware.NewAuthMiddleware(
 		customMiddleware.WithAuthMiddlewareLogger(logger),
+		customMiddleware.WithAuthMiddlewareTokens(tokens),
 	)
 	// create router
 	r := chi.NewRouter()
@@ -168,9 +177,10 @@ func Example_pingDB() {
 	ts := httptest.NewServer(r)
 	defer ts.Close()
 
-	// Create request with authorization header
+	// Create request with the token of the user
 	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/ping", nil)
-	req.Header.Set("Authorization", "Bearer user-token")
+	token, _ := tokens.Generate("user1")
+	req.Header.Set("Authorization", "Bearer "+token)
 
 	// Send request
 	resp, err := http.DefaultClient.Do(req)
@@ -8,7 +8,7 @@ import (
 
 //go:generate mockgen -source=interfaces.go -destination=./mocks/handlers_mock.go -package=mocks
 type URLSaver interface {
-	Add(ctx context.Context, userID string, originalURL string) (string, error)
+	Add(ctx context.Context, userID string, url entity.URL) (string, error)
 }
 
 // URLGetter is the interface for the URL getter.
@@ -28,10 +28,60 @@ type BatchURLSaver interface {
 
 // UserURLGetter is the interface for the user URL getter.
 type UserURLGetter interface {
-	GetUserURLs(ctx context.Context, userID string) ([]entity.URL, error)
+	GetUserURLs(ctx context.Context, userID string, includeDeleted bool) ([]entity.URL, error)
+}
+
+// UserURLUpdater is the interface for the user URL updater.
+type UserURLUpdater interface {
+	UpdateOriginalURL(ctx context.Context, userID, shortURL, originalURL string) ([]entity.URLChange, error)
 }
 
 // UserURLDeleter is the interface for the user URL deleter.
 type UserURLDeleter interface {
-	DeleteUserURLs(ctx context.Context, userID string, shortURLs []string) error
+	DeleteUserURLs(ctx context.Context, userID string, shortURLs []string) (entity.Job, error)
+}
+
+// UserURLRestorer is the interface for the user URL restorer.
+type UserURLRestorer interface {
+	RestoreUserURLs(ctx context.Context, userID string, shortURLs []string) (entity.Job, error)
+}
+
+// UserJobGetter is the interface for the user job getter.
+type UserJobGetter interface {
+	GetUserJob(ctx context.Context, userID, jobID string) (entity.Job, error)
+}
+
+// ClickRecorder is the interface for the click recorder.
+type ClickRecorder interface {
+	RecordClick(ctx context.Context, click entity.Click)
+}
+
+// ServiceStatsGetter is the interface for the service stats getter.
+type ServiceStatsGetter interface {
+	GetServiceStats(ctx context.Context) (entity.ServiceStats, error)
+}
+
+// DeadLettersGetter is the interface for the dead letters getter.
+type DeadLettersGetter interface {
+	GetDeadLetters(ctx context.Context) ([]entity.DeleteTask, error)
+}
+
+// URLStatsGetter is the interface for the URL stats getter.
+type URLStatsGetter interface {
+	GetURLStats(ctx context.Context, userID, shortURL string) (entity.URLStats, error)
+}
+
+// APIKeyCreator is the interface for the API key creator.
+type APIKeyCreator interface {
+	CreateAPIKey(ctx context.Context, userID, name string, scopes []string) (entity.APIKey, string, error)
+}
+
+// UserAPIKeysGetter is the interface for the user API keys getter.
+type UserAPIKeysGetter interface {
+	GetUserAPIKeys(ctx context.Context, userID string) ([]entity.APIKey, error)
+}
+
+// APIKeyDeleter is the interface for the API key deleter.
+type APIKeyDeleter interface {
+	DeleteAPIKeys(ctx context.Context, userID string, ids []string) ([]string, error)
 }
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/clientinfo"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+)
+
+type internalDeadLettersOptions struct {
+	usecase       DeadLettersGetter
+	logger        *zap.Logger
+	trustedSubnet netip.Prefix
+}
+
+// InternalDeadLettersOption is the option for the internal dead letters handler.
+type InternalDeadLettersOption func(options *internalDeadLettersOptions) error
+
+// InternalDeadLettersHandler is the handler for the delete and restore jobs that ran out of attempts.
+// Only clients whose X-Real-IP belongs to the trusted subnet are served.
+type InternalDeadLettersHandler struct {
+	usecase       DeadLettersGetter
+	logger        *zap.Logger
+	trustedSubnet netip.Prefix
+}
+
+// WithInternalDeadLettersUsecase is the option for the internal dead letters handler to set the usecase.
+func WithInternalDeadLettersUsecase(usecase DeadLettersGetter) InternalDeadLettersOption {
+	return func(options *internalDeadLettersOptions) error {
+		options.usecase = usecase
+		return nil
+	}
+}
+
+// WithInternalDeadLettersLogger is the option for the internal dead letters handler to set the logger.
+func WithInternalDeadLettersLogger(logger *zap.Logger) InternalDeadLettersOption {
+	return func(options *internalDeadLettersOptions) error {
+		options.logger = logger.With(zap.String("handler", "InternalDeadLettersHandler"))
+		return nil
+	}
+}
+
+// WithInternalDeadLettersTrustedSubnet is the option for the internal dead letters handler to set
+// the trusted subnet in CIDR notation. Every request is forbidden while the subnet is empty.
+func WithInternalDeadLettersTrustedSubnet(subnet string) InternalDeadLettersOption {
+	return func(options *internalDeadLettersOptions) error {
+		prefix, err := clientinfo.ParseTrustedSubnet(subnet)
+		if err != nil {
+			return err
+		}
+		options.trustedSubnet = prefix
+		return nil
+	}
+}
+
+// NewInternalDeadLettersHandler creates a new internal dead letters handler.
+func NewInternalDeadLettersHandler(opts ...InternalDeadLettersOption) (*InternalDeadLettersHandler, error) {
+	options := &internalDeadLettersOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.usecase == nil {
+		return nil, errors.New("usecase is required")
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	return &InternalDeadLettersHandler{
+		usecase:       options.usecase,
+		logger:        options.logger,
+		trustedSubnet: options.trustedSubnet,
+	}, nil
+}
+
+// Pattern is the pattern for the internal dead letters.
+func (h *InternalDeadLettersHandler) Pattern() string {
+	return "/api/internal/dead-letters"
+}
+
+// Method is the method for the internal dead letters.
+func (h *InternalDeadLettersHandler) Method() string {
+	return http.MethodGet
+}
+
+// HandlerFunc is the handler func for the internal dead letters.
+func (h *InternalDeadLettersHandler) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !clientinfo.Trusted(h.trustedSubnet, r.Header.Get("X-Real-IP")) {
+			h.logger.Warn("untrusted client", zap.String("x_real_ip", r.Header.Get("X-Real-IP")))
+			JSONResponse(w, http.StatusForbidden, "Forbidden")
+			return
+		}
+
+		tasks, err := h.usecase.GetDeadLetters(r.Context())
+		if err != nil {
+			h.logger.Error("failed to get dead letters", zap.Error(err))
+			JSONResponse(w, http.StatusInternalServerError, "Failed to get dead letters")
+			return
+		}
+
+		response := make([]dto.DeadLetterResponse, 0, len(tasks))
+		for _, task := range tasks {
+			response = append(response, dto.DeadLetterResponse{
+				CreatedAt: task.Job.CreatedAt,
+				DeadAt:    task.DeadAt,
+				JobID:     task.Job.ID,
+				UserID:    task.Job.UserID,
+				Operation: string(task.Job.Operation),
+				LastError: task.LastError,
+				ShortURLs: task.Job.ShortURLs,
+				Attempts:  task.Attempts,
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if errEncode := json.NewEncoder(w).Encode(response); errEncode != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
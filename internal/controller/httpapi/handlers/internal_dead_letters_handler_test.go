@@ -0,0 +1,168 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers/mocks"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+func TestInternalDeadLettersHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usecase := mocks.NewMockDeadLettersGetter(ctrl)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	_, err = handlers.NewInternalDeadLettersHandler(
+		handlers.WithInternalDeadLettersUsecase(usecase),
+		handlers.WithInternalDeadLettersLogger(logger),
+		handlers.WithInternalDeadLettersTrustedSubnet("10.0.0.0/33"),
+	)
+	require.Error(t, err)
+
+	newRouter := func(subnet string) *chi.Mux {
+		handler, errHandler := handlers.NewInternalDeadLettersHandler(
+			handlers.WithInternalDeadLettersUsecase(usecase),
+			handlers.WithInternalDeadLettersLogger(logger),
+			handlers.WithInternalDeadLettersTrustedSubnet(subnet),
+		)
+		require.NoError(t, errHandler)
+		require.Equal(t, "/api/internal/dead-letters", handler.Pattern())
+		require.Equal(t, http.MethodGet, handler.Method())
+
+		router := chi.NewRouter()
+		router.Method(handler.Method(), handler.Pattern(), handler.HandlerFunc())
+		return router
+	}
+
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	deadAt := createdAt.Add(time.Minute)
+	task := entity.DeleteTask{
+		DeadAt: deadAt,
+		Job: entity.Job{
+			CreatedAt: createdAt,
+			ID:        "job1",
+			UserID:    "user1",
+			Operation: entity.JobDelete,
+			Status:    entity.JobPending,
+			ShortURLs: []string{"abc123", "def456"},
+		},
+		LastError: "connection refused",
+		Attempts:  5,
+	}
+
+	type want struct {
+		response   any
+		statusCode int
+	}
+	tests := []struct {
+		setup  func()
+		name   string
+		subnet string
+		realIP string
+		want   want
+	}{
+		{
+			name:   "trusted ip",
+			subnet: "192.168.1.0/24",
+			realIP: "192.168.1.17",
+			want: want{
+				statusCode: http.StatusOK,
+				response: []dto.DeadLetterResponse{
+					{
+						CreatedAt: createdAt,
+						DeadAt:    deadAt,
+						JobID:     "job1",
+						UserID:    "user1",
+						Operation: "delete",
+						LastError: "connection refused",
+						ShortURLs: []string{"abc123", "def456"},
+						Attempts:  5,
+					},
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().GetDeadLetters(gomock.Any()).Return([]entity.DeleteTask{task}, nil)
+			},
+		},
+		{
+			name:   "no dead letters",
+			subnet: "192.168.1.0/24",
+			realIP: "192.168.1.17",
+			want: want{
+				statusCode: http.StatusOK,
+				response:   []dto.DeadLetterResponse{},
+			},
+			setup: func() {
+				usecase.EXPECT().GetDeadLetters(gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name:   "untrusted ip",
+			subnet: "192.168.1.0/24",
+			realIP: "192.168.2.17",
+			want: want{
+				statusCode: http.StatusForbidden,
+				response:   handlers.Response{Status: http.StatusForbidden, Message: "Forbidden", Data: "Forbidden"},
+			},
+		},
+		{
+			name:   "subnet not configured",
+			realIP: "192.168.1.17",
+			want: want{
+				statusCode: http.StatusForbidden,
+				response:   handlers.Response{Status: http.StatusForbidden, Message: "Forbidden", Data: "Forbidden"},
+			},
+		},
+		{
+			name:   "queue error",
+			subnet: "192.168.1.0/24",
+			realIP: "192.168.1.17",
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				response: handlers.Response{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error",
+					Data:    "Failed to get dead letters",
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().GetDeadLetters(gomock.Any()).Return(nil, errors.New("connection refused"))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.setup != nil {
+				test.setup()
+			}
+			req, errRequest := http.NewRequest(http.MethodGet, "/api/internal/dead-letters", nil)
+			require.NoError(t, errRequest)
+			if test.realIP != "" {
+				req.Header.Set("X-Real-IP", test.realIP)
+			}
+			recorder := httptest.NewRecorder()
+			newRouter(test.subnet).ServeHTTP(recorder, req)
+
+			require.Equal(t, test.want.statusCode, recorder.Code)
+			require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			expected, errMarshal := json.Marshal(test.want.response)
+			require.NoError(t, errMarshal)
+			require.JSONEq(t, string(expected), recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/clientinfo"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+)
+
+type internalStatsOptions struct {
+	usecase       ServiceStatsGetter
+	logger        *zap.Logger
+	trustedSubnet netip.Prefix
+}
+
+// InternalStatsOption is the option for the internal stats handler.
+type InternalStatsOption func(options *internalStatsOptions) error
+
+// InternalStatsHandler is the handler for the statistics of the whole service.
+// Only clients whose X-Real-IP belongs to the trusted subnet are served.
+type InternalStatsHandler struct {
+	usecase       ServiceStatsGetter
+	logger        *zap.Logger
+	trustedSubnet netip.Prefix
+}
+
+// WithInternalStatsUsecase is the option for the internal stats handler to set the usecase.
+func WithInternalStatsUsecase(usecase ServiceStatsGetter) InternalStatsOption {
+	return func(options *internalStatsOptions) error {
+		options.usecase = usecase
+		return nil
+	}
+}
+
+// WithInternalStatsLogger is the option for the internal stats handler to set the logger.
+func WithInternalStatsLogger(logger *zap.Logger) InternalStatsOption {
+	return func(options *internalStatsOptions) error {
+		options.logger = logger.With(zap.String("handler", "InternalStatsHandler"))
+		return nil
+	}
+}
+
+// WithInternalStatsTrustedSubnet is the option for the internal stats handler to set the trusted subnet
+// in CIDR notation. Every request is forbidden while the subnet is empty.
+func WithInternalStatsTrustedSubnet(subnet string) InternalStatsOption {
+	return func(options *internalStatsOptions) error {
+		prefix, err := clientinfo.ParseTrustedSubnet(subnet)
+		if err != nil {
+			return err
+		}
+		options.trustedSubnet = prefix
+		return nil
+	}
+}
+
+// NewInternalStatsHandler creates a new internal stats handler.
+func NewInternalStatsHandler(opts ...InternalStatsOption) (*InternalStatsHandler, error) {
+	options := &internalStatsOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.usecase == nil {
+		return nil, errors.New("usecase is required")
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	return &InternalStatsHandler{
+		usecase:       options.usecase,
+		logger:        options.logger,
+		trustedSubnet: options.trustedSubnet,
+	}, nil
+}
+
+// Pattern is the pattern for the internal stats.
+func (h *InternalStatsHandler) Pattern() string {
+	return "/api/internal/stats"
+}
+
+// Method is the method for the internal stats.
+func (h *InternalStatsHandler) Method() string {
+	return http.MethodGet
+}
+
+// HandlerFunc is the handler func for the internal stats.
+func (h *InternalStatsHandler) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !clientinfo.Trusted(h.trustedSubnet, r.Header.Get("X-Real-IP")) {
+			h.logger.Warn("untrusted client", zap.String("x_real_ip", r.Header.Get("X-Real-IP")))
+			JSONResponse(w, http.StatusForbidden, "Forbidden")
+			return
+		}
+
+		stats, err := h.usecase.GetServiceStats(r.Context())
+		if err != nil {
+			h.logger.Error("failed to get service stats", zap.Error(err))
+			JSONResponse(w, http.StatusInternalServerError, "Failed to get service stats")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		response := dto.ServiceStatsResponse{URLs: stats.URLs, Users: stats.Users}
+		if errEncode := json.NewEncoder(w).Encode(response); errEncode != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers/mocks"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+func TestInternalStatsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usecase := mocks.NewMockServiceStatsGetter(ctrl)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	_, err = handlers.NewInternalStatsHandler(
+		handlers.WithInternalStatsUsecase(usecase),
+		handlers.WithInternalStatsLogger(logger),
+		handlers.WithInternalStatsTrustedSubnet("10.0.0.0/33"),
+	)
+	require.Error(t, err)
+
+	newRouter := func(subnet string) *chi.Mux {
+		handler, errHandler := handlers.NewInternalStatsHandler(
+			handlers.WithInternalStatsUsecase(usecase),
+			handlers.WithInternalStatsLogger(logger),
+			handlers.WithInternalStatsTrustedSubnet(subnet),
+		)
+		require.NoError(t, errHandler)
+		require.Equal(t, "/api/internal/stats", handler.Pattern())
+		require.Equal(t, http.MethodGet, handler.Method())
+
+		router := chi.NewRouter()
+		router.Method(handler.Method(), handler.Pattern(), handler.HandlerFunc())
+		return router
+	}
+
+	type want struct {
+		response   any
+		statusCode int
+	}
+	tests := []struct {
+		setup  func()
+		name   string
+		subnet string
+		realIP string
+		want   want
+	}{
+		{
+			name:   "trusted ipv4",
+			subnet: "192.168.1.0/24",
+			realIP: "192.168.1.17",
+			want: want{
+				statusCode: http.StatusOK,
+				response:   dto.ServiceStatsResponse{URLs: 10, Users: 3},
+			},
+			setup: func() {
+				usecase.EXPECT().GetServiceStats(gomock.Any()).Return(entity.ServiceStats{URLs: 10, Users: 3}, nil)
+			},
+		},
+		{
+			name:   "trusted ipv6",
+			subnet: "2001:db8::/32",
+			realIP: "2001:db8:1::5",
+			want: want{
+				statusCode: http.StatusOK,
+				response:   dto.ServiceStatsResponse{},
+			},
+			setup: func() {
+				usecase.EXPECT().GetServiceStats(gomock.Any()).Return(entity.ServiceStats{}, nil)
+			},
+		},
+		{
+			name:   "untrusted ip",
+			subnet: "192.168.1.0/24",
+			realIP: "192.168.2.17",
+			want: want{
+				statusCode: http.StatusForbidden,
+				response:   handlers.Response{Status: http.StatusForbidden, Message: "Forbidden", Data: "Forbidden"},
+			},
+		},
+		{
+			name:   "missing real ip",
+			subnet: "192.168.1.0/24",
+			want: want{
+				statusCode: http.StatusForbidden,
+				response:   handlers.Response{Status: http.StatusForbidden, Message: "Forbidden", Data: "Forbidden"},
+			},
+		},
+		{
+			name:   "subnet not configured",
+			realIP: "192.168.1.17",
+			want: want{
+				statusCode: http.StatusForbidden,
+				response:   handlers.Response{Status: http.StatusForbidden, Message: "Forbidden", Data: "Forbidden"},
+			},
+		},
+		{
+			name:   "repository error",
+			subnet: "192.168.1.0/24",
+			realIP: "192.168.1.17",
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				response: handlers.Response{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error",
+					Data:    "Failed to get service stats",
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					GetServiceStats(gomock.Any()).
+					Return(entity.ServiceStats{}, errors.New("connection refused"))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.setup != nil {
+				test.setup()
+			}
+			req, errRequest := http.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+			require.NoError(t, errRequest)
+			if test.realIP != "" {
+				req.Header.Set("X-Real-IP", test.realIP)
+			}
+			recorder := httptest.NewRecorder()
+			newRouter(test.subnet).ServeHTTP(recorder, req)
+
+			require.Equal(t, test.want.statusCode, recorder.Code)
+			require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			expected, errMarshal := json.Marshal(test.want.response)
+			require.NoError(t, errMarshal)
+			require.JSONEq(t, string(expected), recorder.Body.String())
+		})
+	}
+}
@@ -42,18 +42,18 @@ func (m *MockURLSaver) EXPECT() *MockURLSaverMockRecorder {
 }
 
 // Add mocks base method.
-func (m *MockURLSaver) Add(ctx context.Context, userID, originalURL string) (string, error) {
+func (m *MockURLSaver) Add(ctx context.Context, userID string, url entity.URL) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Add", ctx, userID, originalURL)
+	ret := m.ctrl.Call(m, "Add", ctx, userID, url)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Add indicates an expected call of Add.
-func (mr *MockURLSaverMockRecorder) Add(ctx, userID, originalURL any) *gomock.Call {
+func (mr *MockURLSaverMockRecorder) Add(ctx, userID, url any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockURLSaver)(nil).Add), ctx, userID, originalURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockURLSaver)(nil).Add), ctx, userID, url)
 }
 
 // MockURLGetter is a mock of URLGetter interface.
@@ -197,18 +197,57 @@ func (m *MockUserURLGetter) EXPECT() *MockUserURLGetterMockRecorder {
 }
 
 // GetUserURLs mocks base method.
-func (m *MockUserURLGetter) GetUserURLs(ctx context.Context, userID string) ([]entity.URL, error) {
+func (m *MockUserURLGetter) GetUserURLs(ctx context.Context, userID string, includeDeleted bool) ([]entity.URL, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserURLs", ctx, userID)
+	ret := m.ctrl.Call(m, "GetUserURLs", ctx, userID, includeDeleted)
 	ret0, _ := ret[0].([]entity.URL)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserURLs indicates an expected call of GetUserURLs.
-func (mr *MockUserURLGetterMockRecorder) GetUserURLs(ctx, userID any) *gomock.Call {
+func (mr *MockUserURLGetterMockRecorder) GetUserURLs(ctx, userID, includeDeleted any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserURLs", reflect.TypeOf((*MockUserURLGetter)(nil).GetUserURLs), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserURLs", reflect.TypeOf((*MockUserURLGetter)(nil).GetUserURLs), ctx, userID, includeDeleted)
+}
+
+// MockUserURLUpdater is a mock of UserURLUpdater interface.
+type MockUserURLUpdater struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockUserURLUpdaterMockRecorder
+}
+
+// MockUserURLUpdaterMockRecorder is the mock recorder for MockUserURLUpdater.
+type MockUserURLUpdaterMockRecorder struct {
+	mock *MockUserURLUpdater
+}
+
+// NewMockUserURLUpdater creates a new mock instance.
+func NewMockUserURLUpdater(ctrl *gomock.Controller) *MockUserURLUpdater {
+	mock := &MockUserURLUpdater{ctrl: ctrl}
+	mock.recorder = &MockUserURLUpdaterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserURLUpdater) EXPECT() *MockUserURLUpdaterMockRecorder {
+	return m.recorder
+}
+
+// UpdateOriginalURL mocks base method.
+func (m *MockUserURLUpdater) UpdateOriginalURL(ctx context.Context, userID, shortURL, originalURL string) ([]entity.URLChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOriginalURL", ctx, userID, shortURL, originalURL)
+	ret0, _ := ret[0].([]entity.URLChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOriginalURL indicates an expected call of UpdateOriginalURL.
+func (mr *MockUserURLUpdaterMockRecorder) UpdateOriginalURL(ctx, userID, shortURL, originalURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOriginalURL", reflect.TypeOf((*MockUserURLUpdater)(nil).UpdateOriginalURL), ctx, userID, shortURL, originalURL)
 }
 
 // MockUserURLDeleter is a mock of UserURLDeleter interface.
@@ -236,11 +275,12 @@ func (m *MockUserURLDeleter) EXPECT() *MockUserURLDeleterMockRecorder {
 }
 
 // DeleteUserURLs mocks base method.
-func (m *MockUserURLDeleter) DeleteUserURLs(ctx context.Context, userID string, shortURLs []string) error {
+func (m *MockUserURLDeleter) DeleteUserURLs(ctx context.Context, userID string, shortURLs []string) (entity.Job, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteUserURLs", ctx, userID, shortURLs)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(entity.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // DeleteUserURLs indicates an expected call of DeleteUserURLs.
@@ -248,3 +288,352 @@ func (mr *MockUserURLDeleterMockRecorder) DeleteUserURLs(ctx, userID, shortURLs
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserURLs", reflect.TypeOf((*MockUserURLDeleter)(nil).DeleteUserURLs), ctx, userID, shortURLs)
 }
+
+// MockUserURLRestorer is a mock of UserURLRestorer interface.
+type MockUserURLRestorer struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockUserURLRestorerMockRecorder
+}
+
+// MockUserURLRestorerMockRecorder is the mock recorder for MockUserURLRestorer.
+type MockUserURLRestorerMockRecorder struct {
+	mock *MockUserURLRestorer
+}
+
+// NewMockUserURLRestorer creates a new mock instance.
+func NewMockUserURLRestorer(ctrl *gomock.Controller) *MockUserURLRestorer {
+	mock := &MockUserURLRestorer{ctrl: ctrl}
+	mock.recorder = &MockUserURLRestorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserURLRestorer) EXPECT() *MockUserURLRestorerMockRecorder {
+	return m.recorder
+}
+
+// RestoreUserURLs mocks base method.
+func (m *MockUserURLRestorer) RestoreUserURLs(ctx context.Context, userID string, shortURLs []string) (entity.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUserURLs", ctx, userID, shortURLs)
+	ret0, _ := ret[0].(entity.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreUserURLs indicates an expected call of RestoreUserURLs.
+func (mr *MockUserURLRestorerMockRecorder) RestoreUserURLs(ctx, userID, shortURLs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUserURLs", reflect.TypeOf((*MockUserURLRestorer)(nil).RestoreUserURLs), ctx, userID, shortURLs)
+}
+
+// MockUserJobGetter is a mock of UserJobGetter interface.
+type MockUserJobGetter struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockUserJobGetterMockRecorder
+}
+
+// MockUserJobGetterMockRecorder is the mock recorder for MockUserJobGetter.
+type MockUserJobGetterMockRecorder struct {
+	mock *MockUserJobGetter
+}
+
+// NewMockUserJobGetter creates a new mock instance.
+func NewMockUserJobGetter(ctrl *gomock.Controller) *MockUserJobGetter {
+	mock := &MockUserJobGetter{ctrl: ctrl}
+	mock.recorder = &MockUserJobGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserJobGetter) EXPECT() *MockUserJobGetterMockRecorder {
+	return m.recorder
+}
+
+// GetUserJob mocks base method.
+func (m *MockUserJobGetter) GetUserJob(ctx context.Context, userID, jobID string) (entity.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserJob", ctx, userID, jobID)
+	ret0, _ := ret[0].(entity.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserJob indicates an expected call of GetUserJob.
+func (mr *MockUserJobGetterMockRecorder) GetUserJob(ctx, userID, jobID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserJob", reflect.TypeOf((*MockUserJobGetter)(nil).GetUserJob), ctx, userID, jobID)
+}
+
+// MockClickRecorder is a mock of ClickRecorder interface.
+type MockClickRecorder struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockClickRecorderMockRecorder
+}
+
+// MockClickRecorderMockRecorder is the mock recorder for MockClickRecorder.
+type MockClickRecorderMockRecorder struct {
+	mock *MockClickRecorder
+}
+
+// NewMockClickRecorder creates a new mock instance.
+func NewMockClickRecorder(ctrl *gomock.Controller) *MockClickRecorder {
+	mock := &MockClickRecorder{ctrl: ctrl}
+	mock.recorder = &MockClickRecorderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClickRecorder) EXPECT() *MockClickRecorderMockRecorder {
+	return m.recorder
+}
+
+// RecordClick mocks base method.
+func (m *MockClickRecorder) RecordClick(ctx context.Context, click entity.Click) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordClick", ctx, click)
+}
+
+// RecordClick indicates an expected call of RecordClick.
+func (mr *MockClickRecorderMockRecorder) RecordClick(ctx, click any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordClick", reflect.TypeOf((*MockClickRecorder)(nil).RecordClick), ctx, click)
+}
+
+// MockServiceStatsGetter is a mock of ServiceStatsGetter interface.
+type MockServiceStatsGetter struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockServiceStatsGetterMockRecorder
+}
+
+// MockServiceStatsGetterMockRecorder is the mock recorder for MockServiceStatsGetter.
+type MockServiceStatsGetterMockRecorder struct {
+	mock *MockServiceStatsGetter
+}
+
+// NewMockServiceStatsGetter creates a new mock instance.
+func NewMockServiceStatsGetter(ctrl *gomock.Controller) *MockServiceStatsGetter {
+	mock := &MockServiceStatsGetter{ctrl: ctrl}
+	mock.recorder = &MockServiceStatsGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServiceStatsGetter) EXPECT() *MockServiceStatsGetterMockRecorder {
+	return m.recorder
+}
+
+// GetServiceStats mocks base method.
+func (m *MockServiceStatsGetter) GetServiceStats(ctx context.Context) (entity.ServiceStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceStats", ctx)
+	ret0, _ := ret[0].(entity.ServiceStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceStats indicates an expected call of GetServiceStats.
+func (mr *MockServiceStatsGetterMockRecorder) GetServiceStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceStats", reflect.TypeOf((*MockServiceStatsGetter)(nil).GetServiceStats), ctx)
+}
+
+// MockDeadLettersGetter is a mock of DeadLettersGetter interface.
+type MockDeadLettersGetter struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockDeadLettersGetterMockRecorder
+}
+
+// MockDeadLettersGetterMockRecorder is the mock recorder for MockDeadLettersGetter.
+type MockDeadLettersGetterMockRecorder struct {
+	mock *MockDeadLettersGetter
+}
+
+// NewMockDeadLettersGetter creates a new mock instance.
+func NewMockDeadLettersGetter(ctrl *gomock.Controller) *MockDeadLettersGetter {
+	mock := &MockDeadLettersGetter{ctrl: ctrl}
+	mock.recorder = &MockDeadLettersGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeadLettersGetter) EXPECT() *MockDeadLettersGetterMockRecorder {
+	return m.recorder
+}
+
+// GetDeadLetters mocks base method.
+func (m *MockDeadLettersGetter) GetDeadLetters(ctx context.Context) ([]entity.DeleteTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeadLetters", ctx)
+	ret0, _ := ret[0].([]entity.DeleteTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeadLetters indicates an expected call of GetDeadLetters.
+func (mr *MockDeadLettersGetterMockRecorder) GetDeadLetters(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeadLetters", reflect.TypeOf((*MockDeadLettersGetter)(nil).GetDeadLetters), ctx)
+}
+
+// MockURLStatsGetter is a mock of URLStatsGetter interface.
+type MockURLStatsGetter struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockURLStatsGetterMockRecorder
+}
+
+// MockURLStatsGetterMockRecorder is the mock recorder for MockURLStatsGetter.
+type MockURLStatsGetterMockRecorder struct {
+	mock *MockURLStatsGetter
+}
+
+// NewMockURLStatsGetter creates a new mock instance.
+func NewMockURLStatsGetter(ctrl *gomock.Controller) *MockURLStatsGetter {
+	mock := &MockURLStatsGetter{ctrl: ctrl}
+	mock.recorder = &MockURLStatsGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockURLStatsGetter) EXPECT() *MockURLStatsGetterMockRecorder {
+	return m.recorder
+}
+
+// GetURLStats mocks base method.
+func (m *MockURLStatsGetter) GetURLStats(ctx context.Context, userID, shortURL string) (entity.URLStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLStats", ctx, userID, shortURL)
+	ret0, _ := ret[0].(entity.URLStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLStats indicates an expected call of GetURLStats.
+func (mr *MockURLStatsGetterMockRecorder) GetURLStats(ctx, userID, shortURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLStats", reflect.TypeOf((*MockURLStatsGetter)(nil).GetURLStats), ctx, userID, shortURL)
+}
+
+// MockAPIKeyCreator is a mock of APIKeyCreator interface.
+type MockAPIKeyCreator struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyCreatorMockRecorder
+}
+
+// MockAPIKeyCreatorMockRecorder is the mock recorder for MockAPIKeyCreator.
+type MockAPIKeyCreatorMockRecorder struct {
+	mock *MockAPIKeyCreator
+}
+
+// NewMockAPIKeyCreator creates a new mock instance.
+func NewMockAPIKeyCreator(ctrl *gomock.Controller) *MockAPIKeyCreator {
+	mock := &MockAPIKeyCreator{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyCreatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyCreator) EXPECT() *MockAPIKeyCreatorMockRecorder {
+	return m.recorder
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAPIKeyCreator) CreateAPIKey(ctx context.Context, userID, name string, scopes []string) (entity.APIKey, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, userID, name, scopes)
+	ret0, _ := ret[0].(entity.APIKey)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeyCreatorMockRecorder) CreateAPIKey(ctx, userID, name, scopes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeyCreator)(nil).CreateAPIKey), ctx, userID, name, scopes)
+}
+
+// MockUserAPIKeysGetter is a mock of UserAPIKeysGetter interface.
+type MockUserAPIKeysGetter struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockUserAPIKeysGetterMockRecorder
+}
+
+// MockUserAPIKeysGetterMockRecorder is the mock recorder for MockUserAPIKeysGetter.
+type MockUserAPIKeysGetterMockRecorder struct {
+	mock *MockUserAPIKeysGetter
+}
+
+// NewMockUserAPIKeysGetter creates a new mock instance.
+func NewMockUserAPIKeysGetter(ctrl *gomock.Controller) *MockUserAPIKeysGetter {
+	mock := &MockUserAPIKeysGetter{ctrl: ctrl}
+	mock.recorder = &MockUserAPIKeysGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserAPIKeysGetter) EXPECT() *MockUserAPIKeysGetterMockRecorder {
+	return m.recorder
+}
+
+// GetUserAPIKeys mocks base method.
+func (m *MockUserAPIKeysGetter) GetUserAPIKeys(ctx context.Context, userID string) ([]entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAPIKeys", ctx, userID)
+	ret0, _ := ret[0].([]entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAPIKeys indicates an expected call of GetUserAPIKeys.
+func (mr *MockUserAPIKeysGetterMockRecorder) GetUserAPIKeys(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAPIKeys", reflect.TypeOf((*MockUserAPIKeysGetter)(nil).GetUserAPIKeys), ctx, userID)
+}
+
+// MockAPIKeyDeleter is a mock of APIKeyDeleter interface.
+type MockAPIKeyDeleter struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyDeleterMockRecorder
+}
+
+// MockAPIKeyDeleterMockRecorder is the mock recorder for MockAPIKeyDeleter.
+type MockAPIKeyDeleterMockRecorder struct {
+	mock *MockAPIKeyDeleter
+}
+
+// NewMockAPIKeyDeleter creates a new mock instance.
+func NewMockAPIKeyDeleter(ctrl *gomock.Controller) *MockAPIKeyDeleter {
+	mock := &MockAPIKeyDeleter{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyDeleterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyDeleter) EXPECT() *MockAPIKeyDeleterMockRecorder {
+	return m.recorder
+}
+
+// DeleteAPIKeys mocks base method.
+func (m *MockAPIKeyDeleter) DeleteAPIKeys(ctx context.Context, userID string, ids []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIKeys", ctx, userID, ids)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAPIKeys indicates an expected call of DeleteAPIKeys.
+func (mr *MockAPIKeyDeleterMockRecorder) DeleteAPIKeys(ctx, userID, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIKeys", reflect.TypeOf((*MockAPIKeyDeleter)(nil).DeleteAPIKeys), ctx, userID, ids)
+}
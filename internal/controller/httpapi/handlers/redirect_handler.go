@@ -4,9 +4,11 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/AGENT3128/shortener-url/internal/controller/clientinfo"
 	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
 	"github.com/AGENT3128/shortener-url/internal/entity"
 )
@@ -14,11 +16,13 @@ import (
 // RedirectHandler is the handler for the redirect.
 type RedirectHandler struct {
 	usecase URLGetter
+	clicks  ClickRecorder
 	logger  *zap.Logger
 }
 
 type redirectOptions struct {
 	usecase URLGetter
+	clicks  ClickRecorder
 	logger  *zap.Logger
 }
 
@@ -33,6 +37,15 @@ func WithRedirectUsecase(usecase URLGetter) RedirectOption {
 	}
 }
 
+// WithRedirectClickRecorder is the option for the redirect handler to record the clicks.
+// Clicks are not recorded without it.
+func WithRedirectClickRecorder(clicks ClickRecorder) RedirectOption {
+	return func(options *redirectOptions) error {
+		options.clicks = clicks
+		return nil
+	}
+}
+
 // WithRedirectLogger is the option for the redirect handler to set the logger.
 func WithRedirectLogger(logger *zap.Logger) RedirectOption {
 	return func(options *redirectOptions) error {
@@ -56,7 +69,7 @@ func NewRedirectHandler(opts ...RedirectOption) (*RedirectHandler, error) {
 	if options.logger == nil {
 		return nil, errors.New("logger is required")
 	}
-	return &RedirectHandler{usecase: options.usecase, logger: options.logger}, nil
+	return &RedirectHandler{usecase: options.usecase, clicks: options.clicks, logger: options.logger}, nil
 }
 
 // Pattern is the pattern for the redirect.
@@ -92,6 +105,9 @@ func (h *RedirectHandler) HandlerFunc() http.HandlerFunc {
 			h.handleError(w, err)
 			return
 		}
+		if h.clicks != nil {
+			h.clicks.RecordClick(r.Context(), newClick(w, r, shortURL))
+		}
 
 		w.Header().Set("Location", originalURL)
 		w.Header().Set("Content-Type", "text/plain")
@@ -101,9 +117,26 @@ func (h *RedirectHandler) HandlerFunc() http.HandlerFunc {
 
 func (h *RedirectHandler) handleError(w http.ResponseWriter, err error) {
 	h.logger.Error("failed to get original URL", zap.Error(err))
-	if errors.Is(err, entity.ErrURLDeleted) {
+	switch {
+	case errors.Is(err, entity.ErrURLDeleted):
 		JSONResponse(w, http.StatusGone, "URL has been deleted")
-		return
+	case errors.Is(err, entity.ErrURLExpired):
+		JSONResponse(w, http.StatusGone, "URL has expired")
+	case errors.Is(err, entity.ErrURLNotFound):
+		JSONResponse(w, http.StatusNotFound, "URL not found")
+	default:
+		JSONResponse(w, http.StatusInternalServerError, "Failed to get URL")
+	}
+}
+
+// newClick describes the redirect of the request through the short URL.
+func newClick(w http.ResponseWriter, r *http.Request, shortURL string) entity.Click {
+	return entity.Click{
+		Time:      time.Now().UTC(),
+		ShortURL:  shortURL,
+		Referrer:  clientinfo.Truncate(r.Referer(), clientinfo.MaxClickFieldLength),
+		UserAgent: clientinfo.Truncate(r.UserAgent(), clientinfo.MaxClickFieldLength),
+		IPPrefix:  clientinfo.IPPrefix(r.Header.Get("X-Real-IP"), r.RemoteAddr),
+		Gzip:      w.Header().Get("Content-Encoding") == "gzip",
 	}
-	JSONResponse(w, http.StatusNotFound, "URL not found")
 }
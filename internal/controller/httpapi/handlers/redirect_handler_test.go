@@ -1,9 +1,13 @@
 package handlers_test
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
@@ -87,6 +91,21 @@ func TestRedirectHandler(t *testing.T) {
 				usecase.EXPECT().GetByShortURL(gomock.Any(), "shortURL1234").Return("", entity.ErrURLDeleted)
 			},
 		},
+		{
+			name: "url expired",
+			request: request{
+				path:   "/shortURL1234",
+				method: http.MethodGet,
+			},
+			want: want{
+				statusCode:  http.StatusGone,
+				contentType: "application/json",
+				location:    "",
+			},
+			setup: func() {
+				usecase.EXPECT().GetByShortURL(gomock.Any(), "shortURL1234").Return("", entity.ErrURLExpired)
+			},
+		},
 		{
 			name: "url not found",
 			request: request{
@@ -102,6 +121,21 @@ func TestRedirectHandler(t *testing.T) {
 				usecase.EXPECT().GetByShortURL(gomock.Any(), "shortURL12345").Return("", entity.ErrURLNotFound)
 			},
 		},
+		{
+			name: "repository error",
+			request: request{
+				path:   "/shortURL12345",
+				method: http.MethodGet,
+			},
+			want: want{
+				statusCode:  http.StatusInternalServerError,
+				contentType: "application/json",
+				location:    "",
+			},
+			setup: func() {
+				usecase.EXPECT().GetByShortURL(gomock.Any(), "shortURL12345").Return("", errors.New("connection refused"))
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -117,3 +151,87 @@ func TestRedirectHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectHandlerRecordsClick(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usecase := mocks.NewMockURLGetter(ctrl)
+	clicks := mocks.NewMockClickRecorder(ctrl)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	handler, err := handlers.NewRedirectHandler(
+		handlers.WithRedirectUsecase(usecase),
+		handlers.WithRedirectClickRecorder(clicks),
+		handlers.WithRedirectLogger(logger),
+	)
+	require.NoError(t, err)
+
+	authMiddleware, err := customMiddleware.NewAuthMiddleware(
+		customMiddleware.WithAuthMiddlewareLogger(logger),
+	)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(authMiddleware.Handler())
+	router.Use(customMiddleware.GzipMiddleware())
+	router.Method(handler.Method(), handler.Pattern(), handler.HandlerFunc())
+
+	tests := []struct {
+		header http.Header
+		name   string
+		remote string
+		want   entity.Click
+	}{
+		{
+			name:   "ipv4 remote address",
+			remote: "203.0.113.57:41234",
+			header: http.Header{
+				"Referer":         {"https://example.com/page"},
+				"User-Agent":      {"curl/8.0"},
+				"Accept-Encoding": {"gzip"},
+			},
+			want: entity.Click{
+				ShortURL:  "shortURL123",
+				Referrer:  "https://example.com/page",
+				UserAgent: "curl/8.0",
+				IPPrefix:  "203.0.113.0/24",
+				Gzip:      true,
+			},
+		},
+		{
+			name:   "ipv6 real ip",
+			remote: "10.0.0.1:80",
+			header: http.Header{
+				"X-Real-Ip":  {"2001:db8:abcd:12::1"},
+				"User-Agent": {strings.Repeat("a", 600)},
+			},
+			want: entity.Click{
+				ShortURL:  "shortURL123",
+				UserAgent: strings.Repeat("a", 512),
+				IPPrefix:  "2001:db8:abcd::/48",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			usecase.EXPECT().GetByShortURL(gomock.Any(), "shortURL123").Return("https://example.com", nil)
+			var recorded entity.Click
+			clicks.EXPECT().RecordClick(gomock.Any(), gomock.Any()).Do(func(_ context.Context, click entity.Click) {
+				recorded = click
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/shortURL123", nil)
+			req.RemoteAddr = test.remote
+			req.Header = test.header
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, http.StatusTemporaryRedirect, recorder.Code)
+			require.WithinDuration(t, time.Now(), recorded.Time, time.Minute)
+			recorded.Time = time.Time{}
+			require.Equal(t, test.want, recorded)
+		})
+	}
+}
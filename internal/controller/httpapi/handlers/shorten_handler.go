@@ -113,7 +113,7 @@ func (h *ShortenHandler) HandlerFunc() http.HandlerFunc {
 			return
 		}
 
-		shortURL, err := h.usecase.Add(r.Context(), userID, originalURL)
+		shortURL, err := h.usecase.Add(r.Context(), userID, entity.URL{OriginalURL: originalURL})
 		if err != nil {
 			h.handleError(w, err, shortURL)
 			return
@@ -75,7 +75,9 @@ func TestShortenHandler(t *testing.T) {
 				response:    "http://localhost:8080/shortURL123",
 			},
 			setup: func() {
-				usecase.EXPECT().Add(gomock.Any(), gomock.Any(), "https://example.com").Return("shortURL123", nil)
+				usecase.EXPECT().
+					Add(gomock.Any(), gomock.Any(), entity.URL{OriginalURL: "https://example.com"}).
+					Return("shortURL123", nil)
 			},
 		},
 		{
@@ -92,7 +94,7 @@ func TestShortenHandler(t *testing.T) {
 			},
 			setup: func() {
 				usecase.EXPECT().
-					Add(gomock.Any(), gomock.Any(), "https://example.com").
+					Add(gomock.Any(), gomock.Any(), entity.URL{OriginalURL: "https://example.com"}).
 					Return("shortURL123", entity.ErrURLExists)
 			},
 		},
@@ -114,7 +116,7 @@ func TestShortenHandler(t *testing.T) {
 			},
 			setup: func() {
 				usecase.EXPECT().
-					Add(gomock.Any(), gomock.Any(), "https://example.com").
+					Add(gomock.Any(), gomock.Any(), entity.URL{OriginalURL: "https://example.com"}).
 					Return("", errors.New("failed to add URL"))
 			},
 		},
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+const statsDateLayout = "2006-01-02"
+
+type urlStatsOptions struct {
+	usecase URLStatsGetter
+	logger  *zap.Logger
+	baseURL string
+}
+
+// URLStatsOption is the option for the URL stats handler.
+type URLStatsOption func(options *urlStatsOptions) error
+
+// URLStatsHandler is the handler for the click statistics of a user URL.
+type URLStatsHandler struct {
+	usecase URLStatsGetter
+	logger  *zap.Logger
+	baseURL string
+}
+
+// WithURLStatsBaseURL is the option for the URL stats handler to set the base URL.
+func WithURLStatsBaseURL(baseURL string) URLStatsOption {
+	return func(options *urlStatsOptions) error {
+		options.baseURL = baseURL
+		return nil
+	}
+}
+
+// WithURLStatsUsecase is the option for the URL stats handler to set the usecase.
+func WithURLStatsUsecase(usecase URLStatsGetter) URLStatsOption {
+	return func(options *urlStatsOptions) error {
+		options.usecase = usecase
+		return nil
+	}
+}
+
+// WithURLStatsLogger is the option for the URL stats handler to set the logger.
+func WithURLStatsLogger(logger *zap.Logger) URLStatsOption {
+	return func(options *urlStatsOptions) error {
+		options.logger = logger.With(zap.String("handler", "URLStatsHandler"))
+		return nil
+	}
+}
+
+// NewURLStatsHandler creates a new URL stats handler.
+func NewURLStatsHandler(opts ...URLStatsOption) (*URLStatsHandler, error) {
+	options := &urlStatsOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.usecase == nil {
+		return nil, errors.New("usecase is required")
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	return &URLStatsHandler{
+		usecase: options.usecase,
+		logger:  options.logger,
+		baseURL: options.baseURL,
+	}, nil
+}
+
+// Pattern is the pattern for the URL stats.
+func (h *URLStatsHandler) Pattern() string {
+	return "/api/user/urls/{id}/stats"
+}
+
+// Method is the method for the URL stats.
+func (h *URLStatsHandler) Method() string {
+	return http.MethodGet
+}
+
+// HandlerFunc is the handler func for the URL stats.
+func (h *URLStatsHandler) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+		if !ok {
+			h.logger.Error("userID not found in context")
+			JSONResponse(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		shortURL := chi.URLParam(r, "id")
+		stats, err := h.usecase.GetURLStats(r.Context(), userID, shortURL)
+		if err != nil {
+			h.logger.Error("failed to get URL stats", zap.String("short_url", shortURL), zap.Error(err))
+			if errors.Is(err, entity.ErrURLNotFound) {
+				JSONResponse(w, http.StatusNotFound, "URL not found")
+				return
+			}
+			JSONResponse(w, http.StatusInternalServerError, "Failed to get URL stats")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if errEncode := json.NewEncoder(w).Encode(h.toResponse(stats)); errEncode != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
+
+func (h *URLStatsHandler) toResponse(stats entity.URLStats) dto.URLStatsResponse {
+	response := dto.URLStatsResponse{
+		ShortURL: h.baseURL + "/" + stats.ShortURL,
+		Daily:    make([]dto.DailyClicksResponse, 0, len(stats.Daily)),
+		Total:    stats.Total,
+	}
+	for _, day := range stats.Daily {
+		response.Daily = append(response.Daily, dto.DailyClicksResponse{
+			Date:   day.Date.Format(statsDateLayout),
+			Clicks: day.Clicks,
+		})
+	}
+	return response
+}
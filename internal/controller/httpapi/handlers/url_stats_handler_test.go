@@ -0,0 +1,157 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers/mocks"
+	customMiddleware "github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+func TestURLStatsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usecase := mocks.NewMockURLStatsGetter(ctrl)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	handler, err := handlers.NewURLStatsHandler(
+		handlers.WithURLStatsBaseURL("http://localhost:8080"),
+		handlers.WithURLStatsUsecase(usecase),
+		handlers.WithURLStatsLogger(logger),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, "/api/user/urls/{id}/stats", handler.Pattern())
+	require.Equal(t, http.MethodGet, handler.Method())
+
+	authMiddleware, err := customMiddleware.NewAuthMiddleware(
+		customMiddleware.WithAuthMiddlewareLogger(logger),
+	)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(authMiddleware.Handler())
+	router.Method(handler.Method(), handler.Pattern(), handler.HandlerFunc())
+
+	day := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	type want struct {
+		response    any
+		contentType string
+		statusCode  int
+	}
+	tests := []struct {
+		setup func()
+		name  string
+		path  string
+		want  want
+	}{
+		{
+			name: "success",
+			path: "/api/user/urls/shortURL1/stats",
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+				response: dto.URLStatsResponse{
+					ShortURL: "http://localhost:8080/shortURL1",
+					Daily: []dto.DailyClicksResponse{
+						{Date: "2025-03-01", Clicks: 2},
+						{Date: "2025-03-02", Clicks: 1},
+					},
+					Total: 3,
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().GetURLStats(gomock.Any(), gomock.Any(), "shortURL1").Return(entity.URLStats{
+					ShortURL: "shortURL1",
+					Daily: []entity.DailyClicks{
+						{Date: day, Clicks: 2},
+						{Date: day.AddDate(0, 0, 1), Clicks: 1},
+					},
+					Total: 3,
+				}, nil)
+			},
+		},
+		{
+			name: "no clicks",
+			path: "/api/user/urls/shortURL2/stats",
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+				response: dto.URLStatsResponse{
+					ShortURL: "http://localhost:8080/shortURL2",
+					Daily:    []dto.DailyClicksResponse{},
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					GetURLStats(gomock.Any(), gomock.Any(), "shortURL2").
+					Return(entity.URLStats{ShortURL: "shortURL2"}, nil)
+			},
+		},
+		{
+			name: "url not found",
+			path: "/api/user/urls/shortURL3/stats",
+			want: want{
+				statusCode:  http.StatusNotFound,
+				contentType: "application/json",
+				response: handlers.Response{
+					Status:  http.StatusNotFound,
+					Message: "Not Found",
+					Data:    "URL not found",
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					GetURLStats(gomock.Any(), gomock.Any(), "shortURL3").
+					Return(entity.URLStats{}, entity.ErrURLNotFound)
+			},
+		},
+		{
+			name: "repository error",
+			path: "/api/user/urls/shortURL4/stats",
+			want: want{
+				statusCode:  http.StatusInternalServerError,
+				contentType: "application/json",
+				response: handlers.Response{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error",
+					Data:    "Failed to get URL stats",
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					GetURLStats(gomock.Any(), gomock.Any(), "shortURL4").
+					Return(entity.URLStats{}, errors.New("connection refused"))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.setup()
+			req, errRequest := http.NewRequest(http.MethodGet, test.path, nil)
+			require.NoError(t, errRequest)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, test.want.statusCode, recorder.Code)
+			require.Equal(t, test.want.contentType, recorder.Header().Get("Content-Type"))
+			expected, errMarshal := json.Marshal(test.want.response)
+			require.NoError(t, errMarshal)
+			require.JSONEq(t, string(expected), recorder.Body.String())
+		})
+	}
+}
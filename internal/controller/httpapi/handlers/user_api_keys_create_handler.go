@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+type userAPIKeysCreateOptions struct {
+	usecase APIKeyCreator
+	logger  *zap.Logger
+}
+
+// UserAPIKeysCreateOption is the option for the user API keys create handler.
+type UserAPIKeysCreateOption func(options *userAPIKeysCreateOptions) error
+
+// UserAPIKeysCreateHandler is the handler for creating an API key of the user.
+type UserAPIKeysCreateHandler struct {
+	usecase APIKeyCreator
+	logger  *zap.Logger
+}
+
+// WithUserAPIKeysCreateUsecase is the option for the user API keys create handler to set the usecase.
+func WithUserAPIKeysCreateUsecase(usecase APIKeyCreator) UserAPIKeysCreateOption {
+	return func(options *userAPIKeysCreateOptions) error {
+		options.usecase = usecase
+		return nil
+	}
+}
+
+// WithUserAPIKeysCreateLogger is the option for the user API keys create handler to set the logger.
+func WithUserAPIKeysCreateLogger(logger *zap.Logger) UserAPIKeysCreateOption {
+	return func(options *userAPIKeysCreateOptions) error {
+		options.logger = logger.With(zap.String("handler", "UserAPIKeysCreateHandler"))
+		return nil
+	}
+}
+
+// NewUserAPIKeysCreateHandler creates a new user API keys create handler.
+func NewUserAPIKeysCreateHandler(opts ...UserAPIKeysCreateOption) (*UserAPIKeysCreateHandler, error) {
+	options := &userAPIKeysCreateOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.usecase == nil {
+		return nil, errors.New("usecase is required")
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	return &UserAPIKeysCreateHandler{
+		usecase: options.usecase,
+		logger:  options.logger,
+	}, nil
+}
+
+// Pattern is the pattern for the user API keys create.
+func (h *UserAPIKeysCreateHandler) Pattern() string {
+	return "/api/user/keys"
+}
+
+// Method is the method for the user API keys create.
+func (h *UserAPIKeysCreateHandler) Method() string {
+	return http.MethodPost
+}
+
+// HandlerFunc is the handler func for the user API keys create.
+// The response is the only one carrying the key itself.
+func (h *UserAPIKeysCreateHandler) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+		if !ok {
+			h.logger.Error("userID not found in context")
+			JSONResponse(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var request dto.CreateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			h.logger.Error("failed to bind request body", zap.Error(err))
+			JSONResponse(w, http.StatusBadRequest, "invalid request format")
+			return
+		}
+
+		key, plain, err := h.usecase.CreateAPIKey(r.Context(), userID, request.Name, request.Scopes)
+		if err != nil {
+			if errors.Is(err, entity.ErrInvalidScope) {
+				JSONResponse(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			h.logger.Error("failed to create API key", zap.Error(err))
+			JSONResponse(w, http.StatusInternalServerError, "Failed to create API key")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		// the key must not be kept by caches, it is never shown again
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusCreated)
+		response := dto.CreateAPIKeyResponse{
+			CreatedAt: key.CreatedAt,
+			ID:        key.ID,
+			Name:      key.Name,
+			Prefix:    key.Prefix,
+			Key:       plain,
+			Scopes:    scopeStrings(key.Scopes),
+		}
+		if errEncode := json.NewEncoder(w).Encode(response); errEncode != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
+
+// scopeStrings converts the scopes of an API key for the responses.
+func scopeStrings(scopes []entity.APIKeyScope) []string {
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		result = append(result, string(scope))
+	}
+	return result
+}
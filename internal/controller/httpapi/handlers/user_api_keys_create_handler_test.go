@@ -0,0 +1,157 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers/mocks"
+	customMiddleware "github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+func TestUserAPIKeysCreateHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usecase := mocks.NewMockAPIKeyCreator(ctrl)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	_, err = handlers.NewUserAPIKeysCreateHandler(handlers.WithUserAPIKeysCreateLogger(logger))
+	require.Error(t, err)
+
+	handler, err := handlers.NewUserAPIKeysCreateHandler(
+		handlers.WithUserAPIKeysCreateUsecase(usecase),
+		handlers.WithUserAPIKeysCreateLogger(logger),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, "/api/user/keys", handler.Pattern())
+	require.Equal(t, http.MethodPost, handler.Method())
+
+	authMiddleware, err := customMiddleware.NewAuthMiddleware(
+		customMiddleware.WithAuthMiddlewareLogger(logger),
+	)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(authMiddleware.Handler())
+	router.Method(handler.Method(), handler.Pattern(), handler.HandlerFunc())
+
+	createdAt := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	key := entity.APIKey{
+		CreatedAt: createdAt,
+		ID:        "key1",
+		Name:      "ci",
+		Prefix:    "sk_abcdefgh",
+		Scopes:    []entity.APIKeyScope{entity.ScopeShorten, entity.ScopeRead},
+	}
+	type want struct {
+		response   any
+		statusCode int
+	}
+	tests := []struct {
+		setup func()
+		body  any
+		name  string
+		want  want
+	}{
+		{
+			name: "success",
+			body: dto.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"shorten", "read"}},
+			want: want{
+				statusCode: http.StatusCreated,
+				response: dto.CreateAPIKeyResponse{
+					CreatedAt: createdAt,
+					ID:        "key1",
+					Name:      "ci",
+					Prefix:    "sk_abcdefgh",
+					Key:       "sk_abcdefgh-secret",
+					Scopes:    []string{"shorten", "read"},
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any(), "ci", []string{"shorten", "read"}).
+					Return(key, "sk_abcdefgh-secret", nil)
+			},
+		},
+		{
+			name: "invalid request body",
+			body: "invalid",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				response: handlers.Response{
+					Status:  http.StatusBadRequest,
+					Message: "Bad Request",
+					Data:    "invalid request format",
+				},
+			},
+			setup: func() {},
+		},
+		{
+			name: "invalid scope",
+			body: dto.CreateAPIKeyRequest{Scopes: []string{"admin"}},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				response: handlers.Response{
+					Status:  http.StatusBadRequest,
+					Message: "Bad Request",
+					Data:    `invalid api key scope: "admin"`,
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any(), "", []string{"admin"}).
+					Return(entity.APIKey{}, "", fmt.Errorf("%w: %q", entity.ErrInvalidScope, "admin"))
+			},
+		},
+		{
+			name: "internal server error",
+			body: dto.CreateAPIKeyRequest{Scopes: []string{"read"}},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				response: handlers.Response{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error",
+					Data:    "Failed to create API key",
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any(), "", []string{"read"}).
+					Return(entity.APIKey{}, "", errors.New("storage is down"))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.setup()
+			body, errMarshal := json.Marshal(test.body)
+			require.NoError(t, errMarshal)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/keys", bytes.NewReader(body))
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, test.want.statusCode, recorder.Code)
+			require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			expected, errExpected := json.Marshal(test.want.response)
+			require.NoError(t, errExpected)
+			require.JSONEq(t, string(expected), recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+)
+
+type userAPIKeysDeleteOptions struct {
+	usecase APIKeyDeleter
+	logger  *zap.Logger
+}
+
+// UserAPIKeysDeleteOption is the option for the user API keys delete handler.
+type UserAPIKeysDeleteOption func(options *userAPIKeysDeleteOptions) error
+
+// UserAPIKeysDeleteHandler is the handler for revoking API keys of the user.
+type UserAPIKeysDeleteHandler struct {
+	usecase APIKeyDeleter
+	logger  *zap.Logger
+}
+
+// WithUserAPIKeysDeleteUsecase is the option for the user API keys delete handler to set the usecase.
+func WithUserAPIKeysDeleteUsecase(usecase APIKeyDeleter) UserAPIKeysDeleteOption {
+	return func(options *userAPIKeysDeleteOptions) error {
+		options.usecase = usecase
+		return nil
+	}
+}
+
+// WithUserAPIKeysDeleteLogger is the option for the user API keys delete handler to set the logger.
+func WithUserAPIKeysDeleteLogger(logger *zap.Logger) UserAPIKeysDeleteOption {
+	return func(options *userAPIKeysDeleteOptions) error {
+		options.logger = logger.With(zap.String("handler", "UserAPIKeysDeleteHandler"))
+		return nil
+	}
+}
+
+// NewUserAPIKeysDeleteHandler creates a new user API keys delete handler.
+func NewUserAPIKeysDeleteHandler(opts ...UserAPIKeysDeleteOption) (*UserAPIKeysDeleteHandler, error) {
+	options := &userAPIKeysDeleteOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.usecase == nil {
+		return nil, errors.New("usecase is required")
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	return &UserAPIKeysDeleteHandler{
+		usecase: options.usecase,
+		logger:  options.logger,
+	}, nil
+}
+
+// Pattern is the pattern for the user API keys delete.
+func (h *UserAPIKeysDeleteHandler) Pattern() string {
+	return "/api/user/keys"
+}
+
+// Method is the method for the user API keys delete.
+func (h *UserAPIKeysDeleteHandler) Method() string {
+	return http.MethodDelete
+}
+
+// HandlerFunc is the handler func for the user API keys delete.
+// The body is the JSON array of the IDs of the keys to revoke.
+func (h *UserAPIKeysDeleteHandler) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+		if !ok {
+			h.logger.Error("userID not found in context")
+			JSONResponse(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		var ids []string
+		if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+			h.logger.Error("failed to bind request body", zap.Error(err))
+			JSONResponse(w, http.StatusBadRequest, "invalid request format")
+			return
+		}
+
+		if len(ids) == 0 {
+			JSONResponse(w, http.StatusBadRequest, "no API keys provided for deletion")
+			return
+		}
+
+		deleted, err := h.usecase.DeleteAPIKeys(r.Context(), userID, ids)
+		if err != nil {
+			h.logger.Error("failed to delete API keys", zap.Error(err))
+			JSONResponse(w, http.StatusInternalServerError, "failed to delete API keys")
+			return
+		}
+		if len(deleted) == 0 {
+			JSONResponse(w, http.StatusNotFound, "API keys not found")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
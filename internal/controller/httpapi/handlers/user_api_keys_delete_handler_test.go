@@ -0,0 +1,135 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers/mocks"
+	customMiddleware "github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+)
+
+func TestUserAPIKeysDeleteHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usecase := mocks.NewMockAPIKeyDeleter(ctrl)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	handler, err := handlers.NewUserAPIKeysDeleteHandler(
+		handlers.WithUserAPIKeysDeleteUsecase(usecase),
+		handlers.WithUserAPIKeysDeleteLogger(logger),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, "/api/user/keys", handler.Pattern())
+	require.Equal(t, http.MethodDelete, handler.Method())
+
+	authMiddleware, err := customMiddleware.NewAuthMiddleware(
+		customMiddleware.WithAuthMiddlewareLogger(logger),
+	)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(authMiddleware.Handler())
+	router.Method(handler.Method(), handler.Pattern(), handler.HandlerFunc())
+
+	type want struct {
+		response   any
+		statusCode int
+	}
+	tests := []struct {
+		setup func()
+		body  any
+		name  string
+		want  want
+	}{
+		{
+			name: "success",
+			body: []string{"key1", "key2"},
+			want: want{statusCode: http.StatusNoContent},
+			setup: func() {
+				usecase.EXPECT().
+					DeleteAPIKeys(gomock.Any(), gomock.Any(), []string{"key1", "key2"}).
+					Return([]string{"key1"}, nil)
+			},
+		},
+		{
+			name: "not found",
+			body: []string{"key3"},
+			want: want{
+				statusCode: http.StatusNotFound,
+				response: handlers.Response{
+					Status:  http.StatusNotFound,
+					Message: "Not Found",
+					Data:    "API keys not found",
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					DeleteAPIKeys(gomock.Any(), gomock.Any(), []string{"key3"}).
+					Return([]string{}, nil)
+			},
+		},
+		{
+			name: "empty body",
+			body: nil,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				response: handlers.Response{
+					Status:  http.StatusBadRequest,
+					Message: "Bad Request",
+					Data:    "no API keys provided for deletion",
+				},
+			},
+			setup: func() {},
+		},
+		{
+			name: "internal server error",
+			body: []string{"key1"},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				response: handlers.Response{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error",
+					Data:    "failed to delete API keys",
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					DeleteAPIKeys(gomock.Any(), gomock.Any(), []string{"key1"}).
+					Return(nil, errors.New("storage is down"))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.setup()
+			body, errMarshal := json.Marshal(test.body)
+			require.NoError(t, errMarshal)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/user/keys", bytes.NewReader(body))
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, test.want.statusCode, recorder.Code)
+			if test.want.response == nil {
+				require.Empty(t, recorder.Body.String())
+				return
+			}
+			expected, errExpected := json.Marshal(test.want.response)
+			require.NoError(t, errExpected)
+			require.JSONEq(t, string(expected), recorder.Body.String())
+		})
+	}
+}
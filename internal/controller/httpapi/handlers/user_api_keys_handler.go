@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+type userAPIKeysOptions struct {
+	usecase UserAPIKeysGetter
+	logger  *zap.Logger
+}
+
+// UserAPIKeysOption is the option for the user API keys handler.
+type UserAPIKeysOption func(options *userAPIKeysOptions) error
+
+// UserAPIKeysHandler is the handler for listing the API keys of the user.
+type UserAPIKeysHandler struct {
+	usecase UserAPIKeysGetter
+	logger  *zap.Logger
+}
+
+// WithUserAPIKeysUsecase is the option for the user API keys handler to set the usecase.
+func WithUserAPIKeysUsecase(usecase UserAPIKeysGetter) UserAPIKeysOption {
+	return func(options *userAPIKeysOptions) error {
+		options.usecase = usecase
+		return nil
+	}
+}
+
+// WithUserAPIKeysLogger is the option for the user API keys handler to set the logger.
+func WithUserAPIKeysLogger(logger *zap.Logger) UserAPIKeysOption {
+	return func(options *userAPIKeysOptions) error {
+		options.logger = logger.With(zap.String("handler", "UserAPIKeysHandler"))
+		return nil
+	}
+}
+
+// NewUserAPIKeysHandler creates a new user API keys handler.
+func NewUserAPIKeysHandler(opts ...UserAPIKeysOption) (*UserAPIKeysHandler, error) {
+	options := &userAPIKeysOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.usecase == nil {
+		return nil, errors.New("usecase is required")
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	return &UserAPIKeysHandler{
+		usecase: options.usecase,
+		logger:  options.logger,
+	}, nil
+}
+
+// Pattern is the pattern for the user API keys.
+func (h *UserAPIKeysHandler) Pattern() string {
+	return "/api/user/keys"
+}
+
+// Method is the method for the user API keys.
+func (h *UserAPIKeysHandler) Method() string {
+	return http.MethodGet
+}
+
+// HandlerFunc is the handler func for the user API keys.
+func (h *UserAPIKeysHandler) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+		if !ok {
+			h.logger.Error("userID not found in context")
+			JSONResponse(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		keys, err := h.usecase.GetUserAPIKeys(r.Context(), userID)
+		if err != nil {
+			h.logger.Error("failed to get user API keys", zap.Error(err))
+			JSONResponse(w, http.StatusInternalServerError, "Failed to get API keys")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if errEncode := json.NewEncoder(w).Encode(toAPIKeysResponse(keys)); errEncode != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
+
+func toAPIKeysResponse(keys []entity.APIKey) []dto.APIKeyResponse {
+	response := make([]dto.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, dto.APIKeyResponse{
+			CreatedAt: key.CreatedAt,
+			ID:        key.ID,
+			Name:      key.Name,
+			Prefix:    key.Prefix,
+			Scopes:    scopeStrings(key.Scopes),
+		})
+	}
+	return response
+}
@@ -0,0 +1,126 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers/mocks"
+	customMiddleware "github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+func TestUserAPIKeysHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usecase := mocks.NewMockUserAPIKeysGetter(ctrl)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	handler, err := handlers.NewUserAPIKeysHandler(
+		handlers.WithUserAPIKeysUsecase(usecase),
+		handlers.WithUserAPIKeysLogger(logger),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, "/api/user/keys", handler.Pattern())
+	require.Equal(t, http.MethodGet, handler.Method())
+
+	authMiddleware, err := customMiddleware.NewAuthMiddleware(
+		customMiddleware.WithAuthMiddlewareLogger(logger),
+	)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(authMiddleware.Handler())
+	router.Method(handler.Method(), handler.Pattern(), handler.HandlerFunc())
+
+	createdAt := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	type want struct {
+		response   any
+		statusCode int
+	}
+	tests := []struct {
+		setup func()
+		name  string
+		want  want
+	}{
+		{
+			name: "keys without the keys themselves",
+			want: want{
+				statusCode: http.StatusOK,
+				response: []dto.APIKeyResponse{
+					{
+						CreatedAt: createdAt,
+						ID:        "key1",
+						Name:      "ci",
+						Prefix:    "sk_abcdefgh",
+						Scopes:    []string{"read"},
+					},
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().GetUserAPIKeys(gomock.Any(), gomock.Any()).Return([]entity.APIKey{
+					{
+						CreatedAt: createdAt,
+						ID:        "key1",
+						UserID:    "user1",
+						Name:      "ci",
+						Prefix:    "sk_abcdefgh",
+						Hash:      "hash",
+						Scopes:    []entity.APIKeyScope{entity.ScopeRead},
+					},
+				}, nil)
+			},
+		},
+		{
+			name: "no keys",
+			want: want{
+				statusCode: http.StatusOK,
+				response:   []dto.APIKeyResponse{},
+			},
+			setup: func() {
+				usecase.EXPECT().GetUserAPIKeys(gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name: "internal server error",
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				response: handlers.Response{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error",
+					Data:    "Failed to get API keys",
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().GetUserAPIKeys(gomock.Any(), gomock.Any()).Return(nil, errors.New("storage is down"))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.setup()
+			req := httptest.NewRequest(http.MethodGet, "/api/user/keys", nil)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, test.want.statusCode, recorder.Code)
+			require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			expected, errExpected := json.Marshal(test.want.response)
+			require.NoError(t, errExpected)
+			require.JSONEq(t, string(expected), recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+// userJobPath is the path of the user jobs, the job ID is appended to it.
+const userJobPath = "/api/user/jobs/"
+
+type userJobOptions struct {
+	usecase UserJobGetter
+	logger  *zap.Logger
+}
+
+// UserJobOption is the option for the user job handler.
+type UserJobOption func(options *userJobOptions) error
+
+// UserJobHandler is the handler for the state of a user delete or restore job.
+type UserJobHandler struct {
+	usecase UserJobGetter
+	logger  *zap.Logger
+}
+
+// WithUserJobUsecase is the option for the user job handler to set the usecase.
+func WithUserJobUsecase(usecase UserJobGetter) UserJobOption {
+	return func(options *userJobOptions) error {
+		options.usecase = usecase
+		return nil
+	}
+}
+
+// WithUserJobLogger is the option for the user job handler to set the logger.
+func WithUserJobLogger(logger *zap.Logger) UserJobOption {
+	return func(options *userJobOptions) error {
+		options.logger = logger.With(zap.String("handler", "UserJobHandler"))
+		return nil
+	}
+}
+
+// NewUserJobHandler creates a new user job handler.
+func NewUserJobHandler(opts ...UserJobOption) (*UserJobHandler, error) {
+	options := &userJobOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.usecase == nil {
+		return nil, errors.New("usecase is required")
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	return &UserJobHandler{
+		usecase: options.usecase,
+		logger:  options.logger,
+	}, nil
+}
+
+// Pattern is the pattern for the user job.
+func (h *UserJobHandler) Pattern() string {
+	return userJobPath + "{id}"
+}
+
+// Method is the method for the user job.
+func (h *UserJobHandler) Method() string {
+	return http.MethodGet
+}
+
+// HandlerFunc is the handler func for the user job.
+func (h *UserJobHandler) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+		if !ok {
+			h.logger.Error("userID not found in context")
+			JSONResponse(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		jobID := chi.URLParam(r, "id")
+		job, err := h.usecase.GetUserJob(r.Context(), userID, jobID)
+		if err != nil {
+			if errors.Is(err, entity.ErrJobNotFound) {
+				JSONResponse(w, http.StatusNotFound, "job not found")
+				return
+			}
+			h.logger.Error("failed to get job", zap.String("job_id", jobID), zap.Error(err))
+			JSONResponse(w, http.StatusInternalServerError, "failed to get job")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if errEncode := json.NewEncoder(w).Encode(toJobResponse(job)); errEncode != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
+
+// jobAccepted responds to the request that started the job with the pending job and its location.
+func jobAccepted(w http.ResponseWriter, job entity.Job) {
+	w.Header().Set("Location", userJobPath+job.ID)
+	JSONResponse(w, http.StatusAccepted, toJobResponse(job))
+}
+
+func toJobResponse(job entity.Job) dto.JobResponse {
+	return dto.JobResponse{
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+		JobID:      job.ID,
+		Operation:  string(job.Operation),
+		Status:     string(job.Status),
+		ShortURLs:  job.ShortURLs,
+		FailedURLs: job.FailedURLs,
+	}
+}
@@ -0,0 +1,157 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers/mocks"
+	customMiddleware "github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+func TestUserJobHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usecase := mocks.NewMockUserJobGetter(ctrl)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	handler, err := handlers.NewUserJobHandler(
+		handlers.WithUserJobUsecase(usecase),
+		handlers.WithUserJobLogger(logger),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, "/api/user/jobs/{id}", handler.Pattern())
+	require.Equal(t, http.MethodGet, handler.Method())
+
+	authMiddleware, err := customMiddleware.NewAuthMiddleware(
+		customMiddleware.WithAuthMiddlewareLogger(logger),
+	)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(authMiddleware.Handler())
+	router.Method(handler.Method(), handler.Pattern(), handler.HandlerFunc())
+
+	createdAt := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	finishedAt := createdAt.Add(time.Second)
+	type want struct {
+		response   any
+		statusCode int
+	}
+	tests := []struct {
+		setup func()
+		name  string
+		path  string
+		want  want
+	}{
+		{
+			name: "pending",
+			path: "/api/user/jobs/job1",
+			want: want{
+				statusCode: http.StatusOK,
+				response: dto.JobResponse{
+					CreatedAt: createdAt,
+					JobID:     "job1",
+					Operation: "delete",
+					Status:    "pending",
+					ShortURLs: []string{"flyer", "foreign"},
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().GetUserJob(gomock.Any(), gomock.Any(), "job1").Return(entity.Job{
+					CreatedAt: createdAt,
+					ID:        "job1",
+					Operation: entity.JobDelete,
+					Status:    entity.JobPending,
+					ShortURLs: []string{"flyer", "foreign"},
+				}, nil)
+			},
+		},
+		{
+			name: "partially failed",
+			path: "/api/user/jobs/job1",
+			want: want{
+				statusCode: http.StatusOK,
+				response: dto.JobResponse{
+					CreatedAt:  createdAt,
+					FinishedAt: finishedAt,
+					JobID:      "job1",
+					Operation:  "delete",
+					Status:     "partially_failed",
+					ShortURLs:  []string{"flyer", "foreign"},
+					FailedURLs: []string{"foreign"},
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().GetUserJob(gomock.Any(), gomock.Any(), "job1").Return(entity.Job{
+					CreatedAt:  createdAt,
+					FinishedAt: finishedAt,
+					ID:         "job1",
+					Operation:  entity.JobDelete,
+					Status:     entity.JobPartiallyFailed,
+					ShortURLs:  []string{"flyer", "foreign"},
+					FailedURLs: []string{"foreign"},
+				}, nil)
+			},
+		},
+		{
+			name: "job not found",
+			path: "/api/user/jobs/unknown",
+			want: want{
+				statusCode: http.StatusNotFound,
+				response:   handlers.Response{Status: http.StatusNotFound, Message: "Not Found", Data: "job not found"},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					GetUserJob(gomock.Any(), gomock.Any(), "unknown").
+					Return(entity.Job{}, entity.ErrJobNotFound)
+			},
+		},
+		{
+			name: "usecase error",
+			path: "/api/user/jobs/job1",
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				response: handlers.Response{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error",
+					Data:    "failed to get job",
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					GetUserJob(gomock.Any(), gomock.Any(), "job1").
+					Return(entity.Job{}, errors.New("connection refused"))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.setup()
+			req, errRequest := http.NewRequest(http.MethodGet, test.path, nil)
+			require.NoError(t, errRequest)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, test.want.statusCode, recorder.Code)
+			require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			expected, errMarshal := json.Marshal(test.want.response)
+			require.NoError(t, errMarshal)
+			require.JSONEq(t, string(expected), recorder.Body.String())
+		})
+	}
+}
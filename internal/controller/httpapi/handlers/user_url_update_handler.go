@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+type userURLUpdateOptions struct {
+	usecase UserURLUpdater
+	logger  *zap.Logger
+	baseURL string
+}
+
+// UserURLUpdateOption is the option for the user URL update handler.
+type UserURLUpdateOption func(options *userURLUpdateOptions) error
+
+// UserURLUpdateHandler is the handler for changing the destination of a user URL.
+type UserURLUpdateHandler struct {
+	usecase UserURLUpdater
+	logger  *zap.Logger
+	baseURL string
+}
+
+// WithUserURLUpdateBaseURL is the option for the user URL update handler to set the base URL.
+func WithUserURLUpdateBaseURL(baseURL string) UserURLUpdateOption {
+	return func(options *userURLUpdateOptions) error {
+		options.baseURL = baseURL
+		return nil
+	}
+}
+
+// WithUserURLUpdateUsecase is the option for the user URL update handler to set the usecase.
+func WithUserURLUpdateUsecase(usecase UserURLUpdater) UserURLUpdateOption {
+	return func(options *userURLUpdateOptions) error {
+		options.usecase = usecase
+		return nil
+	}
+}
+
+// WithUserURLUpdateLogger is the option for the user URL update handler to set the logger.
+func WithUserURLUpdateLogger(logger *zap.Logger) UserURLUpdateOption {
+	return func(options *userURLUpdateOptions) error {
+		options.logger = logger.With(zap.String("handler", "UserURLUpdateHandler"))
+		return nil
+	}
+}
+
+// NewUserURLUpdateHandler creates a new user URL update handler.
+func NewUserURLUpdateHandler(opts ...UserURLUpdateOption) (*UserURLUpdateHandler, error) {
+	options := &userURLUpdateOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.usecase == nil {
+		return nil, errors.New("usecase is required")
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	return &UserURLUpdateHandler{
+		usecase: options.usecase,
+		logger:  options.logger,
+		baseURL: options.baseURL,
+	}, nil
+}
+
+// Pattern is the pattern for the user URL update.
+func (h *UserURLUpdateHandler) Pattern() string {
+	return "/api/user/urls/{id}"
+}
+
+// Method is the method for the user URL update.
+func (h *UserURLUpdateHandler) Method() string {
+	return http.MethodPatch
+}
+
+// HandlerFunc is the handler func for the user URL update.
+func (h *UserURLUpdateHandler) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+		if !ok {
+			h.logger.Error("userID not found in context")
+			JSONResponse(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		var request dto.UpdateURLRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			JSONResponse(w, http.StatusBadRequest, "Failed to unmarshal request body")
+			return
+		}
+		defer r.Body.Close()
+		if request.OriginalURL == "" {
+			JSONResponse(w, http.StatusBadRequest, "original_url is required")
+			return
+		}
+
+		shortURL := chi.URLParam(r, "id")
+		history, err := h.usecase.UpdateOriginalURL(r.Context(), userID, shortURL, request.OriginalURL)
+		if err != nil {
+			h.handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		response := h.toResponse(shortURL, request.OriginalURL, history)
+		if errEncode := json.NewEncoder(w).Encode(response); errEncode != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+	}
+}
+
+func (h *UserURLUpdateHandler) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, entity.ErrURLNotFound):
+		JSONResponse(w, http.StatusNotFound, "URL not found")
+	case errors.Is(err, entity.ErrURLDeleted):
+		JSONResponse(w, http.StatusGone, "URL has been deleted")
+	case errors.Is(err, entity.ErrURLExists):
+		JSONResponse(w, http.StatusConflict, "URL already exists")
+	default:
+		h.logger.Error("failed to update URL", zap.Error(err))
+		JSONResponse(w, http.StatusInternalServerError, "Failed to update URL")
+	}
+}
+
+func (h *UserURLUpdateHandler) toResponse(
+	shortURL, originalURL string,
+	history []entity.URLChange,
+) dto.UpdateURLResponse {
+	response := dto.UpdateURLResponse{
+		ShortURL:    h.baseURL + "/" + shortURL,
+		OriginalURL: originalURL,
+		History:     make([]dto.URLChangeResponse, 0, len(history)),
+	}
+	for _, change := range history {
+		response.History = append(response.History, dto.URLChangeResponse{
+			ChangedAt:   change.ChangedAt,
+			OriginalURL: change.OriginalURL,
+		})
+	}
+	return response
+}
@@ -0,0 +1,191 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers/mocks"
+	customMiddleware "github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+func TestUserURLUpdateHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usecase := mocks.NewMockUserURLUpdater(ctrl)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	handler, err := handlers.NewUserURLUpdateHandler(
+		handlers.WithUserURLUpdateBaseURL("http://localhost:8080"),
+		handlers.WithUserURLUpdateUsecase(usecase),
+		handlers.WithUserURLUpdateLogger(logger),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, "/api/user/urls/{id}", handler.Pattern())
+	require.Equal(t, http.MethodPatch, handler.Method())
+
+	authMiddleware, err := customMiddleware.NewAuthMiddleware(
+		customMiddleware.WithAuthMiddlewareLogger(logger),
+	)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(authMiddleware.Handler())
+	router.Method(handler.Method(), handler.Pattern(), handler.HandlerFunc())
+
+	changedAt := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	type want struct {
+		response   any
+		statusCode int
+	}
+	tests := []struct {
+		setup func()
+		name  string
+		path  string
+		body  string
+		want  want
+	}{
+		{
+			name: "success",
+			path: "/api/user/urls/flyer",
+			body: `{"original_url":"https://second.com"}`,
+			want: want{
+				statusCode: http.StatusOK,
+				response: dto.UpdateURLResponse{
+					ShortURL:    "http://localhost:8080/flyer",
+					OriginalURL: "https://second.com",
+					History: []dto.URLChangeResponse{
+						{ChangedAt: changedAt, OriginalURL: "https://first.com"},
+					},
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					UpdateOriginalURL(gomock.Any(), gomock.Any(), "flyer", "https://second.com").
+					Return([]entity.URLChange{{ChangedAt: changedAt, OriginalURL: "https://first.com"}}, nil)
+			},
+		},
+		{
+			name: "empty original url",
+			path: "/api/user/urls/flyer",
+			body: `{"original_url":""}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				response: handlers.Response{
+					Status:  http.StatusBadRequest,
+					Message: "Bad Request",
+					Data:    "original_url is required",
+				},
+			},
+		},
+		{
+			name: "invalid body",
+			path: "/api/user/urls/flyer",
+			body: `{"original_url":`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				response: handlers.Response{
+					Status:  http.StatusBadRequest,
+					Message: "Bad Request",
+					Data:    "Failed to unmarshal request body",
+				},
+			},
+		},
+		{
+			name: "url of another user",
+			path: "/api/user/urls/foreign",
+			body: `{"original_url":"https://second.com"}`,
+			want: want{
+				statusCode: http.StatusNotFound,
+				response:   handlers.Response{Status: http.StatusNotFound, Message: "Not Found", Data: "URL not found"},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					UpdateOriginalURL(gomock.Any(), gomock.Any(), "foreign", "https://second.com").
+					Return(nil, entity.ErrURLNotFound)
+			},
+		},
+		{
+			name: "url deleted",
+			path: "/api/user/urls/deleted",
+			body: `{"original_url":"https://second.com"}`,
+			want: want{
+				statusCode: http.StatusGone,
+				response:   handlers.Response{Status: http.StatusGone, Message: "Gone", Data: "URL has been deleted"},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					UpdateOriginalURL(gomock.Any(), gomock.Any(), "deleted", "https://second.com").
+					Return(nil, entity.ErrURLDeleted)
+			},
+		},
+		{
+			name: "original url already shortened",
+			path: "/api/user/urls/flyer",
+			body: `{"original_url":"https://taken.com"}`,
+			want: want{
+				statusCode: http.StatusConflict,
+				response: handlers.Response{
+					Status:  http.StatusConflict,
+					Message: "Conflict",
+					Data:    "URL already exists",
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					UpdateOriginalURL(gomock.Any(), gomock.Any(), "flyer", "https://taken.com").
+					Return(nil, &entity.URLExistsError{ShortURL: "taken"})
+			},
+		},
+		{
+			name: "repository error",
+			path: "/api/user/urls/flyer",
+			body: `{"original_url":"https://second.com"}`,
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				response: handlers.Response{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error",
+					Data:    "Failed to update URL",
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					UpdateOriginalURL(gomock.Any(), gomock.Any(), "flyer", "https://second.com").
+					Return(nil, errors.New("connection refused"))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.setup != nil {
+				test.setup()
+			}
+			req, errRequest := http.NewRequest(http.MethodPatch, test.path, strings.NewReader(test.body))
+			require.NoError(t, errRequest)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, test.want.statusCode, recorder.Code)
+			require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			expected, errMarshal := json.Marshal(test.want.response)
+			require.NoError(t, errMarshal)
+			require.JSONEq(t, string(expected), recorder.Body.String())
+		})
+	}
+}
@@ -91,12 +91,13 @@ func (h *UserURLsDeleteHandler) HandlerFunc() http.HandlerFunc {
 			return
 		}
 
-		if err := h.usecase.DeleteUserURLs(r.Context(), userID, shortURLs); err != nil {
+		job, err := h.usecase.DeleteUserURLs(r.Context(), userID, shortURLs)
+		if err != nil {
 			h.logger.Error("failed to delete user URLs", zap.Error(err))
 			JSONResponse(w, http.StatusInternalServerError, "failed to delete URLs")
 			return
 		}
 
-		JSONResponse(w, http.StatusAccepted, "success")
+		jobAccepted(w, job)
 	}
 }
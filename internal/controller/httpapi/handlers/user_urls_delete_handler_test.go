@@ -16,6 +16,8 @@ import (
 	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers"
 	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers/mocks"
 	customMiddleware "github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
 )
 
 func TestUserURLsDeleteHandler(t *testing.T) {
@@ -49,9 +51,17 @@ func TestUserURLsDeleteHandler(t *testing.T) {
 		path   string
 		method string
 	}
+	job := entity.Job{
+		ID:        "job1",
+		UserID:    "user1",
+		Operation: entity.JobDelete,
+		Status:    entity.JobPending,
+		ShortURLs: []string{"shortURL1", "shortURL2"},
+	}
 	type want struct {
 		response    any
 		contentType string
+		location    string
 		statusCode  int
 	}
 	tests := []struct {
@@ -70,12 +80,22 @@ func TestUserURLsDeleteHandler(t *testing.T) {
 			want: want{
 				statusCode:  http.StatusAccepted,
 				contentType: "application/json",
-				response:    handlers.Response{Status: http.StatusAccepted, Message: "Accepted", Data: "success"},
+				location:    "/api/user/jobs/job1",
+				response: handlers.Response{
+					Status:  http.StatusAccepted,
+					Message: "Accepted",
+					Data: dto.JobResponse{
+						JobID:     "job1",
+						Operation: "delete",
+						Status:    "pending",
+						ShortURLs: []string{"shortURL1", "shortURL2"},
+					},
+				},
 			},
 			setup: func() {
 				usecase.EXPECT().
 					DeleteUserURLs(gomock.Any(), gomock.Any(), []string{"shortURL1", "shortURL2"}).
-					Return(nil)
+					Return(job, nil)
 			},
 		},
 		{
@@ -136,7 +156,7 @@ func TestUserURLsDeleteHandler(t *testing.T) {
 			setup: func() {
 				usecase.EXPECT().
 					DeleteUserURLs(gomock.Any(), gomock.Any(), []string{"shortURL1", "shortURL2"}).
-					Return(errors.New("failed to delete URLs"))
+					Return(entity.Job{}, errors.New("failed to delete URLs"))
 			},
 		},
 	}
@@ -154,12 +174,12 @@ func TestUserURLsDeleteHandler(t *testing.T) {
 
 			require.Equal(t, test.want.statusCode, recorder.Code)
 			require.Equal(t, test.want.contentType, recorder.Header().Get("Content-Type"))
+			require.Equal(t, test.want.location, recorder.Header().Get("Location"))
 			switch test.want.response.(type) {
 			case handlers.Response:
-				var response handlers.Response
-				err = json.NewDecoder(recorder.Body).Decode(&response)
-				require.NoError(t, err)
-				require.Equal(t, test.want.response, response)
+				expected, errExpected := json.Marshal(test.want.response)
+				require.NoError(t, errExpected)
+				require.JSONEq(t, string(expected), recorder.Body.String())
 			default:
 				require.Equal(t, test.want.response, recorder.Body.String())
 			}
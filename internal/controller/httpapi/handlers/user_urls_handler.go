@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"go.uber.org/zap"
 
@@ -93,7 +94,16 @@ func (h *UserURLsHandler) HandlerFunc() http.HandlerFunc {
 			return
 		}
 
-		urls, err := h.usecase.GetUserURLs(r.Context(), userID)
+		includeDeleted := false
+		if value := r.URL.Query().Get("include_deleted"); value != "" {
+			var errParse error
+			if includeDeleted, errParse = strconv.ParseBool(value); errParse != nil {
+				JSONResponse(w, http.StatusBadRequest, "invalid include_deleted value")
+				return
+			}
+		}
+
+		urls, err := h.usecase.GetUserURLs(r.Context(), userID, includeDeleted)
 		h.logger.Info("urls", zap.Any("urls", urls), zap.String("userID", userID))
 		if err != nil {
 			h.logger.Error("failed to get user URLs", zap.Error(err))
@@ -118,6 +128,7 @@ func (h *UserURLsHandler) toResponse(urls []entity.URL) []dto.UserURLsResponse {
 		response = append(response, dto.UserURLsResponse{
 			ShortURL:    h.baseURL + "/" + url.ShortURL,
 			OriginalURL: url.OriginalURL,
+			IsDeleted:   url.DeletedFlag,
 		})
 	}
 	return response
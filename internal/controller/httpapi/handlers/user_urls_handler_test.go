@@ -83,7 +83,7 @@ func TestUserURLsHandler(t *testing.T) {
 				},
 			},
 			setup: func() {
-				usecase.EXPECT().GetUserURLs(gomock.Any(), gomock.Any()).Return([]entity.URL{
+				usecase.EXPECT().GetUserURLs(gomock.Any(), gomock.Any(), false).Return([]entity.URL{
 					{
 						ShortURL:    "shortURL1",
 						OriginalURL: "https://example.com/1",
@@ -104,14 +104,11 @@ func TestUserURLsHandler(t *testing.T) {
 			want: want{
 				statusCode:  http.StatusNoContent,
 				contentType: "application/json",
-				response: handlers.Response{
-					Status:  http.StatusNoContent,
-					Message: "No Content",
-					Data:    "No URLs found",
-				},
+				// a 204 response carries no body
+				response: "",
 			},
 			setup: func() {
-				usecase.EXPECT().GetUserURLs(gomock.Any(), gomock.Any()).Return(nil, nil)
+				usecase.EXPECT().GetUserURLs(gomock.Any(), gomock.Any(), false).Return(nil, nil)
 			},
 		},
 		{
@@ -131,10 +128,62 @@ func TestUserURLsHandler(t *testing.T) {
 			},
 			setup: func() {
 				usecase.EXPECT().
-					GetUserURLs(gomock.Any(), gomock.Any()).
+					GetUserURLs(gomock.Any(), gomock.Any(), false).
 					Return(nil, errors.New("failed to get user URLs"))
 			},
 		},
+		{
+			name: "include deleted urls",
+			request: request{
+				path:   "/api/user/urls?include_deleted=true",
+				method: http.MethodGet,
+			},
+			want: want{
+				statusCode:  http.StatusOK,
+				contentType: "application/json",
+				response: []dto.UserURLsResponse{
+					{
+						ShortURL:    "http://localhost:8080/shortURL1",
+						OriginalURL: "https://example.com/1",
+					},
+					{
+						ShortURL:    "http://localhost:8080/trashed",
+						OriginalURL: "https://example.com/2",
+						IsDeleted:   true,
+					},
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().GetUserURLs(gomock.Any(), gomock.Any(), true).Return([]entity.URL{
+					{
+						ShortURL:    "shortURL1",
+						OriginalURL: "https://example.com/1",
+					},
+					{
+						ShortURL:    "trashed",
+						OriginalURL: "https://example.com/2",
+						DeletedFlag: true,
+					},
+				}, nil)
+			},
+		},
+		{
+			name: "invalid include deleted",
+			request: request{
+				path:   "/api/user/urls?include_deleted=maybe",
+				method: http.MethodGet,
+			},
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json",
+				response: handlers.Response{
+					Status:  http.StatusBadRequest,
+					Message: "Bad Request",
+					Data:    "invalid include_deleted value",
+				},
+			},
+			setup: func() {},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
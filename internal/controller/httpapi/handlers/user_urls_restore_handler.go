@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+)
+
+type userURLsRestoreOptions struct {
+	usecase UserURLRestorer
+	logger  *zap.Logger
+}
+
+// UserURLsRestoreOption is the option for the user URLs restore handler.
+type UserURLsRestoreOption func(options *userURLsRestoreOptions) error
+
+// UserURLsRestoreHandler is the handler for the user URLs restore.
+type UserURLsRestoreHandler struct {
+	usecase UserURLRestorer
+	logger  *zap.Logger
+}
+
+// WithUserURLsRestoreUsecase is the option for the user URLs restore handler to set the usecase.
+func WithUserURLsRestoreUsecase(usecase UserURLRestorer) UserURLsRestoreOption {
+	return func(options *userURLsRestoreOptions) error {
+		options.usecase = usecase
+		return nil
+	}
+}
+
+// WithUserURLsRestoreLogger is the option for the user URLs restore handler to set the logger.
+func WithUserURLsRestoreLogger(logger *zap.Logger) UserURLsRestoreOption {
+	return func(options *userURLsRestoreOptions) error {
+		options.logger = logger.With(zap.String("handler", "UserURLsRestoreHandler"))
+		return nil
+	}
+}
+
+// NewUserURLsRestoreHandler creates a new user URLs restore handler.
+func NewUserURLsRestoreHandler(opts ...UserURLsRestoreOption) (*UserURLsRestoreHandler, error) {
+	options := &userURLsRestoreOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.usecase == nil {
+		return nil, errors.New("usecase is required")
+	}
+	if options.logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	return &UserURLsRestoreHandler{
+		usecase: options.usecase,
+		logger:  options.logger,
+	}, nil
+}
+
+// Pattern is the pattern for the user URLs restore.
+func (h *UserURLsRestoreHandler) Pattern() string {
+	return "/api/user/urls/restore"
+}
+
+// Method is the method for the user URLs restore.
+func (h *UserURLsRestoreHandler) Method() string {
+	return http.MethodPost
+}
+
+// HandlerFunc is the handler func for the user URLs restore.
+func (h *UserURLsRestoreHandler) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+		if !ok {
+			h.logger.Error("userID not found in context")
+			JSONResponse(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		var shortURLs []string
+		if err := json.NewDecoder(r.Body).Decode(&shortURLs); err != nil {
+			h.logger.Error("failed to bind request body", zap.Error(err))
+			JSONResponse(w, http.StatusBadRequest, "invalid request format")
+			return
+		}
+
+		if len(shortURLs) == 0 {
+			JSONResponse(w, http.StatusBadRequest, "no URLs provided for restore")
+			return
+		}
+
+		job, err := h.usecase.RestoreUserURLs(r.Context(), userID, shortURLs)
+		if err != nil {
+			h.logger.Error("failed to restore user URLs", zap.Error(err))
+			JSONResponse(w, http.StatusInternalServerError, "failed to restore URLs")
+			return
+		}
+
+		jobAccepted(w, job)
+	}
+}
@@ -0,0 +1,188 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers/mocks"
+	customMiddleware "github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+func TestUserURLsRestoreHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usecase := mocks.NewMockUserURLRestorer(ctrl)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	handler, err := handlers.NewUserURLsRestoreHandler(
+		handlers.WithUserURLsRestoreUsecase(usecase),
+		handlers.WithUserURLsRestoreLogger(logger),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, "/api/user/urls/restore", handler.Pattern())
+	require.Equal(t, http.MethodPost, handler.Method())
+
+	authMiddleware, err := customMiddleware.NewAuthMiddleware(
+		customMiddleware.WithAuthMiddlewareLogger(logger),
+	)
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(authMiddleware.Handler())
+	router.Method(handler.Method(), handler.Pattern(), handler.HandlerFunc())
+
+	type request struct {
+		body   any
+		path   string
+		method string
+	}
+	job := entity.Job{
+		ID:        "job1",
+		UserID:    "user1",
+		Operation: entity.JobRestore,
+		Status:    entity.JobPending,
+		ShortURLs: []string{"shortURL1", "shortURL2"},
+	}
+	type want struct {
+		response    any
+		contentType string
+		location    string
+		statusCode  int
+	}
+	tests := []struct {
+		setup   func()
+		request request
+		name    string
+		want    want
+	}{
+		{
+			name: "success restore urls",
+			request: request{
+				path:   "/api/user/urls/restore",
+				method: http.MethodPost,
+				body:   []string{"shortURL1", "shortURL2"},
+			},
+			want: want{
+				statusCode:  http.StatusAccepted,
+				contentType: "application/json",
+				location:    "/api/user/jobs/job1",
+				response: handlers.Response{
+					Status:  http.StatusAccepted,
+					Message: "Accepted",
+					Data: dto.JobResponse{
+						JobID:     "job1",
+						Operation: "restore",
+						Status:    "pending",
+						ShortURLs: []string{"shortURL1", "shortURL2"},
+					},
+				},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					RestoreUserURLs(gomock.Any(), gomock.Any(), []string{"shortURL1", "shortURL2"}).
+					Return(job, nil)
+			},
+		},
+		{
+			name: "invalid request body",
+			request: request{
+				path:   "/api/user/urls/restore",
+				method: http.MethodPost,
+				body:   "invalid",
+			},
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json",
+				response: handlers.Response{
+					Status:  http.StatusBadRequest,
+					Message: "Bad Request",
+					Data:    "invalid request format",
+				},
+			},
+			setup: func() {
+
+			},
+		},
+		{
+			name: "empty body",
+			request: request{
+				path:   "/api/user/urls/restore",
+				method: http.MethodPost,
+				body:   nil,
+			},
+			want: want{
+				statusCode:  http.StatusBadRequest,
+				contentType: "application/json",
+				response: handlers.Response{
+					Status:  http.StatusBadRequest,
+					Message: "Bad Request",
+					Data:    "no URLs provided for restore",
+				},
+			},
+			setup: func() {
+			},
+		},
+		{
+			name: "internal server error",
+			want: want{
+				statusCode:  http.StatusInternalServerError,
+				contentType: "application/json",
+				response: handlers.Response{
+					Status:  http.StatusInternalServerError,
+					Message: "Internal Server Error",
+					Data:    "failed to restore URLs",
+				},
+			},
+			request: request{
+				path:   "/api/user/urls/restore",
+				method: http.MethodPost,
+				body:   []string{"shortURL1", "shortURL2"},
+			},
+			setup: func() {
+				usecase.EXPECT().
+					RestoreUserURLs(gomock.Any(), gomock.Any(), []string{"shortURL1", "shortURL2"}).
+					Return(entity.Job{}, errors.New("failed to restore URLs"))
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.setup()
+			body, errMarshal := json.Marshal(test.request.body)
+			require.NoError(t, errMarshal)
+
+			req, errRequest := http.NewRequest(test.request.method, test.request.path, bytes.NewReader(body))
+			require.NoError(t, errRequest)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			require.Equal(t, test.want.statusCode, recorder.Code)
+			require.Equal(t, test.want.contentType, recorder.Header().Get("Content-Type"))
+			require.Equal(t, test.want.location, recorder.Header().Get("Location"))
+			switch test.want.response.(type) {
+			case handlers.Response:
+				expected, errExpected := json.Marshal(test.want.response)
+				require.NoError(t, errExpected)
+				require.JSONEq(t, string(expected), recorder.Body.String())
+			default:
+				require.Equal(t, test.want.response, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -13,17 +13,25 @@ type Response struct {
 }
 
 // JSONResponse is the response for the JSON.
+// The response is encoded before the status is written, so the status is set once.
+// A 204 No Content response carries no body.
 func JSONResponse(w http.ResponseWriter, status int, data any) {
 	response := Response{
 		Status:  status,
 		Message: http.StatusText(status),
 		Data:    data,
 	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	if status == http.StatusNoContent {
+		return
 	}
+	_, _ = w.Write(append(body, '\n'))
 }
 
 // TextResponse is the response for the text.
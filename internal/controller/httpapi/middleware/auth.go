@@ -4,17 +4,21 @@ import (
 	"context"
 	"errors"
 	"net/http"
-	"time"
+	"slices"
+	"strings"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
 )
 
 const (
-	tokenExpires = 1 * time.Hour
-	secretKey    = "NuQu82Q2" //nolint:gosec // secret key is used for authentication (test project)
 	authCookie   = "Auth"
+	authHeader   = "Authorization"
+	bearerScheme = "Bearer "
+	apiKeyHeader = "X-API-Key"
 )
 
 // contextKey is the key for the context.
@@ -29,8 +33,15 @@ type Claims struct {
 	UserID string
 }
 
+// APIKeyResolver resolves an API key to the stored key of its owner.
+type APIKeyResolver interface {
+	ResolveAPIKey(ctx context.Context, key string) (entity.APIKey, error)
+}
+
 type optionsAuthMiddleware struct {
-	logger *zap.Logger
+	logger  *zap.Logger
+	tokens  *TokenManager
+	apiKeys APIKeyResolver
 }
 
 // OptionAuthMiddleware is the option for the auth middleware.
@@ -38,7 +49,9 @@ type OptionAuthMiddleware func(options *optionsAuthMiddleware) error
 
 // AuthMiddleware is the auth middleware.
 type AuthMiddleware struct {
-	logger *zap.Logger
+	logger  *zap.Logger
+	tokens  *TokenManager
+	apiKeys APIKeyResolver
 }
 
 // WithAuthMiddlewareLogger is the option for the auth middleware to set the logger.
@@ -49,7 +62,25 @@ func WithAuthMiddlewareLogger(logger *zap.Logger) OptionAuthMiddleware {
 	}
 }
 
+// WithAuthMiddlewareTokens is the option for the auth middleware to set the token manager.
+func WithAuthMiddlewareTokens(tokens *TokenManager) OptionAuthMiddleware {
+	return func(options *optionsAuthMiddleware) error {
+		options.tokens = tokens
+		return nil
+	}
+}
+
+// WithAuthMiddlewareAPIKeys is the option for the auth middleware to set the resolver of the API keys.
+// Requests with an API key are rejected without it.
+func WithAuthMiddlewareAPIKeys(apiKeys APIKeyResolver) OptionAuthMiddleware {
+	return func(options *optionsAuthMiddleware) error {
+		options.apiKeys = apiKeys
+		return nil
+	}
+}
+
 // NewAuthMiddleware creates a new auth middleware.
+// Without a token manager it signs with a random key, so the tokens do not survive a restart.
 func NewAuthMiddleware(opts ...OptionAuthMiddleware) (*AuthMiddleware, error) {
 	options := &optionsAuthMiddleware{}
 	for _, opt := range opts {
@@ -60,44 +91,71 @@ func NewAuthMiddleware(opts ...OptionAuthMiddleware) (*AuthMiddleware, error) {
 	if options.logger == nil {
 		return nil, errors.New("logger is required")
 	}
-	return &AuthMiddleware{logger: options.logger}, nil
+	if options.tokens == nil {
+		tokens, err := NewTokenManager(WithEphemeralTokenKey())
+		if err != nil {
+			return nil, err
+		}
+		options.tokens = tokens
+	}
+	return &AuthMiddleware{logger: options.logger, tokens: options.tokens, apiKeys: options.apiKeys}, nil
 }
 
 // Handler returns chi middleware for authentication.
-func (m *AuthMiddleware) Handler() func(http.Handler) http.Handler {
+// A request with the X-API-Key header acts as the owner of the key, which must carry one of the scopes;
+// without scopes the route does not accept API keys. Otherwise the token is read from the Authorization
+// Bearer header, then from the Auth cookie. A request without either gets a new user whose token is set
+// in the Auth cookie and returned in the Authorization header.
+func (m *AuthMiddleware) Handler(scopes ...entity.APIKeyScope) func(http.Handler) http.Handler {
+	return m.handler(false, scopes)
+}
+
+// StrictHandler returns chi middleware for authentication that answers 401 to a request without
+// a token or an API key instead of creating a new user.
+func (m *AuthMiddleware) StrictHandler(scopes ...entity.APIKeyScope) func(http.Handler) http.Handler {
+	return m.handler(true, scopes)
+}
+
+func (m *AuthMiddleware) handler(strict bool, scopes []entity.APIKeyScope) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			cookie, err := r.Cookie(authCookie)
-			if errors.Is(err, http.ErrNoCookie) {
-				m.logger.Info("No cookie found, generating new token")
-				// generate token and set cookie
-				userID := uuid.New().String()
-				tokenString, errGenerate := generateToken(userID)
-				if errGenerate != nil {
-					m.logger.Error("Failed to generate token", zap.Error(errGenerate))
-					http.Error(w, "Internal server error", http.StatusInternalServerError)
+			if key := r.Header.Get(apiKeyHeader); key != "" {
+				userID, ok := m.authenticateAPIKey(w, r, key, scopes)
+				if !ok {
 					return
 				}
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), UserIDKey, userID)))
+				return
+			}
 
-				http.SetCookie(w, &http.Cookie{
-					Name:     authCookie,
-					Value:    tokenString,
-					MaxAge:   int(tokenExpires.Seconds()),
-					Path:     "/",
-					HttpOnly: true,
-				})
+			tokenString, err := requestToken(r)
+			if err != nil {
+				m.logger.Error("Failed to get token", zap.Error(err))
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			if tokenString == "" {
+				if strict {
+					m.logger.Info("No token found")
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+				m.logger.Info("No token found, generating new token")
+				userID, errIssue := m.issueToken(w)
+				if errIssue != nil {
+					m.logger.Error("Failed to generate token", zap.Error(errIssue))
+					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					return
+				}
 
 				ctx := r.Context()
 				ctx = context.WithValue(ctx, UserIDKey, userID)
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
-			} else if err != nil {
-				m.logger.Error("Failed to get token", zap.Error(err))
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
-				return
 			}
 
-			userID, err := getUserID(cookie.Value)
+			userID, err := m.tokens.Parse(tokenString)
 			if err != nil {
 				m.logger.Error("Failed to get userID", zap.Error(err))
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -112,34 +170,76 @@ func (m *AuthMiddleware) Handler() func(http.Handler) http.Handler {
 	}
 }
 
-func generateToken(userID string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenExpires)),
-		},
-		UserID: userID,
-	})
+// authenticateAPIKey returns the owner of the API key if the key carries one of the scopes.
+// Otherwise it answers the request itself and reports false.
+func (m *AuthMiddleware) authenticateAPIKey(
+	w http.ResponseWriter,
+	r *http.Request,
+	key string,
+	scopes []entity.APIKeyScope,
+) (string, bool) {
+	if m.apiKeys == nil {
+		m.logger.Info("API keys are not accepted")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
 
-	tokenString, err := token.SignedString([]byte(secretKey))
+	apiKey, err := m.apiKeys.ResolveAPIKey(r.Context(), key)
 	if err != nil {
-		return "", err
+		if errors.Is(err, entity.ErrAPIKeyNotFound) {
+			m.logger.Info("Unknown API key")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return "", false
+		}
+		m.logger.Error("Failed to resolve API key", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return "", false
 	}
-
-	return tokenString, nil
+	if !slices.ContainsFunc(scopes, apiKey.HasScope) {
+		m.logger.Info("API key lacks the scope", zap.String("key_id", apiKey.ID), zap.Any("scopes", scopes))
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return "", false
+	}
+	return apiKey.UserID, true
 }
 
-func getUserID(tokenString string) (string, error) {
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(_ *jwt.Token) (any, error) {
-		return []byte(secretKey), nil
-	})
+// issueToken creates a new user and sends its token in the Auth cookie and the Authorization header.
+func (m *AuthMiddleware) issueToken(w http.ResponseWriter) (string, error) {
+	userID := uuid.New().String()
+	tokenString, err := m.tokens.Generate(userID)
 	if err != nil {
 		return "", err
 	}
 
-	if !token.Valid {
-		return "", errors.New("invalid token")
+	http.SetCookie(w, &http.Cookie{
+		Name:     authCookie,
+		Value:    tokenString,
+		MaxAge:   int(m.tokens.TTL().Seconds()),
+		Path:     "/",
+		HttpOnly: true,
+	})
+	w.Header().Set(authHeader, bearerScheme+tokenString)
+	return userID, nil
+}
+
+// requestToken returns the token of the request, empty if the request has none.
+// An Authorization header of another scheme than Bearer is an error.
+func requestToken(r *http.Request) (string, error) {
+	if header := r.Header.Get(authHeader); header != "" {
+		scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+		token = strings.TrimSpace(token)
+		if !ok || !strings.EqualFold(scheme+" ", bearerScheme) || token == "" {
+			return "", errors.New("unsupported authorization scheme")
+		}
+		return token, nil
 	}
 
-	return claims.UserID, nil
+	cookie, err := r.Cookie(authCookie)
+	if errors.Is(err, http.ErrNoCookie) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
 }
@@ -0,0 +1,186 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+// apiKeys resolves the API keys of the test.
+type apiKeys map[string]entity.APIKey
+
+func (k apiKeys) ResolveAPIKey(_ context.Context, key string) (entity.APIKey, error) {
+	apiKey, ok := k[key]
+	if !ok {
+		return entity.APIKey{}, entity.ErrAPIKeyNotFound
+	}
+	return apiKey, nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	tokens, err := middleware.NewTokenManager(middleware.WithEphemeralTokenKey())
+	require.NoError(t, err)
+	auth, err := middleware.NewAuthMiddleware(
+		middleware.WithAuthMiddlewareLogger(zap.NewNop()),
+		middleware.WithAuthMiddlewareTokens(tokens),
+		middleware.WithAuthMiddlewareAPIKeys(apiKeys{
+			"reader": {ID: "1", UserID: "user2", Scopes: []entity.APIKeyScope{entity.ScopeRead}},
+			"writer": {ID: "2", UserID: "user2", Scopes: []entity.APIKeyScope{entity.ScopeShorten}},
+		}),
+	)
+	require.NoError(t, err)
+
+	// the handlers answer with the user of the request
+	userHandler := func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+		_, _ = w.Write([]byte(userID))
+	}
+	router := chi.NewRouter()
+	router.With(auth.Handler()).Get("/lenient", userHandler)
+	router.With(auth.StrictHandler()).Get("/strict", userHandler)
+	router.With(auth.StrictHandler(entity.ScopeRead)).Get("/read", userHandler)
+
+	token, err := tokens.Generate("user1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		header     map[string]string
+		cookie     *http.Cookie
+		name       string
+		path       string
+		wantUser   string
+		wantStatus int
+		wantIssued bool
+	}{
+		{
+			name:       "new user without a token",
+			path:       "/lenient",
+			wantStatus: http.StatusOK,
+			wantIssued: true,
+		},
+		{
+			name:       "bearer token",
+			path:       "/lenient",
+			header:     map[string]string{"Authorization": "Bearer " + token},
+			wantStatus: http.StatusOK,
+			wantUser:   "user1",
+		},
+		{
+			name:       "cookie",
+			path:       "/lenient",
+			cookie:     &http.Cookie{Name: "Auth", Value: token},
+			wantStatus: http.StatusOK,
+			wantUser:   "user1",
+		},
+		{
+			name:       "bearer token before the cookie",
+			path:       "/lenient",
+			header:     map[string]string{"Authorization": "bearer " + token},
+			cookie:     &http.Cookie{Name: "Auth", Value: "invalid"},
+			wantStatus: http.StatusOK,
+			wantUser:   "user1",
+		},
+		{
+			name:       "invalid bearer token",
+			path:       "/lenient",
+			header:     map[string]string{"Authorization": "Bearer invalid"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "other scheme",
+			path:       "/lenient",
+			header:     map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "strict without a token",
+			path:       "/strict",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "strict with a bearer token",
+			path:       "/strict",
+			header:     map[string]string{"Authorization": "Bearer " + token},
+			wantStatus: http.StatusOK,
+			wantUser:   "user1",
+		},
+		{
+			name:       "api key with the scope",
+			path:       "/read",
+			header:     map[string]string{"X-API-Key": "reader"},
+			wantStatus: http.StatusOK,
+			wantUser:   "user2",
+		},
+		{
+			name:       "api key before the bearer token",
+			path:       "/read",
+			header:     map[string]string{"X-API-Key": "reader", "Authorization": "Bearer " + token},
+			wantStatus: http.StatusOK,
+			wantUser:   "user2",
+		},
+		{
+			name:       "api key without the scope",
+			path:       "/read",
+			header:     map[string]string{"X-API-Key": "writer"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "api key on a route without scopes",
+			path:       "/lenient",
+			header:     map[string]string{"X-API-Key": "reader"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "unknown api key",
+			path:       "/read",
+			header:     map[string]string{"X-API-Key": "unknown"},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for name, value := range tt.header {
+				request.Header.Set(name, value)
+			}
+			if tt.cookie != nil {
+				request.AddCookie(tt.cookie)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			response := recorder.Result()
+			defer response.Body.Close()
+
+			require.Equal(t, tt.wantStatus, response.StatusCode)
+			issued := response.Header.Get("Authorization")
+			if !tt.wantIssued {
+				assert.Empty(t, issued)
+				assert.Empty(t, response.Cookies())
+				if tt.wantUser != "" {
+					assert.Equal(t, tt.wantUser, recorder.Body.String())
+				}
+				return
+			}
+
+			// the issued token identifies the new user in the header and the cookie
+			require.True(t, strings.HasPrefix(issued, "Bearer "))
+			userID, errParse := tokens.Parse(strings.TrimPrefix(issued, "Bearer "))
+			require.NoError(t, errParse)
+			assert.Equal(t, userID, recorder.Body.String())
+			cookies := response.Cookies()
+			require.Len(t, cookies, 1)
+			assert.Equal(t, strings.TrimPrefix(issued, "Bearer "), cookies[0].Value)
+		})
+	}
+}
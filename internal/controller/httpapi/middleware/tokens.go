@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Defaults of the TokenManager.
+const (
+	defaultTokenTTL      = 30 * 24 * time.Hour // default lifetime of a token
+	defaultTokenIssuer   = "shortener-url"     // default issuer of the tokens
+	defaultTokenAudience = "shortener-url"     // default audience of the tokens
+	ephemeralKeyID       = "ephemeral"         // key ID of the random key of WithEphemeralTokenKey
+	ephemeralKeySize     = 32                  // bytes of the random key of WithEphemeralTokenKey
+	keyIDHeader          = "kid"               // token header naming the signing key
+)
+
+var errInvalidToken = errors.New("invalid token")
+
+// SigningKey is a secret signing and verifying the tokens, identified by the kid header of the tokens.
+type SigningKey struct {
+	ID     string
+	Secret []byte
+}
+
+// ParseSigningKeys parses the keys listed as kid:secret pairs separated by commas or new lines.
+// Blank items and lines starting with # are skipped.
+func ParseSigningKeys(list string) ([]SigningKey, error) {
+	keys := make([]SigningKey, 0)
+	for _, item := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == '\n' }) {
+		item = strings.TrimSpace(item)
+		if item == "" || strings.HasPrefix(item, "#") {
+			continue
+		}
+		id, secret, ok := strings.Cut(item, ":")
+		id, secret = strings.TrimSpace(id), strings.TrimSpace(secret)
+		if !ok || id == "" || secret == "" {
+			return nil, fmt.Errorf("invalid signing key %q: expected kid:secret", id)
+		}
+		keys = append(keys, SigningKey{ID: id, Secret: []byte(secret)})
+	}
+	return keys, nil
+}
+
+type optionsTokenManager struct {
+	issuer   string
+	audience string
+	keys     []SigningKey
+	ttl      time.Duration
+}
+
+// OptionTokenManager is the option for the token manager.
+type OptionTokenManager func(options *optionsTokenManager) error
+
+// TokenManager issues and verifies the HS256 tokens of the users.
+// The first key signs the new tokens, every key verifies the tokens carrying its kid, so a new key
+// can be put first while the old one still accepts the tokens issued before the rotation.
+type TokenManager struct {
+	keys       map[string][]byte
+	signingKey SigningKey
+	issuer     string
+	audience   string
+	ttl        time.Duration
+}
+
+// WithTokenKeys is the option for the token manager to set the keys, the first one signs.
+func WithTokenKeys(keys ...SigningKey) OptionTokenManager {
+	return func(options *optionsTokenManager) error {
+		options.keys = append(options.keys, keys...)
+		return nil
+	}
+}
+
+// WithEphemeralTokenKey is the option for the token manager to sign with a random key.
+// The tokens do not survive a restart, so it is meant for development and tests only.
+func WithEphemeralTokenKey() OptionTokenManager {
+	return func(options *optionsTokenManager) error {
+		secret := make([]byte, ephemeralKeySize)
+		if _, err := rand.Read(secret); err != nil {
+			return fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		options.keys = append(options.keys, SigningKey{ID: ephemeralKeyID, Secret: secret})
+		return nil
+	}
+}
+
+// WithTokenTTL is the option for the token manager to set the lifetime of the tokens.
+func WithTokenTTL(ttl time.Duration) OptionTokenManager {
+	return func(options *optionsTokenManager) error {
+		if ttl <= 0 {
+			return errors.New("token ttl must be positive")
+		}
+		options.ttl = ttl
+		return nil
+	}
+}
+
+// WithTokenIssuer is the option for the token manager to set the issuer of the tokens.
+func WithTokenIssuer(issuer string) OptionTokenManager {
+	return func(options *optionsTokenManager) error {
+		options.issuer = issuer
+		return nil
+	}
+}
+
+// WithTokenAudience is the option for the token manager to set the audience of the tokens.
+func WithTokenAudience(audience string) OptionTokenManager {
+	return func(options *optionsTokenManager) error {
+		options.audience = audience
+		return nil
+	}
+}
+
+// NewTokenManager creates a new token manager.
+// It needs at least one key, set by WithTokenKeys or WithEphemeralTokenKey.
+func NewTokenManager(opts ...OptionTokenManager) (*TokenManager, error) {
+	options := &optionsTokenManager{
+		issuer:   defaultTokenIssuer,
+		audience: defaultTokenAudience,
+		ttl:      defaultTokenTTL,
+	}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if len(options.keys) == 0 {
+		return nil, errors.New("no signing keys configured")
+	}
+
+	keys := make(map[string][]byte, len(options.keys))
+	for _, key := range options.keys {
+		if key.ID == "" || len(key.Secret) == 0 {
+			return nil, errors.New("signing key needs an id and a secret")
+		}
+		if _, ok := keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate signing key %q", key.ID)
+		}
+		keys[key.ID] = key.Secret
+	}
+	return &TokenManager{
+		keys:       keys,
+		signingKey: options.keys[0],
+		issuer:     options.issuer,
+		audience:   options.audience,
+		ttl:        options.ttl,
+	}, nil
+}
+
+// TTL returns the lifetime of the tokens.
+func (m *TokenManager) TTL() time.Duration {
+	return m.ttl
+}
+
+// Generate signs a new token of the user with the first key.
+func (m *TokenManager) Generate(userID string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Audience:  jwt.ClaimStrings{m.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.ttl)),
+		},
+		UserID: userID,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header[keyIDHeader] = m.signingKey.ID
+
+	tokenString, err := token.SignedString(m.signingKey.Secret)
+	if err != nil {
+		return "", err
+	}
+	return tokenString, nil
+}
+
+// Parse verifies the token with the key named by its kid header and returns the user it was issued to.
+func (m *TokenManager) Parse(tokenString string) (string, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		keyID, ok := token.Header[keyIDHeader].(string)
+		if !ok {
+			return nil, errors.New("token has no key id")
+		}
+		secret, ok := m.keys[keyID]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", keyID)
+		}
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return "", err
+	}
+
+	if !token.Valid {
+		return "", errInvalidToken
+	}
+	if !claims.VerifyIssuer(m.issuer, true) {
+		return "", fmt.Errorf("%w: unexpected issuer", errInvalidToken)
+	}
+	if !claims.VerifyAudience(m.audience, true) {
+		return "", fmt.Errorf("%w: unexpected audience", errInvalidToken)
+	}
+	if claims.UserID == "" {
+		return "", fmt.Errorf("%w: no user", errInvalidToken)
+	}
+	return claims.UserID, nil
+}
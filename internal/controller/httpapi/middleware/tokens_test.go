@@ -0,0 +1,192 @@
+package middleware_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+)
+
+func TestParseSigningKeys(t *testing.T) {
+	keys, err := middleware.ParseSigningKeys("2025-06: new-secret ,2025-01:old-secret\n# retired\n\n2024-01:older")
+	require.NoError(t, err)
+	assert.Equal(t, []middleware.SigningKey{
+		{ID: "2025-06", Secret: []byte("new-secret")},
+		{ID: "2025-01", Secret: []byte("old-secret")},
+		{ID: "2024-01", Secret: []byte("older")},
+	}, keys)
+
+	keys, err = middleware.ParseSigningKeys("")
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = middleware.ParseSigningKeys("no-secret")
+	require.Error(t, err)
+	_, err = middleware.ParseSigningKeys("kid:")
+	require.Error(t, err)
+}
+
+func TestNewTokenManager(t *testing.T) {
+	key := middleware.SigningKey{ID: "1", Secret: []byte("secret")}
+
+	_, err := middleware.NewTokenManager(middleware.WithTokenKeys(key, key))
+	require.Error(t, err)
+	_, err = middleware.NewTokenManager(middleware.WithTokenKeys(middleware.SigningKey{ID: "1"}))
+	require.Error(t, err)
+	_, err = middleware.NewTokenManager(middleware.WithTokenKeys(key), middleware.WithTokenTTL(0))
+	require.Error(t, err)
+	_, err = middleware.NewTokenManager()
+	require.Error(t, err)
+
+	// an ephemeral key is random, so the tokens of one manager are rejected by another one
+	first, err := middleware.NewTokenManager(middleware.WithEphemeralTokenKey())
+	require.NoError(t, err)
+	second, err := middleware.NewTokenManager(middleware.WithEphemeralTokenKey())
+	require.NoError(t, err)
+	token, err := first.Generate("user1")
+	require.NoError(t, err)
+	_, err = second.Parse(token)
+	require.Error(t, err)
+}
+
+func TestTokenManager_Rotation(t *testing.T) {
+	oldKey := middleware.SigningKey{ID: "old", Secret: []byte("old-secret")}
+	newKey := middleware.SigningKey{ID: "new", Secret: []byte("new-secret")}
+
+	before, err := middleware.NewTokenManager(middleware.WithTokenKeys(oldKey))
+	require.NoError(t, err)
+	oldToken, err := before.Generate("user1")
+	require.NoError(t, err)
+
+	// the new key signs while the old one still verifies the tokens issued before
+	during, err := middleware.NewTokenManager(middleware.WithTokenKeys(newKey, oldKey))
+	require.NoError(t, err)
+	userID, err := during.Parse(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user1", userID)
+	newToken, err := during.Generate("user2")
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &middleware.Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+
+	// the tokens of a retired key are rejected
+	after, err := middleware.NewTokenManager(middleware.WithTokenKeys(newKey))
+	require.NoError(t, err)
+	_, err = after.Parse(oldToken)
+	require.Error(t, err)
+	userID, err = after.Parse(newToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user2", userID)
+}
+
+func TestTokenManager_Parse(t *testing.T) {
+	key := middleware.SigningKey{ID: "1", Secret: []byte("secret")}
+	tokens, err := middleware.NewTokenManager(
+		middleware.WithTokenKeys(key),
+		middleware.WithTokenIssuer("shortener"),
+		middleware.WithTokenAudience("users"),
+		middleware.WithTokenTTL(time.Hour),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, tokens.TTL())
+
+	sign := func(claims middleware.Claims, header map[string]any) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		for name, value := range header {
+			token.Header[name] = value
+		}
+		signed, errSign := token.SignedString(key.Secret)
+		require.NoError(t, errSign)
+		return signed
+	}
+	valid := jwt.RegisteredClaims{
+		Issuer:    "shortener",
+		Audience:  jwt.ClaimStrings{"users"},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+
+	tests := []struct {
+		header  map[string]any
+		name    string
+		claims  middleware.Claims
+		wantErr bool
+	}{
+		{
+			name:   "valid token",
+			header: map[string]any{"kid": "1"},
+			claims: middleware.Claims{RegisteredClaims: valid, UserID: "user1"},
+		},
+		{
+			name:    "no key id",
+			claims:  middleware.Claims{RegisteredClaims: valid, UserID: "user1"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown key id",
+			header:  map[string]any{"kid": "2"},
+			claims:  middleware.Claims{RegisteredClaims: valid, UserID: "user1"},
+			wantErr: true,
+		},
+		{
+			name:   "other issuer",
+			header: map[string]any{"kid": "1"},
+			claims: middleware.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    "someone",
+					Audience:  valid.Audience,
+					ExpiresAt: valid.ExpiresAt,
+				},
+				UserID: "user1",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "other audience",
+			header: map[string]any{"kid": "1"},
+			claims: middleware.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    valid.Issuer,
+					Audience:  jwt.ClaimStrings{"admins"},
+					ExpiresAt: valid.ExpiresAt,
+				},
+				UserID: "user1",
+			},
+			wantErr: true,
+		},
+		{
+			name:   "expired",
+			header: map[string]any{"kid": "1"},
+			claims: middleware.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    valid.Issuer,
+					Audience:  valid.Audience,
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+				},
+				UserID: "user1",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "no user",
+			header:  map[string]any{"kid": "1"},
+			claims:  middleware.Claims{RegisteredClaims: valid},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, errParse := tokens.Parse(sign(tt.claims, tt.header))
+			if tt.wantErr {
+				require.Error(t, errParse)
+				return
+			}
+			require.NoError(t, errParse)
+			assert.Equal(t, "user1", userID)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package httpapi
 
 import (
+	"errors"
+
 	//nolint:gosec // pprof is used for debugging
 	_ "net/http/pprof"
 
@@ -10,12 +12,15 @@ import (
 
 	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/handlers"
 	customMiddleware "github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/entity"
 )
 
 type options struct {
-	URLusecase URLusecase
-	logger     *zap.Logger
-	baseURL    string
+	URLusecase    URLusecase
+	logger        *zap.Logger
+	tokens        *customMiddleware.TokenManager
+	baseURL       string
+	trustedSubnet string
 }
 
 // Option is the option for the router.
@@ -37,6 +42,23 @@ func WithBaseURL(baseURL string) Option {
 	}
 }
 
+// WithTrustedSubnet is the option for the router to set the subnet, in CIDR notation,
+// allowed to read the internal statistics and dead letters.
+func WithTrustedSubnet(subnet string) Option {
+	return func(options *options) error {
+		options.trustedSubnet = subnet
+		return nil
+	}
+}
+
+// WithTokenManager is the option for the router to set the token manager of the user tokens.
+func WithTokenManager(tokens *customMiddleware.TokenManager) Option {
+	return func(options *options) error {
+		options.tokens = tokens
+		return nil
+	}
+}
+
 // WithURLUsecase is the option for the router to set the URL usecase.
 func WithURLUsecase(usecase URLusecase) Option {
 	return func(options *options) error {
@@ -53,6 +75,9 @@ func NewRouter(opts ...Option) (*chi.Mux, error) {
 			return nil, err
 		}
 	}
+	if options.tokens == nil {
+		return nil, errors.New("token manager is required")
+	}
 
 	// custom middlewares
 	customLogger, err := customMiddleware.NewHandlerLogger(
@@ -63,6 +88,8 @@ func NewRouter(opts ...Option) (*chi.Mux, error) {
 	}
 	authMiddleware, err := customMiddleware.NewAuthMiddleware(
 		customMiddleware.WithAuthMiddlewareLogger(options.logger),
+		customMiddleware.WithAuthMiddlewareTokens(options.tokens),
+		customMiddleware.WithAuthMiddlewareAPIKeys(options.URLusecase),
 	)
 	if err != nil {
 		return nil, err
@@ -72,19 +99,29 @@ func NewRouter(opts ...Option) (*chi.Mux, error) {
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
 	router.Use(customLogger.Handler())
-	router.Use(authMiddleware.Handler())
 	router.Use(customMiddleware.GzipMiddleware())
 	// pprof
 	router.Mount("/debug", middleware.Profiler())
 
-	err = initializeHandlers(router, options)
+	err = initializeHandlers(router, authMiddleware, options)
 	if err != nil {
 		return nil, err
 	}
 	return router, nil
 }
 
-func initializeHandlers(router *chi.Mux, options *options) error {
+// route is a handler together with the way its requests are authenticated.
+type route struct {
+	handler handler
+	// scopes are the API key scopes accepted by the route; a route without scopes rejects API keys.
+	scopes []entity.APIKeyScope
+	// strict routes answer 401 instead of creating a new user.
+	strict bool
+	// internal routes skip the user authentication: the trusted subnet is their access control.
+	internal bool
+}
+
+func initializeHandlers(router *chi.Mux, authMiddleware *customMiddleware.AuthMiddleware, options *options) error {
 	// handlers
 	shortenHandler, err := handlers.NewShortenHandler(
 		handlers.WithShortenBaseURL(options.baseURL),
@@ -97,6 +134,7 @@ func initializeHandlers(router *chi.Mux, options *options) error {
 
 	redirectHandler, err := handlers.NewRedirectHandler(
 		handlers.WithRedirectUsecase(options.URLusecase),
+		handlers.WithRedirectClickRecorder(options.URLusecase),
 		handlers.WithRedirectLogger(options.logger),
 	)
 	if err != nil {
@@ -145,18 +183,128 @@ func initializeHandlers(router *chi.Mux, options *options) error {
 	if err != nil {
 		return err
 	}
-	h := []handler{
-		shortenHandler,
-		redirectHandler,
-		apiShortenHandler,
-		pingHandler,
-		batchShortenHandler,
-		userURLsHandler,
-		userURLsDeleteHandler,
+
+	userURLsRestoreHandler, err := handlers.NewUserURLsRestoreHandler(
+		handlers.WithUserURLsRestoreUsecase(options.URLusecase),
+		handlers.WithUserURLsRestoreLogger(options.logger),
+	)
+	if err != nil {
+		return err
+	}
+
+	userJobHandler, err := handlers.NewUserJobHandler(
+		handlers.WithUserJobUsecase(options.URLusecase),
+		handlers.WithUserJobLogger(options.logger),
+	)
+	if err != nil {
+		return err
+	}
+
+	userURLUpdateHandler, err := handlers.NewUserURLUpdateHandler(
+		handlers.WithUserURLUpdateBaseURL(options.baseURL),
+		handlers.WithUserURLUpdateUsecase(options.URLusecase),
+		handlers.WithUserURLUpdateLogger(options.logger),
+	)
+	if err != nil {
+		return err
+	}
+
+	urlStatsHandler, err := handlers.NewURLStatsHandler(
+		handlers.WithURLStatsBaseURL(options.baseURL),
+		handlers.WithURLStatsUsecase(options.URLusecase),
+		handlers.WithURLStatsLogger(options.logger),
+	)
+	if err != nil {
+		return err
+	}
+
+	internalStatsHandler, err := handlers.NewInternalStatsHandler(
+		handlers.WithInternalStatsUsecase(options.URLusecase),
+		handlers.WithInternalStatsLogger(options.logger),
+		handlers.WithInternalStatsTrustedSubnet(options.trustedSubnet),
+	)
+	if err != nil {
+		return err
+	}
+
+	internalDeadLettersHandler, err := handlers.NewInternalDeadLettersHandler(
+		handlers.WithInternalDeadLettersUsecase(options.URLusecase),
+		handlers.WithInternalDeadLettersLogger(options.logger),
+		handlers.WithInternalDeadLettersTrustedSubnet(options.trustedSubnet),
+	)
+	if err != nil {
+		return err
 	}
 
-	for _, h := range h {
-		router.Method(h.Method(), h.Pattern(), h.HandlerFunc())
+	apiKeysHandlers, err := newAPIKeysHandlers(options)
+	if err != nil {
+		return err
+	}
+
+	// the routes of the existing URLs of a user are strict: a new user has none.
+	// API keys are accepted where they carry one of the scopes of the route, never on the key management.
+	anyScope := []entity.APIKeyScope{entity.ScopeShorten, entity.ScopeRead, entity.ScopeDelete}
+	shorten := []entity.APIKeyScope{entity.ScopeShorten}
+	read := []entity.APIKeyScope{entity.ScopeRead}
+	remove := []entity.APIKeyScope{entity.ScopeDelete}
+	routes := []route{
+		{handler: shortenHandler, scopes: shorten},
+		{handler: redirectHandler, scopes: anyScope},
+		{handler: apiShortenHandler, scopes: shorten},
+		{handler: pingHandler, scopes: anyScope},
+		{handler: batchShortenHandler, scopes: shorten},
+		{handler: userURLsHandler, scopes: read, strict: true},
+		{handler: userURLsDeleteHandler, scopes: remove, strict: true},
+		{handler: userURLsRestoreHandler, scopes: remove, strict: true},
+		{handler: userJobHandler, scopes: read, strict: true},
+		{handler: userURLUpdateHandler, scopes: shorten, strict: true},
+		{handler: urlStatsHandler, scopes: read, strict: true},
+		{handler: internalStatsHandler, internal: true},
+		{handler: internalDeadLettersHandler, internal: true},
+	}
+	for _, h := range apiKeysHandlers {
+		routes = append(routes, route{handler: h, strict: true})
+	}
+
+	for _, rt := range routes {
+		h := rt.handler
+		if rt.internal {
+			router.Method(h.Method(), h.Pattern(), h.HandlerFunc())
+			continue
+		}
+		auth := authMiddleware.Handler(rt.scopes...)
+		if rt.strict {
+			auth = authMiddleware.StrictHandler(rt.scopes...)
+		}
+		router.With(auth).Method(h.Method(), h.Pattern(), h.HandlerFunc())
 	}
 	return nil
 }
+
+// newAPIKeysHandlers creates the handlers managing the API keys of the user.
+func newAPIKeysHandlers(options *options) ([]handler, error) {
+	createHandler, err := handlers.NewUserAPIKeysCreateHandler(
+		handlers.WithUserAPIKeysCreateUsecase(options.URLusecase),
+		handlers.WithUserAPIKeysCreateLogger(options.logger),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	listHandler, err := handlers.NewUserAPIKeysHandler(
+		handlers.WithUserAPIKeysUsecase(options.URLusecase),
+		handlers.WithUserAPIKeysLogger(options.logger),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deleteHandler, err := handlers.NewUserAPIKeysDeleteHandler(
+		handlers.WithUserAPIKeysDeleteUsecase(options.URLusecase),
+		handlers.WithUserAPIKeysDeleteLogger(options.logger),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return []handler{createHandler, listHandler, deleteHandler}, nil
+}
@@ -0,0 +1,77 @@
+package httpapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/repository/memory"
+	"github.com/AGENT3128/shortener-url/internal/usecase"
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+func TestNewRouter_InternalRoutes(t *testing.T) {
+	logger := zap.NewNop()
+	repo := memory.NewMemStorage(logger)
+	urlUsecase, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(repo),
+		usecase.WithURLUsecaseLogger(logger),
+		usecase.WithDeleteWorker(worker.NewDeleteWorker(repo, worker.NewMemoryDeleteQueue(), logger)),
+	)
+	require.NoError(t, err)
+	tokens, err := middleware.NewTokenManager(middleware.WithEphemeralTokenKey())
+	require.NoError(t, err)
+	router, err := httpapi.NewRouter(
+		httpapi.WithLogger(logger),
+		httpapi.WithBaseURL("http://localhost:8080"),
+		httpapi.WithURLUsecase(urlUsecase),
+		httpapi.WithTokenManager(tokens),
+		httpapi.WithTrustedSubnet("192.168.1.0/24"),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		header     http.Header
+		name       string
+		realIP     string
+		statusCode int
+	}{
+		{name: "trusted without credentials", realIP: "192.168.1.17", statusCode: http.StatusOK},
+		{
+			name:       "trusted with an invalid token",
+			realIP:     "192.168.1.17",
+			header:     http.Header{"Authorization": {"Bearer invalid"}},
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "trusted with an unknown api key",
+			realIP:     "192.168.1.17",
+			header:     http.Header{"X-Api-Key": {"unknown"}},
+			statusCode: http.StatusOK,
+		},
+		{name: "untrusted", realIP: "192.168.2.17", statusCode: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		for _, path := range []string{"/api/internal/stats", "/api/internal/dead-letters"} {
+			t.Run(test.name+" "+path, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				for name, values := range test.header {
+					req.Header[name] = values
+				}
+				req.Header.Set("X-Real-IP", test.realIP)
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, req)
+
+				assert.Equal(t, test.statusCode, recorder.Code, recorder.Body.String())
+				// no user is created for the internal routes
+				assert.Empty(t, recorder.Header().Values("Set-Cookie"))
+			})
+		}
+	}
+}
@@ -1,12 +1,31 @@
 package dto
 
+import "time"
+
 // ShortenRequest represents the request for shortening a URL.
 type ShortenRequest struct {
-	URL string `json:"url"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"` // optional time the URL stops redirecting
+	URL       string    `json:"url"`
+	Alias     string    `json:"alias,omitempty"`      // optional custom short URL
+	MaxClicks int64     `json:"max_clicks,omitempty"` // optional number of redirects the URL serves
 }
 
 // ShortenBatchRequest represents an item in the batch shortening request.
 type ShortenBatchRequest struct {
-	CorrelationID string `json:"correlation_id"`
-	OriginalURL   string `json:"original_url"`
+	ExpiresAt     time.Time `json:"expires_at,omitzero"` // optional time the URL stops redirecting
+	CorrelationID string    `json:"correlation_id"`
+	OriginalURL   string    `json:"original_url"`
+	Alias         string    `json:"alias,omitempty"`      // optional custom short URL
+	MaxClicks     int64     `json:"max_clicks,omitempty"` // optional number of redirects the URL serves
+}
+
+// UpdateURLRequest represents the request for changing the destination of a short URL.
+type UpdateURLRequest struct {
+	OriginalURL string `json:"original_url"`
+}
+
+// CreateAPIKeyRequest represents the request for creating an API key.
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name,omitempty"` // optional label telling the keys apart
+	Scopes []string `json:"scopes"`         // shorten, read and delete
 }
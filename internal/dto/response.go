@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // ShortenResponse represents the response for a shortened URL.
 type ShortenResponse struct {
 	Result string `json:"result"`
@@ -11,8 +13,83 @@ type ShortenBatchResponse struct {
 	ShortURL      string `json:"short_url"`
 }
 
+// UpdateURLResponse represents the short URL with its new destination and the previous ones.
+type UpdateURLResponse struct {
+	ShortURL    string              `json:"short_url"`
+	OriginalURL string              `json:"original_url"`
+	History     []URLChangeResponse `json:"history"`
+}
+
+// URLChangeResponse represents a previous destination of a short URL.
+type URLChangeResponse struct {
+	ChangedAt   time.Time `json:"changed_at"`
+	OriginalURL string    `json:"original_url"`
+}
+
 // UserURLsResponse represents individual URL in the response.
 type UserURLsResponse struct {
 	ShortURL    string `json:"short_url"`
 	OriginalURL string `json:"original_url"`
+	IsDeleted   bool   `json:"is_deleted,omitempty"` // set for the deleted URLs listed with include_deleted
+}
+
+// URLStatsResponse represents the click statistics of a short URL.
+type URLStatsResponse struct {
+	ShortURL string                `json:"short_url"`
+	Daily    []DailyClicksResponse `json:"daily"`
+	Total    int64                 `json:"total"`
+}
+
+// DailyClicksResponse represents the number of clicks on one UTC day.
+type DailyClicksResponse struct {
+	Date   string `json:"date"`
+	Clicks int64  `json:"clicks"`
+}
+
+// ServiceStatsResponse represents the totals of the whole service.
+type ServiceStatsResponse struct {
+	URLs  int64 `json:"urls"`
+	Users int64 `json:"users"`
+}
+
+// JobResponse represents the state of an asynchronous delete or restore job.
+type JobResponse struct {
+	CreatedAt  time.Time `json:"created_at"`
+	FinishedAt time.Time `json:"finished_at,omitzero"` // set once the job is processed
+	JobID      string    `json:"job_id"`
+	Operation  string    `json:"operation"` // delete or restore
+	Status     string    `json:"status"`    // pending, succeeded, partially_failed or failed
+	ShortURLs  []string  `json:"short_urls"`
+	FailedURLs []string  `json:"failed_urls,omitempty"` // short URLs that do not exist or belong to another user
+}
+
+// DeadLetterResponse represents a delete or restore job that ran out of attempts.
+type DeadLetterResponse struct {
+	CreatedAt time.Time `json:"created_at"`
+	DeadAt    time.Time `json:"dead_at"`
+	JobID     string    `json:"job_id"`
+	UserID    string    `json:"user_id"`
+	Operation string    `json:"operation"` // delete or restore
+	LastError string    `json:"last_error"`
+	ShortURLs []string  `json:"short_urls"`
+	Attempts  int       `json:"attempts"`
+}
+
+// APIKeyResponse represents an API key of the user without the key itself.
+type APIKeyResponse struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	Prefix    string    `json:"prefix"` // beginning of the key
+	Scopes    []string  `json:"scopes"`
+}
+
+// CreateAPIKeyResponse represents a created API key, the only response carrying the key itself.
+type CreateAPIKeyResponse struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	Prefix    string    `json:"prefix"` // beginning of the key
+	Key       string    `json:"key"`    // sent in the X-API-Key header, it cannot be read again
+	Scopes    []string  `json:"scopes"`
 }
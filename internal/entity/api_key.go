@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// APIKeyScope is an action an API key is allowed to perform.
+type APIKeyScope string
+
+// Scopes of the API keys.
+const (
+	ScopeShorten APIKeyScope = "shorten" // shortens and updates URLs
+	ScopeRead    APIKeyScope = "read"    // lists URLs, their statistics and jobs
+	ScopeDelete  APIKeyScope = "delete"  // deletes and restores URLs
+)
+
+// Errors for the API keys.
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")     // error when the key is unknown or belongs to another user
+	ErrInvalidScope   = errors.New("invalid api key scope") // error when a requested scope is unknown or none is given
+)
+
+// ParseAPIKeyScopes validates the scopes and drops the duplicates keeping their order.
+// At least one scope is required.
+func ParseAPIKeyScopes(scopes []string) ([]APIKeyScope, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: no scopes", ErrInvalidScope)
+	}
+	parsed := make([]APIKeyScope, 0, len(scopes))
+	for _, scope := range scopes {
+		switch s := APIKeyScope(scope); s {
+		case ScopeShorten, ScopeRead, ScopeDelete:
+			if !slices.Contains(parsed, s) {
+				parsed = append(parsed, s)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+	return parsed, nil
+}
+
+// APIKey represents a long-lived key acting as its owner for programmatic clients.
+// Only the hash of the key is stored; the key itself is shown once when it is created.
+type APIKey struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name,omitempty"`
+	// Prefix is the beginning of the key letting the owner tell the keys apart.
+	Prefix string `json:"prefix"`
+	// Hash is the hex encoded SHA-256 of the key.
+	Hash   string        `json:"hash"`
+	Scopes []APIKeyScope `json:"scopes"`
+}
+
+// HasScope reports whether the key carries the scope.
+func (k APIKey) HasScope(scope APIKeyScope) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// SortAPIKeys sorts the keys by their creation time, the oldest first.
+func SortAPIKeys(keys []APIKey) {
+	slices.SortFunc(keys, func(a, b APIKey) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of the key under which it is stored.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"slices"
+	"time"
+)
+
+// Click represents one redirect through a short URL.
+type Click struct {
+	Time      time.Time `json:"time"`
+	ShortURL  string    `json:"short_url"`
+	Referrer  string    `json:"referrer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	// IPPrefix is the client address with the host bits dropped, e.g. 203.0.113.0/24.
+	IPPrefix string `json:"ip_prefix,omitempty"`
+	// Gzip reports whether the redirect response went through the gzip encoder.
+	Gzip bool `json:"gzip,omitempty"`
+}
+
+// DailyClicks is the number of clicks of a short URL on one UTC day.
+type DailyClicks struct {
+	Date   time.Time `json:"date"`
+	Clicks int64     `json:"clicks"`
+}
+
+// URLStats represents the click statistics of a short URL.
+type URLStats struct {
+	ShortURL string `json:"short_url"`
+	// Daily holds the days with clicks in ascending order.
+	Daily []DailyClicks `json:"daily"`
+	Total int64         `json:"total"`
+}
+
+// ClickDay returns the UTC day of the click time.
+func ClickDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// NewURLStats aggregates the clicks of the short URL by UTC day.
+func NewURLStats(shortURL string, clicks []Click) URLStats {
+	counts := make(map[time.Time]int64)
+	for _, click := range clicks {
+		counts[ClickDay(click.Time)]++
+	}
+	return NewURLStatsFromDaily(shortURL, counts)
+}
+
+// NewURLStatsFromDaily builds the statistics of the short URL from its click counts by UTC day.
+func NewURLStatsFromDaily(shortURL string, counts map[time.Time]int64) URLStats {
+	stats := URLStats{
+		ShortURL: shortURL,
+		Daily:    make([]DailyClicks, 0, len(counts)),
+	}
+	for date, count := range counts {
+		stats.Daily = append(stats.Daily, DailyClicks{Date: date, Clicks: count})
+		stats.Total += count
+	}
+	slices.SortFunc(stats.Daily, func(a, b DailyClicks) int {
+		return a.Date.Compare(b.Date)
+	})
+	return stats
+}
@@ -0,0 +1,38 @@
+package entity
+
+import "fmt"
+
+// DedupScope is the scope within which an original URL is shortened only once.
+type DedupScope string
+
+const (
+	// DedupGlobal gives every original URL one short URL shared by all users.
+	DedupGlobal DedupScope = "global"
+	// DedupUser gives every user their own short URL of an original URL.
+	DedupUser DedupScope = "user"
+	// DedupNone gives an original URL a new short URL every time it is shortened.
+	DedupNone DedupScope = "none"
+)
+
+// ParseDedupScope parses the deduplication scope from its string representation.
+func ParseDedupScope(scope string) (DedupScope, error) {
+	switch s := DedupScope(scope); s {
+	case DedupGlobal, DedupUser, DedupNone:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unknown dedup scope %q", scope)
+	}
+}
+
+// Key returns the key the user's original URL is deduplicated by within the scope
+// and false if the scope does not deduplicate original URLs.
+func (s DedupScope) Key(userID, originalURL string) (string, bool) {
+	switch s {
+	case DedupNone:
+		return "", false
+	case DedupUser:
+		return userID + "\x00" + originalURL, true
+	default:
+		return originalURL, true
+	}
+}
@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// JobOperation is the change a job applies to the short URLs.
+type JobOperation string
+
+// Operations of the jobs.
+const (
+	JobDelete  JobOperation = "delete"  // marks the short URLs deleted
+	JobRestore JobOperation = "restore" // clears the deleted mark of the short URLs
+)
+
+// JobStatus is the state of a job.
+type JobStatus string
+
+// Statuses of the jobs.
+const (
+	JobPending         JobStatus = "pending"          // the job waits for its batch to be processed
+	JobSucceeded       JobStatus = "succeeded"        // every short URL was processed
+	JobPartiallyFailed JobStatus = "partially_failed" // some of the short URLs were not processed
+	JobFailed          JobStatus = "failed"           // none of the short URLs was processed
+)
+
+// Job represents an asynchronous request of a user to delete or restore short URLs.
+type Job struct {
+	CreatedAt time.Time `json:"created_at"`
+	// FinishedAt is the time the job was processed; the zero time means it is pending.
+	FinishedAt time.Time    `json:"finished_at,omitzero"`
+	ID         string       `json:"id"`
+	UserID     string       `json:"user_id"`
+	Operation  JobOperation `json:"operation"`
+	Status     JobStatus    `json:"status"`
+	ShortURLs  []string     `json:"short_urls"`
+	// FailedURLs holds the short URLs that were not processed: they do not exist, belong to another user
+	// or the storage failed.
+	FailedURLs []string `json:"failed_urls,omitempty"`
+}
+
+// Finish returns the job finished at the moment now given the short URLs the storage processed.
+// A storage error fails every short URL of the job.
+func (j Job) Finish(now time.Time, processed map[string]struct{}, err error) Job {
+	j.FinishedAt = now
+	if err != nil {
+		j.Status = JobFailed
+		j.FailedURLs = slices.Clone(j.ShortURLs)
+		return j
+	}
+
+	j.FailedURLs = make([]string, 0)
+	for _, shortURL := range j.ShortURLs {
+		if _, ok := processed[shortURL]; !ok {
+			j.FailedURLs = append(j.FailedURLs, shortURL)
+		}
+	}
+	switch len(j.FailedURLs) {
+	case 0:
+		j.Status = JobSucceeded
+	case len(j.ShortURLs):
+		j.Status = JobFailed
+	default:
+		j.Status = JobPartiallyFailed
+	}
+	return j
+}
+
+// DeleteTask is a job waiting in the delete queue.
+type DeleteTask struct {
+	// NextAttemptAt is the earliest time the task is claimed.
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// DeadAt is the time the task ran out of attempts; the zero time means it is queued.
+	DeadAt time.Time `json:"dead_at,omitzero"`
+	Job    Job       `json:"job"`
+	// LastError is the error of the last failed attempt.
+	LastError string `json:"last_error,omitempty"`
+	// Seq orders the tasks in the queue, it is assigned by the queue.
+	Seq      int64 `json:"seq"`
+	Attempts int   `json:"attempts"`
+}
+
+// DeadJob returns the job of the dead task, failed when the task ran out of attempts.
+func (t DeleteTask) DeadJob() Job {
+	return t.Job.Finish(t.DeadAt, nil, errors.New(t.LastError))
+}
+
+// Errors for the job.
+var (
+	ErrJobNotFound = errors.New("job not found") // error when the job is unknown or belongs to another user
+)
@@ -0,0 +1,9 @@
+package entity
+
+// ServiceStats represents the totals of the whole service.
+type ServiceStats struct {
+	// URLs is the number of stored short URLs, deleted ones included.
+	URLs int64 `json:"urls"`
+	// Users is the number of distinct owners of the stored short URLs.
+	Users int64 `json:"users"`
+}
@@ -7,16 +7,60 @@ import (
 
 // URL represents a URL entity in the storage.
 type URL struct {
-	CreatedAt   time.Time `json:"created_at"`
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is the time the URL stops redirecting; the zero time means it never expires.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	// DeletedAt is the time the URL was deleted; the zero time means it is not deleted.
+	DeletedAt   time.Time `json:"deleted_at,omitzero"`
 	ShortURL    string    `json:"short_url"`
 	OriginalURL string    `json:"original_url"`
 	UserID      string    `json:"user_id"`
-	DeletedFlag bool      `json:"is_deleted"`
+	// MaxClicks is the number of redirects the URL serves; zero means unlimited.
+	MaxClicks int64 `json:"max_clicks,omitempty"`
+	// Clicks is the number of redirects counted against MaxClicks; redirects of unlimited URLs are not counted.
+	Clicks      int64 `json:"clicks"`
+	DeletedFlag bool  `json:"is_deleted"`
+}
+
+// URLChange is a previous destination of a short URL.
+type URLChange struct {
+	// ChangedAt is the time the short URL stopped pointing to OriginalURL.
+	ChangedAt   time.Time `json:"changed_at"`
+	OriginalURL string    `json:"original_url"`
+}
+
+// Expired reports whether the URL has passed its expiration time or used up its clicks at the moment now.
+func (u URL) Expired(now time.Time) bool {
+	if !u.ExpiresAt.IsZero() && !now.Before(u.ExpiresAt) {
+		return true
+	}
+	return u.MaxClicks > 0 && u.Clicks >= u.MaxClicks
 }
 
 // Errors for the URL.
 var (
-	ErrURLExists   = errors.New("url already exists") // error when url already exists
-	ErrURLDeleted  = errors.New("url deleted")        // error when url is deleted
-	ErrURLNotFound = errors.New("url not found")      // error when url is not found
+	ErrURLExists     = errors.New("url already exists")      // error when url already exists
+	ErrURLDeleted    = errors.New("url deleted")             // error when url is deleted
+	ErrURLNotFound   = errors.New("url not found")           // error when url is not found
+	ErrShortURLTaken = errors.New("short url already taken") // error when short url is already used by another url
+	ErrAliasTaken    = errors.New("alias already taken")     // error when the requested alias is already used
+	ErrInvalidAlias  = errors.New("invalid alias")           // error when the requested alias is malformed or reserved
+	ErrURLExpired    = errors.New("url expired")             // error when url has expired or used up its clicks
+	ErrInvalidLimits = errors.New("invalid url limits")      // error when the expiration or click limit is wrong
 )
+
+// URLExistsError is returned by repositories when the original URL is already shortened.
+// It carries the existing short URL and matches ErrURLExists with errors.Is.
+type URLExistsError struct {
+	ShortURL string
+}
+
+// Error returns the error message.
+func (e *URLExistsError) Error() string {
+	return ErrURLExists.Error() + ": " + e.ShortURL
+}
+
+// Unwrap returns ErrURLExists.
+func (e *URLExistsError) Unwrap() error {
+	return ErrURLExists
+}
@@ -0,0 +1,102 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+)
+
+// Constants for the Server.
+const (
+	defaultAddress = "localhost:3200" // default address
+)
+
+// options is the options for the Server.
+type options struct {
+	server  *grpc.Server
+	address string
+}
+
+// Option is the option for the Server.
+type Option func(options *options) error
+
+// Server is the gRPC server.
+type Server struct {
+	grpcServer *grpc.Server
+	address    string
+}
+
+// New creates a new Server.
+func New(opts ...Option) (*Server, error) {
+	options := &options{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.server == nil {
+		return nil, errors.New("grpc server is required")
+	}
+
+	server := &Server{
+		grpcServer: options.server,
+		address:    defaultAddress,
+	}
+	if options.address != "" {
+		server.address = options.address
+	}
+	return server, nil
+}
+
+// Address returns the address of the Server.
+func (s *Server) Address() string {
+	return s.address
+}
+
+// Start listens on the address and serves until the Server is shut down.
+// It returns grpc.ErrServerStopped if the Server was shut down before it started.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.address, err)
+	}
+	return s.grpcServer.Serve(listener)
+}
+
+// Shutdown stops accepting calls and waits for the running ones to finish.
+// The calls still running when ctx is done are canceled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
+
+// WithServer is the option for the Server to set the gRPC server with the registered services.
+func WithServer(server *grpc.Server) Option {
+	return func(options *options) error {
+		options.server = server
+		return nil
+	}
+}
+
+// WithAddress is the option for the Server to set the address.
+func WithAddress(address string) Option {
+	return func(options *options) error {
+		options.address = address
+		return nil
+	}
+}
@@ -0,0 +1,157 @@
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+const (
+	apiKeyLogSuffix = ".keys"
+	// maxAPIKeyRecordSize bounds a single key line; the names and the revoked IDs are client supplied.
+	maxAPIKeyRecordSize = 1024 * 1024
+)
+
+// apiKeyOp is the operation recorded in the API key log.
+type apiKeyOp string
+
+const (
+	apiKeyOpAdd    apiKeyOp = "add"
+	apiKeyOpDelete apiKeyOp = "delete"
+)
+
+// apiKeyEntry is one operation appended to the API key log.
+type apiKeyEntry struct {
+	Key    *entity.APIKey `json:"key,omitempty"`
+	Op     apiKeyOp       `json:"op"`
+	UserID string         `json:"user_id,omitempty"`
+	IDs    []string       `json:"ids,omitempty"`
+}
+
+// apiKeyLog is the append-only log of the API keys kept next to the snapshot.
+// The keys are few and rarely change, so the log is not compacted into the snapshot.
+type apiKeyLog struct {
+	file   *os.File
+	writer *bufio.Writer
+	logger *zap.Logger
+	path   string
+	policy SyncPolicy
+	mu     sync.Mutex
+}
+
+// openAPIKeyLog opens (or creates) the API key log next to the snapshot file.
+func openAPIKeyLog(snapshotPath string, policy SyncPolicy, logger *zap.Logger) (*apiKeyLog, error) {
+	path := snapshotPath + apiKeyLogSuffix
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open api key log: %w", err)
+	}
+	if errTerminate := terminateTornLine(file); errTerminate != nil {
+		return nil, errors.Join(fmt.Errorf("failed to open api key log: %w", errTerminate), file.Close())
+	}
+	return &apiKeyLog{
+		file:   file,
+		writer: bufio.NewWriter(file),
+		logger: logger,
+		path:   path,
+		policy: policy,
+	}, nil
+}
+
+// append writes the entry to the log honoring the sync policy.
+func (l *apiKeyLog) append(entry apiKeyEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, errWrite := l.writer.Write(data); errWrite != nil {
+		return errWrite
+	}
+	if errWrite := l.writer.WriteByte('\n'); errWrite != nil {
+		return errWrite
+	}
+
+	if l.policy == SyncAlways {
+		return l.syncLocked()
+	}
+	return l.writer.Flush()
+}
+
+// sync flushes buffered entries and fsyncs the log.
+func (l *apiKeyLog) sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.syncLocked()
+}
+
+func (l *apiKeyLog) syncLocked() error {
+	if err := l.writer.Flush(); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+// close flushes and closes the log.
+func (l *apiKeyLog) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return errors.Join(l.syncLocked(), l.file.Close())
+}
+
+// replay returns the API keys left by the log by their hash.
+func (l *apiKeyLog) replay() (map[string]entity.APIKey, error) {
+	keys := make(map[string]entity.APIKey)
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxAPIKeyRecordSize)
+	for scanner.Scan() {
+		var entry apiKeyEntry
+		if errUnmarshal := json.Unmarshal(scanner.Bytes(), &entry); errUnmarshal != nil {
+			// a torn tail is expected after a crash in the middle of an append
+			l.logger.Warn("skipping unreadable api key entry", zap.String("path", l.path), zap.Error(errUnmarshal))
+			continue
+		}
+		applyAPIKeyEntry(keys, entry)
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		return nil, errScan
+	}
+	return keys, nil
+}
+
+// applyAPIKeyEntry applies the entry to the keys and returns the IDs of the deleted keys.
+func applyAPIKeyEntry(keys map[string]entity.APIKey, entry apiKeyEntry) []string {
+	switch entry.Op {
+	case apiKeyOpAdd:
+		if entry.Key != nil {
+			keys[entry.Key.Hash] = *entry.Key
+		}
+	case apiKeyOpDelete:
+		deleted := make([]string, 0, len(entry.IDs))
+		for hash, key := range keys {
+			if key.UserID == entry.UserID && slices.Contains(entry.IDs, key.ID) {
+				delete(keys, hash)
+				deleted = append(deleted, key.ID)
+			}
+		}
+		return deleted
+	}
+	return nil
+}
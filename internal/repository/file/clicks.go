@@ -0,0 +1,267 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+const (
+	clickLogSuffix           = ".clicks"
+	clickLogCompactingSuffix = ".clicks.compacting"
+	// maxClickRecordSize bounds a single click line; longer lines are skipped on replay.
+	maxClickRecordSize = 64 * 1024
+	// maxClickFieldSize bounds the client supplied referrer and user agent of a click,
+	// so that a click fits in a line even when JSON escapes every byte of them.
+	maxClickFieldSize = 4 * 1024
+	// clickCompactRecords is the number of appended clicks after which the log is rewritten with the daily counts.
+	clickCompactRecords = 1024
+)
+
+// clickRecord is one line of the click log: a click, or the clicks of a short URL on one UTC day
+// counted when the log was rewritten.
+type clickRecord struct {
+	entity.Click
+	// Count is the number of clicks on the day of Time; zero for a single click.
+	Count int64 `json:"count,omitempty"`
+}
+
+// clickCounts holds the number of clicks of the short URLs by UTC day.
+type clickCounts map[string]map[time.Time]int64
+
+// add counts n clicks of the short URL on the day.
+func (c clickCounts) add(shortURL string, day time.Time, n int64) {
+	daily, ok := c[shortURL]
+	if !ok {
+		daily = make(map[time.Time]int64)
+		c[shortURL] = daily
+	}
+	daily[day] += n
+}
+
+// clickLog is the append-only log of click events kept next to the snapshot.
+// The statistics need only the daily counts, so every clickCompactRecords clicks the log is rewritten
+// with one record per short URL and day, and the referrer, the user agent and the address of the clicks are dropped.
+type clickLog struct {
+	file           *os.File
+	writer         *bufio.Writer
+	logger         *zap.Logger
+	path           string
+	compactingPath string
+	policy         SyncPolicy
+	// appended is the number of clicks written since the log was rewritten.
+	appended int
+	mu       sync.Mutex
+}
+
+// openClickLog opens (or creates) the click log next to the snapshot file.
+func openClickLog(snapshotPath string, policy SyncPolicy, logger *zap.Logger) (*clickLog, error) {
+	l := &clickLog{
+		logger:         logger,
+		path:           snapshotPath + clickLogSuffix,
+		compactingPath: snapshotPath + clickLogCompactingSuffix,
+		policy:         policy,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *clickLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open click log: %w", err)
+	}
+	if errTerminate := terminateTornLine(file); errTerminate != nil {
+		return errors.Join(fmt.Errorf("failed to open click log: %w", errTerminate), file.Close())
+	}
+	l.file = file
+	l.writer = bufio.NewWriter(file)
+	return nil
+}
+
+// append writes the clicks to the log honoring the sync policy.
+// The referrer and the user agent are cut to maxClickFieldSize bytes.
+func (l *clickLog) append(clicks []entity.Click) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, click := range clicks {
+		click.Referrer = truncateClickField(click.Referrer)
+		click.UserAgent = truncateClickField(click.UserAgent)
+		data, err := json.Marshal(clickRecord{Click: click})
+		if err != nil {
+			return err
+		}
+		if _, errWrite := l.writer.Write(data); errWrite != nil {
+			return errWrite
+		}
+		if errWrite := l.writer.WriteByte('\n'); errWrite != nil {
+			return errWrite
+		}
+	}
+	l.appended += len(clicks)
+
+	if l.policy == SyncAlways {
+		return l.syncLocked()
+	}
+	return l.writer.Flush()
+}
+
+// sync flushes buffered clicks and fsyncs the log.
+func (l *clickLog) sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.syncLocked()
+}
+
+func (l *clickLog) syncLocked() error {
+	if err := l.writer.Flush(); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+// close flushes and closes the log.
+func (l *clickLog) close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return errors.Join(l.syncLocked(), l.file.Close())
+}
+
+// replay returns the daily click counts of the log.
+// Unreadable and oversized lines are skipped.
+func (l *clickLog) replay() (clickCounts, error) {
+	counts := make(clickCounts)
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	clicks := 0
+	errRead := readClickLines(file, func(line []byte) {
+		var record clickRecord
+		if errUnmarshal := json.Unmarshal(line, &record); errUnmarshal != nil {
+			// a torn tail is expected after a crash in the middle of an append
+			l.logger.Warn("skipping unreadable click", zap.String("path", l.path), zap.Error(errUnmarshal))
+			return
+		}
+		if record.Count == 0 {
+			clicks++
+			record.Count = 1
+		}
+		counts.add(record.ShortURL, entity.ClickDay(record.Time), record.Count)
+	}, func() {
+		l.logger.Warn("skipping oversized click", zap.String("path", l.path), zap.Int("max_size", maxClickRecordSize))
+	})
+	if errRead != nil {
+		return nil, errRead
+	}
+
+	l.mu.Lock()
+	l.appended = clicks
+	l.mu.Unlock()
+	return counts, nil
+}
+
+// needsCompaction reports whether enough clicks were appended since the log was rewritten.
+func (l *clickLog) needsCompaction() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.appended >= clickCompactRecords
+}
+
+// compact rewrites the log with the daily click counts and reopens it for appending.
+// The new log replaces the old one with a rename, so a crash leaves one of them complete.
+// The counts must include every click of the log.
+func (l *clickLog) compact(counts clickCounts) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := writeClickCounts(l.compactingPath, counts); err != nil {
+		return err
+	}
+	if err := l.writer.Flush(); err != nil {
+		return errors.Join(err, os.Remove(l.compactingPath))
+	}
+	if err := l.file.Close(); err != nil {
+		l.logger.Warn("failed to close click log", zap.Error(err))
+	}
+	if errRename := os.Rename(l.compactingPath, l.path); errRename != nil {
+		return errors.Join(errRename, l.open())
+	}
+	l.appended = 0
+	return l.open()
+}
+
+// writeClickCounts writes one record per short URL and day to a new click log file and fsyncs it.
+func writeClickCounts(path string, counts clickCounts) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for shortURL, daily := range counts {
+		for day, count := range daily {
+			record := clickRecord{Click: entity.Click{Time: day, ShortURL: shortURL}, Count: count}
+			if errEncode := encoder.Encode(record); errEncode != nil {
+				return errors.Join(errEncode, file.Close())
+			}
+		}
+	}
+	if errFlush := writer.Flush(); errFlush != nil {
+		return errors.Join(errFlush, file.Close())
+	}
+	return errors.Join(file.Sync(), file.Close())
+}
+
+// readClickLines calls record with every non-empty line of r up to maxClickRecordSize bytes
+// and oversized for every longer line.
+func readClickLines(r io.Reader, record func(line []byte), oversized func()) error {
+	reader := bufio.NewReaderSize(r, maxClickRecordSize)
+	skipping := false
+	for {
+		line, err := reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			// the rest of the line is skipped with it
+			skipping = true
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		switch {
+		case skipping:
+			oversized()
+			skipping = false
+		case len(bytes.TrimSpace(line)) > 0:
+			record(line)
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// truncateClickField cuts the client supplied field to maxClickFieldSize bytes and drops a rune split by the cut.
+func truncateClickField(s string) string {
+	if len(s) <= maxClickFieldSize {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxClickFieldSize], "")
+}
@@ -0,0 +1,312 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+const (
+	deleteSpoolSuffix           = ".deletes"
+	deleteSpoolCompactingSuffix = ".deletes.compacting"
+	// spoolCompactEntries is the number of appended entries after which the spool is rewritten.
+	spoolCompactEntries = 1024
+)
+
+// spoolOp is the change of the delete queue recorded in the spool.
+type spoolOp string
+
+const (
+	spoolOpPush  spoolOp = "push"
+	spoolOpAck   spoolOp = "ack"
+	spoolOpRetry spoolOp = "retry"
+	spoolOpBury  spoolOp = "bury"
+)
+
+// spoolEntry is one change of the delete queue appended to the spool.
+// An ack entry carries the finished job of the acknowledged task.
+type spoolEntry struct {
+	Task *entity.DeleteTask `json:"task,omitempty"`
+	Job  *entity.Job        `json:"job,omitempty"`
+	Op   spoolOp            `json:"op"`
+}
+
+// DeleteSpool is the delete queue of the file storage.
+// The queue is kept in memory and every change is appended to the spool file next to the storage
+// and fsynced before it is applied, so the queued tasks, the finished jobs and the dead letters survive a restart.
+// Leases are not recorded: the tasks claimed before a restart are claimed again.
+// The spool is rewritten with the queued tasks, the finished jobs and the dead tasks on startup,
+// every spoolCompactEntries changes and once jobs are pruned.
+type DeleteSpool struct {
+	queue          *worker.MemoryDeleteQueue
+	file           *os.File
+	logger         *zap.Logger
+	path           string
+	compactingPath string
+	entries        int
+	mu             sync.Mutex
+}
+
+// NewDeleteSpool opens (or creates) the delete spool next to the storage file and restores the queue from it.
+func NewDeleteSpool(storagePath string, logger *zap.Logger) (*DeleteSpool, error) {
+	s := &DeleteSpool{
+		queue:          worker.NewMemoryDeleteQueue(),
+		logger:         logger.With(zap.String("queue", "spool")),
+		path:           storagePath + deleteSpoolSuffix,
+		compactingPath: storagePath + deleteSpoolCompactingSuffix,
+	}
+	if err := s.replay(); err != nil {
+		return nil, fmt.Errorf("failed to replay delete spool: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.compactLocked(); err != nil {
+		return nil, fmt.Errorf("failed to compact delete spool: %w", err)
+	}
+	return s, nil
+}
+
+// Push appends the task to the queue.
+func (s *DeleteSpool) Push(ctx context.Context, task entity.DeleteTask) error {
+	return s.record(spoolEntry{Op: spoolOpPush, Task: &task}, func() error {
+		return s.queue.Push(ctx, task)
+	})
+}
+
+// Claim leases up to limit tasks due at the moment now.
+func (s *DeleteSpool) Claim(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+	lease time.Duration,
+) ([]entity.DeleteTask, error) {
+	return s.queue.Claim(ctx, now, limit, lease)
+}
+
+// Ack removes the task of the job from the queue and keeps the finished job.
+func (s *DeleteSpool) Ack(ctx context.Context, job entity.Job) error {
+	return s.record(spoolEntry{Op: spoolOpAck, Job: &job}, func() error {
+		return s.queue.Ack(ctx, job)
+	})
+}
+
+// Retry stores the attempts, the next attempt time and the last error of the task and drops its lease.
+func (s *DeleteSpool) Retry(ctx context.Context, task entity.DeleteTask) error {
+	return s.record(spoolEntry{Op: spoolOpRetry, Task: &task}, func() error {
+		return s.queue.Retry(ctx, task)
+	})
+}
+
+// Bury moves the task to the dead letters.
+func (s *DeleteSpool) Bury(ctx context.Context, task entity.DeleteTask) error {
+	return s.record(spoolEntry{Op: spoolOpBury, Task: &task}, func() error {
+		return s.queue.Bury(ctx, task)
+	})
+}
+
+// DeadLetters returns up to limit dead tasks, the latest first. A zero limit returns all of them.
+func (s *DeleteSpool) DeadLetters(ctx context.Context, limit int) ([]entity.DeleteTask, error) {
+	return s.queue.DeadLetters(ctx, limit)
+}
+
+// Job returns the job by its ID, entity.ErrJobNotFound if it is neither queued, finished nor dead.
+func (s *DeleteSpool) Job(ctx context.Context, jobID string) (entity.Job, error) {
+	return s.queue.Job(ctx, jobID)
+}
+
+// Prune drops the jobs finished before finishedBefore and the dead tasks buried before deadBefore
+// and rewrites the spool without them.
+func (s *DeleteSpool) Prune(ctx context.Context, finishedBefore, deadBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.queue.Prune(ctx, finishedBefore, deadBefore); err != nil {
+		return err
+	}
+	if err := s.compactLocked(); err != nil {
+		return fmt.Errorf("failed to compact delete spool: %w", err)
+	}
+	return nil
+}
+
+// Close closes the spool file.
+func (s *DeleteSpool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// record appends the entry to the spool and applies the change once the entry is on disk.
+func (s *DeleteSpool) record(entry spoolEntry, apply func() error) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		// a failed rewrite could not reopen the spool
+		if errOpen := s.open(); errOpen != nil {
+			return errOpen
+		}
+	}
+	if _, errWrite := s.file.Write(append(data, '\n')); errWrite != nil {
+		return fmt.Errorf("failed to append to delete spool: %w", errWrite)
+	}
+	if errSync := s.file.Sync(); errSync != nil {
+		return fmt.Errorf("failed to sync delete spool: %w", errSync)
+	}
+	if errApply := apply(); errApply != nil {
+		return errApply
+	}
+
+	s.entries++
+	if s.entries < spoolCompactEntries {
+		return nil
+	}
+	if errCompact := s.compactLocked(); errCompact != nil {
+		// the change is recorded, the spool is rewritten on the next attempt
+		s.logger.Error("failed to compact delete spool", zap.Error(errCompact))
+	}
+	return nil
+}
+
+// apply replays one spool entry on the queue.
+func (s *DeleteSpool) apply(ctx context.Context, entry spoolEntry) error {
+	if entry.Op == spoolOpAck && entry.Job == nil {
+		return errors.New("ack entry without job")
+	}
+	if entry.Op != spoolOpAck && entry.Task == nil {
+		return fmt.Errorf("%s entry without task", entry.Op)
+	}
+	switch entry.Op {
+	case spoolOpPush:
+		return s.queue.Push(ctx, *entry.Task)
+	case spoolOpAck:
+		return s.queue.Ack(ctx, *entry.Job)
+	case spoolOpRetry:
+		return s.queue.Retry(ctx, *entry.Task)
+	case spoolOpBury:
+		return s.queue.Bury(ctx, *entry.Task)
+	default:
+		return fmt.Errorf("unknown spool operation %q", entry.Op)
+	}
+}
+
+// replay restores the queue from the spool left by a previous run.
+func (s *DeleteSpool) replay() error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	ctx := context.Background()
+	replayed := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxWALEntrySize)
+	for scanner.Scan() {
+		var entry spoolEntry
+		if errUnmarshal := json.Unmarshal(scanner.Bytes(), &entry); errUnmarshal != nil {
+			// a torn tail is expected after a crash in the middle of an append
+			s.logger.Warn("skipping unreadable spool entry", zap.Error(errUnmarshal))
+			continue
+		}
+		if errApply := s.apply(ctx, entry); errApply != nil {
+			s.logger.Warn("skipping invalid spool entry", zap.Error(errApply))
+			continue
+		}
+		replayed++
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		return errScan
+	}
+	s.logger.Info("delete spool replayed", zap.String("path", s.path), zap.Int("entries", replayed))
+	return nil
+}
+
+// compactLocked rewrites the spool with the queued tasks, the finished jobs and the dead letters
+// and reopens it for appending.
+// The new spool replaces the old one with a rename, so a crash leaves one of them complete.
+// The caller must hold the lock.
+func (s *DeleteSpool) compactLocked() error {
+	ctx := context.Background()
+	dead, err := s.queue.DeadLetters(ctx, 0)
+	if err != nil {
+		return err
+	}
+	slices.Reverse(dead)
+
+	entries := make([]spoolEntry, 0)
+	for _, task := range s.queue.Queued() {
+		entries = append(entries, spoolEntry{Op: spoolOpPush, Task: &task})
+	}
+	for _, job := range s.queue.Finished() {
+		entries = append(entries, spoolEntry{Op: spoolOpAck, Job: &job})
+	}
+	for _, task := range dead {
+		entries = append(entries, spoolEntry{Op: spoolOpPush, Task: &task}, spoolEntry{Op: spoolOpBury, Task: &task})
+	}
+	if errWrite := writeSpool(s.compactingPath, entries); errWrite != nil {
+		return errWrite
+	}
+
+	if s.file != nil {
+		if errClose := s.file.Close(); errClose != nil {
+			s.logger.Warn("failed to close delete spool", zap.Error(errClose))
+		}
+		s.file = nil
+	}
+	if errRename := os.Rename(s.compactingPath, s.path); errRename != nil {
+		return errors.Join(errRename, s.open())
+	}
+	s.entries = 0
+	return s.open()
+}
+
+func (s *DeleteSpool) open() error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open delete spool: %w", err)
+	}
+	s.file = file
+	return nil
+}
+
+// writeSpool writes the entries to a new spool file and fsyncs it.
+func writeSpool(path string, entries []spoolEntry) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if errEncode := encoder.Encode(entry); errEncode != nil {
+			return errors.Join(errEncode, file.Close())
+		}
+	}
+	if errFlush := writer.Flush(); errFlush != nil {
+		return errors.Join(errFlush, file.Close())
+	}
+	return errors.Join(file.Sync(), file.Close())
+}
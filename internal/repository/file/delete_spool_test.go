@@ -0,0 +1,110 @@
+package file_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/repository/file"
+	"github.com/AGENT3128/shortener-url/internal/repository/repositorytest"
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+func TestDeleteSpool_Contract(t *testing.T) {
+	repositorytest.RunDeleteQueue(t, func(t *testing.T) worker.DeleteQueue {
+		spool, err := file.NewDeleteSpool(filepath.Join(t.TempDir(), "storage.json"), zap.NewNop())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, spool.Close())
+		})
+		return spool
+	})
+}
+
+func TestDeleteSpool_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	now := time.Now()
+	task := func(id, userID string) entity.DeleteTask {
+		return entity.DeleteTask{
+			NextAttemptAt: now,
+			Job: entity.Job{
+				CreatedAt: now,
+				ID:        id,
+				UserID:    userID,
+				Operation: entity.JobDelete,
+				Status:    entity.JobPending,
+				ShortURLs: []string{"short-" + id},
+			},
+		}
+	}
+
+	spool, err := file.NewDeleteSpool(path, zap.NewNop())
+	require.NoError(t, err)
+	for _, queued := range []entity.DeleteTask{task("done", "user1"), task("retried", "user1"), task("dead", "user2")} {
+		require.NoError(t, spool.Push(t.Context(), queued))
+	}
+	claimed, err := spool.Claim(t.Context(), now, 10, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, claimed, 3)
+
+	require.NoError(t, spool.Ack(t.Context(), claimed[0].Job.Finish(now, map[string]struct{}{"short-done": {}}, nil)))
+	retried := claimed[1]
+	retried.Attempts = 1
+	retried.NextAttemptAt = now.Add(time.Minute)
+	retried.LastError = "connection refused"
+	require.NoError(t, spool.Retry(t.Context(), retried))
+	dead := claimed[2]
+	dead.Attempts = 5
+	dead.DeadAt = now
+	dead.LastError = "connection refused"
+	require.NoError(t, spool.Bury(t.Context(), dead))
+	require.NoError(t, spool.Close())
+
+	// a crash in the middle of an append leaves a torn line
+	spoolFile, err := os.OpenFile(path+".deletes", os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = spoolFile.WriteString(`{"op":"ack","job_`)
+	require.NoError(t, err)
+	require.NoError(t, spoolFile.Close())
+
+	spool, err = file.NewDeleteSpool(path, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, spool.Close())
+	})
+
+	// the retried task keeps its state, leases are not restored
+	claimed, err = spool.Claim(t.Context(), now, 10, time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+	claimed, err = spool.Claim(t.Context(), now.Add(time.Minute), 10, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, "retried", claimed[0].Job.ID)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	assert.Equal(t, "connection refused", claimed[0].LastError)
+
+	letters, err := spool.DeadLetters(t.Context(), 0)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, "dead", letters[0].Job.ID)
+	assert.Equal(t, 5, letters[0].Attempts)
+
+	// the finished job survives the restart
+	job, err := spool.Job(t.Context(), "done")
+	require.NoError(t, err)
+	assert.Equal(t, entity.JobSucceeded, job.Status)
+
+	// the spool is rewritten on open: the retried task pushed, the finished job acked,
+	// the dead letter pushed and buried
+	data, err := os.ReadFile(path + ".deletes")
+	require.NoError(t, err)
+	assert.Equal(t, 4, bytes.Count(data, []byte("\n")))
+}
@@ -1,10 +1,10 @@
 package file
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
-	"os"
+	"errors"
+	"fmt"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
@@ -14,25 +14,145 @@ import (
 	"maps"
 
 	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/repository/index"
 )
 
 const (
-	defaultSaveTicker = 10 * time.Second
+	defaultSaveTicker   = 10 * time.Second
+	defaultSyncInterval = 1 * time.Second
+)
+
+// Versions of the on-disk record format.
+const (
+	// RecordVersionV1 is the legacy format: uuid, short_url and original_url only.
+	RecordVersionV1 = 1
+	// RecordVersionV2 adds the owner, the deletion flag and the creation time.
+	RecordVersionV2 = 2
+	// RecordVersionV3 adds the expiration time, the click limit and the click count.
+	RecordVersionV3 = 3
+	// RecordVersionV4 adds the previous destinations of the short URL.
+	RecordVersionV4 = 4
+	// RecordVersionV5 adds the deletion time.
+	RecordVersionV5 = 5
+	// currentRecordVersion is the version written by save.
+	currentRecordVersion = RecordVersionV5
 )
 
 // URLData is the data for the URL.
 type URLData struct {
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	DeletedAt   time.Time
 	OriginalURL string
 	UUID        string
 	UserID      string
+	History     []entity.URLChange
+	MaxClicks   int64
+	Clicks      int64
 	IsDeleted   bool
 }
 
+// toURL converts the in-memory data to the entity.
+func (d URLData) toURL(shortURL string) entity.URL {
+	return entity.URL{
+		CreatedAt:   d.CreatedAt,
+		ExpiresAt:   d.ExpiresAt,
+		DeletedAt:   d.DeletedAt,
+		ShortURL:    shortURL,
+		OriginalURL: d.OriginalURL,
+		UserID:      d.UserID,
+		MaxClicks:   d.MaxClicks,
+		Clicks:      d.Clicks,
+		DeletedFlag: d.IsDeleted,
+	}
+}
+
+// markDeleted marks the URL deleted at the moment now keeping the time of an earlier deletion.
+func (d URLData) markDeleted(now time.Time) URLData {
+	if !d.IsDeleted {
+		d.IsDeleted = true
+		d.DeletedAt = now
+	}
+	return d
+}
+
 // URLRecord is the record for the URL.
+// Lines written before versioning was introduced have no "version" field and are read as RecordVersionV1.
 type URLRecord struct {
-	UUID        string `json:"uuid"`
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
+	CreatedAt   time.Time          `json:"created_at,omitzero"`
+	ExpiresAt   time.Time          `json:"expires_at,omitzero"`
+	DeletedAt   time.Time          `json:"deleted_at,omitzero"`
+	UUID        string             `json:"uuid"`
+	ShortURL    string             `json:"short_url"`
+	OriginalURL string             `json:"original_url"`
+	UserID      string             `json:"user_id,omitempty"`
+	History     []entity.URLChange `json:"history,omitempty"`
+	Version     int                `json:"version,omitempty"`
+	MaxClicks   int64              `json:"max_clicks,omitempty"`
+	Clicks      int64              `json:"clicks,omitempty"`
+	IsDeleted   bool               `json:"is_deleted,omitempty"`
+}
+
+// newURLRecord converts the in-memory data to the current on-disk record.
+func newURLRecord(shortURL string, urlData URLData) URLRecord {
+	return URLRecord{
+		Version:     currentRecordVersion,
+		UUID:        urlData.UUID,
+		ShortURL:    shortURL,
+		OriginalURL: urlData.OriginalURL,
+		UserID:      urlData.UserID,
+		IsDeleted:   urlData.IsDeleted,
+		CreatedAt:   urlData.CreatedAt,
+		ExpiresAt:   urlData.ExpiresAt,
+		DeletedAt:   urlData.DeletedAt,
+		MaxClicks:   urlData.MaxClicks,
+		Clicks:      urlData.Clicks,
+		History:     urlData.History,
+	}
+}
+
+// toURLData converts the on-disk record of any supported version to the in-memory data.
+func (r URLRecord) toURLData() (URLData, error) {
+	switch r.Version {
+	case 0, RecordVersionV1:
+		return URLData{
+			OriginalURL: r.OriginalURL,
+			UUID:        r.UUID,
+		}, nil
+	case RecordVersionV2:
+		return URLData{
+			CreatedAt:   r.CreatedAt,
+			OriginalURL: r.OriginalURL,
+			UUID:        r.UUID,
+			UserID:      r.UserID,
+			IsDeleted:   r.IsDeleted,
+			DeletedAt:   r.deletedAt(),
+		}, nil
+	case RecordVersionV3, RecordVersionV4, RecordVersionV5:
+		return URLData{
+			CreatedAt:   r.CreatedAt,
+			ExpiresAt:   r.ExpiresAt,
+			DeletedAt:   r.deletedAt(),
+			OriginalURL: r.OriginalURL,
+			UUID:        r.UUID,
+			UserID:      r.UserID,
+			History:     r.History,
+			MaxClicks:   r.MaxClicks,
+			Clicks:      r.Clicks,
+			IsDeleted:   r.IsDeleted,
+		}, nil
+	default:
+		return URLData{}, fmt.Errorf("unsupported record version %d", r.Version)
+	}
+}
+
+// deletedAt returns the deletion time of the record. Deleted records written before RecordVersionV5
+// carry no deletion time, so their retention is counted from the moment they are read.
+func (r URLRecord) deletedAt() time.Time {
+	if r.IsDeleted && r.DeletedAt.IsZero() {
+		return time.Now()
+	}
+	return r.DeletedAt
 }
 
 // Memento represents a snapshot of the storage state.
@@ -41,73 +161,197 @@ type Memento struct {
 	LastUUID int
 }
 
+// apply replays one write-ahead log entry on top of the snapshot.
+func (m *Memento) apply(entry WALEntry) {
+	switch entry.Op {
+	case walOpAdd, walOpUpdate:
+		for _, record := range entry.Records {
+			urlData, err := record.toURLData()
+			if err != nil {
+				continue
+			}
+			m.URLs[record.ShortURL] = urlData
+			m.trackUUID(record.UUID)
+		}
+	case walOpDelete:
+		for _, shortURL := range entry.ShortURLs {
+			urlData, exists := m.URLs[shortURL]
+			if exists && urlData.UserID == entry.UserID {
+				m.URLs[shortURL] = urlData.markDeleted(entry.at())
+			}
+		}
+	case walOpRestore:
+		for _, shortURL := range entry.ShortURLs {
+			urlData, exists := m.URLs[shortURL]
+			if exists && urlData.UserID == entry.UserID {
+				urlData.IsDeleted = false
+				urlData.DeletedAt = time.Time{}
+				m.URLs[shortURL] = urlData
+			}
+		}
+	case walOpVisit:
+		if urlData, exists := m.URLs[entry.ShortURL]; exists {
+			urlData.Clicks = entry.Clicks
+			m.URLs[entry.ShortURL] = urlData
+		}
+		for _, shortURL := range entry.ShortURLs {
+			if urlData, exists := m.URLs[shortURL]; exists {
+				urlData.Clicks++
+				m.URLs[shortURL] = urlData
+			}
+		}
+	case walOpExpire:
+		for _, shortURL := range entry.ShortURLs {
+			if urlData, exists := m.URLs[shortURL]; exists {
+				m.URLs[shortURL] = urlData.markDeleted(entry.at())
+			}
+		}
+	case walOpPurge:
+		for _, shortURL := range entry.ShortURLs {
+			delete(m.URLs, shortURL)
+		}
+	}
+}
+
+// trackUUID keeps LastUUID at the highest restored UUID.
+func (m *Memento) trackUUID(uuid string) {
+	if n, err := strconv.Atoi(uuid); err == nil && n > m.LastUUID {
+		m.LastUUID = n
+	}
+}
+
 // Caretaker handles saving and restoring storage state.
 type Caretaker struct {
-	logger      *zap.Logger
-	filePath    string
-	saveTimeout time.Duration
+	logger       *zap.Logger
+	filePath     string
+	syncPolicy   SyncPolicy
+	saveTimeout  time.Duration
+	syncInterval time.Duration
+	// corruptionPolicy is applied when the snapshot fails verification on startup.
+	corruptionPolicy CorruptionPolicy
+	// dedupScope is the scope within which an original URL is shortened only once.
+	dedupScope entity.DedupScope
 }
 
 // Storage is the file storage for the URL.
+// Every mutation is appended to a write-ahead log; the log is periodically compacted into a snapshot.
 type Storage struct {
 	urls       map[string]URLData
+	clicks     clickCounts
+	apiKeys    map[string]entity.APIKey
+	index      *index.URLIndex
 	logger     *zap.Logger
 	caretaker  *Caretaker
+	wal        *writeAheadLog
+	clickLog   *clickLog
+	apiKeyLog  *apiKeyLog
 	stopSaving chan struct{}
+	saveDone   chan struct{}
 	lastUUID   int
 	mu         sync.RWMutex
-	isDirty    bool
+	// snapshotValid reports whether the snapshot on disk is known to be good
+	// and may be kept as the previous snapshot when it is replaced.
+	snapshotValid bool
 }
 
 // Option is the option for the FileStorage.
 type Option func(*Caretaker)
 
-// WithSaveTicker is the option for the FileStorage.
+// WithSaveTicker is the option for the FileStorage to set the compaction interval.
 func WithSaveTicker(ticker time.Duration) Option {
 	return func(c *Caretaker) {
 		c.saveTimeout = ticker
 	}
 }
 
+// WithSyncPolicy is the option for the FileStorage to set the write-ahead log sync policy.
+func WithSyncPolicy(policy SyncPolicy) Option {
+	return func(c *Caretaker) {
+		c.syncPolicy = policy
+	}
+}
+
+// WithSyncInterval is the option for the FileStorage to set the fsync interval for the SyncInterval policy.
+func WithSyncInterval(interval time.Duration) Option {
+	return func(c *Caretaker) {
+		c.syncInterval = interval
+	}
+}
+
+// WithCorruptionPolicy is the option for the FileStorage to set the reaction to a corrupted snapshot.
+func WithCorruptionPolicy(policy CorruptionPolicy) Option {
+	return func(c *Caretaker) {
+		c.corruptionPolicy = policy
+	}
+}
+
+// WithDedupScope is the option for the FileStorage to set the scope within which
+// an original URL is shortened only once. Original URLs are deduplicated globally by default.
+func WithDedupScope(scope entity.DedupScope) Option {
+	return func(c *Caretaker) {
+		c.dedupScope = scope
+	}
+}
+
 // NewFileStorage creates a new FileStorage.
 func NewFileStorage(path string, logger *zap.Logger, opts ...Option) (*Storage, error) {
 	logger = logger.With(zap.String("storage", "file"))
 
 	caretaker := &Caretaker{
-		filePath:    path,
-		logger:      logger,
-		saveTimeout: defaultSaveTicker,
+		filePath:     path,
+		logger:       logger,
+		saveTimeout:  defaultSaveTicker,
+		syncPolicy:   SyncInterval,
+		syncInterval: defaultSyncInterval,
+
+		corruptionPolicy: CorruptionFail,
+		dedupScope:       entity.DedupGlobal,
 	}
 
 	for _, opt := range opts {
 		opt(caretaker)
 	}
 
+	wal, err := openWAL(path, caretaker.syncPolicy, logger)
+	if err != nil {
+		return nil, err
+	}
+	clickLog, err := openClickLog(path, caretaker.syncPolicy, logger)
+	if err != nil {
+		return nil, errors.Join(err, wal.close())
+	}
+	apiKeyLog, err := openAPIKeyLog(path, caretaker.syncPolicy, logger)
+	if err != nil {
+		return nil, errors.Join(err, wal.close(), clickLog.close())
+	}
+
 	storage := &Storage{
 		urls:       make(map[string]URLData),
+		index:      index.NewURLIndex(caretaker.dedupScope),
 		lastUUID:   0,
 		logger:     logger,
 		caretaker:  caretaker,
-		isDirty:    false,
+		wal:        wal,
+		clickLog:   clickLog,
+		apiKeyLog:  apiKeyLog,
 		stopSaving: make(chan struct{}),
+		saveDone:   make(chan struct{}),
 	}
 
-	if err := storage.restore(); err != nil {
-		return nil, err
+	if errRestore := storage.restore(); errRestore != nil {
+		return nil, errors.Join(errRestore, wal.close(), clickLog.close(), apiKeyLog.close())
 	}
 
-	// Start periodic saving
+	// Start periodic compaction
 	go storage.periodicSave()
 
 	return storage, nil
 }
 
 // createMemento creates a snapshot of the current state.
+// The caller must hold the lock.
 func (f *Storage) createMemento() *Memento {
-	f.mu.RLock()
-	defer f.mu.RUnlock()
-
-	urlsCopy := make(map[string]URLData)
+	urlsCopy := make(map[string]URLData, len(f.urls))
 	maps.Copy(urlsCopy, f.urls)
 
 	return &Memento{
@@ -116,27 +360,61 @@ func (f *Storage) createMemento() *Memento {
 	}
 }
 
-// restoreFromMemento restores state from a memento.
+// restoreFromMemento restores state from a memento and rebuilds the index.
 func (f *Storage) restoreFromMemento(m *Memento) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	f.urls = m.URLs
 	f.lastUUID = m.LastUUID
+	f.index = index.NewURLIndex(f.caretaker.dedupScope)
+	for shortURL, urlData := range f.urls {
+		f.index.Put(shortURL, urlData.OriginalURL, urlData.UserID)
+	}
 }
 
-// periodicSave periodically saves state if it's dirty.
+// put stores the URL data and keeps the index consistent. The caller must hold the write lock.
+func (f *Storage) put(shortURL string, urlData URLData) {
+	if old, exists := f.urls[shortURL]; exists {
+		f.index.Remove(shortURL, old.OriginalURL, old.UserID)
+	}
+	f.urls[shortURL] = urlData
+	f.index.Put(shortURL, urlData.OriginalURL, urlData.UserID)
+}
+
+// periodicSave periodically compacts the write-ahead log into a snapshot
+// and fsyncs the log when the SyncInterval policy is used.
 func (f *Storage) periodicSave() {
+	defer close(f.saveDone)
+
 	ticker := time.NewTicker(f.caretaker.saveTimeout)
 	defer ticker.Stop()
 
+	var syncTick <-chan time.Time
+	if f.caretaker.syncPolicy == SyncInterval {
+		syncTicker := time.NewTicker(f.caretaker.syncInterval)
+		defer syncTicker.Stop()
+		syncTick = syncTicker.C
+	}
+
 	for {
 		select {
 		case <-ticker.C:
-			if f.isDirty {
-				if err := f.save(); err != nil {
-					f.logger.Error("periodic save failed", zap.Error(err))
-				}
+			if err := f.compact(); err != nil {
+				f.logger.Error("periodic compaction failed", zap.Error(err))
+			}
+			if err := f.compactClicks(); err != nil {
+				f.logger.Error("click log compaction failed", zap.Error(err))
+			}
+		case <-syncTick:
+			if err := f.wal.sync(); err != nil {
+				f.logger.Error("write-ahead log sync failed", zap.Error(err))
+			}
+			if err := f.clickLog.sync(); err != nil {
+				f.logger.Error("click log sync failed", zap.Error(err))
+			}
+			if err := f.apiKeyLog.sync(); err != nil {
+				f.logger.Error("api key log sync failed", zap.Error(err))
 			}
 		case <-f.stopSaving:
 			return
@@ -144,106 +422,125 @@ func (f *Storage) periodicSave() {
 	}
 }
 
-// save saves the current state to file.
-func (f *Storage) save() error {
+// compact writes a snapshot of the current state and retires the log entries it covers.
+// The retired entries are kept until the next compaction for a fallback to the previous snapshot.
+func (f *Storage) compact() error {
+	if f.wal.pending() == 0 {
+		return nil
+	}
+
+	f.mu.Lock()
 	memento := f.createMemento()
+	errRotate := f.wal.rotate()
+	f.mu.Unlock()
+	if errRotate != nil {
+		return fmt.Errorf("failed to rotate write-ahead log: %w", errRotate)
+	}
 
-	file, err := os.OpenFile(f.caretaker.filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
+	replacedPrevious := f.snapshotValid
+	if err := f.save(memento); err != nil {
 		return err
 	}
-	defer file.Close()
-
-	writer := bufio.NewWriter(file)
-
-	for shortURL, urlData := range memento.URLs {
-		record := URLRecord{
-			UUID:        urlData.UUID,
-			ShortURL:    shortURL,
-			OriginalURL: urlData.OriginalURL,
-		}
-
-		data, errMarshal := json.Marshal(record)
-		if errMarshal != nil {
-			continue
-		}
+	return f.wal.retireRotated(replacedPrevious)
+}
 
-		if _, errWrite := writer.Write(data); errWrite != nil {
-			return errWrite
-		}
-		if errWrite := writer.WriteByte('\n'); errWrite != nil {
-			return errWrite
-		}
+// compactClicks rewrites the click log with the daily click counts once enough clicks are appended to it.
+func (f *Storage) compactClicks() error {
+	if !f.clickLog.needsCompaction() {
+		return nil
 	}
 
-	if errFlush := writer.Flush(); errFlush != nil {
-		return errFlush
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if err := f.clickLog.compact(f.clicks); err != nil {
+		return fmt.Errorf("failed to rewrite click log: %w", err)
 	}
+	return nil
+}
 
-	f.mu.Lock()
-	f.isDirty = false
-	f.mu.Unlock()
-
+// save writes the memento to the snapshot file.
+func (f *Storage) save(memento *Memento) error {
+	if err := writeSnapshot(f.caretaker.filePath, memento, f.snapshotValid); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	f.snapshotValid = true
 	return nil
 }
 
-// restore loads the state from file.
+// restore loads the snapshot from file and replays the write-ahead log on top of it.
 func (f *Storage) restore() error {
-	file, err := os.OpenFile(f.caretaker.filePath, os.O_RDONLY|os.O_CREATE, 0600)
+	memento, err := readSnapshot(f.caretaker.filePath)
+	f.snapshotValid = err == nil
 	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	urls := make(map[string]URLData)
-	lastUUID := 0
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		var record URLRecord
-		if errUnmarshal := json.Unmarshal(scanner.Bytes(), &record); errUnmarshal != nil {
-			continue
+		if !errors.Is(err, ErrSnapshotCorrupted) || f.caretaker.corruptionPolicy != CorruptionFallback {
+			return fmt.Errorf("failed to restore snapshot: %w", err)
 		}
-
-		urls[record.ShortURL] = URLData{
-			OriginalURL: record.OriginalURL,
-			UUID:        record.UUID,
+		f.logger.Error("snapshot corrupted, falling back to the previous snapshot", zap.Error(err))
+		memento, err = readPreviousSnapshot(f.caretaker.filePath)
+		if err != nil {
+			return fmt.Errorf("failed to restore previous snapshot: %w", err)
 		}
-
-		if uuid, errAtoi := strconv.Atoi(record.UUID); errAtoi == nil && uuid > lastUUID {
-			lastUUID = uuid
+		found, errReplay := f.wal.replayPrevious(memento.apply)
+		if errReplay != nil {
+			return fmt.Errorf("failed to replay write-ahead log of the previous snapshot: %w", errReplay)
+		}
+		if !found {
+			f.logger.Error("write-ahead log of the previous snapshot is missing, the changes made since it are lost")
 		}
 	}
 
-	if errScan := scanner.Err(); errScan != nil {
-		return errScan
+	if errReplay := f.wal.replay(memento.apply); errReplay != nil {
+		return fmt.Errorf("failed to replay write-ahead log: %w", errReplay)
 	}
-
-	memento := &Memento{
-		URLs:     urls,
-		LastUUID: lastUUID,
+	clicks, errReplay := f.clickLog.replay()
+	if errReplay != nil {
+		return fmt.Errorf("failed to replay click log: %w", errReplay)
+	}
+	f.clicks = clicks
+	apiKeys, errReplay := f.apiKeyLog.replay()
+	if errReplay != nil {
+		return fmt.Errorf("failed to replay api key log: %w", errReplay)
 	}
+	f.apiKeys = apiKeys
 
 	f.restoreFromMemento(memento)
+	// the clicks of purged URLs stay in the click log until it is rewritten
+	maps.DeleteFunc(f.clicks, func(shortURL string, _ map[time.Time]int64) bool {
+		_, exists := f.urls[shortURL]
+		return !exists
+	})
 	return nil
 }
 
 // Add adds a URL.
-func (f *Storage) Add(_ context.Context, userID, shortID, originalURL string) (string, error) {
+func (f *Storage) Add(_ context.Context, userID string, url entity.URL) (string, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	f.lastUUID++
-	uuid := strconv.Itoa(f.lastUUID)
+	if existing, ok := f.index.ShortURL(userID, url.OriginalURL); ok {
+		return existing, &entity.URLExistsError{ShortURL: existing}
+	}
+	if err := index.CheckShortURLsFree(f.urls, url.ShortURL); err != nil {
+		return "", err
+	}
 
-	f.urls[shortID] = URLData{
-		OriginalURL: originalURL,
-		UUID:        uuid,
+	urlData := URLData{
+		CreatedAt:   time.Now(),
+		ExpiresAt:   url.ExpiresAt,
+		OriginalURL: url.OriginalURL,
+		UUID:        strconv.Itoa(f.lastUUID + 1),
 		UserID:      userID,
+		MaxClicks:   url.MaxClicks,
 	}
 
-	f.isDirty = true
-	return shortID, nil
+	record := newURLRecord(url.ShortURL, urlData)
+	if err := f.wal.append(WALEntry{Op: walOpAdd, Records: []URLRecord{record}}); err != nil {
+		return "", err
+	}
+
+	f.lastUUID++
+	f.put(url.ShortURL, urlData)
+	return url.ShortURL, nil
 }
 
 // GetByShortURL gets the original URL by the short URL.
@@ -263,19 +560,58 @@ func (f *Storage) GetByShortURL(_ context.Context, shortURL string) (string, err
 	return url.OriginalURL, nil
 }
 
-// GetByOriginalURL gets the short URL by the original URL.
-func (f *Storage) GetByOriginalURL(_ context.Context, originalURL string) (string, error) {
+// Visit gets the original URL by the short URL and counts the click of a URL limited by MaxClicks.
+// A URL that has expired or used up its clicks is reported as entity.ErrURLExpired.
+// Unlimited URLs are served under the read lock only and never touch the write-ahead log.
+func (f *Storage) Visit(_ context.Context, shortURL string) (string, error) {
+	f.mu.RLock()
+	urlData, err := f.visitable(shortURL)
+	f.mu.RUnlock()
+	if err != nil || urlData.MaxClicks == 0 {
+		return urlData.OriginalURL, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// the URL may have changed between the locks
+	if urlData, err = f.visitable(shortURL); err != nil {
+		return "", err
+	}
+	urlData.Clicks++
+	if err := f.wal.append(WALEntry{Op: walOpVisit, ShortURL: shortURL, Clicks: urlData.Clicks}); err != nil {
+		return "", err
+	}
+	f.urls[shortURL] = urlData
+	return urlData.OriginalURL, nil
+}
+
+// visitable returns the URL data if it can be redirected to. The caller must hold the lock.
+func (f *Storage) visitable(shortURL string) (URLData, error) {
+	urlData, ok := f.urls[shortURL]
+	switch {
+	case !ok:
+		return URLData{}, entity.ErrURLNotFound
+	case urlData.IsDeleted:
+		return URLData{}, entity.ErrURLDeleted
+	case urlData.toURL(shortURL).Expired(time.Now()):
+		return URLData{}, entity.ErrURLExpired
+	}
+	return urlData, nil
+}
+
+// GetByOriginalURL gets the short URL the user's original URL is deduplicated to.
+func (f *Storage) GetByOriginalURL(_ context.Context, userID, originalURL string) (string, error) {
 	const method = "GetByOriginalURL"
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	for shortID, urlData := range f.urls {
-		if urlData.OriginalURL == originalURL {
-			f.logger.Info(method, zap.String("shortID", shortID), zap.String("originalURL", originalURL))
-			return shortID, nil
-		}
+	shortID, ok := f.index.ShortURL(userID, originalURL)
+	if !ok {
+		return "", entity.ErrURLNotFound
 	}
-	return "", entity.ErrURLNotFound
+	f.logger.Info(method, zap.String("shortID", shortID), zap.String("originalURL", originalURL))
+	return shortID, nil
 }
 
 // AddBatch adds a batch of URLs.
@@ -284,34 +620,59 @@ func (f *Storage) AddBatch(_ context.Context, userID string, urls []entity.URL)
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	originalURLs := make([]string, 0, len(urls))
+	shortURLs := make([]string, 0, len(urls))
 	for _, url := range urls {
-		f.lastUUID++
-		uuid := strconv.Itoa(f.lastUUID)
+		originalURLs = append(originalURLs, url.OriginalURL)
+		shortURLs = append(shortURLs, url.ShortURL)
+	}
+	if err := f.index.CheckAbsent(userID, originalURLs); err != nil {
+		return err
+	}
+	if err := index.CheckShortURLsFree(f.urls, shortURLs...); err != nil {
+		return err
+	}
 
-		f.urls[url.ShortURL] = URLData{
+	now := time.Now()
+	records := make([]URLRecord, 0, len(urls))
+	for i, url := range urls {
+		records = append(records, newURLRecord(url.ShortURL, URLData{
+			CreatedAt:   now,
+			ExpiresAt:   url.ExpiresAt,
 			OriginalURL: url.OriginalURL,
-			UUID:        uuid,
+			UUID:        strconv.Itoa(f.lastUUID + i + 1),
 			UserID:      userID,
+			MaxClicks:   url.MaxClicks,
+		}))
+	}
+
+	if err := f.wal.append(WALEntry{Op: walOpAdd, Records: records}); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		urlData, err := record.toURLData()
+		if err != nil {
+			return err
 		}
+		f.lastUUID++
+		f.put(record.ShortURL, urlData)
 		f.logger.Info(
 			method,
-			zap.String("shortURL", url.ShortURL),
-			zap.String("originalURL", url.OriginalURL),
+			zap.String("shortURL", record.ShortURL),
+			zap.String("originalURL", record.OriginalURL),
 			zap.String("userID", userID),
 		)
 	}
 
-	f.isDirty = true
 	return nil
 }
 
-// Close closes the file storage.
+// Close stops the background compaction, writes a final snapshot and closes the logs.
 func (f *Storage) Close() error {
 	close(f.stopSaving)
-	if f.isDirty {
-		return f.save()
-	}
-	return nil
+	<-f.saveDone
+	return errors.Join(f.compact(), f.wal.close(), f.clickLog.close(), f.apiKeyLog.close())
 }
 
 // Ping pings the file storage.
@@ -320,37 +681,277 @@ func (f *Storage) Ping(_ context.Context) error {
 	return nil
 }
 
+// GetURLSequence returns the number of URLs ever added, purged ones included.
+// It is the UUID of the last added record and survives restarts with the snapshot and the log.
+func (f *Storage) GetURLSequence(_ context.Context) (int64, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return int64(f.lastUUID), nil
+}
+
 // GetUserURLs gets user URLs.
 func (f *Storage) GetUserURLs(_ context.Context, userID string) ([]entity.URL, error) {
 	const method = "GetUserURLs"
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
-	urls := make([]entity.URL, 0)
-	for shortURL, urlData := range f.urls {
-		if urlData.UserID == userID {
-			urls = append(urls, entity.URL{ShortURL: shortURL, OriginalURL: urlData.OriginalURL})
-		}
+	shortURLs := f.index.UserShortURLs(userID)
+	urls := make([]entity.URL, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		urls = append(urls, f.urls[shortURL].toURL(shortURL))
 	}
 	f.logger.Info(method, zap.String("userID", userID), zap.Int("count", len(urls)))
 	return urls, nil
 }
 
-// MarkDeletedBatch marks URLs as deleted in batch.
-func (f *Storage) MarkDeletedBatch(_ context.Context, userID string, shortURLs []string) error {
+// MarkDeletedBatch marks the user's URLs as deleted in batch and returns the short URLs it marked.
+func (f *Storage) MarkDeletedBatch(_ context.Context, userID string, shortURLs []string) ([]string, error) {
 	const method = "MarkDeletedBatch"
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	now := time.Now()
+	if err := f.wal.append(WALEntry{At: now, Op: walOpDelete, UserID: userID, ShortURLs: shortURLs}); err != nil {
+		return nil, err
+	}
+
+	marked := make([]string, 0, len(shortURLs))
 	for _, shortURL := range shortURLs {
 		urlData, exists := f.urls[shortURL]
 		if exists && urlData.UserID == userID {
-			urlData.IsDeleted = true
-			f.urls[shortURL] = urlData
-			f.isDirty = true
+			f.urls[shortURL] = urlData.markDeleted(now)
+			marked = append(marked, shortURL)
 			f.logger.Info(method, zap.String("shortURL", shortURL), zap.String("userID", userID))
 		}
 	}
 
+	return marked, nil
+}
+
+// MarkRestoredBatch clears the deleted mark of the user's URLs in batch and returns the short URLs it cleared.
+func (f *Storage) MarkRestoredBatch(_ context.Context, userID string, shortURLs []string) ([]string, error) {
+	const method = "MarkRestoredBatch"
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	restored := make([]string, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		urlData, exists := f.urls[shortURL]
+		if exists && urlData.UserID == userID && urlData.IsDeleted {
+			restored = append(restored, shortURL)
+		}
+	}
+	if len(restored) == 0 {
+		return restored, nil
+	}
+
+	if err := f.wal.append(WALEntry{Op: walOpRestore, UserID: userID, ShortURLs: restored}); err != nil {
+		return nil, err
+	}
+	for _, shortURL := range restored {
+		urlData := f.urls[shortURL]
+		urlData.IsDeleted = false
+		urlData.DeletedAt = time.Time{}
+		f.urls[shortURL] = urlData
+		f.logger.Info(method, zap.String("shortURL", shortURL), zap.String("userID", userID))
+	}
+
+	return restored, nil
+}
+
+// MarkExpiredDeleted marks the URLs expired at the moment now as deleted and returns their number.
+func (f *Storage) MarkExpiredDeleted(_ context.Context, now time.Time) (int64, error) {
+	const method = "MarkExpiredDeleted"
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	shortURLs := make([]string, 0)
+	for shortURL, urlData := range f.urls {
+		if !urlData.IsDeleted && urlData.toURL(shortURL).Expired(now) {
+			shortURLs = append(shortURLs, shortURL)
+		}
+	}
+	if len(shortURLs) == 0 {
+		return 0, nil
+	}
+
+	if err := f.wal.append(WALEntry{At: now, Op: walOpExpire, ShortURLs: shortURLs}); err != nil {
+		return 0, err
+	}
+	for _, shortURL := range shortURLs {
+		f.urls[shortURL] = f.urls[shortURL].markDeleted(now)
+	}
+	f.logger.Info(method, zap.Int("count", len(shortURLs)))
+	return int64(len(shortURLs)), nil
+}
+
+// PurgeDeleted permanently removes at most limit URLs deleted before deletedBefore
+// together with their clicks, and returns their number.
+func (f *Storage) PurgeDeleted(_ context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	const method = "PurgeDeleted"
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	shortURLs := make([]string, 0)
+	for shortURL, urlData := range f.urls {
+		if len(shortURLs) >= limit {
+			break
+		}
+		if urlData.IsDeleted && urlData.DeletedAt.Before(deletedBefore) {
+			shortURLs = append(shortURLs, shortURL)
+		}
+	}
+	if len(shortURLs) == 0 {
+		return 0, nil
+	}
+
+	if err := f.wal.append(WALEntry{Op: walOpPurge, ShortURLs: shortURLs}); err != nil {
+		return 0, err
+	}
+	for _, shortURL := range shortURLs {
+		urlData := f.urls[shortURL]
+		f.index.Remove(shortURL, urlData.OriginalURL, urlData.UserID)
+		delete(f.urls, shortURL)
+		delete(f.clicks, shortURL)
+	}
+	f.logger.Info(method, zap.Int("count", len(shortURLs)))
+	return int64(len(shortURLs)), nil
+}
+
+// UpdateOriginalURL points the user's short URL to the original URL and returns its previous destinations.
+// An original URL shortened by another short URL is reported as entity.URLExistsError.
+func (f *Storage) UpdateOriginalURL(
+	_ context.Context,
+	userID, shortURL, originalURL string,
+) ([]entity.URLChange, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	urlData, exists := f.urls[shortURL]
+	if !exists || urlData.UserID != userID {
+		return nil, entity.ErrURLNotFound
+	}
+	if urlData.IsDeleted {
+		return nil, entity.ErrURLDeleted
+	}
+	if urlData.OriginalURL != originalURL {
+		if existing, ok := f.index.ShortURL(userID, originalURL); ok {
+			return nil, &entity.URLExistsError{ShortURL: existing}
+		}
+		urlData.History = append(slices.Clip(urlData.History), entity.URLChange{
+			ChangedAt:   time.Now(),
+			OriginalURL: urlData.OriginalURL,
+		})
+		urlData.OriginalURL = originalURL
+		record := newURLRecord(shortURL, urlData)
+		if err := f.wal.append(WALEntry{Op: walOpUpdate, Records: []URLRecord{record}}); err != nil {
+			return nil, err
+		}
+		f.put(shortURL, urlData)
+	}
+	return append([]entity.URLChange{}, urlData.History...), nil
+}
+
+// AddClicks appends the click events to the click log. Clicks of unknown short URLs are dropped.
+func (f *Storage) AddClicks(_ context.Context, clicks []entity.Click) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	known := make([]entity.Click, 0, len(clicks))
+	for _, click := range clicks {
+		if _, exists := f.urls[click.ShortURL]; exists {
+			known = append(known, click)
+		}
+	}
+	if err := f.clickLog.append(known); err != nil {
+		return err
+	}
+	for _, click := range known {
+		f.clicks.add(click.ShortURL, entity.ClickDay(click.Time), 1)
+	}
+	return nil
+}
+
+// GetClickStats gets the click statistics of the short URL owned by the user.
+func (f *Storage) GetClickStats(_ context.Context, userID, shortURL string) (entity.URLStats, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	urlData, exists := f.urls[shortURL]
+	if !exists || urlData.UserID != userID {
+		return entity.URLStats{}, entity.ErrURLNotFound
+	}
+	return entity.NewURLStatsFromDaily(shortURL, f.clicks[shortURL]), nil
+}
+
+// GetServiceStats counts the stored short URLs and their distinct owners.
+func (f *Storage) GetServiceStats(_ context.Context) (entity.ServiceStats, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return entity.ServiceStats{URLs: int64(len(f.urls)), Users: int64(f.index.Users())}, nil
+}
+
+// AddAPIKey appends the API key to the API key log.
+func (f *Storage) AddAPIKey(_ context.Context, key entity.APIKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry := apiKeyEntry{Op: apiKeyOpAdd, Key: &key}
+	if err := f.apiKeyLog.append(entry); err != nil {
+		return err
+	}
+	applyAPIKeyEntry(f.apiKeys, entry)
 	return nil
 }
+
+// GetAPIKeyByHash gets the API key by its hash.
+func (f *Storage) GetAPIKeyByHash(_ context.Context, hash string) (entity.APIKey, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	key, ok := f.apiKeys[hash]
+	if !ok {
+		return entity.APIKey{}, entity.ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// GetUserAPIKeys gets the API keys of the user, the oldest first.
+func (f *Storage) GetUserAPIKeys(_ context.Context, userID string) ([]entity.APIKey, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	keys := make([]entity.APIKey, 0)
+	for _, key := range f.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	entity.SortAPIKeys(keys)
+	return keys, nil
+}
+
+// DeleteAPIKeys appends the deletion of the user's API keys to the API key log
+// and returns the IDs of the deleted ones.
+func (f *Storage) DeleteAPIKeys(_ context.Context, userID string, ids []string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	owned := make([]string, 0, len(ids))
+	for _, key := range f.apiKeys {
+		if key.UserID == userID && slices.Contains(ids, key.ID) {
+			owned = append(owned, key.ID)
+		}
+	}
+	if len(owned) == 0 {
+		return owned, nil
+	}
+
+	entry := apiKeyEntry{Op: apiKeyOpDelete, UserID: userID, IDs: owned}
+	if err := f.apiKeyLog.append(entry); err != nil {
+		return nil, err
+	}
+	return applyAPIKeyEntry(f.apiKeys, entry), nil
+}
@@ -1,8 +1,12 @@
 package file_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,8 +16,37 @@ import (
 
 	"github.com/AGENT3128/shortener-url/internal/entity"
 	"github.com/AGENT3128/shortener-url/internal/repository/file"
+	"github.com/AGENT3128/shortener-url/internal/repository/repositorytest"
+	"github.com/AGENT3128/shortener-url/internal/usecase"
+	"github.com/AGENT3128/shortener-url/pkg/shorneter"
 )
 
+func TestStorage_Contract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) usecase.URLRepository {
+		storage, err := file.NewFileStorage(filepath.Join(t.TempDir(), "storage.json"), zap.NewNop())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, storage.Close())
+		})
+		return storage
+	})
+}
+
+func TestStorage_DedupScopes(t *testing.T) {
+	repositorytest.RunDedupScopes(t, func(t *testing.T, scope entity.DedupScope) usecase.URLRepository {
+		storage, err := file.NewFileStorage(
+			filepath.Join(t.TempDir(), "storage.json"),
+			zap.NewNop(),
+			file.WithDedupScope(scope),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			assert.NoError(t, storage.Close())
+		})
+		return storage
+	})
+}
+
 type testStorage struct {
 	storage *file.Storage
 	file    *os.File
@@ -79,7 +112,8 @@ func TestBasicOperations(t *testing.T) {
 			ctx, cancel := context.WithTimeout(t.Context(), 2*time.Second)
 			defer cancel()
 
-			shortURL, err := ts.storage.Add(ctx, tt.userID, tt.shortID, tt.originalURL)
+			url := entity.URL{ShortURL: tt.shortID, OriginalURL: tt.originalURL}
+			shortURL, err := ts.storage.Add(ctx, tt.userID, url)
 			if tt.wantError {
 				require.Error(t, err)
 			} else {
@@ -90,7 +124,7 @@ func TestBasicOperations(t *testing.T) {
 				require.NoError(t, errGet)
 				assert.Equal(t, tt.originalURL, gotURL)
 
-				gotShortURL, errGet := ts.storage.GetByOriginalURL(ctx, tt.originalURL)
+				gotShortURL, errGet := ts.storage.GetByOriginalURL(ctx, tt.userID, tt.originalURL)
 				require.NoError(t, errGet)
 				assert.Equal(t, tt.shortID, gotShortURL)
 			}
@@ -129,9 +163,9 @@ func TestGetUserURLs(t *testing.T) {
 	ctx, cancel := context.WithTimeout(t.Context(), 2*time.Second)
 	defer cancel()
 
-	_, err := ts.storage.Add(ctx, "user3", "test1", "https://test1.com")
+	_, err := ts.storage.Add(ctx, "user3", entity.URL{ShortURL: "test1", OriginalURL: "https://test1.com"})
 	require.NoError(t, err)
-	_, err = ts.storage.Add(ctx, "user3", "test2", "https://test2.com")
+	_, err = ts.storage.Add(ctx, "user3", entity.URL{ShortURL: "test2", OriginalURL: "https://test2.com"})
 	require.NoError(t, err)
 
 	userURLs, err := ts.storage.GetUserURLs(ctx, "user3")
@@ -147,10 +181,10 @@ func TestURLDeletion(t *testing.T) {
 	defer cancel()
 
 	shortURL := "delete-test"
-	_, err := ts.storage.Add(ctx, "user4", shortURL, "https://delete-test.com")
+	_, err := ts.storage.Add(ctx, "user4", entity.URL{ShortURL: shortURL, OriginalURL: "https://delete-test.com"})
 	require.NoError(t, err)
 
-	err = ts.storage.MarkDeletedBatch(ctx, "user4", []string{shortURL})
+	_, err = ts.storage.MarkDeletedBatch(ctx, "user4", []string{shortURL})
 	require.NoError(t, err)
 
 	_, err = ts.storage.GetByShortURL(ctx, shortURL)
@@ -170,7 +204,7 @@ func TestNonExistentURLs(t *testing.T) {
 	})
 
 	t.Run("get non-existent original URL", func(t *testing.T) {
-		_, err := ts.storage.GetByOriginalURL(ctx, "https://non-existent.com")
+		_, err := ts.storage.GetByOriginalURL(ctx, "user1", "https://non-existent.com")
 		assert.ErrorIs(t, err, entity.ErrURLNotFound)
 	})
 }
@@ -197,9 +231,9 @@ func TestStatePreservation(t *testing.T) {
 	require.NoError(t, err)
 
 	ctx := t.Context()
-	_, err = storage1.Add(ctx, "user1", "test1", "https://example1.com")
+	_, err = storage1.Add(ctx, "user1", entity.URL{ShortURL: "test1", OriginalURL: "https://example1.com"})
 	require.NoError(t, err)
-	_, err = storage1.Add(ctx, "user1", "test2", "https://example2.com")
+	_, err = storage1.Add(ctx, "user1", entity.URL{ShortURL: "test2", OriginalURL: "https://example2.com"})
 	require.NoError(t, err)
 
 	// Close storage to ensure state is saved
@@ -236,7 +270,7 @@ func TestPeriodicSaving(t *testing.T) {
 	require.NoError(t, err)
 
 	ctx := t.Context()
-	_, err = storage.Add(ctx, "user1", "periodic1", "https://periodic1.com")
+	_, err = storage.Add(ctx, "user1", entity.URL{ShortURL: "periodic1", OriginalURL: "https://periodic1.com"})
 	require.NoError(t, err)
 
 	// Wait for periodic save
@@ -255,3 +289,776 @@ func TestPeriodicSaving(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "https://periodic1.com", url)
 }
+
+func TestStatePreservationUserData(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	storage1, err := file.NewFileStorage(filePath, logger)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	_, err = storage1.Add(ctx, "user1", entity.URL{ShortURL: "keep", OriginalURL: "https://keep.com"})
+	require.NoError(t, err)
+	_, err = storage1.Add(ctx, "user1", entity.URL{ShortURL: "gone", OriginalURL: "https://gone.com"})
+	require.NoError(t, err)
+	_, err = storage1.MarkDeletedBatch(ctx, "user1", []string{"gone"})
+	require.NoError(t, err)
+
+	before, err := storage1.GetUserURLs(ctx, "user1")
+	require.NoError(t, err)
+	require.NoError(t, storage1.Close())
+
+	storage2, err := file.NewFileStorage(filePath, logger)
+	require.NoError(t, err)
+	defer storage2.Close()
+
+	after, err := storage2.GetUserURLs(ctx, "user1")
+	require.NoError(t, err)
+	require.Len(t, after, len(before))
+	restored := make(map[string]entity.URL, len(after))
+	for _, url := range after {
+		restored[url.ShortURL] = url
+	}
+	for _, want := range before {
+		got, ok := restored[want.ShortURL]
+		require.True(t, ok, "short URL %s was not restored", want.ShortURL)
+		assert.Equal(t, want.OriginalURL, got.OriginalURL)
+		assert.Equal(t, want.UserID, got.UserID)
+		assert.Equal(t, want.DeletedFlag, got.DeletedFlag)
+		assert.True(t, want.CreatedAt.Equal(got.CreatedAt))
+	}
+
+	_, err = storage2.GetByShortURL(ctx, "gone")
+	require.ErrorIs(t, err, entity.ErrURLDeleted)
+
+	// the reverse index is rebuilt on restore
+	shortURL, err := storage2.GetByOriginalURL(ctx, "user1", "https://keep.com")
+	require.NoError(t, err)
+	assert.Equal(t, "keep", shortURL)
+}
+
+func TestRestoreV1Records(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	v1 := `{"uuid":"1","short_url":"legacy1","original_url":"https://legacy1.com"}` + "\n" +
+		`{"uuid":"2","short_url":"legacy2","original_url":"https://legacy2.com"}` + "\n"
+	require.NoError(t, os.WriteFile(filePath, []byte(v1), 0600))
+
+	storage, err := file.NewFileStorage(filePath, logger)
+	require.NoError(t, err)
+	defer storage.Close()
+
+	ctx := t.Context()
+	url, err := storage.GetByShortURL(ctx, "legacy2")
+	require.NoError(t, err)
+	assert.Equal(t, "https://legacy2.com", url)
+
+	// new records continue the UUID sequence of the legacy ones
+	_, err = storage.Add(ctx, "user1", entity.URL{ShortURL: "fresh", OriginalURL: "https://fresh.com"})
+	require.NoError(t, err)
+	userURLs, err := storage.GetUserURLs(ctx, "user1")
+	require.NoError(t, err)
+	require.Len(t, userURLs, 1)
+	assert.Equal(t, "fresh", userURLs[0].ShortURL)
+	assert.False(t, userURLs[0].CreatedAt.IsZero())
+}
+
+func TestWriteAheadLogReplay(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	// compaction never runs during the test, so only the log holds the data
+	crashed, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithSaveTicker(time.Hour),
+		file.WithSyncPolicy(file.SyncAlways),
+	)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	_, err = crashed.Add(ctx, "user1", entity.URL{ShortURL: "wal1", OriginalURL: "https://wal1.com"})
+	require.NoError(t, err)
+	err = crashed.AddBatch(ctx, "user1", []entity.URL{
+		{ShortURL: "wal2", OriginalURL: "https://wal2.com"},
+		{ShortURL: "wal3", OriginalURL: "https://wal3.com"},
+	})
+	require.NoError(t, err)
+	_, err = crashed.MarkDeletedBatch(ctx, "user1", []string{"wal2", "wal3"})
+	require.NoError(t, err)
+	_, err = crashed.MarkRestoredBatch(ctx, "user1", []string{"wal2"})
+	require.NoError(t, err)
+
+	_, err = os.Stat(filePath)
+	require.ErrorIs(t, err, os.ErrNotExist, "snapshot must not be written before compaction")
+
+	// open the same path without closing the first instance to simulate a crash
+	restored, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+
+	url, err := restored.GetByShortURL(ctx, "wal2")
+	require.NoError(t, err)
+	assert.Equal(t, "https://wal2.com", url)
+	_, err = restored.GetByShortURL(ctx, "wal3")
+	require.ErrorIs(t, err, entity.ErrURLDeleted)
+
+	userURLs, err := restored.GetUserURLs(ctx, "user1")
+	require.NoError(t, err)
+	assert.Len(t, userURLs, 3)
+
+	require.NoError(t, restored.Close())
+}
+
+func TestWriteAheadLogReplayTornTail(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	crashed, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithSaveTicker(time.Hour),
+		file.WithSyncPolicy(file.SyncAlways),
+	)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	_, err = crashed.Add(ctx, "user1", entity.URL{ShortURL: "before", OriginalURL: "https://before.com"})
+	require.NoError(t, err)
+
+	// an entry torn by the crash is skipped
+	walLog, err := os.OpenFile(filePath+".wal", os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = walLog.WriteString(`{"op":"add","user_id":"user1","records":[{"short_`)
+	require.NoError(t, err)
+	require.NoError(t, walLog.Close())
+
+	// open the same path without closing the first instance to simulate a crash
+	restored, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithSaveTicker(time.Hour),
+		file.WithSyncPolicy(file.SyncAlways),
+	)
+	require.NoError(t, err)
+	_, err = restored.Add(ctx, "user1", entity.URL{ShortURL: "after", OriginalURL: "https://after.com"})
+	require.NoError(t, err)
+
+	// the entry appended after the torn tail must survive the next crash
+	reopened, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+
+	for shortURL, originalURL := range map[string]string{
+		"before": "https://before.com",
+		"after":  "https://after.com",
+	} {
+		url, errGet := reopened.GetByShortURL(ctx, shortURL)
+		require.NoError(t, errGet, shortURL)
+		assert.Equal(t, originalURL, url)
+	}
+
+	require.NoError(t, reopened.Close())
+}
+
+func TestWriteAheadLogReplayLimits(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	crashed, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithSaveTicker(time.Hour),
+		file.WithSyncPolicy(file.SyncAlways),
+	)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	_, err = crashed.Add(ctx, "user1", entity.URL{ShortURL: "once", OriginalURL: "https://once.com", MaxClicks: 1})
+	require.NoError(t, err)
+	hour := entity.URL{ExpiresAt: expiresAt, ShortURL: "hour", OriginalURL: "https://hour.com"}
+	_, err = crashed.Add(ctx, "user1", hour)
+	require.NoError(t, err)
+	_, err = crashed.Visit(ctx, "once")
+	require.NoError(t, err)
+	count, err := crashed.MarkExpiredDeleted(ctx, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// open the same path without closing the first instance to simulate a crash
+	restored, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+
+	userURLs, err := restored.GetUserURLs(ctx, "user1")
+	require.NoError(t, err)
+	require.Len(t, userURLs, 2)
+	for _, url := range userURLs {
+		assert.True(t, url.DeletedFlag, url.ShortURL)
+		switch url.ShortURL {
+		case "once":
+			assert.Equal(t, int64(1), url.MaxClicks)
+			assert.Equal(t, int64(1), url.Clicks)
+		case "hour":
+			assert.True(t, expiresAt.Equal(url.ExpiresAt))
+		}
+	}
+
+	require.NoError(t, restored.Close())
+}
+
+func TestWriteAheadLogReplayVisitAfterInterruptedCompaction(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+	ctx := t.Context()
+
+	storage, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+	_, err = storage.Add(ctx, "user1", entity.URL{ShortURL: "limited", OriginalURL: "https://limited.com", MaxClicks: 5})
+	require.NoError(t, err)
+	require.NoError(t, storage.Close())
+
+	storage, err = file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithSaveTicker(time.Hour),
+		file.WithSyncPolicy(file.SyncAlways),
+	)
+	require.NoError(t, err)
+	for range 2 {
+		_, err = storage.Visit(ctx, "limited")
+		require.NoError(t, err)
+	}
+	visits, err := os.ReadFile(filePath + ".wal")
+	require.NoError(t, err)
+	require.NotEmpty(t, visits)
+	require.NoError(t, storage.Close())
+
+	// a crash after the snapshot was saved but before the rotated log was removed
+	// leaves the visits both in the snapshot and in the rotated log
+	require.NoError(t, os.WriteFile(filePath+".wal.compacting", visits, 0600))
+
+	reopened, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+	userURLs, err := reopened.GetUserURLs(ctx, "user1")
+	require.NoError(t, err)
+	require.Len(t, userURLs, 1)
+	assert.Equal(t, int64(2), userURLs[0].Clicks)
+
+	require.NoError(t, reopened.Close())
+}
+
+func TestWriteAheadLogReplayUpdate(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	crashed, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithSaveTicker(time.Hour),
+		file.WithSyncPolicy(file.SyncAlways),
+	)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	_, err = crashed.Add(ctx, "user1", entity.URL{ShortURL: "flyer", OriginalURL: "https://first.com"})
+	require.NoError(t, err)
+	_, err = crashed.UpdateOriginalURL(ctx, "user1", "flyer", "https://second.com")
+	require.NoError(t, err)
+
+	// open the same path without closing the first instance to simulate a crash
+	restored, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+
+	got, err := restored.GetByShortURL(ctx, "flyer")
+	require.NoError(t, err)
+	assert.Equal(t, "https://second.com", got)
+	_, err = restored.GetByOriginalURL(ctx, "user1", "https://first.com")
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+
+	// the history survives the compaction into the snapshot
+	require.NoError(t, restored.Close())
+	reopened, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+	history, err := reopened.UpdateOriginalURL(ctx, "user1", "flyer", "https://third.com")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "https://first.com", history[0].OriginalURL)
+	assert.Equal(t, "https://second.com", history[1].OriginalURL)
+
+	require.NoError(t, reopened.Close())
+}
+
+func TestWriteAheadLogReplayPurge(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	crashed, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithSaveTicker(time.Hour),
+		file.WithSyncPolicy(file.SyncAlways),
+	)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	err = crashed.AddBatch(ctx, "user1", []entity.URL{
+		{ShortURL: "purged", OriginalURL: "https://purged.com"},
+		{ShortURL: "trashed", OriginalURL: "https://trashed.com"},
+	})
+	require.NoError(t, err)
+	_, err = crashed.MarkDeletedBatch(ctx, "user1", []string{"purged"})
+	require.NoError(t, err)
+	count, err := crashed.PurgeDeleted(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	_, err = crashed.MarkDeletedBatch(ctx, "user1", []string{"trashed"})
+	require.NoError(t, err)
+
+	// open the same path without closing the first instance to simulate a crash
+	restored, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+
+	_, err = restored.GetByShortURL(ctx, "purged")
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+	userURLs, err := restored.GetUserURLs(ctx, "user1")
+	require.NoError(t, err)
+	require.Len(t, userURLs, 1)
+	deletedAt := userURLs[0].DeletedAt
+	assert.False(t, deletedAt.IsZero())
+
+	// the deletion time survives the compaction into the snapshot
+	require.NoError(t, restored.Close())
+	reopened, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+	userURLs, err = reopened.GetUserURLs(ctx, "user1")
+	require.NoError(t, err)
+	require.Len(t, userURLs, 1)
+	assert.True(t, deletedAt.Equal(userURLs[0].DeletedAt))
+
+	require.NoError(t, reopened.Close())
+}
+
+func TestClickLogReplay(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	crashed, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithSaveTicker(time.Hour),
+		file.WithSyncPolicy(file.SyncAlways),
+	)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	day := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	_, err = crashed.Add(ctx, "user1", entity.URL{ShortURL: "clicked", OriginalURL: "https://clicked.com"})
+	require.NoError(t, err)
+	require.NoError(t, crashed.AddClicks(ctx, []entity.Click{
+		{Time: day.Add(time.Hour), ShortURL: "clicked", Referrer: "https://example.com"},
+		{Time: day.Add(2 * time.Hour), ShortURL: "clicked", IPPrefix: "203.0.113.0/24"},
+	}))
+
+	// a click torn by the crash is skipped
+	clickLog, err := os.OpenFile(filePath+".clicks", os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = clickLog.WriteString(`{"time":"2025-03-01T05:00:00Z","short_`)
+	require.NoError(t, err)
+	require.NoError(t, clickLog.Close())
+
+	// open the same path without closing the first instance to simulate a crash
+	restored, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, restored.AddClicks(ctx, []entity.Click{{Time: day.Add(3 * time.Hour), ShortURL: "clicked"}}))
+	require.NoError(t, restored.Close())
+
+	reopened, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+
+	stats, err := reopened.GetClickStats(ctx, "user1", "clicked")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Total)
+	require.Len(t, stats.Daily, 1)
+	assert.True(t, day.Equal(stats.Daily[0].Date))
+
+	require.NoError(t, reopened.Close())
+}
+
+func TestClickLogBounds(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	storage, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	day := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	_, err = storage.Add(ctx, "user1", entity.URL{ShortURL: "clicked", OriginalURL: "https://clicked.com"})
+	require.NoError(t, err)
+	// a huge referrer is cut instead of making the line unreadable
+	require.NoError(t, storage.AddClicks(ctx, []entity.Click{
+		{Time: day, ShortURL: "clicked", Referrer: "https://example.com/" + strings.Repeat("<", 100*1024)},
+	}))
+	require.NoError(t, storage.Close())
+
+	// an oversized line written by an older version is skipped
+	clickLog, err := os.OpenFile(filePath+".clicks", os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = clickLog.WriteString(`{"time":"2025-03-01T05:00:00Z","short_url":"clicked","user_agent":"` +
+		strings.Repeat("a", 100*1024) + "\"}\n")
+	require.NoError(t, err)
+	require.NoError(t, clickLog.Close())
+
+	reopened, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(10*time.Millisecond))
+	require.NoError(t, err)
+	stats, err := reopened.GetClickStats(ctx, "user1", "clicked")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Total)
+
+	// enough clicks make the periodic compaction rewrite the log with the daily counts
+	clicks := make([]entity.Click, 0, 2000)
+	for i := range 2000 {
+		clicks = append(clicks, entity.Click{
+			Time:      day.Add(time.Duration(i%2) * 24 * time.Hour),
+			ShortURL:  "clicked",
+			UserAgent: "curl/8.0",
+		})
+	}
+	require.NoError(t, reopened.AddClicks(ctx, clicks))
+	assert.Eventually(t, func() bool {
+		data, errRead := os.ReadFile(filePath + ".clicks")
+		return errRead == nil && bytes.Count(data, []byte("\n")) == 2
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, reopened.Close())
+
+	restored, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+	stats, err = restored.GetClickStats(ctx, "user1", "clicked")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2001), stats.Total)
+	require.Len(t, stats.Daily, 2)
+	assert.Equal(t, int64(1001), stats.Daily[0].Clicks)
+	assert.Equal(t, int64(1000), stats.Daily[1].Clicks)
+	require.NoError(t, restored.Close())
+}
+
+func TestAPIKeyLogReplay(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	crashed, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithSaveTicker(time.Hour),
+		file.WithSyncPolicy(file.SyncAlways),
+	)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	kept := entity.APIKey{ID: "kept", UserID: "user1", Hash: "hash1", Scopes: []entity.APIKeyScope{entity.ScopeRead}}
+	revoked := entity.APIKey{
+		ID:     "revoked",
+		UserID: "user1",
+		Hash:   "hash2",
+		Scopes: []entity.APIKeyScope{entity.ScopeDelete},
+	}
+	require.NoError(t, crashed.AddAPIKey(ctx, kept))
+	require.NoError(t, crashed.AddAPIKey(ctx, revoked))
+	deleted, err := crashed.DeleteAPIKeys(ctx, "user1", []string{"revoked"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"revoked"}, deleted)
+
+	// an entry torn by the crash is skipped
+	keyLog, err := os.OpenFile(filePath+".keys", os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = keyLog.WriteString(`{"op":"add","key":{"id":"torn"`)
+	require.NoError(t, err)
+	require.NoError(t, keyLog.Close())
+
+	// open the same path without closing the first instance to simulate a crash
+	restored, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+
+	got, err := restored.GetAPIKeyByHash(ctx, "hash1")
+	require.NoError(t, err)
+	assert.Equal(t, kept.Scopes, got.Scopes)
+	_, err = restored.GetAPIKeyByHash(ctx, "hash2")
+	require.ErrorIs(t, err, entity.ErrAPIKeyNotFound)
+	keys, err := restored.GetUserAPIKeys(ctx, "user1")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "kept", keys[0].ID)
+
+	require.NoError(t, restored.Close())
+}
+
+func TestWriteAheadLogCompaction(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	storage, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithSaveTicker(50*time.Millisecond),
+		file.WithSyncPolicy(file.SyncNever),
+	)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	_, err = storage.Add(ctx, "user1", entity.URL{ShortURL: "compact1", OriginalURL: "https://compact1.com"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		wal, errStat := os.Stat(filePath + ".wal")
+		snapshot, errSnapshot := os.Stat(filePath)
+		return errStat == nil && errSnapshot == nil && wal.Size() == 0 && snapshot.Size() > 0
+	}, 2*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, storage.Close())
+
+	restored, err := file.NewFileStorage(filePath, logger)
+	require.NoError(t, err)
+	defer restored.Close()
+
+	url, err := restored.GetByShortURL(ctx, "compact1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://compact1.com", url)
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	for _, policy := range []file.SyncPolicy{file.SyncAlways, file.SyncInterval, file.SyncNever} {
+		got, err := file.ParseSyncPolicy(string(policy))
+		require.NoError(t, err)
+		assert.Equal(t, policy, got)
+	}
+
+	_, err := file.ParseSyncPolicy("sometimes")
+	require.Error(t, err)
+}
+
+// writeTwoSnapshots produces a snapshot with "second" and a previous snapshot with only "first".
+func writeTwoSnapshots(t *testing.T, filePath string, logger *zap.Logger) {
+	t.Helper()
+	ctx := t.Context()
+
+	storage, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+	_, err = storage.Add(ctx, "user1", entity.URL{ShortURL: "first", OriginalURL: "https://first.com"})
+	require.NoError(t, err)
+	require.NoError(t, storage.Close())
+
+	storage, err = file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+	_, err = storage.Add(ctx, "user1", entity.URL{ShortURL: "second", OriginalURL: "https://second.com"})
+	require.NoError(t, err)
+	require.NoError(t, storage.Close())
+}
+
+func corruptSnapshot(t *testing.T, filePath string) {
+	t.Helper()
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	// drop the tail of the last record as a crash in the middle of a write would
+	require.NoError(t, os.WriteFile(filePath, data[:len(data)-10], 0600))
+}
+
+func TestSnapshotCorruptionFail(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	writeTwoSnapshots(t, filePath, logger)
+	corruptSnapshot(t, filePath)
+
+	_, err = file.NewFileStorage(filePath, logger)
+	require.ErrorIs(t, err, file.ErrSnapshotCorrupted)
+}
+
+func TestSnapshotCorruptionFallback(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	writeTwoSnapshots(t, filePath, logger)
+	corruptSnapshot(t, filePath)
+
+	storage, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithCorruptionPolicy(file.CorruptionFallback),
+	)
+	require.NoError(t, err)
+
+	// the log kept with the previous snapshot brings back the URLs added since
+	ctx := t.Context()
+	url, err := storage.GetByShortURL(ctx, "first")
+	require.NoError(t, err)
+	assert.Equal(t, "https://first.com", url)
+	url, err = storage.GetByShortURL(ctx, "second")
+	require.NoError(t, err)
+	assert.Equal(t, "https://second.com", url)
+	require.NoError(t, storage.Close())
+
+	// the next compaction keeps the previous snapshot and its log, so a second fallback loses nothing
+	corruptSnapshot(t, filePath)
+	storage, err = file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithCorruptionPolicy(file.CorruptionFallback),
+	)
+	require.NoError(t, err)
+	defer storage.Close()
+	_, err = storage.GetByShortURL(ctx, "second")
+	require.NoError(t, err)
+}
+
+func TestSnapshotCorruptionFallbackWithoutPreviousLog(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	writeTwoSnapshots(t, filePath, logger)
+	corruptSnapshot(t, filePath)
+	require.NoError(t, os.Remove(filePath+".wal.prev"))
+
+	storage, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithCorruptionPolicy(file.CorruptionFallback),
+	)
+	require.NoError(t, err)
+	defer storage.Close()
+
+	ctx := t.Context()
+	_, err = storage.GetByShortURL(ctx, "first")
+	require.NoError(t, err)
+	_, err = storage.GetByShortURL(ctx, "second")
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+}
+
+func TestSnapshotCorruptionFallbackWithoutPrevious(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filePath, []byte(`{"uuid":"1","short_url":"half`), 0600))
+
+	_, err = file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithCorruptionPolicy(file.CorruptionFallback),
+	)
+	require.Error(t, err)
+}
+
+func TestDuplicateOriginalURL(t *testing.T) {
+	ts := setupTestStorage(t)
+	defer ts.cleanup(t)
+
+	ctx := t.Context()
+	_, err := ts.storage.Add(ctx, "user1", entity.URL{ShortURL: "first", OriginalURL: "https://dup.com"})
+	require.NoError(t, err)
+
+	shortURL, err := ts.storage.Add(ctx, "user2", entity.URL{ShortURL: "second", OriginalURL: "https://dup.com"})
+	var existsErr *entity.URLExistsError
+	require.ErrorAs(t, err, &existsErr)
+	assert.Equal(t, "first", existsErr.ShortURL)
+	assert.Equal(t, "first", shortURL)
+
+	err = ts.storage.AddBatch(ctx, "user2", []entity.URL{{ShortURL: "third", OriginalURL: "https://dup.com"}})
+	require.ErrorIs(t, err, entity.ErrURLExists)
+}
+
+func TestURLSequenceAfterRestart(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+	ctx := t.Context()
+
+	storage, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+	generator, err := shorneter.NewGenerator(shorneter.StrategyCounter)
+	require.NoError(t, err)
+	for i := range 3 {
+		shortURL, errGenerate := generator.Generate(1)
+		require.NoError(t, errGenerate)
+		_, err = storage.Add(ctx, "user1", entity.URL{ShortURL: shortURL, OriginalURL: fmt.Sprintf("https://%d.com", i)})
+		require.NoError(t, err)
+	}
+	// purging the oldest URL must not let the counter reissue the newest short URL
+	_, err = storage.MarkDeletedBatch(ctx, "user1", []string{"a"})
+	require.NoError(t, err)
+	count, err := storage.PurgeDeleted(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+	require.NoError(t, storage.Close())
+
+	restarted, err := file.NewFileStorage(filePath, logger, file.WithSaveTicker(time.Hour))
+	require.NoError(t, err)
+	sequence, err := restarted.GetURLSequence(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), sequence)
+
+	generator, err = shorneter.NewGenerator(shorneter.StrategyCounter, shorneter.WithCounterStart(uint64(sequence)))
+	require.NoError(t, err)
+	for i := range 2 {
+		shortURL, errGenerate := generator.Generate(1)
+		require.NoError(t, errGenerate)
+		_, err = restarted.Add(ctx, "user1", entity.URL{ShortURL: shortURL, OriginalURL: fmt.Sprintf("https://new%d.com", i)})
+		require.NoError(t, err, shortURL)
+	}
+
+	require.NoError(t, restarted.Close())
+}
+
+func TestVisitUnlimitedSkipsWriteAheadLog(t *testing.T) {
+	filePath := t.TempDir() + "/storage.json"
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	storage, err := file.NewFileStorage(
+		filePath,
+		logger,
+		file.WithSaveTicker(time.Hour),
+		file.WithSyncPolicy(file.SyncAlways),
+	)
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	_, err = storage.Add(ctx, "user1", entity.URL{ShortURL: "unlimited", OriginalURL: "https://unlimited.com"})
+	require.NoError(t, err)
+	_, err = storage.Add(ctx, "user1", entity.URL{ShortURL: "limited", OriginalURL: "https://limited.com", MaxClicks: 5})
+	require.NoError(t, err)
+	before, err := os.Stat(filePath + ".wal")
+	require.NoError(t, err)
+
+	for range 3 {
+		_, err = storage.Visit(ctx, "unlimited")
+		require.NoError(t, err)
+	}
+	unlimited, err := os.Stat(filePath + ".wal")
+	require.NoError(t, err)
+	assert.Equal(t, before.Size(), unlimited.Size(), "visits of unlimited URLs must not be logged")
+
+	_, err = storage.Visit(ctx, "limited")
+	require.NoError(t, err)
+	limited, err := os.Stat(filePath + ".wal")
+	require.NoError(t, err)
+	assert.Greater(t, limited.Size(), unlimited.Size())
+
+	require.NoError(t, storage.Close())
+}
@@ -0,0 +1,249 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	snapshotFormat     = "shortener-url-snapshot"
+	snapshotVersion    = 1
+	snapshotPrevSuffix = ".prev"
+	// maxSnapshotLineSize bounds a single snapshot line.
+	maxSnapshotLineSize = 1024 * 1024
+)
+
+// ErrSnapshotCorrupted is returned when a snapshot does not match its header.
+var ErrSnapshotCorrupted = errors.New("snapshot corrupted")
+
+// CorruptionPolicy defines how the storage reacts to a corrupted snapshot on startup.
+type CorruptionPolicy string
+
+// Available corruption policies.
+const (
+	// CorruptionFail refuses to start.
+	CorruptionFail CorruptionPolicy = "fail"
+	// CorruptionFallback restores the previous good snapshot and replays the log written since.
+	CorruptionFallback CorruptionPolicy = "fallback"
+)
+
+// ParseCorruptionPolicy parses the corruption policy from its string representation.
+func ParseCorruptionPolicy(policy string) (CorruptionPolicy, error) {
+	switch p := CorruptionPolicy(policy); p {
+	case CorruptionFail, CorruptionFallback:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown corruption policy %q", policy)
+	}
+}
+
+// SnapshotHeader is the first line of a snapshot file.
+// Snapshots written before headers were introduced are read without verification.
+type SnapshotHeader struct {
+	Format   string `json:"format"`
+	Checksum string `json:"checksum"`
+	Version  int    `json:"version"`
+	Count    int    `json:"count"`
+}
+
+// writeSnapshot atomically replaces the snapshot at path with the memento.
+// The data is written to a temporary file, fsynced and renamed into place.
+// When keepCurrent is set, the replaced snapshot is kept next to it as the previous good one.
+func writeSnapshot(path string, memento *Memento, keepCurrent bool) error {
+	var body bytes.Buffer
+	for shortURL, urlData := range memento.URLs {
+		data, err := json.Marshal(newURLRecord(shortURL, urlData))
+		if err != nil {
+			return err
+		}
+		body.Write(data)
+		body.WriteByte('\n')
+	}
+
+	sum := sha256.Sum256(body.Bytes())
+	header, err := json.Marshal(SnapshotHeader{
+		Format:   snapshotFormat,
+		Version:  snapshotVersion,
+		Count:    len(memento.URLs),
+		Checksum: hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if errWrite := writeAndSync(tmp, header, body.Bytes()); errWrite != nil {
+		return errors.Join(errWrite, os.Remove(tmpPath))
+	}
+
+	if keepCurrent {
+		if errKeep := keepPrevious(path); errKeep != nil {
+			return errors.Join(errKeep, os.Remove(tmpPath))
+		}
+	}
+	if errRename := os.Rename(tmpPath, path); errRename != nil {
+		return errors.Join(errRename, os.Remove(tmpPath))
+	}
+	return syncDir(dir)
+}
+
+func writeAndSync(file *os.File, header, body []byte) error {
+	writer := bufio.NewWriter(file)
+	if _, err := writer.Write(header); err != nil {
+		return errors.Join(err, file.Close())
+	}
+	if err := writer.WriteByte('\n'); err != nil {
+		return errors.Join(err, file.Close())
+	}
+	if _, err := writer.Write(body); err != nil {
+		return errors.Join(err, file.Close())
+	}
+	if err := writer.Flush(); err != nil {
+		return errors.Join(err, file.Close())
+	}
+	if err := file.Sync(); err != nil {
+		return errors.Join(err, file.Close())
+	}
+	return file.Close()
+}
+
+// keepPrevious hard-links the current snapshot as the previous one, so the snapshot path
+// itself is never missing while the new snapshot is renamed into place.
+func keepPrevious(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+
+	prevPath := path + snapshotPrevSuffix
+	if errRemove := os.Remove(prevPath); errRemove != nil && !os.IsNotExist(errRemove) {
+		return errRemove
+	}
+	return os.Link(path, prevPath)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	return errors.Join(d.Sync(), d.Close())
+}
+
+// readPreviousSnapshot reads the previous good snapshot kept next to path.
+func readPreviousSnapshot(path string) (*Memento, error) {
+	prevPath := path + snapshotPrevSuffix
+	if _, err := os.Stat(prevPath); err != nil {
+		return nil, fmt.Errorf("no previous snapshot: %w", err)
+	}
+	return readSnapshot(prevPath)
+}
+
+// readSnapshot reads and verifies the snapshot at path. A missing or empty file is an empty snapshot.
+func readSnapshot(path string) (*Memento, error) {
+	memento := &Memento{
+		URLs:     make(map[string]URLData),
+		LastUUID: 0,
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return memento, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	first, err := reader.ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(first)) == 0 {
+		return memento, nil
+	}
+
+	var header SnapshotHeader
+	if errUnmarshal := json.Unmarshal(first, &header); errUnmarshal == nil && header.Format == snapshotFormat {
+		return readVerifiedRecords(reader, header, memento)
+	}
+
+	// legacy snapshot without a header: every line must still be a valid record
+	if errAdd := addSnapshotLine(memento, first); errAdd != nil {
+		return nil, errAdd
+	}
+	if _, errRead := readRecords(reader, memento); errRead != nil {
+		return nil, errRead
+	}
+	return memento, nil
+}
+
+func readVerifiedRecords(reader io.Reader, header SnapshotHeader, memento *Memento) (*Memento, error) {
+	if header.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+
+	hasher := sha256.New()
+	count, err := readRecords(io.TeeReader(reader, hasher), memento)
+	if err != nil {
+		return nil, err
+	}
+	if count != header.Count {
+		return nil, fmt.Errorf("%w: expected %d records, got %d", ErrSnapshotCorrupted, header.Count, count)
+	}
+	if checksum := hex.EncodeToString(hasher.Sum(nil)); checksum != header.Checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrSnapshotCorrupted)
+	}
+	return memento, nil
+}
+
+func readRecords(reader io.Reader, memento *Memento) (int, error) {
+	count := 0
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxSnapshotLineSize)
+	for scanner.Scan() {
+		if err := addSnapshotLine(memento, scanner.Bytes()); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, fmt.Errorf("%w: %w", ErrSnapshotCorrupted, err)
+	}
+	return count, nil
+}
+
+func addSnapshotLine(memento *Memento, line []byte) error {
+	var record URLRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshotCorrupted, err)
+	}
+	urlData, err := record.toURLData()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSnapshotCorrupted, err)
+	}
+	memento.URLs[record.ShortURL] = urlData
+	memento.trackUUID(record.UUID)
+	return nil
+}
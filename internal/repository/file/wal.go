@@ -0,0 +1,324 @@
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	walSuffix        = ".wal"
+	walRotatedSuffix = ".wal.compacting"
+	walPrevSuffix    = ".wal.prev"
+	// maxWALEntrySize bounds a single log line; batch inserts are logged as one entry.
+	maxWALEntrySize = 64 * 1024 * 1024
+)
+
+// SyncPolicy defines when appended log entries are flushed to stable storage.
+type SyncPolicy string
+
+// Available sync policies.
+const (
+	// SyncAlways fsyncs the log after every appended entry.
+	SyncAlways SyncPolicy = "always"
+	// SyncInterval fsyncs the log on every sync tick.
+	SyncInterval SyncPolicy = "interval"
+	// SyncNever leaves flushing to the operating system.
+	SyncNever SyncPolicy = "never"
+)
+
+// ParseSyncPolicy parses the sync policy from its string representation.
+func ParseSyncPolicy(policy string) (SyncPolicy, error) {
+	switch p := SyncPolicy(policy); p {
+	case SyncAlways, SyncInterval, SyncNever:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown sync policy %q", policy)
+	}
+}
+
+// walOp is the operation recorded in the write-ahead log.
+type walOp string
+
+const (
+	walOpAdd    walOp = "add"
+	walOpDelete walOp = "delete"
+	// walOpVisit sets the click count of the short URL, so replaying it twice does not count the clicks twice.
+	// Entries written before the count was recorded list the short URLs and count one click of each.
+	walOpVisit walOp = "visit"
+	// walOpExpire marks the listed short URLs deleted regardless of their owner.
+	walOpExpire walOp = "expire"
+	// walOpUpdate replaces the records of existing short URLs.
+	walOpUpdate walOp = "update"
+	// walOpRestore clears the deleted mark of the listed short URLs owned by the user.
+	walOpRestore walOp = "restore"
+	// walOpPurge permanently removes the listed short URLs.
+	walOpPurge walOp = "purge"
+)
+
+// WALEntry is one operation appended to the write-ahead log.
+type WALEntry struct {
+	// At is the time of a delete or expire operation; entries written before it was recorded have the zero time.
+	At        time.Time   `json:"at,omitzero"`
+	Op        walOp       `json:"op"`
+	UserID    string      `json:"user_id,omitempty"`
+	Records   []URLRecord `json:"records,omitempty"`
+	ShortURLs []string    `json:"short_urls,omitempty"`
+	// ShortURL and Clicks are the short URL of a visit operation and its resulting click count.
+	ShortURL string `json:"short_url,omitempty"`
+	Clicks   int64  `json:"clicks,omitempty"`
+}
+
+// at returns the time of the operation, the replay time for entries that did not record it.
+func (e WALEntry) at() time.Time {
+	if e.At.IsZero() {
+		return time.Now()
+	}
+	return e.At
+}
+
+// writeAheadLog is the append-only log of storage mutations.
+// Entries are appended between two snapshots and replayed on startup.
+type writeAheadLog struct {
+	file        *os.File
+	writer      *bufio.Writer
+	logger      *zap.Logger
+	path        string
+	rotatedPath string
+	prevPath    string
+	policy      SyncPolicy
+	entries     int
+	mu          sync.Mutex
+}
+
+// openWAL opens (or creates) the write-ahead log next to the snapshot file.
+func openWAL(snapshotPath string, policy SyncPolicy, logger *zap.Logger) (*writeAheadLog, error) {
+	w := &writeAheadLog{
+		path:        snapshotPath + walSuffix,
+		rotatedPath: snapshotPath + walRotatedSuffix,
+		prevPath:    snapshotPath + walPrevSuffix,
+		policy:      policy,
+		logger:      logger,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *writeAheadLog) open() error {
+	file, err := os.OpenFile(w.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open write-ahead log: %w", err)
+	}
+	// an entry appended onto a torn tail would be skipped together with it on replay
+	if errTerminate := terminateTornLine(file); errTerminate != nil {
+		return errors.Join(fmt.Errorf("failed to open write-ahead log: %w", errTerminate), file.Close())
+	}
+	w.file = file
+	w.writer = bufio.NewWriter(file)
+	return nil
+}
+
+// terminateTornLine ends a line torn by a crash so that the next entry starts on a line of its own.
+func terminateTornLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, errRead := file.ReadAt(last, info.Size()-1); errRead != nil {
+		return errRead
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	_, err = file.Write([]byte{'\n'})
+	return err
+}
+
+// append writes the entry to the log honoring the sync policy.
+func (w *writeAheadLog) append(entry WALEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, errWrite := w.writer.Write(data); errWrite != nil {
+		return errWrite
+	}
+	if errWrite := w.writer.WriteByte('\n'); errWrite != nil {
+		return errWrite
+	}
+	w.entries++
+
+	if w.policy == SyncAlways {
+		return w.syncLocked()
+	}
+	if w.policy == SyncNever {
+		return w.writer.Flush()
+	}
+	return nil
+}
+
+// sync flushes buffered entries and fsyncs the log.
+func (w *writeAheadLog) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.syncLocked()
+}
+
+func (w *writeAheadLog) syncLocked() error {
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// pending returns the number of entries appended since the last rotation.
+func (w *writeAheadLog) pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.entries
+}
+
+// rotate moves the current log aside so that a snapshot can be taken while new entries go to a fresh log.
+// If a previous compaction did not complete, the current log is appended to the leftover rotated log
+// so that no entry is lost until a snapshot succeeds.
+func (w *writeAheadLog) rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.syncLocked(); err != nil {
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	if _, errStat := os.Stat(w.rotatedPath); errStat == nil {
+		if errAppend := appendFile(w.rotatedPath, w.path); errAppend != nil {
+			return errors.Join(errAppend, w.open())
+		}
+		if errRemove := os.Remove(w.path); errRemove != nil {
+			return errors.Join(errRemove, w.open())
+		}
+	} else if errRename := os.Rename(w.path, w.rotatedPath); errRename != nil {
+		return errors.Join(errRename, w.open())
+	}
+
+	w.entries = 0
+	return w.open()
+}
+
+// retireRotated keeps the rotated log, once its entries are covered by a snapshot, as the log leading
+// from the previous snapshot to the new one, so that a fallback to the previous snapshot replays it.
+// When the previous snapshot was not replaced, the rotated log is appended to the kept one.
+func (w *writeAheadLog) retireRotated(replacedPrevious bool) error {
+	if _, err := os.Stat(w.rotatedPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if _, errStat := os.Stat(w.prevPath); !replacedPrevious && errStat == nil {
+		if errAppend := appendFile(w.prevPath, w.rotatedPath); errAppend != nil {
+			return errAppend
+		}
+		return os.Remove(w.rotatedPath)
+	}
+	return os.Rename(w.rotatedPath, w.prevPath)
+}
+
+// replayPrevious applies every entry of the log leading from the previous snapshot to the current one.
+// It reports false when that log is missing.
+func (w *writeAheadLog) replayPrevious(apply func(entry WALEntry)) (bool, error) {
+	if _, err := os.Stat(w.prevPath); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, w.replayFile(w.prevPath, apply)
+}
+
+// close flushes and closes the log.
+func (w *writeAheadLog) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return errors.Join(w.syncLocked(), w.file.Close())
+}
+
+// replay applies every entry of the rotated and the current log in order.
+// Replayed entries count as pending so that the next compaction folds them into a snapshot.
+func (w *writeAheadLog) replay(apply func(entry WALEntry)) error {
+	for _, path := range []string{w.rotatedPath, w.path} {
+		if err := w.replayFile(path, apply); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *writeAheadLog) replayFile(path string, apply func(entry WALEntry)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	replayed := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxWALEntrySize)
+	for scanner.Scan() {
+		var entry WALEntry
+		if errUnmarshal := json.Unmarshal(scanner.Bytes(), &entry); errUnmarshal != nil {
+			// a torn tail is expected after a crash in the middle of an append
+			w.logger.Warn("skipping unreadable log entry", zap.String("path", path), zap.Error(errUnmarshal))
+			continue
+		}
+		apply(entry)
+		replayed++
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		return errScan
+	}
+
+	w.mu.Lock()
+	w.entries += replayed
+	w.mu.Unlock()
+
+	w.logger.Info("write-ahead log replayed", zap.String("path", path), zap.Int("entries", replayed))
+	return nil
+}
+
+// appendFile appends the content of src to dst.
+func appendFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, errCopy := io.Copy(out, in); errCopy != nil {
+		return errors.Join(errCopy, out.Close())
+	}
+	return errors.Join(out.Sync(), out.Close())
+}
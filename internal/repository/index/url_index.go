@@ -0,0 +1,112 @@
+package index
+
+import "github.com/AGENT3128/shortener-url/internal/entity"
+
+// URLIndex is the secondary index of the in-memory repositories.
+// It maps original URLs and users to short URLs so lookups do not scan the whole storage.
+// Original URLs are indexed by their key within the dedup scope, see entity.DedupScope.Key.
+// URLIndex is not safe for concurrent use; the owning repository guards it with its own lock.
+type URLIndex struct {
+	byOriginal map[string]string
+	byUser     map[string]map[string]struct{}
+	scope      entity.DedupScope
+}
+
+// NewURLIndex creates an empty URLIndex deduplicating original URLs within the scope.
+func NewURLIndex(scope entity.DedupScope) *URLIndex {
+	return &URLIndex{
+		byOriginal: make(map[string]string),
+		byUser:     make(map[string]map[string]struct{}),
+		scope:      scope,
+	}
+}
+
+// Put indexes the short URL under its original URL and owner.
+func (i *URLIndex) Put(shortURL, originalURL, userID string) {
+	if key, ok := i.scope.Key(userID, originalURL); ok {
+		i.byOriginal[key] = shortURL
+	}
+
+	shortURLs, ok := i.byUser[userID]
+	if !ok {
+		shortURLs = make(map[string]struct{})
+		i.byUser[userID] = shortURLs
+	}
+	shortURLs[shortURL] = struct{}{}
+}
+
+// Remove drops the short URL from the index entries of its original URL and owner.
+func (i *URLIndex) Remove(shortURL, originalURL, userID string) {
+	if key, ok := i.scope.Key(userID, originalURL); ok && i.byOriginal[key] == shortURL {
+		delete(i.byOriginal, key)
+	}
+
+	shortURLs, ok := i.byUser[userID]
+	if !ok {
+		return
+	}
+	delete(shortURLs, shortURL)
+	if len(shortURLs) == 0 {
+		delete(i.byUser, userID)
+	}
+}
+
+// ShortURL returns the short URL the user's original URL is deduplicated to.
+func (i *URLIndex) ShortURL(userID, originalURL string) (string, bool) {
+	key, ok := i.scope.Key(userID, originalURL)
+	if !ok {
+		return "", false
+	}
+	shortURL, ok := i.byOriginal[key]
+	return shortURL, ok
+}
+
+// UserShortURLs returns the short URLs owned by the user.
+func (i *URLIndex) UserShortURLs(userID string) []string {
+	shortURLs := make([]string, 0, len(i.byUser[userID]))
+	for shortURL := range i.byUser[userID] {
+		shortURLs = append(shortURLs, shortURL)
+	}
+	return shortURLs
+}
+
+// Users returns the number of distinct owners of the indexed short URLs.
+func (i *URLIndex) Users() int {
+	return len(i.byUser)
+}
+
+// CheckAbsent returns entity.URLExistsError if any of the user's original URLs is already indexed
+// and entity.ErrURLExists if one is repeated within the list.
+func (i *URLIndex) CheckAbsent(userID string, originalURLs []string) error {
+	seen := make(map[string]struct{}, len(originalURLs))
+	for _, originalURL := range originalURLs {
+		key, ok := i.scope.Key(userID, originalURL)
+		if !ok {
+			continue
+		}
+		if shortURL, exists := i.byOriginal[key]; exists {
+			return &entity.URLExistsError{ShortURL: shortURL}
+		}
+		if _, exists := seen[key]; exists {
+			return entity.ErrURLExists
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+// CheckShortURLsFree returns entity.ErrShortURLTaken if any of the short URLs is already stored
+// or repeated within the list.
+func CheckShortURLsFree[V any](stored map[string]V, shortURLs ...string) error {
+	seen := make(map[string]struct{}, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		if _, ok := stored[shortURL]; ok {
+			return entity.ErrShortURLTaken
+		}
+		if _, ok := seen[shortURL]; ok {
+			return entity.ErrShortURLTaken
+		}
+		seen[shortURL] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package index_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/repository/index"
+)
+
+func TestURLIndex(t *testing.T) {
+	idx := index.NewURLIndex(entity.DedupGlobal)
+	idx.Put("short1", "https://example1.com", "user1")
+	idx.Put("short2", "https://example2.com", "user1")
+	idx.Put("short3", "https://example3.com", "user2")
+
+	t.Run("lookup by original URL", func(t *testing.T) {
+		shortURL, ok := idx.ShortURL("user2", "https://example2.com")
+		assert.True(t, ok)
+		assert.Equal(t, "short2", shortURL)
+
+		_, ok = idx.ShortURL("user1", "https://missing.com")
+		assert.False(t, ok)
+	})
+
+	t.Run("lookup by user", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"short1", "short2"}, idx.UserShortURLs("user1"))
+		assert.ElementsMatch(t, []string{"short3"}, idx.UserShortURLs("user2"))
+		assert.Empty(t, idx.UserShortURLs("user3"))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		idx.Remove("short1", "https://example1.com", "user1")
+
+		_, ok := idx.ShortURL("user1", "https://example1.com")
+		assert.False(t, ok)
+		assert.ElementsMatch(t, []string{"short2"}, idx.UserShortURLs("user1"))
+	})
+
+	t.Run("remove keeps a newer short URL for the same original URL", func(t *testing.T) {
+		idx.Put("short4", "https://example3.com", "user2")
+		idx.Remove("short3", "https://example3.com", "user2")
+
+		shortURL, ok := idx.ShortURL("user2", "https://example3.com")
+		assert.True(t, ok)
+		assert.Equal(t, "short4", shortURL)
+	})
+}
+
+func TestURLIndexDedupScope(t *testing.T) {
+	t.Run("user", func(t *testing.T) {
+		idx := index.NewURLIndex(entity.DedupUser)
+		idx.Put("short1", "https://example.com", "user1")
+
+		shortURL, ok := idx.ShortURL("user1", "https://example.com")
+		assert.True(t, ok)
+		assert.Equal(t, "short1", shortURL)
+		_, ok = idx.ShortURL("user2", "https://example.com")
+		assert.False(t, ok)
+
+		assert.NoError(t, idx.CheckAbsent("user2", []string{"https://example.com"}))
+		var existsErr *entity.URLExistsError
+		assert.ErrorAs(t, idx.CheckAbsent("user1", []string{"https://example.com"}), &existsErr)
+		assert.ErrorIs(t, idx.CheckAbsent("user2", []string{"https://a.com", "https://a.com"}), entity.ErrURLExists)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		idx := index.NewURLIndex(entity.DedupNone)
+		idx.Put("short1", "https://example.com", "user1")
+
+		_, ok := idx.ShortURL("user1", "https://example.com")
+		assert.False(t, ok)
+		assert.NoError(t, idx.CheckAbsent("user1", []string{"https://example.com", "https://example.com"}))
+		assert.ElementsMatch(t, []string{"short1"}, idx.UserShortURLs("user1"))
+	})
+}
+
+func TestCheckShortURLsFree(t *testing.T) {
+	stored := map[string]string{"short1": "https://example1.com"}
+
+	assert.NoError(t, index.CheckShortURLsFree(stored, "short2", "short3"))
+	assert.ErrorIs(t, index.CheckShortURLsFree(stored, "short2", "short1"), entity.ErrShortURLTaken)
+	assert.ErrorIs(t, index.CheckShortURLsFree(stored, "short2", "short2"), entity.ErrShortURLTaken)
+}
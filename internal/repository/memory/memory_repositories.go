@@ -2,42 +2,91 @@ package memory
 
 import (
 	"context"
+	"slices"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/repository/index"
 )
 
 // MemStorage is the memory storage for the URL.
 type MemStorage struct {
-	urls   map[string]entity.URL
-	logger *zap.Logger
-	mu     sync.RWMutex
+	urls    map[string]entity.URL
+	clicks  map[string][]entity.Click
+	history map[string][]entity.URLChange
+	// apiKeys holds the API keys by their hash.
+	apiKeys map[string]entity.APIKey
+	index   *index.URLIndex
+	logger  *zap.Logger
+	// added counts the URLs ever added, purged ones included.
+	added int64
+	mu    sync.RWMutex
+}
+
+// Option is the option for the MemStorage.
+type Option func(m *MemStorage)
+
+// WithDedupScope is the option for the MemStorage to set the scope within which
+// an original URL is shortened only once. Original URLs are deduplicated globally by default.
+func WithDedupScope(scope entity.DedupScope) Option {
+	return func(m *MemStorage) {
+		m.index = index.NewURLIndex(scope)
+	}
 }
 
 // NewMemStorage creates a new MemStorage.
-func NewMemStorage(logger *zap.Logger) *MemStorage {
+func NewMemStorage(logger *zap.Logger, opts ...Option) *MemStorage {
 	logger = logger.With(zap.String("storage", "memory"))
-	return &MemStorage{
-		urls:   make(map[string]entity.URL),
-		logger: logger,
+	m := &MemStorage{
+		urls:    make(map[string]entity.URL),
+		clicks:  make(map[string][]entity.Click),
+		history: make(map[string][]entity.URLChange),
+		apiKeys: make(map[string]entity.APIKey),
+		index:   index.NewURLIndex(entity.DedupGlobal),
+		logger:  logger,
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
+	return m
+}
+
+// put stores the URL and keeps the index consistent. The caller must hold the write lock.
+func (m *MemStorage) put(url entity.URL) {
+	if old, exists := m.urls[url.ShortURL]; exists {
+		m.index.Remove(old.ShortURL, old.OriginalURL, old.UserID)
+	}
+	m.urls[url.ShortURL] = url
+	m.index.Put(url.ShortURL, url.OriginalURL, url.UserID)
 }
 
 // Add adds a URL.
-func (m *MemStorage) Add(_ context.Context, userID, shortURL, originalURL string) (string, error) {
+func (m *MemStorage) Add(_ context.Context, userID string, url entity.URL) (string, error) {
 	const method = "Add"
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.urls[shortURL] = entity.URL{
-		ShortURL:    shortURL,
-		OriginalURL: originalURL,
-		UserID:      userID,
+	if existing, ok := m.index.ShortURL(userID, url.OriginalURL); ok {
+		return existing, &entity.URLExistsError{ShortURL: existing}
 	}
-	m.logger.Info(method, zap.String("shortURL", shortURL), zap.String("originalURL", originalURL))
-	return shortURL, nil
+	if err := index.CheckShortURLsFree(m.urls, url.ShortURL); err != nil {
+		return "", err
+	}
+
+	m.put(entity.URL{
+		CreatedAt:   time.Now(),
+		ExpiresAt:   url.ExpiresAt,
+		ShortURL:    url.ShortURL,
+		OriginalURL: url.OriginalURL,
+		UserID:      userID,
+		MaxClicks:   url.MaxClicks,
+	})
+	m.added++
+	m.logger.Info(method, zap.String("shortURL", url.ShortURL), zap.String("originalURL", url.OriginalURL))
+	return url.ShortURL, nil
 }
 
 // GetByShortURL gets the original URL by the short URL.
@@ -62,27 +111,88 @@ func (m *MemStorage) GetByShortURL(_ context.Context, shortURL string) (string,
 	return url.OriginalURL, nil
 }
 
-// GetByOriginalURL gets the short URL by the original URL.
-func (m *MemStorage) GetByOriginalURL(_ context.Context, originalURL string) (string, error) {
+// Visit gets the original URL by the short URL and counts the click of a URL limited by MaxClicks.
+// A URL that has expired or used up its clicks is reported as entity.ErrURLExpired.
+// Unlimited URLs are served under the read lock only.
+func (m *MemStorage) Visit(_ context.Context, shortURL string) (string, error) {
+	m.mu.RLock()
+	url, err := m.visitable(shortURL)
+	m.mu.RUnlock()
+	if err != nil || url.MaxClicks == 0 {
+		return url.OriginalURL, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// the URL may have changed between the locks
+	if url, err = m.visitable(shortURL); err != nil {
+		return "", err
+	}
+	url.Clicks++
+	m.urls[shortURL] = url
+	return url.OriginalURL, nil
+}
+
+// visitable returns the URL if it can be redirected to. The caller must hold the lock.
+func (m *MemStorage) visitable(shortURL string) (entity.URL, error) {
+	url, ok := m.urls[shortURL]
+	switch {
+	case !ok:
+		return entity.URL{}, entity.ErrURLNotFound
+	case url.DeletedFlag:
+		return entity.URL{}, entity.ErrURLDeleted
+	case url.Expired(time.Now()):
+		return entity.URL{}, entity.ErrURLExpired
+	}
+	return url, nil
+}
+
+// GetByOriginalURL gets the short URL the user's original URL is deduplicated to.
+func (m *MemStorage) GetByOriginalURL(_ context.Context, userID, originalURL string) (string, error) {
 	const method = "GetByOriginalURL"
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	for shortURL, url := range m.urls {
-		if url.OriginalURL == originalURL {
-			m.logger.Info(method, zap.String("shortURL", shortURL), zap.String("url", url.OriginalURL))
-			return shortURL, nil
-		}
+	shortURL, ok := m.index.ShortURL(userID, originalURL)
+	if !ok {
+		return "", entity.ErrURLNotFound
 	}
-	return "", entity.ErrURLNotFound
+	m.logger.Info(method, zap.String("shortURL", shortURL), zap.String("url", originalURL))
+	return shortURL, nil
 }
 
 // AddBatch adds a batch of URLs.
 func (m *MemStorage) AddBatch(_ context.Context, userID string, urls []entity.URL) error {
-	const method = "AddBatch"
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if err := m.checkBatch(userID, urls); err != nil {
+		return err
+	}
+	m.putBatch(userID, urls)
+	return nil
+}
+
+// checkBatch returns an error if any URL of the user's batch conflicts with the stored ones.
+// The caller must hold the lock.
+func (m *MemStorage) checkBatch(userID string, urls []entity.URL) error {
+	originalURLs := make([]string, 0, len(urls))
+	shortURLs := make([]string, 0, len(urls))
+	for _, url := range urls {
+		originalURLs = append(originalURLs, url.OriginalURL)
+		shortURLs = append(shortURLs, url.ShortURL)
+	}
+	if err := m.index.CheckAbsent(userID, originalURLs); err != nil {
+		return err
+	}
+	return index.CheckShortURLsFree(m.urls, shortURLs...)
+}
+
+// putBatch stores the checked batch. The caller must hold the write lock.
+func (m *MemStorage) putBatch(userID string, urls []entity.URL) {
+	const method = "AddBatch"
+	now := time.Now()
 	for _, url := range urls {
 		m.logger.Info(
 			method,
@@ -90,14 +200,16 @@ func (m *MemStorage) AddBatch(_ context.Context, userID string, urls []entity.UR
 			zap.String("originalURL", url.OriginalURL),
 			zap.String("userID", userID),
 		)
-		m.urls[url.ShortURL] = entity.URL{
+		m.put(entity.URL{
+			CreatedAt:   now,
+			ExpiresAt:   url.ExpiresAt,
 			ShortURL:    url.ShortURL,
 			OriginalURL: url.OriginalURL,
 			UserID:      userID,
-		}
+			MaxClicks:   url.MaxClicks,
+		})
 	}
-
-	return nil
+	m.added += int64(len(urls))
 }
 
 // Ping pings the memory storage.
@@ -106,40 +218,249 @@ func (m *MemStorage) Ping(_ context.Context) error {
 	return nil
 }
 
+// GetURLSequence returns the number of URLs ever added, purged ones included.
+func (m *MemStorage) GetURLSequence(_ context.Context) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.added, nil
+}
+
 // GetUserURLs gets user URLs.
 func (m *MemStorage) GetUserURLs(_ context.Context, userID string) ([]entity.URL, error) {
 	const method = "GetUserURLs"
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	urls := make([]entity.URL, 0)
-	for _, url := range m.urls {
-		if url.UserID == userID {
-			urls = append(urls, url)
-		}
+	shortURLs := m.index.UserShortURLs(userID)
+	urls := make([]entity.URL, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		urls = append(urls, m.urls[shortURL])
 	}
 	m.logger.Info(method, zap.String("userID", userID), zap.Int("count", len(urls)))
 	return urls, nil
 }
 
-// MarkDeletedBatch marks URLs as deleted in batch.
-func (m *MemStorage) MarkDeletedBatch(_ context.Context, userID string, shortURLs []string) error {
+// MarkDeletedBatch marks the user's URLs as deleted in batch and returns the short URLs it marked.
+func (m *MemStorage) MarkDeletedBatch(_ context.Context, userID string, shortURLs []string) ([]string, error) {
 	const method = "MarkDeletedBatch"
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	now := time.Now()
+	marked := make([]string, 0, len(shortURLs))
 	for _, shortURL := range shortURLs {
 		url, exists := m.urls[shortURL]
 		if exists && url.UserID == userID {
+			if !url.DeletedFlag {
+				url.DeletedAt = now
+			}
 			url.DeletedFlag = true
 			m.urls[shortURL] = url
+			marked = append(marked, shortURL)
+			m.logger.Info(method, zap.String("shortURL", shortURL), zap.String("userID", userID))
+		}
+	}
+
+	return marked, nil
+}
+
+// MarkRestoredBatch clears the deleted mark of the user's URLs in batch and returns the short URLs it cleared.
+func (m *MemStorage) MarkRestoredBatch(_ context.Context, userID string, shortURLs []string) ([]string, error) {
+	const method = "MarkRestoredBatch"
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	restored := make([]string, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		url, exists := m.urls[shortURL]
+		if exists && url.UserID == userID && url.DeletedFlag {
+			url.DeletedFlag = false
+			url.DeletedAt = time.Time{}
+			m.urls[shortURL] = url
+			restored = append(restored, shortURL)
 			m.logger.Info(method, zap.String("shortURL", shortURL), zap.String("userID", userID))
 		}
 	}
 
+	return restored, nil
+}
+
+// MarkExpiredDeleted marks the URLs expired at the moment now as deleted and returns their number.
+func (m *MemStorage) MarkExpiredDeleted(_ context.Context, now time.Time) (int64, error) {
+	const method = "MarkExpiredDeleted"
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var count int64
+	for shortURL, url := range m.urls {
+		if !url.DeletedFlag && url.Expired(now) {
+			url.DeletedFlag = true
+			url.DeletedAt = now
+			m.urls[shortURL] = url
+			count++
+		}
+	}
+	m.logger.Info(method, zap.Int64("count", count))
+	return count, nil
+}
+
+// PurgeDeleted permanently removes at most limit URLs deleted before deletedBefore
+// together with their clicks and history, and returns their number.
+func (m *MemStorage) PurgeDeleted(_ context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	const method = "PurgeDeleted"
+	purged := m.purgeDeleted(deletedBefore, limit)
+	m.logger.Info(method, zap.Int("count", len(purged)))
+	return int64(len(purged)), nil
+}
+
+// purgeDeleted removes at most limit URLs deleted before deletedBefore and returns them.
+func (m *MemStorage) purgeDeleted(deletedBefore time.Time, limit int) []entity.URL {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := make([]entity.URL, 0)
+	for shortURL, url := range m.urls {
+		if len(purged) >= limit {
+			break
+		}
+		if !url.DeletedFlag || !url.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+		m.index.Remove(shortURL, url.OriginalURL, url.UserID)
+		delete(m.urls, shortURL)
+		delete(m.clicks, shortURL)
+		delete(m.history, shortURL)
+		purged = append(purged, url)
+	}
+	return purged
+}
+
+// owned returns entity.ErrURLNotFound if the user does not own the short URL
+// and entity.ErrURLDeleted if the URL is deleted. The caller must hold the lock.
+func (m *MemStorage) owned(userID, shortURL string) (entity.URL, error) {
+	url, exists := m.urls[shortURL]
+	if !exists || url.UserID != userID {
+		return entity.URL{}, entity.ErrURLNotFound
+	}
+	if url.DeletedFlag {
+		return entity.URL{}, entity.ErrURLDeleted
+	}
+	return url, nil
+}
+
+// UpdateOriginalURL points the user's short URL to the original URL and returns its previous destinations.
+// An original URL shortened by another short URL is reported as entity.URLExistsError.
+func (m *MemStorage) UpdateOriginalURL(
+	_ context.Context,
+	userID, shortURL, originalURL string,
+) ([]entity.URLChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	url, err := m.owned(userID, shortURL)
+	if err != nil {
+		return nil, err
+	}
+	if url.OriginalURL != originalURL {
+		if existing, ok := m.index.ShortURL(userID, originalURL); ok {
+			return nil, &entity.URLExistsError{ShortURL: existing}
+		}
+		m.history[shortURL] = append(m.history[shortURL], entity.URLChange{
+			ChangedAt:   time.Now(),
+			OriginalURL: url.OriginalURL,
+		})
+		url.OriginalURL = originalURL
+		m.put(url)
+		m.logger.Info("UpdateOriginalURL", zap.String("shortURL", shortURL), zap.String("originalURL", originalURL))
+	}
+	return append([]entity.URLChange{}, m.history[shortURL]...), nil
+}
+
+// AddClicks stores the click events. Clicks of unknown short URLs are dropped.
+func (m *MemStorage) AddClicks(_ context.Context, clicks []entity.Click) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, click := range clicks {
+		if _, exists := m.urls[click.ShortURL]; exists {
+			m.clicks[click.ShortURL] = append(m.clicks[click.ShortURL], click)
+		}
+	}
+	return nil
+}
+
+// GetClickStats gets the click statistics of the short URL owned by the user.
+func (m *MemStorage) GetClickStats(_ context.Context, userID, shortURL string) (entity.URLStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	url, exists := m.urls[shortURL]
+	if !exists || url.UserID != userID {
+		return entity.URLStats{}, entity.ErrURLNotFound
+	}
+	return entity.NewURLStats(shortURL, m.clicks[shortURL]), nil
+}
+
+// GetServiceStats counts the stored short URLs and their distinct owners.
+func (m *MemStorage) GetServiceStats(_ context.Context) (entity.ServiceStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return entity.ServiceStats{URLs: int64(len(m.urls)), Users: int64(m.index.Users())}, nil
+}
+
+// AddAPIKey stores the API key.
+func (m *MemStorage) AddAPIKey(_ context.Context, key entity.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.apiKeys[key.Hash] = key
 	return nil
 }
 
+// GetAPIKeyByHash gets the API key by its hash.
+func (m *MemStorage) GetAPIKeyByHash(_ context.Context, hash string) (entity.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, ok := m.apiKeys[hash]
+	if !ok {
+		return entity.APIKey{}, entity.ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// GetUserAPIKeys gets the API keys of the user, the oldest first.
+func (m *MemStorage) GetUserAPIKeys(_ context.Context, userID string) ([]entity.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]entity.APIKey, 0)
+	for _, key := range m.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	entity.SortAPIKeys(keys)
+	return keys, nil
+}
+
+// DeleteAPIKeys deletes the user's API keys and returns the IDs of the deleted ones.
+func (m *MemStorage) DeleteAPIKeys(_ context.Context, userID string, ids []string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := make([]string, 0, len(ids))
+	for hash, key := range m.apiKeys {
+		if key.UserID == userID && slices.Contains(ids, key.ID) {
+			delete(m.apiKeys, hash)
+			deleted = append(deleted, key.ID)
+		}
+	}
+	return deleted, nil
+}
+
 // Close closes the repository.
 func (m *MemStorage) Close() error {
 	return nil
@@ -9,8 +9,22 @@ import (
 
 	"github.com/AGENT3128/shortener-url/internal/entity"
 	"github.com/AGENT3128/shortener-url/internal/repository/memory"
+	"github.com/AGENT3128/shortener-url/internal/repository/repositorytest"
+	"github.com/AGENT3128/shortener-url/internal/usecase"
 )
 
+func TestMemStorage_Contract(t *testing.T) {
+	repositorytest.Run(t, func(_ *testing.T) usecase.URLRepository {
+		return memory.NewMemStorage(zap.NewNop())
+	})
+}
+
+func TestMemStorage_DedupScopes(t *testing.T) {
+	repositorytest.RunDedupScopes(t, func(_ *testing.T, scope entity.DedupScope) usecase.URLRepository {
+		return memory.NewMemStorage(zap.NewNop(), memory.WithDedupScope(scope))
+	})
+}
+
 func TestMemStorage_Add(t *testing.T) {
 	logger, err := zap.NewDevelopment()
 	require.NoError(t, err)
@@ -41,7 +55,8 @@ func TestMemStorage_Add(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			shortURL, errAdd := repo.Add(t.Context(), tt.userID, tt.shortURL, tt.originalURL)
+			url := entity.URL{ShortURL: tt.shortURL, OriginalURL: tt.originalURL}
+			shortURL, errAdd := repo.Add(t.Context(), tt.userID, url)
 			if tt.wantErr {
 				require.Error(t, errAdd)
 				return
@@ -61,7 +76,7 @@ func TestMemStorage_GetByShortURL(t *testing.T) {
 	shortURL := "test-short"
 	originalURL := "https://test.com"
 	userID := "user1"
-	_, err = repo.Add(t.Context(), userID, shortURL, originalURL)
+	_, err = repo.Add(t.Context(), userID, entity.URL{ShortURL: shortURL, OriginalURL: originalURL})
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -102,7 +117,7 @@ func TestMemStorage_GetByOriginalURL(t *testing.T) {
 	shortURL := "test-short"
 	originalURL := "https://test.com"
 	userID := "user1"
-	_, err = repo.Add(t.Context(), userID, shortURL, originalURL)
+	_, err = repo.Add(t.Context(), userID, entity.URL{ShortURL: shortURL, OriginalURL: originalURL})
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -127,7 +142,7 @@ func TestMemStorage_GetByOriginalURL(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, errGet := repo.GetByOriginalURL(t.Context(), tt.originalURL)
+			got, errGet := repo.GetByOriginalURL(t.Context(), userID, tt.originalURL)
 			assert.Equal(t, tt.wantError, errGet)
 			assert.Equal(t, tt.want, got)
 		})
@@ -240,7 +255,7 @@ func TestMemStorage_MarkDeletedBatch(t *testing.T) {
 
 	// Mark URLs as deleted
 	shortURLsToDelete := []string{"short1", "short2"}
-	err = repo.MarkDeletedBatch(t.Context(), userID, shortURLsToDelete)
+	_, err = repo.MarkDeletedBatch(t.Context(), userID, shortURLsToDelete)
 	require.NoError(t, err)
 
 	deletedURLs, err := repo.GetUserURLs(t.Context(), userID)
@@ -259,3 +274,59 @@ func TestMemStorage_Ping(t *testing.T) {
 	err = repo.Ping(t.Context())
 	require.NoError(t, err)
 }
+
+func TestMemStorage_IndexConsistency(t *testing.T) {
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+	repo := memory.NewMemStorage(logger)
+
+	ctx := t.Context()
+	_, err = repo.Add(ctx, "user1", entity.URL{ShortURL: "short1", OriginalURL: "https://old.com"})
+	require.NoError(t, err)
+
+	// a taken short URL is rejected and leaves the index untouched
+	err = repo.AddBatch(ctx, "user2", []entity.URL{{ShortURL: "short1", OriginalURL: "https://new.com"}})
+	require.ErrorIs(t, err, entity.ErrShortURLTaken)
+
+	got, err := repo.GetByOriginalURL(ctx, "user1", "https://old.com")
+	require.NoError(t, err)
+	assert.Equal(t, "short1", got)
+	_, err = repo.GetByOriginalURL(ctx, "user2", "https://new.com")
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+
+	user2URLs, err := repo.GetUserURLs(ctx, "user2")
+	require.NoError(t, err)
+	assert.Empty(t, user2URLs)
+
+	_, err = repo.MarkDeletedBatch(ctx, "user1", []string{"short1"})
+	require.NoError(t, err)
+	user1URLs, err := repo.GetUserURLs(ctx, "user1")
+	require.NoError(t, err)
+	require.Len(t, user1URLs, 1)
+	assert.True(t, user1URLs[0].DeletedFlag)
+}
+
+func TestMemStorage_Duplicates(t *testing.T) {
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+	repo := memory.NewMemStorage(logger)
+
+	ctx := t.Context()
+	_, err = repo.Add(ctx, "user1", entity.URL{ShortURL: "short1", OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+
+	shortURL, err := repo.Add(ctx, "user2", entity.URL{ShortURL: "short2", OriginalURL: "https://example.com"})
+	var existsErr *entity.URLExistsError
+	require.ErrorAs(t, err, &existsErr)
+	require.ErrorIs(t, err, entity.ErrURLExists)
+	assert.Equal(t, "short1", existsErr.ShortURL)
+	assert.Equal(t, "short1", shortURL)
+
+	err = repo.AddBatch(ctx, "user2", []entity.URL{
+		{ShortURL: "short3", OriginalURL: "https://other.com"},
+		{ShortURL: "short4", OriginalURL: "https://example.com"},
+	})
+	require.ErrorIs(t, err, entity.ErrURLExists)
+	_, err = repo.GetByShortURL(ctx, "short3")
+	require.ErrorIs(t, err, entity.ErrURLNotFound, "a rejected batch must not be applied partially")
+}
@@ -0,0 +1,424 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"hash/maphash"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+const defaultShardCount = 32
+
+// ShardedMemStorage is the memory storage for the URL that spreads short URLs across
+// independently locked shards, so writes to one shard do not stall reads from the others.
+// Every shard is a MemStorage holding the URLs and the index entries of its own keys.
+type ShardedMemStorage struct {
+	shards []*MemStorage
+	// originalLocks serialize writers of the same original URL,
+	// whose short URLs may land in different shards.
+	originalLocks []sync.Mutex
+	// owners counts the short URLs of every user across the shards for the service statistics.
+	owners     map[string]int
+	ownersMu   sync.Mutex
+	seed       maphash.Seed
+	dedupScope entity.DedupScope
+}
+
+type shardedOptions struct {
+	dedupScope entity.DedupScope
+	shardCount int
+}
+
+// ShardedOption is the option for the ShardedMemStorage.
+type ShardedOption func(options *shardedOptions) error
+
+// WithShardCount is the option for the ShardedMemStorage to set the number of shards.
+func WithShardCount(count int) ShardedOption {
+	return func(options *shardedOptions) error {
+		if count < 1 {
+			return errors.New("shard count must be positive")
+		}
+		options.shardCount = count
+		return nil
+	}
+}
+
+// WithShardedDedupScope is the option for the ShardedMemStorage to set the scope within which
+// an original URL is shortened only once. Original URLs are deduplicated globally by default.
+func WithShardedDedupScope(scope entity.DedupScope) ShardedOption {
+	return func(options *shardedOptions) error {
+		options.dedupScope = scope
+		return nil
+	}
+}
+
+// NewShardedMemStorage creates a new ShardedMemStorage.
+func NewShardedMemStorage(logger *zap.Logger, opts ...ShardedOption) (*ShardedMemStorage, error) {
+	options := &shardedOptions{dedupScope: entity.DedupGlobal, shardCount: defaultShardCount}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+
+	shards := make([]*MemStorage, options.shardCount)
+	for i := range shards {
+		shards[i] = NewMemStorage(logger.With(zap.Int("shard", i)), WithDedupScope(options.dedupScope))
+	}
+	return &ShardedMemStorage{
+		shards:        shards,
+		originalLocks: make([]sync.Mutex, options.shardCount),
+		owners:        make(map[string]int),
+		seed:          maphash.MakeSeed(),
+		dedupScope:    options.dedupScope,
+	}, nil
+}
+
+// shard returns the shard owning the short URL.
+func (s *ShardedMemStorage) shard(shortURL string) *MemStorage {
+	return s.shards[maphash.String(s.seed, shortURL)%uint64(len(s.shards))]
+}
+
+// lockOriginals locks the stripes of the original URLs in a stable order and returns the unlock function.
+func (s *ShardedMemStorage) lockOriginals(originalURLs []string) func() {
+	stripes := make([]int, 0, len(originalURLs))
+	for _, originalURL := range originalURLs {
+		stripes = append(stripes, int(maphash.String(s.seed, originalURL)%uint64(len(s.originalLocks))))
+	}
+	slices.Sort(stripes)
+	stripes = slices.Compact(stripes)
+
+	for _, stripe := range stripes {
+		s.originalLocks[stripe].Lock()
+	}
+	return func() {
+		for _, stripe := range stripes {
+			s.originalLocks[stripe].Unlock()
+		}
+	}
+}
+
+// checkAbsent returns URLExistsError if any of the user's original URLs is stored in any shard
+// and ErrURLExists if one is repeated within the list. The caller must hold the original URL locks.
+func (s *ShardedMemStorage) checkAbsent(ctx context.Context, userID string, originalURLs []string) error {
+	seen := make(map[string]struct{}, len(originalURLs))
+	for _, originalURL := range originalURLs {
+		key, ok := s.dedupScope.Key(userID, originalURL)
+		if !ok {
+			continue
+		}
+		shortURL, err := s.GetByOriginalURL(ctx, userID, originalURL)
+		if err == nil {
+			return &entity.URLExistsError{ShortURL: shortURL}
+		}
+		if !errors.Is(err, entity.ErrURLNotFound) {
+			return err
+		}
+		if _, exists := seen[key]; exists {
+			return entity.ErrURLExists
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+// group splits the values by the shard owning their short URL.
+func group[T any](s *ShardedMemStorage, values []T, shortURL func(T) string) map[*MemStorage][]T {
+	groups := make(map[*MemStorage][]T)
+	for _, value := range values {
+		shard := s.shard(shortURL(value))
+		groups[shard] = append(groups[shard], value)
+	}
+	return groups
+}
+
+// Add adds a URL.
+func (s *ShardedMemStorage) Add(ctx context.Context, userID string, url entity.URL) (string, error) {
+	unlock := s.lockOriginals([]string{url.OriginalURL})
+	defer unlock()
+
+	if err := s.checkAbsent(ctx, userID, []string{url.OriginalURL}); err != nil {
+		var existsErr *entity.URLExistsError
+		if errors.As(err, &existsErr) {
+			return existsErr.ShortURL, err
+		}
+		return "", err
+	}
+	shortURL, err := s.shard(url.ShortURL).Add(ctx, userID, url)
+	if err != nil {
+		return "", err
+	}
+	s.countOwned(userID, 1)
+	return shortURL, nil
+}
+
+// countOwned adds the number of short URLs the user stored; a negative count removes them.
+func (s *ShardedMemStorage) countOwned(userID string, count int) {
+	s.ownersMu.Lock()
+	defer s.ownersMu.Unlock()
+	s.owners[userID] += count
+	if s.owners[userID] <= 0 {
+		delete(s.owners, userID)
+	}
+}
+
+// GetByShortURL gets the original URL by the short URL.
+func (s *ShardedMemStorage) GetByShortURL(ctx context.Context, shortURL string) (string, error) {
+	return s.shard(shortURL).GetByShortURL(ctx, shortURL)
+}
+
+// Visit gets the original URL by the short URL and counts the click.
+func (s *ShardedMemStorage) Visit(ctx context.Context, shortURL string) (string, error) {
+	return s.shard(shortURL).Visit(ctx, shortURL)
+}
+
+// GetByOriginalURL gets the short URL the user's original URL is deduplicated to.
+// The original URL index is kept per shard, so every shard is asked in turn.
+func (s *ShardedMemStorage) GetByOriginalURL(ctx context.Context, userID, originalURL string) (string, error) {
+	for _, shard := range s.shards {
+		shortURL, err := shard.GetByOriginalURL(ctx, userID, originalURL)
+		if err == nil {
+			return shortURL, nil
+		}
+		if !errors.Is(err, entity.ErrURLNotFound) {
+			return "", err
+		}
+	}
+	return "", entity.ErrURLNotFound
+}
+
+// AddBatch adds a batch of URLs.
+func (s *ShardedMemStorage) AddBatch(ctx context.Context, userID string, urls []entity.URL) error {
+	originalURLs := make([]string, 0, len(urls))
+	for _, url := range urls {
+		originalURLs = append(originalURLs, url.OriginalURL)
+	}
+	unlock := s.lockOriginals(originalURLs)
+	defer unlock()
+
+	if err := s.checkAbsent(ctx, userID, originalURLs); err != nil {
+		return err
+	}
+
+	// every involved shard is locked in shard order, so the batch is checked and stored as a whole
+	groups := group(s, urls, func(url entity.URL) string { return url.ShortURL })
+	locked := make([]*MemStorage, 0, len(groups))
+	for _, shard := range s.shards {
+		if _, ok := groups[shard]; ok {
+			shard.mu.Lock()
+			locked = append(locked, shard)
+		}
+	}
+	defer func() {
+		for _, shard := range locked {
+			shard.mu.Unlock()
+		}
+	}()
+
+	for shard, shardURLs := range groups {
+		if err := shard.checkBatch(userID, shardURLs); err != nil {
+			return err
+		}
+	}
+	for shard, shardURLs := range groups {
+		shard.putBatch(userID, shardURLs)
+	}
+	s.countOwned(userID, len(urls))
+	return nil
+}
+
+// Ping pings the memory storage.
+func (s *ShardedMemStorage) Ping(_ context.Context) error {
+	// not needed for memory storage
+	return nil
+}
+
+// GetURLSequence returns the number of URLs ever added to all shards, purged ones included.
+func (s *ShardedMemStorage) GetURLSequence(ctx context.Context) (int64, error) {
+	var sequence int64
+	for _, shard := range s.shards {
+		shardSequence, err := shard.GetURLSequence(ctx)
+		if err != nil {
+			return 0, err
+		}
+		sequence += shardSequence
+	}
+	return sequence, nil
+}
+
+// GetUserURLs gets user URLs.
+func (s *ShardedMemStorage) GetUserURLs(ctx context.Context, userID string) ([]entity.URL, error) {
+	urls := make([]entity.URL, 0)
+	for _, shard := range s.shards {
+		shardURLs, err := shard.GetUserURLs(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, shardURLs...)
+	}
+	return urls, nil
+}
+
+// MarkDeletedBatch marks the user's URLs as deleted in batch and returns the short URLs it marked.
+func (s *ShardedMemStorage) MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error) {
+	marked := make([]string, 0, len(shortURLs))
+	for shard, shardShortURLs := range group(s, shortURLs, func(shortURL string) string { return shortURL }) {
+		shardMarked, err := shard.MarkDeletedBatch(ctx, userID, shardShortURLs)
+		if err != nil {
+			return marked, err
+		}
+		marked = append(marked, shardMarked...)
+	}
+	return marked, nil
+}
+
+// MarkRestoredBatch clears the deleted mark of the user's URLs in batch and returns the short URLs it cleared.
+func (s *ShardedMemStorage) MarkRestoredBatch(
+	ctx context.Context,
+	userID string,
+	shortURLs []string,
+) ([]string, error) {
+	restored := make([]string, 0, len(shortURLs))
+	for shard, shardShortURLs := range group(s, shortURLs, func(shortURL string) string { return shortURL }) {
+		shardRestored, err := shard.MarkRestoredBatch(ctx, userID, shardShortURLs)
+		if err != nil {
+			return restored, err
+		}
+		restored = append(restored, shardRestored...)
+	}
+	return restored, nil
+}
+
+// MarkExpiredDeleted marks the URLs expired at the moment now as deleted and returns their number.
+func (s *ShardedMemStorage) MarkExpiredDeleted(ctx context.Context, now time.Time) (int64, error) {
+	var count int64
+	for _, shard := range s.shards {
+		shardCount, err := shard.MarkExpiredDeleted(ctx, now)
+		if err != nil {
+			return count, err
+		}
+		count += shardCount
+	}
+	return count, nil
+}
+
+// PurgeDeleted permanently removes at most limit URLs deleted before deletedBefore and returns their number.
+func (s *ShardedMemStorage) PurgeDeleted(_ context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	var count int64
+	for _, shard := range s.shards {
+		if count >= int64(limit) {
+			break
+		}
+		for _, url := range shard.purgeDeleted(deletedBefore, limit-int(count)) {
+			s.countOwned(url.UserID, -1)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// UpdateOriginalURL points the user's short URL to the original URL and returns its previous destinations.
+// The original URL index is kept per shard, so the new original URL is looked up in every shard.
+func (s *ShardedMemStorage) UpdateOriginalURL(
+	ctx context.Context,
+	userID, shortURL, originalURL string,
+) ([]entity.URLChange, error) {
+	unlock := s.lockOriginals([]string{originalURL})
+	defer unlock()
+
+	shard := s.shard(shortURL)
+	shard.mu.RLock()
+	_, err := shard.owned(userID, shortURL)
+	shard.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if errAbsent := s.checkAbsent(ctx, userID, []string{originalURL}); errAbsent != nil {
+		var existsErr *entity.URLExistsError
+		// pointing the short URL to its own original URL changes nothing
+		if !errors.As(errAbsent, &existsErr) || existsErr.ShortURL != shortURL {
+			return nil, errAbsent
+		}
+	}
+	return shard.UpdateOriginalURL(ctx, userID, shortURL, originalURL)
+}
+
+// AddClicks stores the click events.
+func (s *ShardedMemStorage) AddClicks(ctx context.Context, clicks []entity.Click) error {
+	for shard, shardClicks := range group(s, clicks, func(click entity.Click) string { return click.ShortURL }) {
+		if err := shard.AddClicks(ctx, shardClicks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetClickStats gets the click statistics of the short URL owned by the user.
+func (s *ShardedMemStorage) GetClickStats(ctx context.Context, userID, shortURL string) (entity.URLStats, error) {
+	return s.shard(shortURL).GetClickStats(ctx, userID, shortURL)
+}
+
+// GetServiceStats counts the stored short URLs and their distinct owners.
+func (s *ShardedMemStorage) GetServiceStats(ctx context.Context) (entity.ServiceStats, error) {
+	var stats entity.ServiceStats
+	for _, shard := range s.shards {
+		shardStats, err := shard.GetServiceStats(ctx)
+		if err != nil {
+			return entity.ServiceStats{}, err
+		}
+		stats.URLs += shardStats.URLs
+	}
+
+	s.ownersMu.Lock()
+	defer s.ownersMu.Unlock()
+	stats.Users = int64(len(s.owners))
+	return stats, nil
+}
+
+// AddAPIKey stores the API key in the shard of its hash.
+func (s *ShardedMemStorage) AddAPIKey(ctx context.Context, key entity.APIKey) error {
+	return s.shard(key.Hash).AddAPIKey(ctx, key)
+}
+
+// GetAPIKeyByHash gets the API key by its hash.
+func (s *ShardedMemStorage) GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error) {
+	return s.shard(hash).GetAPIKeyByHash(ctx, hash)
+}
+
+// GetUserAPIKeys gets the API keys of the user from every shard, the oldest first.
+func (s *ShardedMemStorage) GetUserAPIKeys(ctx context.Context, userID string) ([]entity.APIKey, error) {
+	keys := make([]entity.APIKey, 0)
+	for _, shard := range s.shards {
+		shardKeys, err := shard.GetUserAPIKeys(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, shardKeys...)
+	}
+	entity.SortAPIKeys(keys)
+	return keys, nil
+}
+
+// DeleteAPIKeys deletes the user's API keys from every shard and returns the IDs of the deleted ones.
+func (s *ShardedMemStorage) DeleteAPIKeys(ctx context.Context, userID string, ids []string) ([]string, error) {
+	deleted := make([]string, 0, len(ids))
+	for _, shard := range s.shards {
+		shardDeleted, err := shard.DeleteAPIKeys(ctx, userID, ids)
+		if err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, shardDeleted...)
+	}
+	return deleted, nil
+}
+
+// Close closes the repository.
+func (s *ShardedMemStorage) Close() error {
+	return nil
+}
@@ -0,0 +1,168 @@
+package memory_test
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/repository/memory"
+	"github.com/AGENT3128/shortener-url/internal/repository/repositorytest"
+	"github.com/AGENT3128/shortener-url/internal/usecase"
+)
+
+var _ usecase.URLRepository = (*memory.ShardedMemStorage)(nil)
+
+func TestShardedMemStorage(t *testing.T) {
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	_, err = memory.NewShardedMemStorage(logger, memory.WithShardCount(0))
+	require.Error(t, err)
+
+	repo, err := memory.NewShardedMemStorage(logger, memory.WithShardCount(4))
+	require.NoError(t, err)
+
+	ctx := t.Context()
+	urls := make([]entity.URL, 0, 20)
+	for i := range 20 {
+		urls = append(urls, entity.URL{
+			ShortURL:    "short" + strconv.Itoa(i),
+			OriginalURL: "https://example" + strconv.Itoa(i) + ".com",
+		})
+	}
+	require.NoError(t, repo.AddBatch(ctx, "user1", urls))
+	_, err = repo.Add(ctx, "user2", entity.URL{ShortURL: "single", OriginalURL: "https://single.com"})
+	require.NoError(t, err)
+
+	for _, url := range urls {
+		got, errGet := repo.GetByShortURL(ctx, url.ShortURL)
+		require.NoError(t, errGet)
+		assert.Equal(t, url.OriginalURL, got)
+
+		shortURL, errGet := repo.GetByOriginalURL(ctx, "user1", url.OriginalURL)
+		require.NoError(t, errGet)
+		assert.Equal(t, url.ShortURL, shortURL)
+	}
+
+	_, err = repo.GetByShortURL(ctx, "missing")
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+	_, err = repo.GetByOriginalURL(ctx, "user1", "https://missing.com")
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+
+	user1URLs, err := repo.GetUserURLs(ctx, "user1")
+	require.NoError(t, err)
+	assert.Len(t, user1URLs, len(urls))
+
+	_, err = repo.MarkDeletedBatch(ctx, "user1", []string{"short1", "short2", "single"})
+	require.NoError(t, err)
+	_, err = repo.GetByShortURL(ctx, "short1")
+	require.ErrorIs(t, err, entity.ErrURLDeleted)
+	// not owned by user1
+	_, err = repo.GetByShortURL(ctx, "single")
+	require.NoError(t, err)
+
+	shortURL, err := repo.Add(ctx, "user2", entity.URL{ShortURL: "dup", OriginalURL: "https://example3.com"})
+	require.ErrorIs(t, err, entity.ErrURLExists)
+	assert.Equal(t, "short3", shortURL)
+	err = repo.AddBatch(ctx, "user2", []entity.URL{{ShortURL: "dup", OriginalURL: "https://example4.com"}})
+	require.ErrorIs(t, err, entity.ErrURLExists)
+
+	require.NoError(t, repo.Ping(ctx))
+	require.NoError(t, repo.Close())
+}
+
+func TestShardedMemStorage_Contract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) usecase.URLRepository {
+		repo, err := memory.NewShardedMemStorage(zap.NewNop(), memory.WithShardCount(4))
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+func TestShardedMemStorage_DedupScopes(t *testing.T) {
+	repositorytest.RunDedupScopes(t, func(t *testing.T, scope entity.DedupScope) usecase.URLRepository {
+		repo, err := memory.NewShardedMemStorage(
+			zap.NewNop(),
+			memory.WithShardCount(4),
+			memory.WithShardedDedupScope(scope),
+		)
+		require.NoError(t, err)
+		return repo
+	})
+}
+
+// BenchmarkMemStorageVisit compares redirects of unlimited URLs, served under the read lock,
+// with redirects of URLs limited by MaxClicks, counted under the write lock.
+func BenchmarkMemStorageVisit(b *testing.B) {
+	for name, maxClicks := range map[string]int64{"unlimited": 0, "max_clicks": math.MaxInt64} {
+		b.Run(name, func(b *testing.B) {
+			repo := memory.NewMemStorage(zap.NewNop())
+			_, err := repo.Add(context.Background(), "user", entity.URL{
+				ShortURL:    "visited",
+				OriginalURL: "https://visited.com",
+				MaxClicks:   maxClicks,
+			})
+			require.NoError(b, err)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, _ = repo.Visit(context.Background(), "visited")
+				}
+			})
+		})
+	}
+}
+
+// runConcurrently splits b.N redirect-heavy operations (one write per ten reads) across the goroutines.
+func runConcurrently(b *testing.B, repo usecase.URLRepository, goroutines int) {
+	b.Helper()
+	ctx := context.Background()
+	const preloaded = 10000
+	keys := make([]string, preloaded)
+	for i := range preloaded {
+		id := strconv.Itoa(i)
+		keys[i] = "pre" + id
+		_, _ = repo.Add(ctx, "user", entity.URL{ShortURL: keys[i], OriginalURL: "https://pre" + id + ".com"})
+	}
+
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	perGoroutine := b.N/goroutines + 1
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perGoroutine {
+				if i%10 == 0 {
+					id := strconv.Itoa(g) + "-" + strconv.Itoa(i)
+					url := entity.URL{ShortURL: "new" + id, OriginalURL: "https://new" + id + ".com"}
+					_, _ = repo.Add(ctx, "user", url)
+					continue
+				}
+				_, _ = repo.GetByShortURL(ctx, keys[i%preloaded])
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkMemStorageConcurrency(b *testing.B) {
+	for _, goroutines := range []int{1, 8, 64} {
+		b.Run("single_lock/goroutines="+strconv.Itoa(goroutines), func(b *testing.B) {
+			runConcurrently(b, memory.NewMemStorage(zap.NewNop()), goroutines)
+		})
+		b.Run("sharded/goroutines="+strconv.Itoa(goroutines), func(b *testing.B) {
+			repo, err := memory.NewShardedMemStorage(zap.NewNop())
+			require.NoError(b, err)
+			runConcurrently(b, repo, goroutines)
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/repository/postgres/generated"
+	"github.com/AGENT3128/shortener-url/pkg/database"
+)
+
+// DeleteQueue is the delete queue kept in the delete_queue outbox table.
+// The jobs of the acknowledged tasks move to the delete_jobs table, the dead tasks stay in the outbox.
+// Claims are serialized with a transaction level advisory lock, so several instances of the service
+// can share the queue without applying the tasks of one user out of order.
+type DeleteQueue struct {
+	db      *database.Database
+	logger  *zap.Logger
+	queries *generated.Queries
+}
+
+// NewDeleteQueue creates a new DeleteQueue.
+func NewDeleteQueue(db *database.Database, logger *zap.Logger) *DeleteQueue {
+	return &DeleteQueue{
+		db:      db,
+		logger:  logger.With(zap.String("queue", "outbox")),
+		queries: generated.New(db.Pool),
+	}
+}
+
+// Push inserts the task into the outbox.
+func (q *DeleteQueue) Push(ctx context.Context, task entity.DeleteTask) error {
+	return q.queries.PushDeleteTask(ctx, generated.PushDeleteTaskParams{
+		CreatedAt:     task.Job.CreatedAt,
+		NextAttemptAt: task.NextAttemptAt,
+		JobID:         task.Job.ID,
+		UserID:        task.Job.UserID,
+		Operation:     string(task.Job.Operation),
+		LastError:     task.LastError,
+		ShortUrls:     task.Job.ShortURLs,
+		Attempts:      toInt32(task.Attempts),
+	})
+}
+
+// Claim leases up to limit tasks due at the moment now.
+func (q *DeleteQueue) Claim(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+	lease time.Duration,
+) ([]entity.DeleteTask, error) {
+	tx, err := q.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errRollback := tx.Rollback(ctx); errRollback != nil && !errors.Is(errRollback, pgx.ErrTxClosed) {
+			q.logger.Error("failed to rollback transaction", zap.Error(errRollback))
+		}
+	}()
+
+	qtx := q.queries.WithTx(tx)
+	if errLock := qtx.LockDeleteQueue(ctx); errLock != nil {
+		return nil, errLock
+	}
+	rows, err := qtx.ClaimDeleteTasks(ctx, generated.ClaimDeleteTasksParams{
+		LeasedUntil: toTimestamptz(now.Add(lease)),
+		Now:         toTimestamptz(now),
+		MaxTasks:    toInt32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if errCommit := tx.Commit(ctx); errCommit != nil {
+		return nil, errCommit
+	}
+
+	tasks := toDeleteTasks(rows)
+	slices.SortFunc(tasks, func(a, b entity.DeleteTask) int { return cmp.Compare(a.Seq, b.Seq) })
+	return tasks, nil
+}
+
+// Ack deletes the task of the job from the outbox and keeps the finished job in one transaction.
+func (q *DeleteQueue) Ack(ctx context.Context, job entity.Job) error {
+	tx, err := q.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errRollback := tx.Rollback(ctx); errRollback != nil && !errors.Is(errRollback, pgx.ErrTxClosed) {
+			q.logger.Error("failed to rollback transaction", zap.Error(errRollback))
+		}
+	}()
+
+	qtx := q.queries.WithTx(tx)
+	if errAck := qtx.AckDeleteTask(ctx, job.ID); errAck != nil {
+		return errAck
+	}
+	if errAdd := qtx.AddDeleteJob(ctx, generated.AddDeleteJobParams{
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+		JobID:      job.ID,
+		UserID:     job.UserID,
+		Operation:  string(job.Operation),
+		Status:     string(job.Status),
+		ShortUrls:  job.ShortURLs,
+		FailedUrls: job.FailedURLs,
+	}); errAdd != nil {
+		return errAdd
+	}
+	return tx.Commit(ctx)
+}
+
+// Retry stores the attempts, the next attempt time and the last error of the task and drops its lease.
+func (q *DeleteQueue) Retry(ctx context.Context, task entity.DeleteTask) error {
+	return q.queries.RetryDeleteTask(ctx, generated.RetryDeleteTaskParams{
+		NextAttemptAt: task.NextAttemptAt,
+		JobID:         task.Job.ID,
+		LastError:     task.LastError,
+		Attempts:      toInt32(task.Attempts),
+	})
+}
+
+// Bury marks the task dead, it stays in the outbox for the operators.
+func (q *DeleteQueue) Bury(ctx context.Context, task entity.DeleteTask) error {
+	return q.queries.BuryDeleteTask(ctx, generated.BuryDeleteTaskParams{
+		DeadAt:    toTimestamptz(task.DeadAt),
+		JobID:     task.Job.ID,
+		LastError: task.LastError,
+		Attempts:  toInt32(task.Attempts),
+	})
+}
+
+// DeadLetters returns up to limit dead tasks, the latest first. A zero limit returns all of them.
+func (q *DeleteQueue) DeadLetters(ctx context.Context, limit int) ([]entity.DeleteTask, error) {
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+	rows, err := q.queries.GetDeadDeleteTasks(ctx, toInt32(limit))
+	if err != nil {
+		return nil, err
+	}
+	return toDeleteTasks(rows), nil
+}
+
+// Job returns the job by its ID: a queued task is a pending job, a dead one a failed job,
+// otherwise the job is looked up among the finished ones.
+func (q *DeleteQueue) Job(ctx context.Context, jobID string) (entity.Job, error) {
+	row, err := q.queries.GetDeleteTask(ctx, jobID)
+	if err == nil {
+		task := toDeleteTask(row)
+		if !task.DeadAt.IsZero() {
+			return task.DeadJob(), nil
+		}
+		return task.Job, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return entity.Job{}, err
+	}
+
+	job, err := q.queries.GetDeleteJob(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Job{}, entity.ErrJobNotFound
+		}
+		return entity.Job{}, err
+	}
+	return entity.Job{
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+		ID:         job.JobID,
+		UserID:     job.UserID,
+		Operation:  entity.JobOperation(job.Operation),
+		Status:     entity.JobStatus(job.Status),
+		ShortURLs:  job.ShortUrls,
+		FailedURLs: job.FailedUrls,
+	}, nil
+}
+
+// Prune deletes the jobs finished before finishedBefore and the dead tasks buried before deadBefore.
+func (q *DeleteQueue) Prune(ctx context.Context, finishedBefore, deadBefore time.Time) error {
+	if err := q.queries.PruneDeleteJobs(ctx, toTimestamptz(finishedBefore)); err != nil {
+		return err
+	}
+	return q.queries.PruneDeadDeleteTasks(ctx, toTimestamptz(deadBefore))
+}
+
+func toDeleteTasks(rows []generated.DeleteQueue) []entity.DeleteTask {
+	tasks := make([]entity.DeleteTask, 0, len(rows))
+	for _, row := range rows {
+		tasks = append(tasks, toDeleteTask(row))
+	}
+	return tasks
+}
+
+func toDeleteTask(row generated.DeleteQueue) entity.DeleteTask {
+	return entity.DeleteTask{
+		NextAttemptAt: row.NextAttemptAt,
+		DeadAt:        row.DeadAt.Time,
+		Job: entity.Job{
+			CreatedAt: row.CreatedAt,
+			ID:        row.JobID,
+			UserID:    row.UserID,
+			Operation: entity.JobOperation(row.Operation),
+			Status:    entity.JobPending,
+			ShortURLs: row.ShortUrls,
+		},
+		LastError: row.LastError,
+		Seq:       row.ID,
+		Attempts:  int(row.Attempts),
+	}
+}
+
+// toInt32 clamps n to the int32 range of the integer columns.
+func toInt32(n int) int32 {
+	return int32(min(max(n, math.MinInt32), math.MaxInt32)) //nolint:gosec // clamped to the int32 range
+}
@@ -8,19 +8,24 @@ package generated
 import (
 	"context"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const addURL = `-- name: AddURL :one
-INSERT INTO urls (user_id, short_url, original_url, created_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO urls (user_id, short_url, original_url, created_at, expires_at, max_clicks, dedup_scope)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING short_url
 `
 
 type AddURLParams struct {
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UserID      string    `db:"user_id" json:"user_id"`
-	ShortUrl    string    `db:"short_url" json:"short_url"`
-	OriginalUrl string    `db:"original_url" json:"original_url"`
+	CreatedAt   time.Time          `db:"created_at" json:"created_at"`
+	ExpiresAt   pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	UserID      string             `db:"user_id" json:"user_id"`
+	ShortUrl    string             `db:"short_url" json:"short_url"`
+	OriginalUrl string             `db:"original_url" json:"original_url"`
+	MaxClicks   int64              `db:"max_clicks" json:"max_clicks"`
+	DedupScope  string             `db:"dedup_scope" json:"dedup_scope"`
 }
 
 func (q *Queries) AddURL(ctx context.Context, arg AddURLParams) (string, error) {
@@ -29,6 +34,9 @@ func (q *Queries) AddURL(ctx context.Context, arg AddURLParams) (string, error)
 		arg.ShortUrl,
 		arg.OriginalUrl,
 		arg.CreatedAt,
+		arg.ExpiresAt,
+		arg.MaxClicks,
+		arg.DedupScope,
 	)
 	var short_url string
 	err := row.Scan(&short_url)
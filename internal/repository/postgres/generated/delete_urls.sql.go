@@ -9,10 +9,11 @@ import (
 	"context"
 )
 
-const markDeletedBatch = `-- name: MarkDeletedBatch :exec
+const markDeletedBatch = `-- name: MarkDeletedBatch :many
 UPDATE urls 
-SET is_deleted = true 
+SET is_deleted = true, deleted_at = COALESCE(deleted_at, now()) 
 WHERE user_id = $1 AND short_url = ANY($2::text[])
+RETURNING short_url
 `
 
 type MarkDeletedBatchParams struct {
@@ -20,7 +21,22 @@ type MarkDeletedBatchParams struct {
 	Column2 []string `db:"column_2" json:"column_2"`
 }
 
-func (q *Queries) MarkDeletedBatch(ctx context.Context, arg MarkDeletedBatchParams) error {
-	_, err := q.db.Exec(ctx, markDeletedBatch, arg.UserID, arg.Column2)
-	return err
+func (q *Queries) MarkDeletedBatch(ctx context.Context, arg MarkDeletedBatchParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, markDeletedBatch, arg.UserID, arg.Column2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var short_url string
+		if err := rows.Scan(&short_url); err != nil {
+			return nil, err
+		}
+		items = append(items, short_url)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
@@ -10,7 +10,7 @@ import (
 )
 
 const getURLsByUserID = `-- name: GetURLsByUserID :many
-SELECT id, user_id, short_url, original_url, created_at, is_deleted FROM urls WHERE user_id = $1
+SELECT id, user_id, short_url, original_url, created_at, is_deleted, expires_at, max_clicks, clicks, deleted_at, dedup_scope FROM urls WHERE user_id = $1
 `
 
 func (q *Queries) GetURLsByUserID(ctx context.Context, userID string) ([]Url, error) {
@@ -29,6 +29,11 @@ func (q *Queries) GetURLsByUserID(ctx context.Context, userID string) ([]Url, er
 			&i.OriginalUrl,
 			&i.CreatedAt,
 			&i.IsDeleted,
+			&i.ExpiresAt,
+			&i.MaxClicks,
+			&i.Clicks,
+			&i.DeletedAt,
+			&i.DedupScope,
 		); err != nil {
 			return nil, err
 		}
@@ -10,7 +10,7 @@ import (
 )
 
 const getURLByOriginalURL = `-- name: GetURLByOriginalURL :one
-SELECT short_url FROM urls WHERE original_url = $1
+SELECT short_url FROM urls WHERE original_url = $1 AND dedup_scope = 'global'
 LIMIT 1
 `
 
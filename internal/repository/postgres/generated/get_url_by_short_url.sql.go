@@ -7,21 +7,32 @@ package generated
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const getURLByShortURL = `-- name: GetURLByShortURL :one
-SELECT original_url, is_deleted FROM urls WHERE short_url = $1
+SELECT original_url, is_deleted, expires_at, max_clicks, clicks FROM urls WHERE short_url = $1
 LIMIT 1
 `
 
 type GetURLByShortURLRow struct {
-	OriginalUrl string `db:"original_url" json:"original_url"`
-	IsDeleted   bool   `db:"is_deleted" json:"is_deleted"`
+	ExpiresAt   pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	OriginalUrl string             `db:"original_url" json:"original_url"`
+	MaxClicks   int64              `db:"max_clicks" json:"max_clicks"`
+	Clicks      int64              `db:"clicks" json:"clicks"`
+	IsDeleted   bool               `db:"is_deleted" json:"is_deleted"`
 }
 
 func (q *Queries) GetURLByShortURL(ctx context.Context, shortUrl string) (GetURLByShortURLRow, error) {
 	row := q.db.QueryRow(ctx, getURLByShortURL, shortUrl)
 	var i GetURLByShortURLRow
-	err := row.Scan(&i.OriginalUrl, &i.IsDeleted)
+	err := row.Scan(
+		&i.OriginalUrl,
+		&i.IsDeleted,
+		&i.ExpiresAt,
+		&i.MaxClicks,
+		&i.Clicks,
+	)
 	return i, err
 }
@@ -6,13 +6,72 @@ package generated
 
 import (
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiKey struct {
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	ID        string    `db:"id" json:"id"`
+	UserID    string    `db:"user_id" json:"user_id"`
+	Name      string    `db:"name" json:"name"`
+	Prefix    string    `db:"prefix" json:"prefix"`
+	KeyHash   string    `db:"key_hash" json:"key_hash"`
+	Scopes    []string  `db:"scopes" json:"scopes"`
+}
+
+type Click struct {
+	ClickedAt time.Time `db:"clicked_at" json:"clicked_at"`
+	ShortUrl  string    `db:"short_url" json:"short_url"`
+	Referrer  string    `db:"referrer" json:"referrer"`
+	UserAgent string    `db:"user_agent" json:"user_agent"`
+	IpPrefix  string    `db:"ip_prefix" json:"ip_prefix"`
+	ID        int64     `db:"id" json:"id"`
+	Gzip      bool      `db:"gzip" json:"gzip"`
+}
+
+type DeleteJob struct {
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	FinishedAt time.Time `db:"finished_at" json:"finished_at"`
+	JobID      string    `db:"job_id" json:"job_id"`
+	UserID     string    `db:"user_id" json:"user_id"`
+	Operation  string    `db:"operation" json:"operation"`
+	Status     string    `db:"status" json:"status"`
+	ShortUrls  []string  `db:"short_urls" json:"short_urls"`
+	FailedUrls []string  `db:"failed_urls" json:"failed_urls"`
+}
+
+type DeleteQueue struct {
+	CreatedAt     time.Time          `db:"created_at" json:"created_at"`
+	NextAttemptAt time.Time          `db:"next_attempt_at" json:"next_attempt_at"`
+	LeasedUntil   pgtype.Timestamptz `db:"leased_until" json:"leased_until"`
+	DeadAt        pgtype.Timestamptz `db:"dead_at" json:"dead_at"`
+	JobID         string             `db:"job_id" json:"job_id"`
+	UserID        string             `db:"user_id" json:"user_id"`
+	Operation     string             `db:"operation" json:"operation"`
+	LastError     string             `db:"last_error" json:"last_error"`
+	ShortUrls     []string           `db:"short_urls" json:"short_urls"`
+	ID            int64              `db:"id" json:"id"`
+	Attempts      int32              `db:"attempts" json:"attempts"`
+}
+
 type Url struct {
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UserID      string    `db:"user_id" json:"user_id"`
+	CreatedAt   time.Time          `db:"created_at" json:"created_at"`
+	ExpiresAt   pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	DeletedAt   pgtype.Timestamptz `db:"deleted_at" json:"deleted_at"`
+	UserID      string             `db:"user_id" json:"user_id"`
+	ShortUrl    string             `db:"short_url" json:"short_url"`
+	OriginalUrl string             `db:"original_url" json:"original_url"`
+	DedupScope  string             `db:"dedup_scope" json:"dedup_scope"`
+	MaxClicks   int64              `db:"max_clicks" json:"max_clicks"`
+	Clicks      int64              `db:"clicks" json:"clicks"`
+	ID          int32              `db:"id" json:"id"`
+	IsDeleted   bool               `db:"is_deleted" json:"is_deleted"`
+}
+
+type UrlHistory struct {
+	ChangedAt   time.Time `db:"changed_at" json:"changed_at"`
 	ShortUrl    string    `db:"short_url" json:"short_url"`
 	OriginalUrl string    `db:"original_url" json:"original_url"`
-	ID          int32     `db:"id" json:"id"`
-	IsDeleted   bool      `db:"is_deleted" json:"is_deleted"`
+	ID          int64     `db:"id" json:"id"`
 }
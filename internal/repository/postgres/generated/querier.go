@@ -6,14 +6,46 @@ package generated
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AckDeleteTask(ctx context.Context, jobID string) error
+	AddAPIKey(ctx context.Context, arg AddAPIKeyParams) error
+	AddDeleteJob(ctx context.Context, arg AddDeleteJobParams) error
+	AddClicks(ctx context.Context, arg AddClicksParams) (int64, error)
 	AddURL(ctx context.Context, arg AddURLParams) (string, error)
+	AddURLHistory(ctx context.Context, arg AddURLHistoryParams) error
+	BuryDeleteTask(ctx context.Context, arg BuryDeleteTaskParams) error
+	ClaimDeleteTasks(ctx context.Context, arg ClaimDeleteTasksParams) ([]DeleteQueue, error)
+	DeleteAPIKeys(ctx context.Context, arg DeleteAPIKeysParams) ([]string, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
+	GetDailyClicks(ctx context.Context, shortUrl string) ([]GetDailyClicksRow, error)
+	GetDeadDeleteTasks(ctx context.Context, limit int32) ([]DeleteQueue, error)
+	GetDeleteJob(ctx context.Context, jobID string) (DeleteJob, error)
+	GetDeleteTask(ctx context.Context, jobID string) (DeleteQueue, error)
+	GetServiceStats(ctx context.Context) (GetServiceStatsRow, error)
 	GetURLByOriginalURL(ctx context.Context, originalUrl string) (string, error)
 	GetURLByShortURL(ctx context.Context, shortUrl string) (GetURLByShortURLRow, error)
+	GetURLHistory(ctx context.Context, shortUrl string) ([]GetURLHistoryRow, error)
+	GetURLOwner(ctx context.Context, shortUrl string) (string, error)
+	GetURLSequence(ctx context.Context) (int64, error)
 	GetURLsByUserID(ctx context.Context, userID string) ([]Url, error)
-	MarkDeletedBatch(ctx context.Context, arg MarkDeletedBatchParams) error
+	GetUserAPIKeys(ctx context.Context, userID string) ([]ApiKey, error)
+	GetUserURLByOriginalURL(ctx context.Context, arg GetUserURLByOriginalURLParams) (string, error)
+	LockDeleteQueue(ctx context.Context) error
+	LockUserURL(ctx context.Context, arg LockUserURLParams) (LockUserURLRow, error)
+	MarkDeletedBatch(ctx context.Context, arg MarkDeletedBatchParams) ([]string, error)
+	MarkExpiredDeleted(ctx context.Context, now pgtype.Timestamptz) (int64, error)
+	MarkRestoredBatch(ctx context.Context, arg MarkRestoredBatchParams) ([]string, error)
+	PruneDeadDeleteTasks(ctx context.Context, deadBefore pgtype.Timestamptz) error
+	PruneDeleteJobs(ctx context.Context, finishedBefore pgtype.Timestamptz) error
+	PurgeDeleted(ctx context.Context, arg PurgeDeletedParams) (int64, error)
+	PushDeleteTask(ctx context.Context, arg PushDeleteTaskParams) error
+	RetryDeleteTask(ctx context.Context, arg RetryDeleteTaskParams) error
+	UpdateOriginalURL(ctx context.Context, arg UpdateOriginalURLParams) error
+	VisitURL(ctx context.Context, shortUrl string) (string, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,2 @@
+-- name: AckDeleteTask :exec
+DELETE FROM delete_queue WHERE job_id = $1 AND dead_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: AddAPIKey :exec
+INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- name: AddClicks :execrows
+INSERT INTO clicks (short_url, clicked_at, referrer, user_agent, ip_prefix, gzip)
+SELECT c.short_url, c.clicked_at, c.referrer, c.user_agent, c.ip_prefix, c.gzip
+FROM unnest(
+    sqlc.arg(short_urls)::text[],
+    sqlc.arg(clicked_at)::timestamptz[],
+    sqlc.arg(referrers)::text[],
+    sqlc.arg(user_agents)::text[],
+    sqlc.arg(ip_prefixes)::text[],
+    sqlc.arg(gzip)::boolean[]
+) AS c(short_url, clicked_at, referrer, user_agent, ip_prefix, gzip)
+JOIN urls u ON u.short_url = c.short_url
+FOR KEY SHARE OF u;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: AddDeleteJob :exec
+INSERT INTO delete_jobs (job_id, user_id, operation, status, short_urls, failed_urls, created_at, finished_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (job_id) DO NOTHING;
\ No newline at end of file
@@ -1,4 +1,4 @@
 -- name: AddURL :one
-INSERT INTO urls (user_id, short_url, original_url, created_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO urls (user_id, short_url, original_url, created_at, expires_at, max_clicks, dedup_scope)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING short_url;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: AddURLHistory :exec
+INSERT INTO url_history (short_url, original_url, changed_at) VALUES ($1, $2, $3);
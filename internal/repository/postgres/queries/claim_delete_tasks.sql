@@ -0,0 +1,17 @@
+-- name: ClaimDeleteTasks :many
+UPDATE delete_queue
+SET leased_until = sqlc.arg(leased_until)::timestamptz
+WHERE id IN (
+    SELECT q.id FROM delete_queue q
+    WHERE q.dead_at IS NULL
+      AND q.next_attempt_at <= sqlc.arg(now)::timestamptz
+      AND (q.leased_until IS NULL OR q.leased_until <= sqlc.arg(now)::timestamptz)
+      AND NOT EXISTS (
+          SELECT 1 FROM delete_queue e
+          WHERE e.user_id = q.user_id AND e.id < q.id AND e.dead_at IS NULL
+            AND (e.next_attempt_at > sqlc.arg(now)::timestamptz OR e.leased_until > sqlc.arg(now)::timestamptz)
+      )
+    ORDER BY q.id
+    LIMIT sqlc.arg(max_tasks)
+)
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- name: DeleteAPIKeys :many
+DELETE FROM api_keys
+WHERE user_id = sqlc.arg(user_id) AND id = ANY(sqlc.arg(ids)::text[])
+RETURNING id;
\ No newline at end of file
@@ -1,4 +1,5 @@
--- name: MarkDeletedBatch :exec
+-- name: MarkDeletedBatch :many
 UPDATE urls 
-SET is_deleted = true 
-WHERE user_id = $1 AND short_url = ANY($2::text[]);
\ No newline at end of file
+SET is_deleted = true, deleted_at = COALESCE(deleted_at, now()) 
+WHERE user_id = $1 AND short_url = ANY($2::text[])
+RETURNING short_url;
\ No newline at end of file
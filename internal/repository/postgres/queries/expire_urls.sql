@@ -0,0 +1,6 @@
+-- name: MarkExpiredDeleted :execrows
+UPDATE urls
+SET is_deleted = true, deleted_at = sqlc.arg(now)::timestamptz
+WHERE NOT is_deleted
+  AND ((expires_at IS NOT NULL AND expires_at <= sqlc.arg(now)::timestamptz)
+    OR (max_clicks > 0 AND clicks >= max_clicks));
\ No newline at end of file
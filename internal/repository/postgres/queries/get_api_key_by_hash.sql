@@ -0,0 +1,2 @@
+-- name: GetAPIKeyByHash :one
+SELECT * FROM api_keys WHERE key_hash = $1;
\ No newline at end of file
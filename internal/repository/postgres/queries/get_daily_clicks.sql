@@ -0,0 +1,6 @@
+-- name: GetDailyClicks :many
+SELECT (clicked_at AT TIME ZONE 'UTC')::date AS day, count(*) AS clicks
+FROM clicks
+WHERE short_url = $1
+GROUP BY day
+ORDER BY day;
\ No newline at end of file
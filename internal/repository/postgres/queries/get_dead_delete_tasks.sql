@@ -0,0 +1,5 @@
+-- name: GetDeadDeleteTasks :many
+SELECT * FROM delete_queue
+WHERE dead_at IS NOT NULL
+ORDER BY dead_at DESC
+LIMIT $1;
\ No newline at end of file
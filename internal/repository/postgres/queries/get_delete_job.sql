@@ -0,0 +1,2 @@
+-- name: GetDeleteJob :one
+SELECT * FROM delete_jobs WHERE job_id = $1;
\ No newline at end of file
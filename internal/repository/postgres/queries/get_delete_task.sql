@@ -0,0 +1,2 @@
+-- name: GetDeleteTask :one
+SELECT * FROM delete_queue WHERE job_id = $1;
\ No newline at end of file
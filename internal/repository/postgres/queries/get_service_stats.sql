@@ -0,0 +1,2 @@
+-- name: GetServiceStats :one
+SELECT count(*) AS urls, count(DISTINCT user_id) AS users FROM urls;
@@ -1,3 +1,3 @@
 -- name: GetURLByOriginalURL :one
-SELECT short_url FROM urls WHERE original_url = $1
+SELECT short_url FROM urls WHERE original_url = $1 AND dedup_scope = 'global'
 LIMIT 1;
\ No newline at end of file
@@ -1,3 +1,3 @@
 -- name: GetURLByShortURL :one
-SELECT original_url, is_deleted FROM urls WHERE short_url = $1
+SELECT original_url, is_deleted, expires_at, max_clicks, clicks FROM urls WHERE short_url = $1
 LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: GetURLHistory :many
+SELECT original_url, changed_at FROM url_history WHERE short_url = $1
+ORDER BY id;
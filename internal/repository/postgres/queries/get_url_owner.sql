@@ -0,0 +1,3 @@
+-- name: GetURLOwner :one
+SELECT user_id FROM urls WHERE short_url = $1
+LIMIT 1;
\ No newline at end of file
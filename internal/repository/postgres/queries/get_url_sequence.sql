@@ -0,0 +1,2 @@
+-- name: GetURLSequence :one
+SELECT COALESCE(pg_sequence_last_value('urls_id_seq'), 0)::bigint AS sequence;
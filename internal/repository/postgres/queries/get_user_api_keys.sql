@@ -0,0 +1,3 @@
+-- name: GetUserAPIKeys :many
+SELECT * FROM api_keys WHERE user_id = $1
+ORDER BY created_at, id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: GetUserURLByOriginalURL :one
+SELECT short_url FROM urls WHERE user_id = $1 AND original_url = $2 AND dedup_scope <> 'none'
+LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- name: LockDeleteQueue :exec
+SELECT pg_advisory_xact_lock(hashtext('delete_queue'));
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- name: LockUserURL :one
+SELECT original_url, is_deleted FROM urls WHERE short_url = $1 AND user_id = $2
+FOR UPDATE;
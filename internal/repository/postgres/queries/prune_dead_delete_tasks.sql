@@ -0,0 +1,2 @@
+-- name: PruneDeadDeleteTasks :exec
+DELETE FROM delete_queue WHERE dead_at < sqlc.arg(dead_before)::timestamptz;
\ No newline at end of file
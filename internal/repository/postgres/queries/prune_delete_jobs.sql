@@ -0,0 +1,2 @@
+-- name: PruneDeleteJobs :exec
+DELETE FROM delete_jobs WHERE finished_at < sqlc.arg(finished_before)::timestamptz;
\ No newline at end of file
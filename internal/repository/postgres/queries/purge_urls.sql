@@ -0,0 +1,8 @@
+-- name: PurgeDeleted :execrows
+DELETE FROM urls
+WHERE id IN (
+    SELECT id FROM urls
+    WHERE is_deleted AND deleted_at < sqlc.arg(deleted_before)::timestamptz
+    ORDER BY deleted_at
+    LIMIT sqlc.arg(batch_size)
+);
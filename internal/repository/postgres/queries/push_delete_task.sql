@@ -0,0 +1,3 @@
+-- name: PushDeleteTask :exec
+INSERT INTO delete_queue (job_id, user_id, operation, short_urls, created_at, attempts, next_attempt_at, last_error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
\ No newline at end of file
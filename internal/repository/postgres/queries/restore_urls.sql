@@ -0,0 +1,5 @@
+-- name: MarkRestoredBatch :many
+UPDATE urls
+SET is_deleted = false, deleted_at = NULL
+WHERE user_id = $1 AND short_url = ANY($2::text[]) AND is_deleted
+RETURNING short_url;
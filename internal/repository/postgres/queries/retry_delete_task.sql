@@ -0,0 +1,4 @@
+-- name: RetryDeleteTask :exec
+UPDATE delete_queue
+SET attempts = $2, next_attempt_at = $3, last_error = $4, leased_until = NULL
+WHERE job_id = $1 AND dead_at IS NULL;
\ No newline at end of file
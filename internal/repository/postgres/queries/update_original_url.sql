@@ -0,0 +1,2 @@
+-- name: UpdateOriginalURL :exec
+UPDATE urls SET original_url = $2 WHERE short_url = $1;
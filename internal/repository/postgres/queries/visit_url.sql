@@ -0,0 +1,8 @@
+-- name: VisitURL :one
+UPDATE urls
+SET clicks = clicks + 1
+WHERE short_url = $1
+  AND NOT is_deleted
+  AND (expires_at IS NULL OR expires_at > now())
+  AND (max_clicks = 0 OR clicks < max_clicks)
+RETURNING original_url;
\ No newline at end of file
@@ -2,8 +2,15 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"math"
 	"time"
 
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
 	"github.com/AGENT3128/shortener-url/internal/entity"
 	"github.com/AGENT3128/shortener-url/internal/repository/postgres/generated"
 
@@ -14,41 +21,115 @@ import (
 
 //go:generate sqlc generate
 
+// shortURLConstraint is the unique constraint of the short_url column.
+const shortURLConstraint = "urls_short_url_key"
+
 // URLRepository is the repository for the URL.
 type URLRepository struct {
-	db      *database.Database
-	logger  *zap.Logger
-	queries *generated.Queries
+	db         *database.Database
+	logger     *zap.Logger
+	queries    *generated.Queries
+	dedupScope entity.DedupScope
+}
+
+// Option is the option for the URLRepository.
+type Option func(r *URLRepository)
+
+// WithDedupScope is the option for the URLRepository to set the scope within which
+// an original URL is shortened only once. Original URLs are deduplicated globally by default.
+func WithDedupScope(scope entity.DedupScope) Option {
+	return func(r *URLRepository) {
+		r.dedupScope = scope
+	}
 }
 
 // NewURLRepository creates a new URLRepository.
-func NewURLRepository(db *database.Database, logger *zap.Logger) *URLRepository {
-	return &URLRepository{
-		db:      db,
-		logger:  logger.With(zap.String("repository", "url")),
-		queries: generated.New(db.Pool),
+func NewURLRepository(db *database.Database, logger *zap.Logger, opts ...Option) *URLRepository {
+	r := &URLRepository{
+		db:         db,
+		logger:     logger.With(zap.String("repository", "url")),
+		queries:    generated.New(db.Pool),
+		dedupScope: entity.DedupGlobal,
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
 }
 
 // Add adds a URL.
-func (r *URLRepository) Add(ctx context.Context, userID, shortURL, originalURL string) (string, error) {
+func (r *URLRepository) Add(ctx context.Context, userID string, url entity.URL) (string, error) {
 	shortURL, err := r.queries.AddURL(ctx, generated.AddURLParams{
 		UserID:      userID,
-		ShortUrl:    shortURL,
-		OriginalUrl: originalURL,
+		ShortUrl:    url.ShortURL,
+		OriginalUrl: url.OriginalURL,
 		CreatedAt:   time.Now(),
+		ExpiresAt:   toTimestamptz(url.ExpiresAt),
+		MaxClicks:   url.MaxClicks,
+		DedupScope:  string(r.dedupScope),
 	})
 	if err != nil {
-		return shortURL, err
+		if !isUniqueViolation(err) {
+			return "", err
+		}
+		if isShortURLViolation(err) {
+			return "", errors.Join(entity.ErrShortURLTaken, err)
+		}
+		existing, errGet := r.GetByOriginalURL(ctx, userID, url.OriginalURL)
+		if errGet != nil {
+			return "", errors.Join(err, errGet)
+		}
+		return existing, &entity.URLExistsError{ShortURL: existing}
 	}
 	return shortURL, nil
 }
 
-// GetByOriginalURL gets the short URL by the original URL.
-func (r *URLRepository) GetByOriginalURL(ctx context.Context, originalURL string) (string, error) {
-	shortURL, err := r.queries.GetURLByOriginalURL(ctx, originalURL)
+// toTimestamptz converts the time to a nullable timestamp, the zero time being NULL.
+func toTimestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: !t.IsZero()}
+}
+
+// translateNotFound maps the pgx no rows error to entity.ErrURLNotFound.
+func translateNotFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entity.ErrURLNotFound
+	}
+	return err
+}
+
+// isUniqueViolation reports whether the error is a Postgres unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation
+}
+
+// isShortURLViolation reports whether the error is a violation of the short URL unique constraint.
+func isShortURLViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) &&
+		pgErr.Code == pgerrcode.UniqueViolation &&
+		pgErr.ConstraintName == shortURLConstraint
+}
+
+// GetByOriginalURL gets the short URL the user's original URL is deduplicated to.
+func (r *URLRepository) GetByOriginalURL(ctx context.Context, userID, originalURL string) (string, error) {
+	var (
+		shortURL string
+		err      error
+	)
+	switch r.dedupScope {
+	case entity.DedupNone:
+		return "", entity.ErrURLNotFound
+	case entity.DedupUser:
+		shortURL, err = r.queries.GetUserURLByOriginalURL(ctx, generated.GetUserURLByOriginalURLParams{
+			UserID:      userID,
+			OriginalUrl: originalURL,
+		})
+	default:
+		shortURL, err = r.queries.GetURLByOriginalURL(ctx, originalURL)
+	}
 	if err != nil {
-		return "", err
+		return "", translateNotFound(err)
 	}
 	r.logger.Info("original URL found", zap.String("original_url", originalURL), zap.String("short_url", shortURL))
 	return shortURL, nil
@@ -58,7 +139,7 @@ func (r *URLRepository) GetByOriginalURL(ctx context.Context, originalURL string
 func (r *URLRepository) GetByShortURL(ctx context.Context, shortURL string) (string, error) {
 	row, err := r.queries.GetURLByShortURL(ctx, shortURL)
 	if err != nil {
-		return "", err
+		return "", translateNotFound(err)
 	}
 	if row.IsDeleted {
 		return row.OriginalUrl, entity.ErrURLDeleted
@@ -66,11 +147,56 @@ func (r *URLRepository) GetByShortURL(ctx context.Context, shortURL string) (str
 	return row.OriginalUrl, nil
 }
 
+// Visit gets the original URL by the short URL and counts the click of a URL limited by MaxClicks.
+// A URL that has expired or used up its clicks is reported as entity.ErrURLExpired.
+// Unlimited URLs are served by a plain SELECT and never updated.
+func (r *URLRepository) Visit(ctx context.Context, shortURL string) (string, error) {
+	row, err := r.visitable(ctx, shortURL)
+	if err != nil || row.MaxClicks == 0 {
+		return row.OriginalUrl, err
+	}
+
+	originalURL, err := r.queries.VisitURL(ctx, shortURL)
+	if err == nil {
+		return originalURL, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+	// the URL was not updated: it was deleted or used up its clicks in the meantime
+	if _, errVisitable := r.visitable(ctx, shortURL); errVisitable != nil {
+		return "", errVisitable
+	}
+	return "", entity.ErrURLExpired
+}
+
+// visitable returns the URL if it can be redirected to.
+func (r *URLRepository) visitable(ctx context.Context, shortURL string) (generated.GetURLByShortURLRow, error) {
+	row, err := r.queries.GetURLByShortURL(ctx, shortURL)
+	if err != nil {
+		return generated.GetURLByShortURLRow{}, translateNotFound(err)
+	}
+	url := entity.URL{ExpiresAt: row.ExpiresAt.Time, MaxClicks: row.MaxClicks, Clicks: row.Clicks}
+	switch {
+	case row.IsDeleted:
+		return generated.GetURLByShortURLRow{}, entity.ErrURLDeleted
+	case url.Expired(time.Now()):
+		return generated.GetURLByShortURLRow{}, entity.ErrURLExpired
+	}
+	return row, nil
+}
+
 // Ping pings the database.
 func (r *URLRepository) Ping(ctx context.Context) error {
 	return r.db.Pool.Ping(ctx)
 }
 
+// GetURLSequence returns the last value of the URL id sequence, which covers the purged URLs
+// and the inserts rolled back by a conflict.
+func (r *URLRepository) GetURLSequence(ctx context.Context) (int64, error) {
+	return r.queries.GetURLSequence(ctx)
+}
+
 // AddBatch adds a batch of URLs.
 func (r *URLRepository) AddBatch(ctx context.Context, userID string, urls []entity.URL) error {
 	tx, err := r.db.Pool.Begin(ctx)
@@ -92,8 +218,17 @@ func (r *URLRepository) AddBatch(ctx context.Context, userID string, urls []enti
 			ShortUrl:    url.ShortURL,
 			OriginalUrl: url.OriginalURL,
 			CreatedAt:   now,
+			ExpiresAt:   toTimestamptz(url.ExpiresAt),
+			MaxClicks:   url.MaxClicks,
+			DedupScope:  string(r.dedupScope),
 		})
 		if errAdd != nil {
+			if isShortURLViolation(errAdd) {
+				return errors.Join(entity.ErrShortURLTaken, errAdd)
+			}
+			if isUniqueViolation(errAdd) {
+				return errors.Join(entity.ErrURLExists, errAdd)
+			}
 			return errAdd
 		}
 	}
@@ -110,26 +245,264 @@ func (r *URLRepository) GetUserURLs(ctx context.Context, userID string) ([]entit
 	result := make([]entity.URL, 0, len(urls))
 	for _, url := range urls {
 		result = append(result, entity.URL{
+			CreatedAt:   url.CreatedAt,
+			ExpiresAt:   url.ExpiresAt.Time,
+			DeletedAt:   url.DeletedAt.Time,
 			ShortURL:    url.ShortUrl,
 			OriginalURL: url.OriginalUrl,
+			UserID:      url.UserID,
+			MaxClicks:   url.MaxClicks,
+			Clicks:      url.Clicks,
+			DeletedFlag: url.IsDeleted,
 		})
 	}
 	return result, nil
 }
 
-// MarkDeletedBatch marks a batch of URLs as deleted.
-func (r *URLRepository) MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) error {
-	err := r.queries.MarkDeletedBatch(ctx, generated.MarkDeletedBatchParams{
+// MarkDeletedBatch marks a batch of the user's URLs as deleted and returns the short URLs it marked.
+func (r *URLRepository) MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error) {
+	marked, err := r.queries.MarkDeletedBatch(ctx, generated.MarkDeletedBatchParams{
 		UserID:  userID,
 		Column2: shortURLs,
 	})
 	r.logger.Info("marked deleted batch", zap.String("userID", userID), zap.Any("shortURLs", shortURLs))
+	if err != nil {
+		return nil, err
+	}
+	return marked, nil
+}
+
+// MarkRestoredBatch clears the deleted mark of a batch of the user's URLs and returns the short URLs it cleared.
+func (r *URLRepository) MarkRestoredBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error) {
+	restored, err := r.queries.MarkRestoredBatch(ctx, generated.MarkRestoredBatchParams{
+		UserID:  userID,
+		Column2: shortURLs,
+	})
+	r.logger.Info("marked restored batch", zap.String("userID", userID), zap.Any("shortURLs", shortURLs))
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// UpdateOriginalURL points the user's short URL to the original URL and returns its previous destinations.
+// An original URL shortened by another short URL is reported as entity.URLExistsError.
+func (r *URLRepository) UpdateOriginalURL(
+	ctx context.Context,
+	userID, shortURL, originalURL string,
+) ([]entity.URLChange, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errRollback := tx.Rollback(ctx); errRollback != nil && !errors.Is(errRollback, pgx.ErrTxClosed) {
+			r.logger.Error("failed to rollback transaction", zap.Error(errRollback))
+		}
+	}()
+
+	qtx := r.queries.WithTx(tx)
+	row, err := qtx.LockUserURL(ctx, generated.LockUserURLParams{ShortUrl: shortURL, UserID: userID})
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	if row.IsDeleted {
+		return nil, entity.ErrURLDeleted
+	}
+	if row.OriginalUrl != originalURL {
+		errUpdate := qtx.UpdateOriginalURL(ctx, generated.UpdateOriginalURLParams{
+			ShortUrl:    shortURL,
+			OriginalUrl: originalURL,
+		})
+		if errUpdate != nil {
+			if !isUniqueViolation(errUpdate) {
+				return nil, errUpdate
+			}
+			// the transaction is aborted, the existing short URL is read outside of it
+			existing, errGet := r.GetByOriginalURL(ctx, userID, originalURL)
+			if errGet != nil {
+				return nil, errors.Join(errUpdate, errGet)
+			}
+			return nil, &entity.URLExistsError{ShortURL: existing}
+		}
+		errHistory := qtx.AddURLHistory(ctx, generated.AddURLHistoryParams{
+			ChangedAt:   time.Now(),
+			ShortUrl:    shortURL,
+			OriginalUrl: row.OriginalUrl,
+		})
+		if errHistory != nil {
+			return nil, errHistory
+		}
+	}
+
+	rows, err := qtx.GetURLHistory(ctx, shortURL)
+	if err != nil {
+		return nil, err
+	}
+	if errCommit := tx.Commit(ctx); errCommit != nil {
+		return nil, errCommit
+	}
+	history := make([]entity.URLChange, 0, len(rows))
+	for _, change := range rows {
+		history = append(history, entity.URLChange{ChangedAt: change.ChangedAt, OriginalURL: change.OriginalUrl})
+	}
+	return history, nil
+}
+
+// MarkExpiredDeleted marks the URLs expired at the moment now as deleted and returns their number.
+func (r *URLRepository) MarkExpiredDeleted(ctx context.Context, now time.Time) (int64, error) {
+	count, err := r.queries.MarkExpiredDeleted(ctx, toTimestamptz(now))
+	if err != nil {
+		return 0, err
+	}
+	r.logger.Info("marked expired urls deleted", zap.Int64("count", count))
+	return count, nil
+}
+
+// PurgeDeleted permanently removes at most limit URLs deleted before deletedBefore and returns their number.
+// Their clicks and history are removed by the foreign keys.
+func (r *URLRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	count, err := r.queries.PurgeDeleted(ctx, generated.PurgeDeletedParams{
+		DeletedBefore: toTimestamptz(deletedBefore),
+		BatchSize:     int32(min(limit, math.MaxInt32)), //nolint:gosec // clamped to the int32 range
+	})
+	if err != nil {
+		return 0, err
+	}
+	r.logger.Info("purged deleted urls", zap.Int64("count", count))
+	return count, nil
+}
+
+// AddClicks stores the click events. Clicks of unknown short URLs, purged in the meantime, are dropped.
+func (r *URLRepository) AddClicks(ctx context.Context, clicks []entity.Click) error {
+	params := generated.AddClicksParams{
+		ShortUrls:  make([]string, 0, len(clicks)),
+		ClickedAt:  make([]pgtype.Timestamptz, 0, len(clicks)),
+		Referrers:  make([]string, 0, len(clicks)),
+		UserAgents: make([]string, 0, len(clicks)),
+		IpPrefixes: make([]string, 0, len(clicks)),
+		Gzip:       make([]bool, 0, len(clicks)),
+	}
+	for _, click := range clicks {
+		params.ShortUrls = append(params.ShortUrls, click.ShortURL)
+		params.ClickedAt = append(params.ClickedAt, pgtype.Timestamptz{Time: click.Time, Valid: true})
+		params.Referrers = append(params.Referrers, click.Referrer)
+		params.UserAgents = append(params.UserAgents, click.UserAgent)
+		params.IpPrefixes = append(params.IpPrefixes, click.IPPrefix)
+		params.Gzip = append(params.Gzip, click.Gzip)
+	}
+	saved, err := r.queries.AddClicks(ctx, params)
 	if err != nil {
 		return err
 	}
+	if dropped := int64(len(clicks)) - saved; dropped > 0 {
+		r.logger.Info("dropped clicks of unknown short URLs", zap.Int64("dropped", dropped))
+	}
 	return nil
 }
 
+// GetClickStats gets the click statistics of the short URL owned by the user.
+func (r *URLRepository) GetClickStats(ctx context.Context, userID, shortURL string) (entity.URLStats, error) {
+	owner, err := r.queries.GetURLOwner(ctx, shortURL)
+	if err != nil {
+		return entity.URLStats{}, translateNotFound(err)
+	}
+	if owner != userID {
+		return entity.URLStats{}, entity.ErrURLNotFound
+	}
+
+	rows, err := r.queries.GetDailyClicks(ctx, shortURL)
+	if err != nil {
+		return entity.URLStats{}, err
+	}
+	stats := entity.URLStats{
+		ShortURL: shortURL,
+		Daily:    make([]entity.DailyClicks, 0, len(rows)),
+	}
+	for _, row := range rows {
+		stats.Daily = append(stats.Daily, entity.DailyClicks{Date: row.Day.Time, Clicks: row.Clicks})
+		stats.Total += row.Clicks
+	}
+	return stats, nil
+}
+
+// GetServiceStats counts the stored short URLs and their distinct owners.
+func (r *URLRepository) GetServiceStats(ctx context.Context) (entity.ServiceStats, error) {
+	row, err := r.queries.GetServiceStats(ctx)
+	if err != nil {
+		return entity.ServiceStats{}, err
+	}
+	return entity.ServiceStats{URLs: row.Urls, Users: row.Users}, nil
+}
+
+// AddAPIKey stores the API key.
+func (r *URLRepository) AddAPIKey(ctx context.Context, key entity.APIKey) error {
+	scopes := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	return r.queries.AddAPIKey(ctx, generated.AddAPIKeyParams{
+		CreatedAt: key.CreatedAt,
+		ID:        key.ID,
+		UserID:    key.UserID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   key.Hash,
+		Scopes:    scopes,
+	})
+}
+
+// GetAPIKeyByHash gets the API key by its hash.
+func (r *URLRepository) GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error) {
+	row, err := r.queries.GetAPIKeyByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.APIKey{}, entity.ErrAPIKeyNotFound
+		}
+		return entity.APIKey{}, err
+	}
+	return toAPIKey(row), nil
+}
+
+// GetUserAPIKeys gets the API keys of the user, the oldest first.
+func (r *URLRepository) GetUserAPIKeys(ctx context.Context, userID string) ([]entity.APIKey, error) {
+	rows, err := r.queries.GetUserAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]entity.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, toAPIKey(row))
+	}
+	return keys, nil
+}
+
+// DeleteAPIKeys deletes the user's API keys and returns the IDs of the deleted ones.
+func (r *URLRepository) DeleteAPIKeys(ctx context.Context, userID string, ids []string) ([]string, error) {
+	deleted, err := r.queries.DeleteAPIKeys(ctx, generated.DeleteAPIKeysParams{UserID: userID, Ids: ids})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// toAPIKey converts the stored row to the entity.
+func toAPIKey(row generated.ApiKey) entity.APIKey {
+	scopes := make([]entity.APIKeyScope, 0, len(row.Scopes))
+	for _, scope := range row.Scopes {
+		scopes = append(scopes, entity.APIKeyScope(scope))
+	}
+	return entity.APIKey{
+		CreatedAt: row.CreatedAt,
+		ID:        row.ID,
+		UserID:    row.UserID,
+		Name:      row.Name,
+		Prefix:    row.Prefix,
+		Hash:      row.KeyHash,
+		Scopes:    scopes,
+	}
+}
+
 // Close closes the repository.
 func (r *URLRepository) Close() error {
 	r.db.Pool.Close()
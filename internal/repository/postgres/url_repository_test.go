@@ -0,0 +1,41 @@
+package postgres_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/repository/postgres"
+	"github.com/AGENT3128/shortener-url/internal/repository/repositorytest"
+	"github.com/AGENT3128/shortener-url/internal/usecase"
+	"github.com/AGENT3128/shortener-url/internal/worker"
+	"github.com/AGENT3128/shortener-url/pkg/database"
+)
+
+const testMaxConns = 4
+
+// TestURLRepository_Contract runs against the database from TEST_DATABASE_DSN with the migrations applied.
+// The tests only add rows with unique URLs, so they are safe to run against a shared database.
+func TestURLRepository_Contract(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := database.New(t.Context(), dsn, database.WithMaxConns(testMaxConns))
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	repositorytest.Run(t, func(_ *testing.T) usecase.URLRepository {
+		return postgres.NewURLRepository(db, zap.NewNop())
+	})
+	repositorytest.RunDedupScopes(t, func(_ *testing.T, scope entity.DedupScope) usecase.URLRepository {
+		return postgres.NewURLRepository(db, zap.NewNop(), postgres.WithDedupScope(scope))
+	})
+	repositorytest.RunDeleteQueue(t, func(_ *testing.T) worker.DeleteQueue {
+		return postgres.NewDeleteQueue(db, zap.NewNop())
+	})
+}
@@ -0,0 +1,865 @@
+// Package repositorytest holds the behaviour every URL repository must share.
+// Each repository package runs Run against its own storage so that the usecase
+// and the HTTP API behave the same whatever storage backs them.
+package repositorytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi"
+	"github.com/AGENT3128/shortener-url/internal/controller/httpapi/middleware"
+	"github.com/AGENT3128/shortener-url/internal/dto"
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/usecase"
+)
+
+const baseURL = "http://localhost:8080"
+
+// Run runs the shared repository tests against the repositories built by newRepository.
+// Every test uses unique URLs, so the repository may be backed by a database that is not emptied between runs.
+func Run(t *testing.T, newRepository func(t *testing.T) usecase.URLRepository) {
+	t.Helper()
+
+	t.Run("not found", func(t *testing.T) {
+		repo := newRepository(t)
+
+		_, err := repo.GetByShortURL(t.Context(), uniqueShortURL())
+		require.ErrorIs(t, err, entity.ErrURLNotFound)
+		_, err = repo.GetByOriginalURL(t.Context(), uuid.NewString(), uniqueOriginalURL())
+		require.ErrorIs(t, err, entity.ErrURLNotFound)
+	})
+
+	t.Run("duplicate original url", func(t *testing.T) {
+		repo := newRepository(t)
+		originalURL := uniqueOriginalURL()
+
+		shortURL, err := repo.Add(t.Context(), uuid.NewString(), entity.URL{
+			ShortURL:    uniqueShortURL(),
+			OriginalURL: originalURL,
+		})
+		require.NoError(t, err)
+
+		existing, err := repo.Add(t.Context(), uuid.NewString(), entity.URL{
+			ShortURL:    uniqueShortURL(),
+			OriginalURL: originalURL,
+		})
+		var existsErr *entity.URLExistsError
+		require.ErrorAs(t, err, &existsErr)
+		require.ErrorIs(t, err, entity.ErrURLExists)
+		assert.Equal(t, shortURL, existing)
+		assert.Equal(t, shortURL, existsErr.ShortURL)
+	})
+
+	t.Run("taken short url", func(t *testing.T) {
+		repo := newRepository(t)
+		shortURL := uniqueShortURL()
+		originalURL := uniqueOriginalURL()
+
+		_, err := repo.Add(t.Context(), uuid.NewString(), entity.URL{ShortURL: shortURL, OriginalURL: originalURL})
+		require.NoError(t, err)
+
+		_, err = repo.Add(t.Context(), uuid.NewString(), entity.URL{
+			ShortURL:    shortURL,
+			OriginalURL: uniqueOriginalURL(),
+		})
+		require.ErrorIs(t, err, entity.ErrShortURLTaken)
+
+		// the batch is rejected as a whole
+		userID := uuid.NewString()
+		stored := uniqueOriginalURL()
+		err = repo.AddBatch(t.Context(), userID, []entity.URL{
+			{ShortURL: uniqueShortURL(), OriginalURL: stored},
+			{ShortURL: shortURL, OriginalURL: uniqueOriginalURL()},
+		})
+		require.ErrorIs(t, err, entity.ErrShortURLTaken)
+		_, err = repo.GetByOriginalURL(t.Context(), userID, stored)
+		require.ErrorIs(t, err, entity.ErrURLNotFound)
+
+		got, err := repo.GetByShortURL(t.Context(), shortURL)
+		require.NoError(t, err)
+		assert.Equal(t, originalURL, got)
+	})
+
+	t.Run("user urls", func(t *testing.T) {
+		repo := newRepository(t)
+		userID := uuid.NewString()
+		shortURL := uniqueShortURL()
+		originalURL := uniqueOriginalURL()
+		before := time.Now().Add(-time.Minute)
+
+		_, err := repo.Add(t.Context(), userID, entity.URL{ShortURL: shortURL, OriginalURL: originalURL})
+		require.NoError(t, err)
+		_, err = repo.Add(t.Context(), uuid.NewString(), entity.URL{ShortURL: uniqueShortURL(), OriginalURL: uniqueOriginalURL()})
+		require.NoError(t, err)
+
+		urls, err := repo.GetUserURLs(t.Context(), userID)
+		require.NoError(t, err)
+		require.Len(t, urls, 1)
+		assert.Equal(t, shortURL, urls[0].ShortURL)
+		assert.Equal(t, originalURL, urls[0].OriginalURL)
+		assert.Equal(t, userID, urls[0].UserID)
+		assert.True(t, urls[0].CreatedAt.After(before), urls[0].CreatedAt)
+	})
+
+	t.Run("mark deleted", func(t *testing.T) {
+		repo := newRepository(t)
+		userID := uuid.NewString()
+		shortURL := uniqueShortURL()
+
+		_, err := repo.Add(t.Context(), userID, entity.URL{ShortURL: shortURL, OriginalURL: uniqueOriginalURL()})
+		require.NoError(t, err)
+		foreign := uniqueShortURL()
+		_, err = repo.Add(t.Context(), uuid.NewString(), entity.URL{
+			ShortURL:    foreign,
+			OriginalURL: uniqueOriginalURL(),
+		})
+		require.NoError(t, err)
+
+		// only the short URLs of the user are marked
+		marked, err := repo.MarkDeletedBatch(t.Context(), userID, []string{shortURL, foreign, uniqueShortURL()})
+		require.NoError(t, err)
+		assert.Equal(t, []string{shortURL}, marked)
+
+		_, err = repo.GetByShortURL(t.Context(), shortURL)
+		require.ErrorIs(t, err, entity.ErrURLDeleted)
+		_, err = repo.GetByShortURL(t.Context(), foreign)
+		require.NoError(t, err)
+	})
+
+	t.Run("url sequence", func(t *testing.T) {
+		testURLSequence(t, newRepository(t))
+	})
+
+	t.Run("mark restored", func(t *testing.T) {
+		repo := newRepository(t)
+		userID := uuid.NewString()
+		shortURL := uniqueShortURL()
+		originalURL := uniqueOriginalURL()
+
+		_, err := repo.Add(t.Context(), userID, entity.URL{ShortURL: shortURL, OriginalURL: originalURL})
+		require.NoError(t, err)
+
+		// a URL that is not deleted is not restored
+		restored, err := repo.MarkRestoredBatch(t.Context(), userID, []string{shortURL})
+		require.NoError(t, err)
+		assert.Empty(t, restored)
+
+		_, err = repo.MarkDeletedBatch(t.Context(), userID, []string{shortURL})
+		require.NoError(t, err)
+
+		urls, err := repo.GetUserURLs(t.Context(), userID)
+		require.NoError(t, err)
+		require.Len(t, urls, 1)
+		assert.True(t, urls[0].DeletedFlag)
+
+		// only the owner restores the URL
+		restored, err = repo.MarkRestoredBatch(t.Context(), uuid.NewString(), []string{shortURL})
+		require.NoError(t, err)
+		assert.Empty(t, restored)
+		_, err = repo.GetByShortURL(t.Context(), shortURL)
+		require.ErrorIs(t, err, entity.ErrURLDeleted)
+
+		restored, err = repo.MarkRestoredBatch(t.Context(), userID, []string{shortURL, uniqueShortURL()})
+		require.NoError(t, err)
+		assert.Equal(t, []string{shortURL}, restored)
+		got, err := repo.GetByShortURL(t.Context(), shortURL)
+		require.NoError(t, err)
+		assert.Equal(t, originalURL, got)
+
+		urls, err = repo.GetUserURLs(t.Context(), userID)
+		require.NoError(t, err)
+		require.Len(t, urls, 1)
+		assert.False(t, urls[0].DeletedFlag)
+
+		// restoring it again reports nothing restored
+		restored, err = repo.MarkRestoredBatch(t.Context(), userID, []string{shortURL})
+		require.NoError(t, err)
+		assert.Empty(t, restored)
+	})
+
+	t.Run("max clicks", func(t *testing.T) {
+		repo := newRepository(t)
+		shortURL := uniqueShortURL()
+		originalURL := uniqueOriginalURL()
+
+		_, err := repo.Add(t.Context(), uuid.NewString(), entity.URL{
+			ShortURL:    shortURL,
+			OriginalURL: originalURL,
+			MaxClicks:   2,
+		})
+		require.NoError(t, err)
+
+		for range 2 {
+			got, errVisit := repo.Visit(t.Context(), shortURL)
+			require.NoError(t, errVisit)
+			assert.Equal(t, originalURL, got)
+		}
+		_, err = repo.Visit(t.Context(), shortURL)
+		require.ErrorIs(t, err, entity.ErrURLExpired)
+		_, err = repo.Visit(t.Context(), uniqueShortURL())
+		require.ErrorIs(t, err, entity.ErrURLNotFound)
+
+		count, err := repo.MarkExpiredDeleted(t.Context(), time.Now())
+		require.NoError(t, err)
+		assert.Positive(t, count)
+		_, err = repo.Visit(t.Context(), shortURL)
+		require.ErrorIs(t, err, entity.ErrURLDeleted)
+	})
+
+	t.Run("unlimited clicks are not counted", func(t *testing.T) {
+		repo := newRepository(t)
+		userID := uuid.NewString()
+		shortURL := uniqueShortURL()
+
+		_, err := repo.Add(t.Context(), userID, entity.URL{ShortURL: shortURL, OriginalURL: uniqueOriginalURL()})
+		require.NoError(t, err)
+		for range 3 {
+			_, errVisit := repo.Visit(t.Context(), shortURL)
+			require.NoError(t, errVisit)
+		}
+
+		urls, err := repo.GetUserURLs(t.Context(), userID)
+		require.NoError(t, err)
+		require.Len(t, urls, 1)
+		assert.Zero(t, urls[0].Clicks)
+	})
+
+	t.Run("expires at", func(t *testing.T) {
+		repo := newRepository(t)
+		shortURL := uniqueShortURL()
+		expiresAt := time.Now().Add(time.Hour)
+
+		_, err := repo.Add(t.Context(), uuid.NewString(), entity.URL{
+			ExpiresAt:   expiresAt,
+			ShortURL:    shortURL,
+			OriginalURL: uniqueOriginalURL(),
+		})
+		require.NoError(t, err)
+		_, err = repo.Visit(t.Context(), shortURL)
+		require.NoError(t, err)
+
+		_, err = repo.MarkExpiredDeleted(t.Context(), expiresAt.Add(-time.Minute))
+		require.NoError(t, err)
+		_, err = repo.GetByShortURL(t.Context(), shortURL)
+		require.NoError(t, err)
+
+		count, err := repo.MarkExpiredDeleted(t.Context(), expiresAt)
+		require.NoError(t, err)
+		assert.Positive(t, count)
+		_, err = repo.GetByShortURL(t.Context(), shortURL)
+		require.ErrorIs(t, err, entity.ErrURLDeleted)
+	})
+
+	t.Run("click stats", func(t *testing.T) {
+		testClickStats(t, newRepository(t))
+	})
+
+	t.Run("update original url", func(t *testing.T) {
+		testUpdateOriginalURL(t, newRepository(t))
+	})
+
+	t.Run("api keys", func(t *testing.T) {
+		testAPIKeys(t, newRepository(t))
+	})
+
+	t.Run("service stats", func(t *testing.T) {
+		testServiceStats(t, newRepository(t))
+	})
+
+	t.Run("purge deleted", func(t *testing.T) {
+		testPurgeDeleted(t, newRepository(t))
+	})
+
+	t.Run("redirect expiring link", func(t *testing.T) {
+		testRedirectExpiring(t, newRepository(t))
+	})
+
+	t.Run("shorten batch", func(t *testing.T) {
+		testShortenBatch(t, newRepository(t))
+	})
+
+	t.Run("shorten with alias", func(t *testing.T) {
+		testShortenAlias(t, newRepository(t))
+	})
+
+	t.Run("api key requests", func(t *testing.T) {
+		testAPIKeyRequests(t, newRepository(t))
+	})
+}
+
+// RunDedupScopes runs the tests of original URL deduplication against the repositories
+// built by newRepository for the given scope.
+func RunDedupScopes(t *testing.T, newRepository func(t *testing.T, scope entity.DedupScope) usecase.URLRepository) {
+	t.Helper()
+
+	t.Run("global", func(t *testing.T) {
+		repo := newRepository(t, entity.DedupGlobal)
+		originalURL := uniqueOriginalURL()
+
+		shortURL, err := repo.Add(t.Context(), uuid.NewString(), entity.URL{
+			ShortURL:    uniqueShortURL(),
+			OriginalURL: originalURL,
+		})
+		require.NoError(t, err)
+		got, err := repo.GetByOriginalURL(t.Context(), uuid.NewString(), originalURL)
+		require.NoError(t, err)
+		assert.Equal(t, shortURL, got)
+	})
+
+	t.Run("user", func(t *testing.T) {
+		testDedupUser(t, newRepository(t, entity.DedupUser))
+	})
+
+	t.Run("none", func(t *testing.T) {
+		repo := newRepository(t, entity.DedupNone)
+		userID := uuid.NewString()
+		originalURL := uniqueOriginalURL()
+
+		first, err := repo.Add(t.Context(), userID, entity.URL{ShortURL: uniqueShortURL(), OriginalURL: originalURL})
+		require.NoError(t, err)
+		second, err := repo.Add(t.Context(), userID, entity.URL{ShortURL: uniqueShortURL(), OriginalURL: originalURL})
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+		require.NoError(t, repo.AddBatch(t.Context(), userID, []entity.URL{
+			{ShortURL: uniqueShortURL(), OriginalURL: originalURL},
+		}))
+
+		_, err = repo.GetByOriginalURL(t.Context(), userID, originalURL)
+		require.ErrorIs(t, err, entity.ErrURLNotFound)
+		urls, err := repo.GetUserURLs(t.Context(), userID)
+		require.NoError(t, err)
+		assert.Len(t, urls, 3)
+	})
+}
+
+// testDedupUser shortens the same original URL for two users, each of them getting a short URL of their own.
+func testDedupUser(t *testing.T, repo usecase.URLRepository) {
+	t.Helper()
+
+	owner, other := uuid.NewString(), uuid.NewString()
+	originalURL := uniqueOriginalURL()
+	shortURL, err := repo.Add(t.Context(), owner, entity.URL{ShortURL: uniqueShortURL(), OriginalURL: originalURL})
+	require.NoError(t, err)
+
+	existing, err := repo.Add(t.Context(), owner, entity.URL{ShortURL: uniqueShortURL(), OriginalURL: originalURL})
+	require.ErrorIs(t, err, entity.ErrURLExists)
+	assert.Equal(t, shortURL, existing)
+	err = repo.AddBatch(t.Context(), owner, []entity.URL{{ShortURL: uniqueShortURL(), OriginalURL: originalURL}})
+	require.ErrorIs(t, err, entity.ErrURLExists)
+
+	_, err = repo.GetByOriginalURL(t.Context(), other, originalURL)
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+	otherShortURL, err := repo.Add(t.Context(), other, entity.URL{ShortURL: uniqueShortURL(), OriginalURL: originalURL})
+	require.NoError(t, err)
+	assert.NotEqual(t, shortURL, otherShortURL)
+
+	urls, err := repo.GetUserURLs(t.Context(), other)
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	assert.Equal(t, otherShortURL, urls[0].ShortURL)
+
+	// the usecase reuses the short URL of the user only
+	urlUsecase, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(repo),
+		usecase.WithURLUsecaseLogger(zap.NewNop()),
+	)
+	require.NoError(t, err)
+	batch, err := urlUsecase.AddBatch(t.Context(), uuid.NewString(), []entity.URL{{OriginalURL: originalURL}})
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.NotContains(t, []string{shortURL, otherShortURL}, batch[0].ShortURL)
+	batch, err = urlUsecase.AddBatch(t.Context(), owner, []entity.URL{{OriginalURL: originalURL}})
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.Equal(t, shortURL, batch[0].ShortURL)
+}
+
+// testClickStats records clicks of a URL and reads its daily statistics as the owner and as another user.
+func testClickStats(t *testing.T, repo usecase.URLRepository) {
+	t.Helper()
+
+	userID := uuid.NewString()
+	shortURL := uniqueShortURL()
+	_, err := repo.Add(t.Context(), userID, entity.URL{ShortURL: shortURL, OriginalURL: uniqueOriginalURL()})
+	require.NoError(t, err)
+
+	firstDay := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	secondDay := firstDay.AddDate(0, 0, 1)
+	err = repo.AddClicks(t.Context(), []entity.Click{
+		{
+			Time:     firstDay.Add(time.Hour),
+			ShortURL: shortURL,
+			Referrer: "https://example.com",
+			IPPrefix: "203.0.113.0/24",
+		},
+		{Time: firstDay.Add(23 * time.Hour), ShortURL: shortURL, UserAgent: "curl/8.0", Gzip: true},
+		// the click of a URL purged in the meantime is dropped without failing the batch
+		{Time: firstDay.Add(23 * time.Hour), ShortURL: uniqueShortURL()},
+		{Time: secondDay.Add(time.Minute), ShortURL: shortURL},
+	})
+	require.NoError(t, err)
+
+	stats, err := repo.GetClickStats(t.Context(), userID, shortURL)
+	require.NoError(t, err)
+	assert.Equal(t, shortURL, stats.ShortURL)
+	assert.Equal(t, int64(3), stats.Total)
+	require.Len(t, stats.Daily, 2)
+	assert.True(t, firstDay.Equal(stats.Daily[0].Date), stats.Daily[0].Date)
+	assert.Equal(t, int64(2), stats.Daily[0].Clicks)
+	assert.True(t, secondDay.Equal(stats.Daily[1].Date), stats.Daily[1].Date)
+	assert.Equal(t, int64(1), stats.Daily[1].Clicks)
+
+	_, err = repo.GetClickStats(t.Context(), uuid.NewString(), shortURL)
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+	_, err = repo.GetClickStats(t.Context(), userID, uniqueShortURL())
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+
+	// a URL without clicks has empty statistics
+	idle := uniqueShortURL()
+	_, err = repo.Add(t.Context(), userID, entity.URL{ShortURL: idle, OriginalURL: uniqueOriginalURL()})
+	require.NoError(t, err)
+	stats, err = repo.GetClickStats(t.Context(), userID, idle)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Total)
+	assert.Empty(t, stats.Daily)
+}
+
+// testUpdateOriginalURL changes the destination of a URL and checks its history and the original URL lookups.
+func testAPIKeys(t *testing.T, repo usecase.URLRepository) {
+	t.Helper()
+
+	userID := uuid.NewString()
+	createdAt := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	newKey := func(userID string, createdAt time.Time, scopes ...entity.APIKeyScope) entity.APIKey {
+		return entity.APIKey{
+			CreatedAt: createdAt,
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			Name:      "ci",
+			Prefix:    "sk_test",
+			Hash:      entity.HashAPIKey(uuid.NewString()),
+			Scopes:    scopes,
+		}
+	}
+	older := newKey(userID, createdAt, entity.ScopeShorten, entity.ScopeRead)
+	newer := newKey(userID, createdAt.Add(time.Hour), entity.ScopeDelete)
+	other := newKey(uuid.NewString(), createdAt, entity.ScopeRead)
+	for _, key := range []entity.APIKey{newer, older, other} {
+		require.NoError(t, repo.AddAPIKey(t.Context(), key))
+	}
+
+	got, err := repo.GetAPIKeyByHash(t.Context(), older.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, older.ID, got.ID)
+	assert.Equal(t, userID, got.UserID)
+	assert.Equal(t, "ci", got.Name)
+	assert.Equal(t, "sk_test", got.Prefix)
+	assert.Equal(t, []entity.APIKeyScope{entity.ScopeShorten, entity.ScopeRead}, got.Scopes)
+	assert.True(t, createdAt.Equal(got.CreatedAt), got.CreatedAt)
+	_, err = repo.GetAPIKeyByHash(t.Context(), entity.HashAPIKey(uuid.NewString()))
+	require.ErrorIs(t, err, entity.ErrAPIKeyNotFound)
+
+	keys, err := repo.GetUserAPIKeys(t.Context(), userID)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, older.ID, keys[0].ID)
+	assert.Equal(t, newer.ID, keys[1].ID)
+
+	// the keys of other users are not deleted
+	deleted, err := repo.DeleteAPIKeys(t.Context(), userID, []string{older.ID, other.ID, uuid.NewString()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{older.ID}, deleted)
+	_, err = repo.GetAPIKeyByHash(t.Context(), older.Hash)
+	require.ErrorIs(t, err, entity.ErrAPIKeyNotFound)
+	_, err = repo.GetAPIKeyByHash(t.Context(), other.Hash)
+	require.NoError(t, err)
+
+	deleted, err = repo.DeleteAPIKeys(t.Context(), userID, []string{older.ID})
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+	keys, err = repo.GetUserAPIKeys(t.Context(), userID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, newer.ID, keys[0].ID)
+}
+
+func testUpdateOriginalURL(t *testing.T, repo usecase.URLRepository) {
+	t.Helper()
+
+	userID := uuid.NewString()
+	shortURL := uniqueShortURL()
+	first := uniqueOriginalURL()
+	second := uniqueOriginalURL()
+	third := uniqueOriginalURL()
+	_, err := repo.Add(t.Context(), userID, entity.URL{ShortURL: shortURL, OriginalURL: first})
+	require.NoError(t, err)
+
+	history, err := repo.UpdateOriginalURL(t.Context(), userID, shortURL, second)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, first, history[0].OriginalURL)
+	assert.WithinDuration(t, time.Now(), history[0].ChangedAt, time.Minute)
+
+	got, err := repo.GetByShortURL(t.Context(), shortURL)
+	require.NoError(t, err)
+	assert.Equal(t, second, got)
+	got, err = repo.GetByOriginalURL(t.Context(), userID, second)
+	require.NoError(t, err)
+	assert.Equal(t, shortURL, got)
+	_, err = repo.GetByOriginalURL(t.Context(), userID, first)
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+
+	// the current destination changes nothing
+	history, err = repo.UpdateOriginalURL(t.Context(), userID, shortURL, second)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+
+	history, err = repo.UpdateOriginalURL(t.Context(), userID, shortURL, third)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, first, history[0].OriginalURL)
+	assert.Equal(t, second, history[1].OriginalURL)
+
+	other := uniqueShortURL()
+	taken := uniqueOriginalURL()
+	_, err = repo.Add(t.Context(), userID, entity.URL{ShortURL: other, OriginalURL: taken})
+	require.NoError(t, err)
+	_, err = repo.UpdateOriginalURL(t.Context(), userID, shortURL, taken)
+	var existsErr *entity.URLExistsError
+	require.ErrorAs(t, err, &existsErr)
+	assert.Equal(t, other, existsErr.ShortURL)
+
+	_, err = repo.UpdateOriginalURL(t.Context(), uuid.NewString(), shortURL, uniqueOriginalURL())
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+	_, err = repo.UpdateOriginalURL(t.Context(), userID, uniqueShortURL(), uniqueOriginalURL())
+	require.ErrorIs(t, err, entity.ErrURLNotFound)
+
+	_, err = repo.MarkDeletedBatch(t.Context(), userID, []string{shortURL})
+	require.NoError(t, err)
+	_, err = repo.UpdateOriginalURL(t.Context(), userID, shortURL, uniqueOriginalURL())
+	require.ErrorIs(t, err, entity.ErrURLDeleted)
+}
+
+// testServiceStats checks the growth of the service totals, so other data in the repository does not matter.
+func testServiceStats(t *testing.T, repo usecase.URLRepository) {
+	t.Helper()
+
+	before, err := repo.GetServiceStats(t.Context())
+	require.NoError(t, err)
+
+	firstUser := uuid.NewString()
+	secondUser := uuid.NewString()
+	_, err = repo.Add(t.Context(), firstUser, entity.URL{ShortURL: uniqueShortURL(), OriginalURL: uniqueOriginalURL()})
+	require.NoError(t, err)
+	err = repo.AddBatch(t.Context(), firstUser, []entity.URL{
+		{ShortURL: uniqueShortURL(), OriginalURL: uniqueOriginalURL()},
+		{ShortURL: uniqueShortURL(), OriginalURL: uniqueOriginalURL()},
+	})
+	require.NoError(t, err)
+	deleted := uniqueShortURL()
+	_, err = repo.Add(t.Context(), secondUser, entity.URL{ShortURL: deleted, OriginalURL: uniqueOriginalURL()})
+	require.NoError(t, err)
+	_, err = repo.MarkDeletedBatch(t.Context(), secondUser, []string{deleted})
+	require.NoError(t, err)
+
+	// rejected URLs are not counted
+	_, err = repo.Add(t.Context(), uuid.NewString(), entity.URL{ShortURL: deleted, OriginalURL: uniqueOriginalURL()})
+	require.ErrorIs(t, err, entity.ErrShortURLTaken)
+
+	after, err := repo.GetServiceStats(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, before.URLs+4, after.URLs)
+	assert.Equal(t, before.Users+2, after.Users)
+}
+
+// newRouter creates the HTTP API backed by the repository.
+func newRouter(t *testing.T, repo usecase.URLRepository) http.Handler {
+	t.Helper()
+
+	logger := zap.NewNop()
+	urlUsecase, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(repo),
+		usecase.WithURLUsecaseLogger(logger),
+	)
+	require.NoError(t, err)
+	tokens, err := middleware.NewTokenManager(middleware.WithEphemeralTokenKey())
+	require.NoError(t, err)
+	router, err := httpapi.NewRouter(
+		httpapi.WithLogger(logger),
+		httpapi.WithBaseURL(baseURL),
+		httpapi.WithURLUsecase(urlUsecase),
+		httpapi.WithTokenManager(tokens),
+	)
+	require.NoError(t, err)
+	return router
+}
+
+// testPurgeDeleted deletes URLs and purges them once they are past the retention.
+// Other deleted URLs of a shared database may be purged too, so only the URLs of the test are checked.
+func testPurgeDeleted(t *testing.T, repo usecase.URLRepository) {
+	t.Helper()
+	userID := uuid.NewString()
+	purged := []entity.URL{
+		{ShortURL: uniqueShortURL(), OriginalURL: uniqueOriginalURL()},
+		{ShortURL: uniqueShortURL(), OriginalURL: uniqueOriginalURL()},
+	}
+	kept := entity.URL{ShortURL: uniqueShortURL(), OriginalURL: uniqueOriginalURL()}
+	require.NoError(t, repo.AddBatch(t.Context(), userID, append(slices.Clone(purged), kept)))
+	_, err := repo.MarkDeletedBatch(t.Context(), userID, []string{purged[0].ShortURL, purged[1].ShortURL})
+	require.NoError(t, err)
+
+	urls, err := repo.GetUserURLs(t.Context(), userID)
+	require.NoError(t, err)
+	require.Len(t, urls, 3)
+	for _, url := range urls {
+		assert.Equal(t, url.DeletedFlag, !url.DeletedAt.IsZero(), url.ShortURL)
+	}
+
+	// the URLs were deleted within the retention
+	_, err = repo.PurgeDeleted(t.Context(), time.Now().Add(-time.Hour), 100)
+	require.NoError(t, err)
+	urls, err = repo.GetUserURLs(t.Context(), userID)
+	require.NoError(t, err)
+	require.Len(t, urls, 3)
+
+	count, err := repo.PurgeDeleted(t.Context(), time.Now().Add(time.Minute), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	for count > 0 {
+		count, err = repo.PurgeDeleted(t.Context(), time.Now().Add(time.Minute), 100)
+		require.NoError(t, err)
+	}
+
+	urls, err = repo.GetUserURLs(t.Context(), userID)
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	assert.Equal(t, kept.ShortURL, urls[0].ShortURL)
+	for _, url := range purged {
+		_, err = repo.GetByShortURL(t.Context(), url.ShortURL)
+		require.ErrorIs(t, err, entity.ErrURLNotFound)
+		// the original URL may be shortened again
+		_, err = repo.Add(t.Context(), userID, entity.URL{ShortURL: uniqueShortURL(), OriginalURL: url.OriginalURL})
+		require.NoError(t, err)
+	}
+}
+
+// testRedirectExpiring follows a single-use link shortened by POST /api/shorten twice.
+func testRedirectExpiring(t *testing.T, repo usecase.URLRepository) {
+	t.Helper()
+
+	router := newRouter(t, repo)
+	originalURL := uniqueOriginalURL()
+
+	recorder := postJSON(t, router, "/api/shorten", dto.ShortenRequest{URL: originalURL, MaxClicks: 1})
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+	var response dto.ShortenResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	path := strings.TrimPrefix(response.Result, baseURL)
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	require.Equal(t, http.StatusTemporaryRedirect, recorder.Code, recorder.Body.String())
+	assert.Equal(t, originalURL, recorder.Header().Get("Location"))
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	require.Equal(t, http.StatusGone, recorder.Code, recorder.Body.String())
+
+	past := dto.ShortenRequest{URL: uniqueOriginalURL(), ExpiresAt: time.Now().Add(-time.Hour)}
+	recorder = postJSON(t, router, "/api/shorten", past)
+	require.Equal(t, http.StatusBadRequest, recorder.Code, recorder.Body.String())
+}
+
+// testShortenAlias posts an alias to POST /api/shorten twice.
+func testShortenAlias(t *testing.T, repo usecase.URLRepository) {
+	t.Helper()
+
+	router := newRouter(t, repo)
+	alias := "alias-" + uniqueShortURL()
+
+	recorder := postJSON(t, router, "/api/shorten", dto.ShortenRequest{URL: uniqueOriginalURL(), Alias: alias})
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+	var response dto.ShortenResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, baseURL+"/"+alias, response.Result)
+
+	recorder = postJSON(t, router, "/api/shorten", dto.ShortenRequest{URL: uniqueOriginalURL(), Alias: alias})
+	require.Equal(t, http.StatusConflict, recorder.Code, recorder.Body.String())
+}
+
+// testShortenBatch posts new and already shortened URLs to POST /api/shorten/batch.
+func testShortenBatch(t *testing.T, repo usecase.URLRepository) {
+	t.Helper()
+
+	router := newRouter(t, repo)
+	first, second := uniqueOriginalURL(), uniqueOriginalURL()
+	created := postBatch(t, router, []dto.ShortenBatchRequest{
+		{CorrelationID: "1", OriginalURL: first},
+		{CorrelationID: "2", OriginalURL: second},
+	})
+	require.Len(t, created, 2)
+
+	third := uniqueOriginalURL()
+	repeated := postBatch(t, router, []dto.ShortenBatchRequest{
+		{CorrelationID: "1", OriginalURL: first},
+		{CorrelationID: "3", OriginalURL: third},
+	})
+	require.Len(t, repeated, 2)
+	assert.Equal(t, created["1"], repeated["1"])
+	assert.NotEqual(t, created["1"], repeated["3"])
+
+	for correlationID, originalURL := range map[string]string{"1": first, "2": second, "3": third} {
+		shortURL := created[correlationID]
+		if shortURL == "" {
+			shortURL = repeated[correlationID]
+		}
+		got, errGet := repo.GetByShortURL(t.Context(), shortURL[len(baseURL)+1:])
+		require.NoError(t, errGet)
+		assert.Equal(t, originalURL, got)
+	}
+}
+
+// postBatch posts the batch and returns the short URLs keyed by correlation ID.
+func postBatch(t *testing.T, router http.Handler, requests []dto.ShortenBatchRequest) map[string]string {
+	t.Helper()
+
+	recorder := postJSON(t, router, "/api/shorten/batch", requests)
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+
+	var responses []dto.ShortenBatchResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &responses))
+	shortURLs := make(map[string]string, len(responses))
+	for _, response := range responses {
+		shortURLs[response.CorrelationID] = response.ShortURL
+	}
+	return shortURLs
+}
+
+// testURLSequence checks that the URL sequence counts every added URL and is not rolled back by a purge.
+// Other tests of a shared database may add URLs too, so the sequence is only checked not to fall behind.
+func testURLSequence(t *testing.T, repo usecase.URLRepository) {
+	t.Helper()
+	userID := uuid.NewString()
+
+	before, err := repo.GetURLSequence(t.Context())
+	require.NoError(t, err)
+
+	purged := uniqueShortURL()
+	_, err = repo.Add(t.Context(), userID, entity.URL{ShortURL: purged, OriginalURL: uniqueOriginalURL()})
+	require.NoError(t, err)
+	err = repo.AddBatch(t.Context(), userID, []entity.URL{
+		{ShortURL: uniqueShortURL(), OriginalURL: uniqueOriginalURL()},
+		{ShortURL: uniqueShortURL(), OriginalURL: uniqueOriginalURL()},
+	})
+	require.NoError(t, err)
+
+	added, err := repo.GetURLSequence(t.Context())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, added, before+3)
+
+	_, err = repo.MarkDeletedBatch(t.Context(), userID, []string{purged})
+	require.NoError(t, err)
+	for count := int64(1); count > 0; {
+		count, err = repo.PurgeDeleted(t.Context(), time.Now().Add(time.Minute), 100)
+		require.NoError(t, err)
+	}
+
+	after, err := repo.GetURLSequence(t.Context())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, after, added)
+}
+
+// postJSON posts the value encoded as JSON to the path.
+func postJSON(t *testing.T, router http.Handler, path string, value any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(value)
+	require.NoError(t, err)
+	req := httptest.NewRequestWithContext(t.Context(), http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func uniqueShortURL() string {
+	return uuid.NewString()[:8]
+}
+
+func uniqueOriginalURL() string {
+	return "https://" + uuid.NewString() + ".example.com"
+}
+
+// testAPIKeyRequests creates an API key with the token of a user and shortens URLs as that user with the key.
+func testAPIKeyRequests(t *testing.T, repo usecase.URLRepository) {
+	t.Helper()
+
+	router := newRouter(t, repo)
+	send := func(method, path string, value any, header map[string]string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(value)
+		require.NoError(t, err)
+		req := httptest.NewRequestWithContext(t.Context(), method, path, bytes.NewReader(body))
+		for name, v := range header {
+			req.Header.Set(name, v)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := postJSON(t, router, "/api/shorten", dto.ShortenRequest{URL: uniqueOriginalURL()})
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+	bearer := map[string]string{"Authorization": recorder.Header().Get("Authorization")}
+
+	recorder = send(http.MethodPost, "/api/user/keys", dto.CreateAPIKeyRequest{
+		Name:   "ci",
+		Scopes: []string{"shorten"},
+	}, bearer)
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+	var created dto.CreateAPIKeyResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	require.NotEmpty(t, created.Key)
+	apiKey := map[string]string{"X-API-Key": created.Key}
+
+	// the key acts as the user within its scopes and cannot manage the keys
+	recorder = send(http.MethodPost, "/api/shorten", dto.ShortenRequest{URL: uniqueOriginalURL()}, apiKey)
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+	assert.Empty(t, recorder.Header().Get("Authorization"))
+	recorder = send(http.MethodGet, "/api/user/urls", nil, apiKey)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	recorder = send(http.MethodGet, "/api/user/keys", nil, apiKey)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = send(http.MethodGet, "/api/user/urls", nil, bearer)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var urls []dto.UserURLsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &urls))
+	assert.Len(t, urls, 2)
+
+	// the key is listed without the key itself
+	recorder = send(http.MethodGet, "/api/user/keys", nil, bearer)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.NotContains(t, recorder.Body.String(), created.Key)
+	var keys []dto.APIKeyResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &keys))
+	require.Len(t, keys, 1)
+	assert.Equal(t, created.ID, keys[0].ID)
+	assert.Equal(t, []string{"shorten"}, keys[0].Scopes)
+
+	recorder = send(http.MethodDelete, "/api/user/keys", []string{created.ID}, bearer)
+	require.Equal(t, http.StatusNoContent, recorder.Code, recorder.Body.String())
+	recorder = send(http.MethodPost, "/api/shorten", dto.ShortenRequest{URL: uniqueOriginalURL()}, apiKey)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
@@ -0,0 +1,214 @@
+package repositorytest
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+const (
+	testLease = time.Minute
+	// testClaimLimit is large enough to claim the tasks left in a shared database by earlier runs as well.
+	testClaimLimit = 1000
+)
+
+// RunDeleteQueue runs the shared delete queue tests against the queues built by newQueue.
+// Every test uses unique users and jobs, so the queue may be backed by a database that is not emptied between runs.
+func RunDeleteQueue(t *testing.T, newQueue func(t *testing.T) worker.DeleteQueue) {
+	t.Helper()
+
+	t.Run("claim leases tasks in order", func(t *testing.T) {
+		queue := newQueue(t)
+		now := time.Now()
+		first, second := uuid.NewString(), uuid.NewString()
+		tasks := []entity.DeleteTask{
+			newDeleteTask(first, now, entity.JobDelete, "a1", "a2"),
+			newDeleteTask(second, now, entity.JobDelete, "b1"),
+			newDeleteTask(first, now, entity.JobRestore, "a1"),
+		}
+		for _, task := range tasks {
+			require.NoError(t, queue.Push(t.Context(), task))
+		}
+
+		claimed, err := queue.Claim(t.Context(), now, testClaimLimit, testLease)
+		require.NoError(t, err)
+		assert.Equal(t, jobIDs(tasks...), jobIDs(tasksOf(claimed, first, second)...))
+		assert.True(t, slices.IsSortedFunc(claimed, func(a, b entity.DeleteTask) int {
+			return cmp.Compare(a.Seq, b.Seq)
+		}))
+		restore := tasksOf(claimed, first)[1]
+		assert.Equal(t, entity.JobRestore, restore.Job.Operation)
+		assert.Equal(t, []string{"a1"}, restore.Job.ShortURLs)
+
+		// leased tasks are skipped until the lease expires
+		claimed, err = queue.Claim(t.Context(), now, testClaimLimit, testLease)
+		require.NoError(t, err)
+		assert.Empty(t, tasksOf(claimed, first, second))
+		claimed, err = queue.Claim(t.Context(), now.Add(testLease+time.Second), testClaimLimit, testLease)
+		require.NoError(t, err)
+		assert.Equal(t, jobIDs(tasks...), jobIDs(tasksOf(claimed, first, second)...))
+	})
+
+	t.Run("retry blocks the following tasks of the user", func(t *testing.T) {
+		queue := newQueue(t)
+		now := time.Now()
+		first, second := uuid.NewString(), uuid.NewString()
+		failed := newDeleteTask(first, now, entity.JobDelete, "a1")
+		following := newDeleteTask(first, now, entity.JobRestore, "a1")
+		other := newDeleteTask(second, now, entity.JobDelete, "b1")
+		for _, task := range []entity.DeleteTask{failed, following, other} {
+			require.NoError(t, queue.Push(t.Context(), task))
+		}
+		_, err := queue.Claim(t.Context(), now, testClaimLimit, testLease)
+		require.NoError(t, err)
+
+		failed.Attempts = 1
+		failed.NextAttemptAt = now.Add(time.Hour)
+		failed.LastError = "connection refused"
+		require.NoError(t, queue.Retry(t.Context(), failed))
+		require.NoError(t, queue.Retry(t.Context(), following))
+		require.NoError(t, queue.Retry(t.Context(), other))
+
+		claimed, err := queue.Claim(t.Context(), now, testClaimLimit, testLease)
+		require.NoError(t, err)
+		assert.Equal(t, jobIDs(other), jobIDs(tasksOf(claimed, first, second)...))
+
+		claimed, err = queue.Claim(t.Context(), now.Add(2*time.Hour), testClaimLimit, testLease)
+		require.NoError(t, err)
+		claimed = tasksOf(claimed, first)
+		require.Len(t, claimed, 2)
+		assert.Equal(t, jobIDs(failed, following), jobIDs(claimed...))
+		assert.Equal(t, 1, claimed[0].Attempts)
+		assert.Equal(t, "connection refused", claimed[0].LastError)
+	})
+
+	t.Run("ack and bury", func(t *testing.T) {
+		queue := newQueue(t)
+		now := time.Now()
+		userID := uuid.NewString()
+		done := newDeleteTask(userID, now, entity.JobDelete, "a1")
+		dead := newDeleteTask(userID, now, entity.JobRestore, "a2", "a3")
+		require.NoError(t, queue.Push(t.Context(), done))
+		require.NoError(t, queue.Push(t.Context(), dead))
+		_, err := queue.Claim(t.Context(), now, testClaimLimit, testLease)
+		require.NoError(t, err)
+
+		require.NoError(t, queue.Ack(t.Context(), done.Job.Finish(now, map[string]struct{}{"a1": {}}, nil)))
+		dead.Attempts = 5
+		dead.DeadAt = now
+		dead.LastError = "connection refused"
+		require.NoError(t, queue.Bury(t.Context(), dead))
+
+		claimed, err := queue.Claim(t.Context(), now.Add(time.Hour), testClaimLimit, testLease)
+		require.NoError(t, err)
+		assert.Empty(t, tasksOf(claimed, userID))
+
+		letters, err := queue.DeadLetters(t.Context(), 0)
+		require.NoError(t, err)
+		letters = tasksOf(letters, userID)
+		require.Len(t, letters, 1)
+		assert.Equal(t, dead.Job.ID, letters[0].Job.ID)
+		assert.Equal(t, entity.JobRestore, letters[0].Job.Operation)
+		assert.Equal(t, []string{"a2", "a3"}, letters[0].Job.ShortURLs)
+		assert.Equal(t, 5, letters[0].Attempts)
+		assert.Equal(t, "connection refused", letters[0].LastError)
+		assert.WithinDuration(t, now, letters[0].DeadAt, time.Millisecond)
+
+		letters, err = queue.DeadLetters(t.Context(), 1)
+		require.NoError(t, err)
+		assert.Len(t, letters, 1)
+	})
+
+	t.Run("jobs", func(t *testing.T) {
+		queue := newQueue(t)
+		now := time.Now()
+		userID := uuid.NewString()
+		pending := newDeleteTask(userID, now, entity.JobDelete, "a1")
+		done := newDeleteTask(userID, now, entity.JobDelete, "a2", "a3")
+		dead := newDeleteTask(userID, now, entity.JobRestore, "a4")
+		for _, task := range []entity.DeleteTask{pending, done, dead} {
+			require.NoError(t, queue.Push(t.Context(), task))
+		}
+		_, err := queue.Claim(t.Context(), now, testClaimLimit, testLease)
+		require.NoError(t, err)
+		require.NoError(t, queue.Retry(t.Context(), pending))
+		require.NoError(t, queue.Ack(t.Context(), done.Job.Finish(now, map[string]struct{}{"a2": {}}, nil)))
+		dead.Attempts = 5
+		dead.DeadAt = now
+		dead.LastError = "connection refused"
+		require.NoError(t, queue.Bury(t.Context(), dead))
+
+		job, err := queue.Job(t.Context(), pending.Job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.JobPending, job.Status)
+		assert.Equal(t, []string{"a1"}, job.ShortURLs)
+
+		job, err = queue.Job(t.Context(), done.Job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, userID, job.UserID)
+		assert.Equal(t, entity.JobPartiallyFailed, job.Status)
+		assert.Equal(t, []string{"a3"}, job.FailedURLs)
+		assert.WithinDuration(t, now, job.FinishedAt, time.Millisecond)
+
+		job, err = queue.Job(t.Context(), dead.Job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.JobFailed, job.Status)
+		assert.Equal(t, []string{"a4"}, job.FailedURLs)
+
+		_, err = queue.Job(t.Context(), uuid.NewString())
+		require.ErrorIs(t, err, entity.ErrJobNotFound)
+
+		// entries finished or buried at the cutoff are kept
+		require.NoError(t, queue.Prune(t.Context(), now.Add(-time.Second), now.Add(-time.Second)))
+		_, err = queue.Job(t.Context(), done.Job.ID)
+		require.NoError(t, err)
+		_, err = queue.Job(t.Context(), dead.Job.ID)
+		require.NoError(t, err)
+
+		require.NoError(t, queue.Prune(t.Context(), now.Add(time.Second), now.Add(time.Second)))
+		_, err = queue.Job(t.Context(), done.Job.ID)
+		require.ErrorIs(t, err, entity.ErrJobNotFound)
+		_, err = queue.Job(t.Context(), dead.Job.ID)
+		require.ErrorIs(t, err, entity.ErrJobNotFound)
+		job, err = queue.Job(t.Context(), pending.Job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.JobPending, job.Status)
+	})
+}
+
+func newDeleteTask(userID string, now time.Time, operation entity.JobOperation, shortURLs ...string) entity.DeleteTask {
+	return entity.DeleteTask{
+		NextAttemptAt: now,
+		Job: entity.Job{
+			CreatedAt: now,
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			Operation: operation,
+			Status:    entity.JobPending,
+			ShortURLs: shortURLs,
+		},
+	}
+}
+
+// tasksOf keeps the tasks of the users, tasks left by other tests are dropped.
+func tasksOf(tasks []entity.DeleteTask, userIDs ...string) []entity.DeleteTask {
+	return slices.DeleteFunc(slices.Clone(tasks), func(task entity.DeleteTask) bool {
+		return !slices.Contains(userIDs, task.Job.UserID)
+	})
+}
+
+func jobIDs(tasks ...entity.DeleteTask) []string {
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.Job.ID)
+	}
+	return ids
+}
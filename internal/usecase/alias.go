@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+// defaultAliasPattern allows 3 to 64 letters, digits, dashes and underscores starting with a letter or digit.
+const defaultAliasPattern = `^[a-zA-Z0-9][a-zA-Z0-9_-]{2,63}$`
+
+// WithAliasPattern is the option for the URLUsecase to set the pattern aliases must match.
+// An empty pattern keeps the default one.
+func WithAliasPattern(pattern string) Option {
+	return func(options *options) error {
+		if pattern == "" {
+			return nil
+		}
+		aliasPattern, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid alias pattern: %w", err)
+		}
+		options.aliasPattern = aliasPattern
+		return nil
+	}
+}
+
+// WithReservedAliases is the option for the URLUsecase to set the aliases that cannot be used,
+// compared case-insensitively. By default api, ping and debug are reserved.
+func WithReservedAliases(aliases []string) Option {
+	return func(options *options) error {
+		options.reservedAliases = aliases
+		return nil
+	}
+}
+
+// validateAlias returns entity.ErrInvalidAlias if the alias does not match the pattern or is reserved.
+func (uc *URLUsecase) validateAlias(alias string) error {
+	if !uc.aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w: %q does not match %s", entity.ErrInvalidAlias, alias, uc.aliasPattern)
+	}
+	if _, ok := uc.reservedAliases[strings.ToLower(alias)]; ok {
+		return fmt.Errorf("%w: %q is reserved", entity.ErrInvalidAlias, alias)
+	}
+	return nil
+}
+
+// addAlias adds a URL under the alias given as its short URL.
+func (uc *URLUsecase) addAlias(ctx context.Context, userID string, url entity.URL) (string, error) {
+	if err := uc.validateAlias(url.ShortURL); err != nil {
+		return "", err
+	}
+	shortURL, err := uc.repository.Add(ctx, userID, url)
+	if err != nil {
+		var existsErr *entity.URLExistsError
+		if errors.As(err, &existsErr) {
+			return existsErr.ShortURL, err
+		}
+		if errors.Is(err, entity.ErrShortURLTaken) {
+			return "", entity.ErrAliasTaken
+		}
+		return "", err
+	}
+	return shortURL, nil
+}
+
+// checkAliasesFree returns entity.ErrAliasTaken if any alias of the URLs is already used, deleted URLs included.
+func (uc *URLUsecase) checkAliasesFree(ctx context.Context, urls []entity.URL) error {
+	for _, url := range urls {
+		if url.ShortURL == "" {
+			continue
+		}
+		_, err := uc.repository.GetByShortURL(ctx, url.ShortURL)
+		switch {
+		case errors.Is(err, entity.ErrURLNotFound):
+			continue
+		case err == nil, errors.Is(err, entity.ErrURLDeleted):
+			return entity.ErrAliasTaken
+		default:
+			return err
+		}
+	}
+	return nil
+}
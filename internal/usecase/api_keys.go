@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+const (
+	// apiKeyPrefix marks the API keys so that leaked keys are easy to spot.
+	apiKeyPrefix = "sk_"
+	// apiKeySize is the number of random bytes of an API key.
+	apiKeySize = 32
+	// apiKeyShownPrefix is the length of the beginning of the key kept to tell the keys apart.
+	apiKeyShownPrefix = len(apiKeyPrefix) + 8
+)
+
+// CreateAPIKey creates an API key of the user with the scopes and returns it together with the key itself.
+// The key is not stored and cannot be read again. Unknown scopes are reported as entity.ErrInvalidScope.
+func (uc *URLUsecase) CreateAPIKey(
+	ctx context.Context,
+	userID, name string,
+	scopes []string,
+) (entity.APIKey, string, error) {
+	parsed, err := entity.ParseAPIKeyScopes(scopes)
+	if err != nil {
+		return entity.APIKey{}, "", err
+	}
+
+	secret := make([]byte, apiKeySize)
+	if _, errRead := rand.Read(secret); errRead != nil {
+		return entity.APIKey{}, "", fmt.Errorf("failed to generate api key: %w", errRead)
+	}
+	plain := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := entity.APIKey{
+		CreatedAt: time.Now().UTC(),
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plain[:apiKeyShownPrefix],
+		Hash:      entity.HashAPIKey(plain),
+		Scopes:    parsed,
+	}
+	if errAdd := uc.repository.AddAPIKey(ctx, key); errAdd != nil {
+		return entity.APIKey{}, "", errAdd
+	}
+	uc.logger.Info("api key created", zap.String("user_id", userID), zap.String("key_id", key.ID))
+	return key, plain, nil
+}
+
+// GetUserAPIKeys gets the API keys of the user.
+func (uc *URLUsecase) GetUserAPIKeys(ctx context.Context, userID string) ([]entity.APIKey, error) {
+	return uc.repository.GetUserAPIKeys(ctx, userID)
+}
+
+// DeleteAPIKeys revokes the user's API keys and returns the IDs of the revoked ones.
+func (uc *URLUsecase) DeleteAPIKeys(ctx context.Context, userID string, ids []string) ([]string, error) {
+	deleted, err := uc.repository.DeleteAPIKeys(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	uc.logger.Info("api keys deleted", zap.String("user_id", userID), zap.Strings("key_ids", deleted))
+	return deleted, nil
+}
+
+// ResolveAPIKey gets the stored API key by the key itself.
+// An unknown or revoked key is reported as entity.ErrAPIKeyNotFound.
+func (uc *URLUsecase) ResolveAPIKey(ctx context.Context, key string) (entity.APIKey, error) {
+	return uc.repository.GetAPIKeyByHash(ctx, entity.HashAPIKey(key))
+}
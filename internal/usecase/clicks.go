@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+// WithClickWorker is the option for the URLUsecase to set the worker recording clicks.
+// Clicks are not recorded without it.
+func WithClickWorker(clicks *worker.ClickWorker) Option {
+	return func(options *options) error {
+		options.clicks = clicks
+		return nil
+	}
+}
+
+// RecordClick queues the click for the click statistics without waiting for it to be saved.
+func (uc *URLUsecase) RecordClick(_ context.Context, click entity.Click) {
+	if uc.clicks == nil {
+		return
+	}
+	if !uc.clicks.EnqueueClick(click) {
+		uc.logger.Debug(
+			"click queue is full, click dropped",
+			zap.String("short_url", click.ShortURL),
+			zap.Int64("dropped", uc.clicks.Dropped()),
+		)
+	}
+}
+
+// GetURLStats gets the click statistics of the user's short URL.
+// A URL of another user is reported as entity.ErrURLNotFound.
+func (uc *URLUsecase) GetURLStats(ctx context.Context, userID, shortURL string) (entity.URLStats, error) {
+	return uc.repository.GetClickStats(ctx, userID, shortURL)
+}
@@ -0,0 +1,23 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+// validateLimits returns entity.ErrInvalidLimits if the URL expires before now or has a negative click limit.
+func validateLimits(url entity.URL, now time.Time) error {
+	if !url.ExpiresAt.IsZero() && !url.ExpiresAt.After(now) {
+		return fmt.Errorf(
+			"%w: expires_at %s is not in the future",
+			entity.ErrInvalidLimits,
+			url.ExpiresAt.Format(time.RFC3339),
+		)
+	}
+	if url.MaxClicks < 0 {
+		return fmt.Errorf("%w: max_clicks %d is negative", entity.ErrInvalidLimits, url.MaxClicks)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/AGENT3128/shortener-url/internal/entity"
 )
@@ -16,17 +17,29 @@ type URLRepository interface {
 	BatchURLSaver
 	UserURLGetter
 	URLDeleter
+	URLRestorer
+	URLUpdater
+	URLVisitor
+	URLExpirer
+	URLPurger
+	ClickSaver
+	ClickStatsGetter
+	ServiceStatsGetter
+	APIKeySaver
+	APIKeyGetter
+	APIKeyDeleter
 	Closer
+	URLSequenceGetter
 }
 
 // URLSaver is the interface for the URLSaver.
 type URLSaver interface {
-	Add(ctx context.Context, userID, shortURL, originalURL string) (string, error)
+	Add(ctx context.Context, userID string, url entity.URL) (string, error)
 }
 
 // URLGetter is the interface for the URLGetter.
 type URLGetter interface {
-	GetByOriginalURL(ctx context.Context, originalURL string) (string, error)
+	GetByOriginalURL(ctx context.Context, userID, originalURL string) (string, error)
 	GetByShortURL(ctx context.Context, shortURL string) (string, error)
 }
 
@@ -47,10 +60,75 @@ type UserURLGetter interface {
 
 // URLDeleter is the interface for the URLDeleter.
 type URLDeleter interface {
-	MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) error
+	MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error)
+}
+
+// URLRestorer is the interface for the URLRestorer.
+type URLRestorer interface {
+	MarkRestoredBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error)
+}
+
+// URLUpdater is the interface for the URLUpdater.
+// It returns the previous destinations of the short URL, the oldest first.
+type URLUpdater interface {
+	UpdateOriginalURL(ctx context.Context, userID, shortURL, originalURL string) ([]entity.URLChange, error)
+}
+
+// URLVisitor is the interface for the URLVisitor.
+type URLVisitor interface {
+	Visit(ctx context.Context, shortURL string) (string, error)
+}
+
+// URLExpirer is the interface for the URLExpirer.
+type URLExpirer interface {
+	MarkExpiredDeleted(ctx context.Context, now time.Time) (int64, error)
+}
+
+// URLPurger is the interface for the URLPurger.
+type URLPurger interface {
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int64, error)
+}
+
+// ClickSaver is the interface for the ClickSaver.
+type ClickSaver interface {
+	AddClicks(ctx context.Context, clicks []entity.Click) error
+}
+
+// ClickStatsGetter is the interface for the ClickStatsGetter.
+type ClickStatsGetter interface {
+	GetClickStats(ctx context.Context, userID, shortURL string) (entity.URLStats, error)
+}
+
+// ServiceStatsGetter is the interface for the ServiceStatsGetter.
+type ServiceStatsGetter interface {
+	GetServiceStats(ctx context.Context) (entity.ServiceStats, error)
+}
+
+// APIKeySaver is the interface for the APIKeySaver.
+type APIKeySaver interface {
+	AddAPIKey(ctx context.Context, key entity.APIKey) error
+}
+
+// APIKeyGetter is the interface for the APIKeyGetter.
+// An unknown hash is reported as entity.ErrAPIKeyNotFound.
+type APIKeyGetter interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID string) ([]entity.APIKey, error)
+}
+
+// APIKeyDeleter is the interface for the APIKeyDeleter.
+// It returns the IDs of the deleted keys, the keys of other users are skipped.
+type APIKeyDeleter interface {
+	DeleteAPIKeys(ctx context.Context, userID string, ids []string) ([]string, error)
 }
 
 // Closer is the interface for the Closer.
 type Closer interface {
 	Close() error
 }
+
+// URLSequenceGetter is the interface for the URLSequenceGetter.
+// The sequence is the number of URLs ever added, purged ones included, and never decreases.
+type URLSequenceGetter interface {
+	GetURLSequence(ctx context.Context) (int64, error)
+}
@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	entity "github.com/AGENT3128/shortener-url/internal/entity"
 	gomock "go.uber.org/mock/gomock"
@@ -42,18 +43,32 @@ func (m *MockURLRepository) EXPECT() *MockURLRepositoryMockRecorder {
 }
 
 // Add mocks base method.
-func (m *MockURLRepository) Add(ctx context.Context, userID, shortURL, originalURL string) (string, error) {
+func (m *MockURLRepository) Add(ctx context.Context, userID string, url entity.URL) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Add", ctx, userID, shortURL, originalURL)
+	ret := m.ctrl.Call(m, "Add", ctx, userID, url)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Add indicates an expected call of Add.
-func (mr *MockURLRepositoryMockRecorder) Add(ctx, userID, shortURL, originalURL any) *gomock.Call {
+func (mr *MockURLRepositoryMockRecorder) Add(ctx, userID, url any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockURLRepository)(nil).Add), ctx, userID, shortURL, originalURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockURLRepository)(nil).Add), ctx, userID, url)
+}
+
+// AddAPIKey mocks base method.
+func (m *MockURLRepository) AddAPIKey(ctx context.Context, key entity.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAPIKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAPIKey indicates an expected call of AddAPIKey.
+func (mr *MockURLRepositoryMockRecorder) AddAPIKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAPIKey", reflect.TypeOf((*MockURLRepository)(nil).AddAPIKey), ctx, key)
 }
 
 // AddBatch mocks base method.
@@ -70,6 +85,20 @@ func (mr *MockURLRepositoryMockRecorder) AddBatch(ctx, userID, urls any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBatch", reflect.TypeOf((*MockURLRepository)(nil).AddBatch), ctx, userID, urls)
 }
 
+// AddClicks mocks base method.
+func (m *MockURLRepository) AddClicks(ctx context.Context, clicks []entity.Click) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddClicks", ctx, clicks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddClicks indicates an expected call of AddClicks.
+func (mr *MockURLRepositoryMockRecorder) AddClicks(ctx, clicks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddClicks", reflect.TypeOf((*MockURLRepository)(nil).AddClicks), ctx, clicks)
+}
+
 // Close mocks base method.
 func (m *MockURLRepository) Close() error {
 	m.ctrl.T.Helper()
@@ -84,19 +113,49 @@ func (mr *MockURLRepositoryMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockURLRepository)(nil).Close))
 }
 
+// DeleteAPIKeys mocks base method.
+func (m *MockURLRepository) DeleteAPIKeys(ctx context.Context, userID string, ids []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIKeys", ctx, userID, ids)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAPIKeys indicates an expected call of DeleteAPIKeys.
+func (mr *MockURLRepositoryMockRecorder) DeleteAPIKeys(ctx, userID, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIKeys", reflect.TypeOf((*MockURLRepository)(nil).DeleteAPIKeys), ctx, userID, ids)
+}
+
+// GetAPIKeyByHash mocks base method.
+func (m *MockURLRepository) GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", ctx, hash)
+	ret0, _ := ret[0].(entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockURLRepositoryMockRecorder) GetAPIKeyByHash(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockURLRepository)(nil).GetAPIKeyByHash), ctx, hash)
+}
+
 // GetByOriginalURL mocks base method.
-func (m *MockURLRepository) GetByOriginalURL(ctx context.Context, originalURL string) (string, error) {
+func (m *MockURLRepository) GetByOriginalURL(ctx context.Context, userID, originalURL string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByOriginalURL", ctx, originalURL)
+	ret := m.ctrl.Call(m, "GetByOriginalURL", ctx, userID, originalURL)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByOriginalURL indicates an expected call of GetByOriginalURL.
-func (mr *MockURLRepositoryMockRecorder) GetByOriginalURL(ctx, originalURL any) *gomock.Call {
+func (mr *MockURLRepositoryMockRecorder) GetByOriginalURL(ctx, userID, originalURL any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOriginalURL", reflect.TypeOf((*MockURLRepository)(nil).GetByOriginalURL), ctx, originalURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOriginalURL", reflect.TypeOf((*MockURLRepository)(nil).GetByOriginalURL), ctx, userID, originalURL)
 }
 
 // GetByShortURL mocks base method.
@@ -114,6 +173,66 @@ func (mr *MockURLRepositoryMockRecorder) GetByShortURL(ctx, shortURL any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByShortURL", reflect.TypeOf((*MockURLRepository)(nil).GetByShortURL), ctx, shortURL)
 }
 
+// GetClickStats mocks base method.
+func (m *MockURLRepository) GetClickStats(ctx context.Context, userID, shortURL string) (entity.URLStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClickStats", ctx, userID, shortURL)
+	ret0, _ := ret[0].(entity.URLStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClickStats indicates an expected call of GetClickStats.
+func (mr *MockURLRepositoryMockRecorder) GetClickStats(ctx, userID, shortURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClickStats", reflect.TypeOf((*MockURLRepository)(nil).GetClickStats), ctx, userID, shortURL)
+}
+
+// GetServiceStats mocks base method.
+func (m *MockURLRepository) GetServiceStats(ctx context.Context) (entity.ServiceStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceStats", ctx)
+	ret0, _ := ret[0].(entity.ServiceStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceStats indicates an expected call of GetServiceStats.
+func (mr *MockURLRepositoryMockRecorder) GetServiceStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceStats", reflect.TypeOf((*MockURLRepository)(nil).GetServiceStats), ctx)
+}
+
+// GetURLSequence mocks base method.
+func (m *MockURLRepository) GetURLSequence(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLSequence", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLSequence indicates an expected call of GetURLSequence.
+func (mr *MockURLRepositoryMockRecorder) GetURLSequence(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLSequence", reflect.TypeOf((*MockURLRepository)(nil).GetURLSequence), ctx)
+}
+
+// GetUserAPIKeys mocks base method.
+func (m *MockURLRepository) GetUserAPIKeys(ctx context.Context, userID string) ([]entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAPIKeys", ctx, userID)
+	ret0, _ := ret[0].([]entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAPIKeys indicates an expected call of GetUserAPIKeys.
+func (mr *MockURLRepositoryMockRecorder) GetUserAPIKeys(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAPIKeys", reflect.TypeOf((*MockURLRepository)(nil).GetUserAPIKeys), ctx, userID)
+}
+
 // GetUserURLs mocks base method.
 func (m *MockURLRepository) GetUserURLs(ctx context.Context, userID string) ([]entity.URL, error) {
 	m.ctrl.T.Helper()
@@ -130,11 +249,12 @@ func (mr *MockURLRepositoryMockRecorder) GetUserURLs(ctx, userID any) *gomock.Ca
 }
 
 // MarkDeletedBatch mocks base method.
-func (m *MockURLRepository) MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) error {
+func (m *MockURLRepository) MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "MarkDeletedBatch", ctx, userID, shortURLs)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // MarkDeletedBatch indicates an expected call of MarkDeletedBatch.
@@ -143,6 +263,36 @@ func (mr *MockURLRepositoryMockRecorder) MarkDeletedBatch(ctx, userID, shortURLs
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDeletedBatch", reflect.TypeOf((*MockURLRepository)(nil).MarkDeletedBatch), ctx, userID, shortURLs)
 }
 
+// MarkExpiredDeleted mocks base method.
+func (m *MockURLRepository) MarkExpiredDeleted(ctx context.Context, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkExpiredDeleted", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkExpiredDeleted indicates an expected call of MarkExpiredDeleted.
+func (mr *MockURLRepositoryMockRecorder) MarkExpiredDeleted(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkExpiredDeleted", reflect.TypeOf((*MockURLRepository)(nil).MarkExpiredDeleted), ctx, now)
+}
+
+// MarkRestoredBatch mocks base method.
+func (m *MockURLRepository) MarkRestoredBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRestoredBatch", ctx, userID, shortURLs)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkRestoredBatch indicates an expected call of MarkRestoredBatch.
+func (mr *MockURLRepositoryMockRecorder) MarkRestoredBatch(ctx, userID, shortURLs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRestoredBatch", reflect.TypeOf((*MockURLRepository)(nil).MarkRestoredBatch), ctx, userID, shortURLs)
+}
+
 // Ping mocks base method.
 func (m *MockURLRepository) Ping(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -157,6 +307,51 @@ func (mr *MockURLRepositoryMockRecorder) Ping(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockURLRepository)(nil).Ping), ctx)
 }
 
+// PurgeDeleted mocks base method.
+func (m *MockURLRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", ctx, deletedBefore, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockURLRepositoryMockRecorder) PurgeDeleted(ctx, deletedBefore, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockURLRepository)(nil).PurgeDeleted), ctx, deletedBefore, limit)
+}
+
+// UpdateOriginalURL mocks base method.
+func (m *MockURLRepository) UpdateOriginalURL(ctx context.Context, userID, shortURL, originalURL string) ([]entity.URLChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOriginalURL", ctx, userID, shortURL, originalURL)
+	ret0, _ := ret[0].([]entity.URLChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOriginalURL indicates an expected call of UpdateOriginalURL.
+func (mr *MockURLRepositoryMockRecorder) UpdateOriginalURL(ctx, userID, shortURL, originalURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOriginalURL", reflect.TypeOf((*MockURLRepository)(nil).UpdateOriginalURL), ctx, userID, shortURL, originalURL)
+}
+
+// Visit mocks base method.
+func (m *MockURLRepository) Visit(ctx context.Context, shortURL string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Visit", ctx, shortURL)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Visit indicates an expected call of Visit.
+func (mr *MockURLRepositoryMockRecorder) Visit(ctx, shortURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Visit", reflect.TypeOf((*MockURLRepository)(nil).Visit), ctx, shortURL)
+}
+
 // MockURLSaver is a mock of URLSaver interface.
 type MockURLSaver struct {
 	isgomock struct{}
@@ -182,18 +377,18 @@ func (m *MockURLSaver) EXPECT() *MockURLSaverMockRecorder {
 }
 
 // Add mocks base method.
-func (m *MockURLSaver) Add(ctx context.Context, userID, shortURL, originalURL string) (string, error) {
+func (m *MockURLSaver) Add(ctx context.Context, userID string, url entity.URL) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Add", ctx, userID, shortURL, originalURL)
+	ret := m.ctrl.Call(m, "Add", ctx, userID, url)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Add indicates an expected call of Add.
-func (mr *MockURLSaverMockRecorder) Add(ctx, userID, shortURL, originalURL any) *gomock.Call {
+func (mr *MockURLSaverMockRecorder) Add(ctx, userID, url any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockURLSaver)(nil).Add), ctx, userID, shortURL, originalURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockURLSaver)(nil).Add), ctx, userID, url)
 }
 
 // MockURLGetter is a mock of URLGetter interface.
@@ -221,18 +416,18 @@ func (m *MockURLGetter) EXPECT() *MockURLGetterMockRecorder {
 }
 
 // GetByOriginalURL mocks base method.
-func (m *MockURLGetter) GetByOriginalURL(ctx context.Context, originalURL string) (string, error) {
+func (m *MockURLGetter) GetByOriginalURL(ctx context.Context, userID, originalURL string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByOriginalURL", ctx, originalURL)
+	ret := m.ctrl.Call(m, "GetByOriginalURL", ctx, userID, originalURL)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByOriginalURL indicates an expected call of GetByOriginalURL.
-func (mr *MockURLGetterMockRecorder) GetByOriginalURL(ctx, originalURL any) *gomock.Call {
+func (mr *MockURLGetterMockRecorder) GetByOriginalURL(ctx, userID, originalURL any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOriginalURL", reflect.TypeOf((*MockURLGetter)(nil).GetByOriginalURL), ctx, originalURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOriginalURL", reflect.TypeOf((*MockURLGetter)(nil).GetByOriginalURL), ctx, userID, originalURL)
 }
 
 // GetByShortURL mocks base method.
@@ -390,11 +585,12 @@ func (m *MockURLDeleter) EXPECT() *MockURLDeleterMockRecorder {
 }
 
 // MarkDeletedBatch mocks base method.
-func (m *MockURLDeleter) MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) error {
+func (m *MockURLDeleter) MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "MarkDeletedBatch", ctx, userID, shortURLs)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // MarkDeletedBatch indicates an expected call of MarkDeletedBatch.
@@ -403,6 +599,448 @@ func (mr *MockURLDeleterMockRecorder) MarkDeletedBatch(ctx, userID, shortURLs an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDeletedBatch", reflect.TypeOf((*MockURLDeleter)(nil).MarkDeletedBatch), ctx, userID, shortURLs)
 }
 
+// MockURLRestorer is a mock of URLRestorer interface.
+type MockURLRestorer struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockURLRestorerMockRecorder
+}
+
+// MockURLRestorerMockRecorder is the mock recorder for MockURLRestorer.
+type MockURLRestorerMockRecorder struct {
+	mock *MockURLRestorer
+}
+
+// NewMockURLRestorer creates a new mock instance.
+func NewMockURLRestorer(ctrl *gomock.Controller) *MockURLRestorer {
+	mock := &MockURLRestorer{ctrl: ctrl}
+	mock.recorder = &MockURLRestorerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockURLRestorer) EXPECT() *MockURLRestorerMockRecorder {
+	return m.recorder
+}
+
+// MarkRestoredBatch mocks base method.
+func (m *MockURLRestorer) MarkRestoredBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRestoredBatch", ctx, userID, shortURLs)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkRestoredBatch indicates an expected call of MarkRestoredBatch.
+func (mr *MockURLRestorerMockRecorder) MarkRestoredBatch(ctx, userID, shortURLs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRestoredBatch", reflect.TypeOf((*MockURLRestorer)(nil).MarkRestoredBatch), ctx, userID, shortURLs)
+}
+
+// MockURLUpdater is a mock of URLUpdater interface.
+type MockURLUpdater struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockURLUpdaterMockRecorder
+}
+
+// MockURLUpdaterMockRecorder is the mock recorder for MockURLUpdater.
+type MockURLUpdaterMockRecorder struct {
+	mock *MockURLUpdater
+}
+
+// NewMockURLUpdater creates a new mock instance.
+func NewMockURLUpdater(ctrl *gomock.Controller) *MockURLUpdater {
+	mock := &MockURLUpdater{ctrl: ctrl}
+	mock.recorder = &MockURLUpdaterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockURLUpdater) EXPECT() *MockURLUpdaterMockRecorder {
+	return m.recorder
+}
+
+// UpdateOriginalURL mocks base method.
+func (m *MockURLUpdater) UpdateOriginalURL(ctx context.Context, userID, shortURL, originalURL string) ([]entity.URLChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOriginalURL", ctx, userID, shortURL, originalURL)
+	ret0, _ := ret[0].([]entity.URLChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOriginalURL indicates an expected call of UpdateOriginalURL.
+func (mr *MockURLUpdaterMockRecorder) UpdateOriginalURL(ctx, userID, shortURL, originalURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOriginalURL", reflect.TypeOf((*MockURLUpdater)(nil).UpdateOriginalURL), ctx, userID, shortURL, originalURL)
+}
+
+// MockURLVisitor is a mock of URLVisitor interface.
+type MockURLVisitor struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockURLVisitorMockRecorder
+}
+
+// MockURLVisitorMockRecorder is the mock recorder for MockURLVisitor.
+type MockURLVisitorMockRecorder struct {
+	mock *MockURLVisitor
+}
+
+// NewMockURLVisitor creates a new mock instance.
+func NewMockURLVisitor(ctrl *gomock.Controller) *MockURLVisitor {
+	mock := &MockURLVisitor{ctrl: ctrl}
+	mock.recorder = &MockURLVisitorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockURLVisitor) EXPECT() *MockURLVisitorMockRecorder {
+	return m.recorder
+}
+
+// Visit mocks base method.
+func (m *MockURLVisitor) Visit(ctx context.Context, shortURL string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Visit", ctx, shortURL)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Visit indicates an expected call of Visit.
+func (mr *MockURLVisitorMockRecorder) Visit(ctx, shortURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Visit", reflect.TypeOf((*MockURLVisitor)(nil).Visit), ctx, shortURL)
+}
+
+// MockURLExpirer is a mock of URLExpirer interface.
+type MockURLExpirer struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockURLExpirerMockRecorder
+}
+
+// MockURLExpirerMockRecorder is the mock recorder for MockURLExpirer.
+type MockURLExpirerMockRecorder struct {
+	mock *MockURLExpirer
+}
+
+// NewMockURLExpirer creates a new mock instance.
+func NewMockURLExpirer(ctrl *gomock.Controller) *MockURLExpirer {
+	mock := &MockURLExpirer{ctrl: ctrl}
+	mock.recorder = &MockURLExpirerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockURLExpirer) EXPECT() *MockURLExpirerMockRecorder {
+	return m.recorder
+}
+
+// MarkExpiredDeleted mocks base method.
+func (m *MockURLExpirer) MarkExpiredDeleted(ctx context.Context, now time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkExpiredDeleted", ctx, now)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkExpiredDeleted indicates an expected call of MarkExpiredDeleted.
+func (mr *MockURLExpirerMockRecorder) MarkExpiredDeleted(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkExpiredDeleted", reflect.TypeOf((*MockURLExpirer)(nil).MarkExpiredDeleted), ctx, now)
+}
+
+// MockURLPurger is a mock of URLPurger interface.
+type MockURLPurger struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockURLPurgerMockRecorder
+}
+
+// MockURLPurgerMockRecorder is the mock recorder for MockURLPurger.
+type MockURLPurgerMockRecorder struct {
+	mock *MockURLPurger
+}
+
+// NewMockURLPurger creates a new mock instance.
+func NewMockURLPurger(ctrl *gomock.Controller) *MockURLPurger {
+	mock := &MockURLPurger{ctrl: ctrl}
+	mock.recorder = &MockURLPurgerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockURLPurger) EXPECT() *MockURLPurgerMockRecorder {
+	return m.recorder
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockURLPurger) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", ctx, deletedBefore, limit)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockURLPurgerMockRecorder) PurgeDeleted(ctx, deletedBefore, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockURLPurger)(nil).PurgeDeleted), ctx, deletedBefore, limit)
+}
+
+// MockClickSaver is a mock of ClickSaver interface.
+type MockClickSaver struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockClickSaverMockRecorder
+}
+
+// MockClickSaverMockRecorder is the mock recorder for MockClickSaver.
+type MockClickSaverMockRecorder struct {
+	mock *MockClickSaver
+}
+
+// NewMockClickSaver creates a new mock instance.
+func NewMockClickSaver(ctrl *gomock.Controller) *MockClickSaver {
+	mock := &MockClickSaver{ctrl: ctrl}
+	mock.recorder = &MockClickSaverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClickSaver) EXPECT() *MockClickSaverMockRecorder {
+	return m.recorder
+}
+
+// AddClicks mocks base method.
+func (m *MockClickSaver) AddClicks(ctx context.Context, clicks []entity.Click) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddClicks", ctx, clicks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddClicks indicates an expected call of AddClicks.
+func (mr *MockClickSaverMockRecorder) AddClicks(ctx, clicks any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddClicks", reflect.TypeOf((*MockClickSaver)(nil).AddClicks), ctx, clicks)
+}
+
+// MockClickStatsGetter is a mock of ClickStatsGetter interface.
+type MockClickStatsGetter struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockClickStatsGetterMockRecorder
+}
+
+// MockClickStatsGetterMockRecorder is the mock recorder for MockClickStatsGetter.
+type MockClickStatsGetterMockRecorder struct {
+	mock *MockClickStatsGetter
+}
+
+// NewMockClickStatsGetter creates a new mock instance.
+func NewMockClickStatsGetter(ctrl *gomock.Controller) *MockClickStatsGetter {
+	mock := &MockClickStatsGetter{ctrl: ctrl}
+	mock.recorder = &MockClickStatsGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClickStatsGetter) EXPECT() *MockClickStatsGetterMockRecorder {
+	return m.recorder
+}
+
+// GetClickStats mocks base method.
+func (m *MockClickStatsGetter) GetClickStats(ctx context.Context, userID, shortURL string) (entity.URLStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClickStats", ctx, userID, shortURL)
+	ret0, _ := ret[0].(entity.URLStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClickStats indicates an expected call of GetClickStats.
+func (mr *MockClickStatsGetterMockRecorder) GetClickStats(ctx, userID, shortURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClickStats", reflect.TypeOf((*MockClickStatsGetter)(nil).GetClickStats), ctx, userID, shortURL)
+}
+
+// MockServiceStatsGetter is a mock of ServiceStatsGetter interface.
+type MockServiceStatsGetter struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockServiceStatsGetterMockRecorder
+}
+
+// MockServiceStatsGetterMockRecorder is the mock recorder for MockServiceStatsGetter.
+type MockServiceStatsGetterMockRecorder struct {
+	mock *MockServiceStatsGetter
+}
+
+// NewMockServiceStatsGetter creates a new mock instance.
+func NewMockServiceStatsGetter(ctrl *gomock.Controller) *MockServiceStatsGetter {
+	mock := &MockServiceStatsGetter{ctrl: ctrl}
+	mock.recorder = &MockServiceStatsGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockServiceStatsGetter) EXPECT() *MockServiceStatsGetterMockRecorder {
+	return m.recorder
+}
+
+// GetServiceStats mocks base method.
+func (m *MockServiceStatsGetter) GetServiceStats(ctx context.Context) (entity.ServiceStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceStats", ctx)
+	ret0, _ := ret[0].(entity.ServiceStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceStats indicates an expected call of GetServiceStats.
+func (mr *MockServiceStatsGetterMockRecorder) GetServiceStats(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceStats", reflect.TypeOf((*MockServiceStatsGetter)(nil).GetServiceStats), ctx)
+}
+
+// MockAPIKeySaver is a mock of APIKeySaver interface.
+type MockAPIKeySaver struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeySaverMockRecorder
+}
+
+// MockAPIKeySaverMockRecorder is the mock recorder for MockAPIKeySaver.
+type MockAPIKeySaverMockRecorder struct {
+	mock *MockAPIKeySaver
+}
+
+// NewMockAPIKeySaver creates a new mock instance.
+func NewMockAPIKeySaver(ctrl *gomock.Controller) *MockAPIKeySaver {
+	mock := &MockAPIKeySaver{ctrl: ctrl}
+	mock.recorder = &MockAPIKeySaverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeySaver) EXPECT() *MockAPIKeySaverMockRecorder {
+	return m.recorder
+}
+
+// AddAPIKey mocks base method.
+func (m *MockAPIKeySaver) AddAPIKey(ctx context.Context, key entity.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAPIKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAPIKey indicates an expected call of AddAPIKey.
+func (mr *MockAPIKeySaverMockRecorder) AddAPIKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAPIKey", reflect.TypeOf((*MockAPIKeySaver)(nil).AddAPIKey), ctx, key)
+}
+
+// MockAPIKeyGetter is a mock of APIKeyGetter interface.
+type MockAPIKeyGetter struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyGetterMockRecorder
+}
+
+// MockAPIKeyGetterMockRecorder is the mock recorder for MockAPIKeyGetter.
+type MockAPIKeyGetterMockRecorder struct {
+	mock *MockAPIKeyGetter
+}
+
+// NewMockAPIKeyGetter creates a new mock instance.
+func NewMockAPIKeyGetter(ctrl *gomock.Controller) *MockAPIKeyGetter {
+	mock := &MockAPIKeyGetter{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyGetter) EXPECT() *MockAPIKeyGetterMockRecorder {
+	return m.recorder
+}
+
+// GetAPIKeyByHash mocks base method.
+func (m *MockAPIKeyGetter) GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", ctx, hash)
+	ret0, _ := ret[0].(entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockAPIKeyGetterMockRecorder) GetAPIKeyByHash(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockAPIKeyGetter)(nil).GetAPIKeyByHash), ctx, hash)
+}
+
+// GetUserAPIKeys mocks base method.
+func (m *MockAPIKeyGetter) GetUserAPIKeys(ctx context.Context, userID string) ([]entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserAPIKeys", ctx, userID)
+	ret0, _ := ret[0].([]entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserAPIKeys indicates an expected call of GetUserAPIKeys.
+func (mr *MockAPIKeyGetterMockRecorder) GetUserAPIKeys(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAPIKeys", reflect.TypeOf((*MockAPIKeyGetter)(nil).GetUserAPIKeys), ctx, userID)
+}
+
+// MockAPIKeyDeleter is a mock of APIKeyDeleter interface.
+type MockAPIKeyDeleter struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyDeleterMockRecorder
+}
+
+// MockAPIKeyDeleterMockRecorder is the mock recorder for MockAPIKeyDeleter.
+type MockAPIKeyDeleterMockRecorder struct {
+	mock *MockAPIKeyDeleter
+}
+
+// NewMockAPIKeyDeleter creates a new mock instance.
+func NewMockAPIKeyDeleter(ctrl *gomock.Controller) *MockAPIKeyDeleter {
+	mock := &MockAPIKeyDeleter{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyDeleterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyDeleter) EXPECT() *MockAPIKeyDeleterMockRecorder {
+	return m.recorder
+}
+
+// DeleteAPIKeys mocks base method.
+func (m *MockAPIKeyDeleter) DeleteAPIKeys(ctx context.Context, userID string, ids []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIKeys", ctx, userID, ids)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAPIKeys indicates an expected call of DeleteAPIKeys.
+func (mr *MockAPIKeyDeleterMockRecorder) DeleteAPIKeys(ctx, userID, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIKeys", reflect.TypeOf((*MockAPIKeyDeleter)(nil).DeleteAPIKeys), ctx, userID, ids)
+}
+
 // MockCloser is a mock of Closer interface.
 type MockCloser struct {
 	isgomock struct{}
@@ -440,3 +1078,42 @@ func (mr *MockCloserMockRecorder) Close() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockCloser)(nil).Close))
 }
+
+// MockURLSequenceGetter is a mock of URLSequenceGetter interface.
+type MockURLSequenceGetter struct {
+	isgomock struct{}
+	ctrl     *gomock.Controller
+	recorder *MockURLSequenceGetterMockRecorder
+}
+
+// MockURLSequenceGetterMockRecorder is the mock recorder for MockURLSequenceGetter.
+type MockURLSequenceGetterMockRecorder struct {
+	mock *MockURLSequenceGetter
+}
+
+// NewMockURLSequenceGetter creates a new mock instance.
+func NewMockURLSequenceGetter(ctrl *gomock.Controller) *MockURLSequenceGetter {
+	mock := &MockURLSequenceGetter{ctrl: ctrl}
+	mock.recorder = &MockURLSequenceGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockURLSequenceGetter) EXPECT() *MockURLSequenceGetterMockRecorder {
+	return m.recorder
+}
+
+// GetURLSequence mocks base method.
+func (m *MockURLSequenceGetter) GetURLSequence(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLSequence", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLSequence indicates an expected call of GetURLSequence.
+func (mr *MockURLSequenceGetterMockRecorder) GetURLSequence(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLSequence", reflect.TypeOf((*MockURLSequenceGetter)(nil).GetURLSequence), ctx)
+}
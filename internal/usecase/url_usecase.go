@@ -2,11 +2,14 @@ package usecase
 
 import (
 	"context"
-	"database/sql"
 	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 
-	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
 
 	"github.com/AGENT3128/shortener-url/internal/entity"
@@ -14,10 +17,28 @@ import (
 	"github.com/AGENT3128/shortener-url/pkg/shorneter"
 )
 
+const (
+	defaultShortURLAttempts = 3
+	// maxShortURLLength bounds the growth of the short URL length on repeated collisions.
+	maxShortURLLength = 32
+	// deadLettersLimit is the number of the latest dead letters listed to the operators.
+	deadLettersLimit = 100
+)
+
+// errNoDeleteWorker is returned when URLs are deleted or restored without a delete worker.
+var errNoDeleteWorker = errors.New("delete worker is not configured")
+
 type options struct {
-	repository URLRepository
-	logger     *zap.Logger
-	worker     *worker.DeleteWorker
+	repository       URLRepository
+	logger           *zap.Logger
+	worker           *worker.DeleteWorker
+	workers          *worker.Manager
+	clicks           *worker.ClickWorker
+	generator        shorneter.Generator
+	aliasPattern     *regexp.Regexp
+	reservedAliases  []string
+	shortURLAttempts int
+	shortURLLength   int
 }
 
 // Option is the option for the URLUsecase.
@@ -25,14 +46,27 @@ type Option func(options *options) error
 
 // URLUsecase is the usecase for the URL.
 type URLUsecase struct {
-	repository URLRepository
-	logger     *zap.Logger
-	worker     *worker.DeleteWorker
+	repository       URLRepository
+	logger           *zap.Logger
+	worker           *worker.DeleteWorker
+	workers          *worker.Manager
+	clicks           *worker.ClickWorker
+	generator        shorneter.Generator
+	aliasPattern     *regexp.Regexp
+	reservedAliases  map[string]struct{}
+	shortURLAttempts int
+	// shortURLLength grows by one every time shortURLAttempts collisions happen in a row.
+	shortURLLength atomic.Int64
 }
 
 // NewURLUsecase creates a new URLUsecase.
 func NewURLUsecase(opts ...Option) (*URLUsecase, error) {
-	options := &options{}
+	options := &options{
+		aliasPattern:     regexp.MustCompile(defaultAliasPattern),
+		reservedAliases:  []string{"api", "ping", "debug"},
+		shortURLAttempts: defaultShortURLAttempts,
+		shortURLLength:   shorneter.LENGTH,
+	}
 	for _, opt := range opts {
 		if err := opt(options); err != nil {
 			return nil, err
@@ -44,11 +78,61 @@ func NewURLUsecase(opts ...Option) (*URLUsecase, error) {
 	if options.logger == nil {
 		return nil, errors.New("logger is required")
 	}
-	return &URLUsecase{
-		repository: options.repository,
-		logger:     options.logger,
-		worker:     options.worker,
-	}, nil
+	if options.generator == nil {
+		generator, err := shorneter.NewGenerator(shorneter.StrategyRandom)
+		if err != nil {
+			return nil, err
+		}
+		options.generator = generator
+	}
+	uc := &URLUsecase{
+		repository:       options.repository,
+		logger:           options.logger,
+		worker:           options.worker,
+		workers:          options.workers,
+		clicks:           options.clicks,
+		generator:        options.generator,
+		aliasPattern:     options.aliasPattern,
+		reservedAliases:  make(map[string]struct{}, len(options.reservedAliases)),
+		shortURLAttempts: options.shortURLAttempts,
+	}
+	for _, alias := range options.reservedAliases {
+		uc.reservedAliases[strings.ToLower(alias)] = struct{}{}
+	}
+	uc.shortURLLength.Store(int64(options.shortURLLength))
+	return uc, nil
+}
+
+// WithGenerator is the option for the URLUsecase to set the short URL generator.
+// The random generator is used by default.
+func WithGenerator(generator shorneter.Generator) Option {
+	return func(options *options) error {
+		options.generator = generator
+		return nil
+	}
+}
+
+// WithShortURLLength is the option for the URLUsecase to set the initial short URL length.
+func WithShortURLLength(length int) Option {
+	return func(options *options) error {
+		if length < 1 || length > maxShortURLLength {
+			return fmt.Errorf("short url length must be between 1 and %d", maxShortURLLength)
+		}
+		options.shortURLLength = length
+		return nil
+	}
+}
+
+// WithShortURLAttempts is the option for the URLUsecase to set how many times a taken short URL
+// is regenerated before the short URL length grows.
+func WithShortURLAttempts(attempts int) Option {
+	return func(options *options) error {
+		if attempts < 1 {
+			return errors.New("short url attempts must be positive")
+		}
+		options.shortURLAttempts = attempts
+		return nil
+	}
 }
 
 // WithDeleteWorker is the option for the URLUsecase to set the delete worker.
@@ -59,6 +143,15 @@ func WithDeleteWorker(worker *worker.DeleteWorker) Option {
 	}
 }
 
+// WithWorkers is the option for the URLUsecase to set the manager running the background workers.
+// The workers are drained when the URLUsecase is shut down.
+func WithWorkers(workers *worker.Manager) Option {
+	return func(options *options) error {
+		options.workers = workers
+		return nil
+	}
+}
+
 // WithURLUsecaseRepository is the option for the URLUsecase to set the repository.
 func WithURLUsecaseRepository(repository URLRepository) Option {
 	return func(options *options) error {
@@ -75,34 +168,69 @@ func WithURLUsecaseLogger(logger *zap.Logger) Option {
 	}
 }
 
-// Shutdown shuts down the URLUsecase.
-func (uc *URLUsecase) Shutdown() {
-	if uc.worker != nil {
-		uc.worker.Shutdown()
+// Shutdown drains the background workers until ctx is done and closes the repository.
+// The repository is left open if the workers did not stop in time, they may still be using it.
+func (uc *URLUsecase) Shutdown(ctx context.Context) error {
+	if uc.workers != nil {
+		if err := uc.workers.Shutdown(ctx); err != nil {
+			return err
+		}
 	}
 	if closer, ok := uc.repository.(Closer); ok {
 		if err := closer.Close(); err != nil {
-			uc.logger.Error("failed to close repository", zap.Error(err))
+			return fmt.Errorf("failed to close repository: %w", err)
+		}
+	}
+	return nil
+}
+
+// withShortURLRetry calls insert with the current short URL length until the repository
+// stops reporting a taken short URL. After shortURLAttempts collisions in a row the length grows by one.
+func (uc *URLUsecase) withShortURLRetry(insert func(length int) error) error {
+	for {
+		length := uc.shortURLLength.Load()
+		for range uc.shortURLAttempts {
+			err := insert(int(length))
+			if !errors.Is(err, entity.ErrShortURLTaken) {
+				return err
+			}
+			uc.logger.Warn("short url collision", zap.Int64("length", length))
+		}
+		if length >= maxShortURLLength {
+			return entity.ErrShortURLTaken
+		}
+		if uc.shortURLLength.CompareAndSwap(length, length+1) {
+			uc.logger.Warn("short url attempts exhausted, growing length", zap.Int64("length", length+1))
 		}
 	}
 }
 
 // Add adds a URL.
-func (uc *URLUsecase) Add(ctx context.Context, userID string, originalURL string) (string, error) {
-	shortURL, err := shorneter.GenerateShortIDOptimized()
-	if err != nil {
+// A non-empty ShortURL is an alias used as the short URL as is; otherwise a taken short URL
+// is regenerated, see withShortURLRetry. ExpiresAt and MaxClicks limit the redirects, see validateLimits.
+func (uc *URLUsecase) Add(ctx context.Context, userID string, url entity.URL) (string, error) {
+	if err := validateLimits(url, time.Now()); err != nil {
 		return "", err
 	}
-	shortURL, err = uc.repository.Add(ctx, userID, shortURL, originalURL)
+	if url.ShortURL != "" {
+		return uc.addAlias(ctx, userID, url)
+	}
+
+	var shortURL string
+	err := uc.withShortURLRetry(func(length int) error {
+		candidate, errGenerate := uc.generator.Generate(length)
+		if errGenerate != nil {
+			return errGenerate
+		}
+		url.ShortURL = candidate
+		var errAdd error
+		shortURL, errAdd = uc.repository.Add(ctx, userID, url)
+		return errAdd
+	})
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
-			// Try to get existing short URL for this original URL
-			existingShortURL, getErr := uc.GetByOriginalURL(ctx, originalURL)
-			if getErr != nil {
-				return "", err
-			}
-			return existingShortURL, entity.ErrURLExists
+		var existsErr *entity.URLExistsError
+		if errors.As(err, &existsErr) {
+			return existsErr.ShortURL, err
 		}
 		return "", err
 	}
@@ -110,22 +238,21 @@ func (uc *URLUsecase) Add(ctx context.Context, userID string, originalURL string
 	return shortURL, nil
 }
 
-// GetByOriginalURL gets the short URL by the original URL.
-func (uc *URLUsecase) GetByOriginalURL(ctx context.Context, originalURL string) (string, error) {
-	shortURL, err := uc.repository.GetByOriginalURL(ctx, originalURL)
+// GetByOriginalURL gets the short URL the user's original URL is deduplicated to.
+// Repositories report a missing URL, or any URL when nothing is deduplicated, as entity.ErrURLNotFound.
+func (uc *URLUsecase) GetByOriginalURL(ctx context.Context, userID, originalURL string) (string, error) {
+	shortURL, err := uc.repository.GetByOriginalURL(ctx, userID, originalURL)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return "", entity.ErrURLNotFound
-		}
 		return "", err
 	}
 	return shortURL, nil
 }
 
-// GetByShortURL gets the original URL by the short URL.
+// GetByShortURL gets the original URL by the short URL to redirect to and counts the click.
+// An expired URL or one that used up its clicks is reported as entity.ErrURLExpired.
 func (uc *URLUsecase) GetByShortURL(ctx context.Context, shortURL string) (string, error) {
 	uc.logger.Info("searching for short URL", zap.String("short_url", shortURL))
-	originalURL, err := uc.repository.GetByShortURL(ctx, shortURL)
+	originalURL, err := uc.repository.Visit(ctx, shortURL)
 	if err != nil {
 		return "", err
 	}
@@ -138,56 +265,206 @@ func (uc *URLUsecase) Ping(ctx context.Context) error {
 	return uc.repository.Ping(ctx)
 }
 
+// GetServiceStats counts the short URLs and users of the service.
+func (uc *URLUsecase) GetServiceStats(ctx context.Context) (entity.ServiceStats, error) {
+	return uc.repository.GetServiceStats(ctx)
+}
+
 // AddBatch adds a batch of URLs.
+// A non-empty ShortURL of an input URL is its alias. Already shortened URLs keep their short URLs,
+// an alias of such a URL or of a repeated one that differs from its short URL is reported as
+// entity.ErrAliasTaken; the rest are stored as a whole and get fresh short URLs on every retry, see withShortURLRetry.
 func (uc *URLUsecase) AddBatch(ctx context.Context, userID string, urls []entity.URL) ([]entity.URL, error) {
 	uc.logger.Info("adding batch of URLs", zap.Any("urls", urls))
-	uniqueURLs := make([]entity.URL, 0, len(urls))
+	shortURLs := make(map[string]string, len(urls))
+	pending, err := uc.pendingURLs(ctx, userID, urls, shortURLs)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		if errStore := uc.storeBatch(ctx, userID, pending, shortURLs); errStore != nil {
+			return nil, errStore
+		}
+	}
+
 	result := make([]entity.URL, 0, len(urls))
 	for _, url := range urls {
+		result = append(result, entity.URL{
+			OriginalURL: url.OriginalURL,
+			ShortURL:    shortURLs[url.OriginalURL],
+		})
+	}
+	uc.logger.Info("response", zap.Any("response", result))
+	return result, nil
+}
+
+// pendingURLs records the short URLs of URLs the user already shortened and returns the URLs to store.
+func (uc *URLUsecase) pendingURLs(
+	ctx context.Context,
+	userID string,
+	urls []entity.URL,
+	shortURLs map[string]string,
+) ([]entity.URL, error) {
+	pending := make([]entity.URL, 0, len(urls))
+	// repeated original URLs within the batch share one short URL, seen holds its alias or existing short URL
+	seen := make(map[string]string, len(urls))
+	aliases := make(map[string]struct{})
+	now := time.Now()
+	for _, url := range urls {
+		if err := validateLimits(url, now); err != nil {
+			return nil, err
+		}
 		uc.logger.Info("processing url", zap.String("url", url.OriginalURL))
+		if shortURL, ok := seen[url.OriginalURL]; ok {
+			if url.ShortURL != "" && url.ShortURL != shortURL {
+				return nil, entity.ErrAliasTaken
+			}
+			continue
+		}
+
 		// if OriginalURL exist in db
-		existingURL, err := uc.GetByOriginalURL(ctx, url.OriginalURL)
-		if err != nil {
-			if errors.Is(err, entity.ErrURLNotFound) {
-				shortURL, errGenerate := shorneter.GenerateShortIDOptimized()
-				if errGenerate != nil {
-					return nil, errGenerate
-				}
-				uniqueURLs = append(uniqueURLs, entity.URL{
-					OriginalURL: url.OriginalURL,
-					ShortURL:    shortURL,
-				})
-				result = append(result, entity.URL{
-					OriginalURL: url.OriginalURL,
-					ShortURL:    shortURL,
-				})
-				continue
+		existingURL, err := uc.GetByOriginalURL(ctx, userID, url.OriginalURL)
+		if err == nil {
+			// the URL keeps its short URL, an alias asking for another one is not honored
+			if url.ShortURL != "" && url.ShortURL != existingURL {
+				return nil, entity.ErrAliasTaken
 			}
+			seen[url.OriginalURL] = existingURL
+			shortURLs[url.OriginalURL] = existingURL
+			continue
+		}
+		if !errors.Is(err, entity.ErrURLNotFound) {
 			return nil, err
 		}
-		result = append(result, entity.URL{
+
+		if url.ShortURL != "" {
+			if errAlias := uc.validateAlias(url.ShortURL); errAlias != nil {
+				return nil, errAlias
+			}
+			if _, ok := aliases[url.ShortURL]; ok {
+				return nil, entity.ErrAliasTaken
+			}
+			aliases[url.ShortURL] = struct{}{}
+		}
+		seen[url.OriginalURL] = url.ShortURL
+		pending = append(pending, entity.URL{
+			ExpiresAt:   url.ExpiresAt,
+			ShortURL:    url.ShortURL,
 			OriginalURL: url.OriginalURL,
-			ShortURL:    existingURL,
+			MaxClicks:   url.MaxClicks,
 		})
 	}
+	if err := uc.checkAliasesFree(ctx, pending); err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
 
-	uc.logger.Info("response", zap.Any("response", result))
-	return result, uc.repository.AddBatch(ctx, userID, uniqueURLs)
+// storeBatch stores the pending URLs generating the short URLs of those without an alias.
+func (uc *URLUsecase) storeBatch(
+	ctx context.Context,
+	userID string,
+	pending []entity.URL,
+	shortURLs map[string]string,
+) error {
+	return uc.withShortURLRetry(func(length int) error {
+		newURLs := make([]entity.URL, 0, len(pending))
+		for _, url := range pending {
+			if url.ShortURL == "" {
+				shortURL, errGenerate := uc.generator.Generate(length)
+				if errGenerate != nil {
+					return errGenerate
+				}
+				url.ShortURL = shortURL
+			}
+			newURLs = append(newURLs, url)
+		}
+		if errAdd := uc.repository.AddBatch(ctx, userID, newURLs); errAdd != nil {
+			if errors.Is(errAdd, entity.ErrShortURLTaken) {
+				// an alias taken in the meantime is not fixed by a retry
+				if errAliases := uc.checkAliasesFree(ctx, pending); errAliases != nil {
+					return errAliases
+				}
+			}
+			return errAdd
+		}
+		for _, url := range newURLs {
+			shortURLs[url.OriginalURL] = url.ShortURL
+		}
+		return nil
+	})
+}
+
+// GetUserURLs gets user URLs. Deleted URLs are left out unless includeDeleted is set.
+func (uc *URLUsecase) GetUserURLs(ctx context.Context, userID string, includeDeleted bool) ([]entity.URL, error) {
+	urls, err := uc.repository.GetUserURLs(ctx, userID)
+	if err != nil || includeDeleted {
+		return urls, err
+	}
+	return slices.DeleteFunc(urls, func(url entity.URL) bool { return url.DeletedFlag }), nil
 }
 
-// GetUserURLs gets user URLs.
-func (uc *URLUsecase) GetUserURLs(ctx context.Context, userID string) ([]entity.URL, error) {
-	return uc.repository.GetUserURLs(ctx, userID)
+// UpdateOriginalURL points the user's short URL to the original URL and returns its previous destinations,
+// the oldest first. A URL of another user is reported as entity.ErrURLNotFound.
+func (uc *URLUsecase) UpdateOriginalURL(
+	ctx context.Context,
+	userID, shortURL, originalURL string,
+) ([]entity.URLChange, error) {
+	uc.logger.Info(
+		"updating original URL",
+		zap.String("userID", userID),
+		zap.String("short_url", shortURL),
+		zap.String("original_url", originalURL),
+	)
+	return uc.repository.UpdateOriginalURL(ctx, userID, shortURL, originalURL)
 }
 
-// DeleteUserURLs deletes user URLs.
-func (uc *URLUsecase) DeleteUserURLs(_ context.Context, userID string, shortURLs []string) error {
+// DeleteUserURLs deletes user URLs asynchronously and returns the pending job tracking the deletion.
+func (uc *URLUsecase) DeleteUserURLs(ctx context.Context, userID string, shortURLs []string) (entity.Job, error) {
 	uc.logger.Info("deleting user URLs", zap.String("userID", userID), zap.Any("shortURLs", shortURLs))
-	if uc.worker != nil {
-		uc.worker.EnqueueDelete(worker.DeleteRequest{
-			UserID:    userID,
-			ShortURLs: shortURLs,
-		})
+	if uc.worker == nil {
+		return entity.Job{}, errNoDeleteWorker
 	}
-	return nil
+	return uc.worker.EnqueueDelete(ctx, worker.DeleteRequest{
+		UserID:    userID,
+		ShortURLs: shortURLs,
+	})
+}
+
+// RestoreUserURLs restores deleted user URLs asynchronously and returns the pending job tracking the restore.
+// Restores share the queue with deletes, so both are applied in the order they were requested.
+func (uc *URLUsecase) RestoreUserURLs(ctx context.Context, userID string, shortURLs []string) (entity.Job, error) {
+	uc.logger.Info("restoring user URLs", zap.String("userID", userID), zap.Any("shortURLs", shortURLs))
+	if uc.worker == nil {
+		return entity.Job{}, errNoDeleteWorker
+	}
+	return uc.worker.EnqueueDelete(ctx, worker.DeleteRequest{
+		UserID:    userID,
+		ShortURLs: shortURLs,
+		Restore:   true,
+	})
+}
+
+// GetUserJob gets the user's delete or restore job.
+// Unknown jobs, jobs past their retention and jobs of other users are reported as entity.ErrJobNotFound.
+func (uc *URLUsecase) GetUserJob(ctx context.Context, userID, jobID string) (entity.Job, error) {
+	if uc.worker == nil {
+		return entity.Job{}, entity.ErrJobNotFound
+	}
+	job, err := uc.worker.Job(ctx, jobID)
+	if err != nil {
+		return entity.Job{}, err
+	}
+	if job.UserID != userID {
+		return entity.Job{}, entity.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// GetDeadLetters gets the latest delete and restore tasks that ran out of attempts.
+func (uc *URLUsecase) GetDeadLetters(ctx context.Context) ([]entity.DeleteTask, error) {
+	if uc.worker == nil {
+		return make([]entity.DeleteTask, 0), nil
+	}
+	return uc.worker.DeadLetters(ctx, deadLettersLimit)
 }
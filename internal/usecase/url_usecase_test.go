@@ -2,21 +2,21 @@ package usecase_test
 
 import (
 	"context"
-	"database/sql"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
 
 	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/repository/memory"
 	"github.com/AGENT3128/shortener-url/internal/usecase"
 	"github.com/AGENT3128/shortener-url/internal/usecase/mocks"
 	"github.com/AGENT3128/shortener-url/internal/worker"
+	"github.com/AGENT3128/shortener-url/pkg/shorneter"
 )
 
 func TestURLUsecase_Add(t *testing.T) {
@@ -50,7 +50,7 @@ func TestURLUsecase_Add(t *testing.T) {
 			},
 			setup: func() {
 				urlRepositoryMock.EXPECT().
-					Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Add(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return("mEENY1b2", nil)
 			},
 			wantErr: false,
@@ -65,7 +65,7 @@ func TestURLUsecase_Add(t *testing.T) {
 			},
 			setup: func() {
 				urlRepositoryMock.EXPECT().
-					Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Add(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return("", entity.ErrURLExists)
 			},
 			wantErr: true,
@@ -73,43 +73,40 @@ func TestURLUsecase_Add(t *testing.T) {
 			want:    "",
 		},
 		{
-			name: "postgres unique violation with successful get existing url",
+			name: "repository reports existing url",
 			url: &entity.URL{
 				ShortURL:    "mEENY1b2",
 				UserID:      "1",
 				OriginalURL: "https://example.com",
 			},
 			setup: func() {
-				pgErr := &pgconn.PgError{Code: pgerrcode.UniqueViolation}
 				urlRepositoryMock.EXPECT().
-					Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-					Return("", pgErr)
-				urlRepositoryMock.EXPECT().
-					GetByOriginalURL(gomock.Any(), "https://example.com").
-					Return("existingShort", nil)
+					Add(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return("existingShort", &entity.URLExistsError{ShortURL: "existingShort"})
 			},
 			wantErr: true,
 			errType: entity.ErrURLExists,
 			want:    "existingShort",
 		},
 		{
-			name: "postgres unique violation with failed get existing url",
+			name: "taken short url is regenerated",
 			url: &entity.URL{
 				ShortURL:    "mEENY1b2",
 				UserID:      "1",
 				OriginalURL: "https://example.com",
 			},
 			setup: func() {
-				pgErr := &pgconn.PgError{Code: pgerrcode.UniqueViolation}
-				urlRepositoryMock.EXPECT().
-					Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-					Return("", pgErr)
-				urlRepositoryMock.EXPECT().
-					GetByOriginalURL(gomock.Any(), "https://example.com").
-					Return("", errors.New("db error"))
+				gomock.InOrder(
+					urlRepositoryMock.EXPECT().
+						Add(gomock.Any(), gomock.Any(), gomock.Any()).
+						Return("", entity.ErrShortURLTaken),
+					urlRepositoryMock.EXPECT().
+						Add(gomock.Any(), gomock.Any(), gomock.Any()).
+						Return("mEENY1b2", nil),
+				)
 			},
-			wantErr: true,
-			want:    "",
+			wantErr: false,
+			want:    "mEENY1b2",
 		},
 		{
 			name: "other repository error",
@@ -120,7 +117,7 @@ func TestURLUsecase_Add(t *testing.T) {
 			},
 			setup: func() {
 				urlRepositoryMock.EXPECT().
-					Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Add(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return("", errors.New("repository error"))
 			},
 			wantErr: true,
@@ -134,7 +131,7 @@ func TestURLUsecase_Add(t *testing.T) {
 			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
 			defer cancel()
 
-			got, errAdd := usecase.Add(ctx, tt.url.UserID, tt.url.OriginalURL)
+			got, errAdd := usecase.Add(ctx, tt.url.UserID, entity.URL{OriginalURL: tt.url.OriginalURL})
 
 			if tt.wantErr {
 				require.Error(t, errAdd)
@@ -149,6 +146,118 @@ func TestURLUsecase_Add(t *testing.T) {
 	}
 }
 
+func TestURLUsecase_Generator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	urlRepositoryMock := mocks.NewMockURLRepository(ctrl)
+	generator, err := shorneter.NewGenerator(
+		shorneter.StrategyCounter,
+		shorneter.WithAlphabet("0123456789"),
+		shorneter.WithCounterStart(42),
+	)
+	require.NoError(t, err)
+
+	_, err = usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(urlRepositoryMock),
+		usecase.WithURLUsecaseLogger(zap.NewNop()),
+		usecase.WithShortURLLength(0),
+	)
+	require.Error(t, err)
+
+	usecase, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(urlRepositoryMock),
+		usecase.WithURLUsecaseLogger(zap.NewNop()),
+		usecase.WithGenerator(generator),
+		usecase.WithShortURLLength(4),
+	)
+	require.NoError(t, err)
+
+	urlRepositoryMock.EXPECT().
+		Add(gomock.Any(), "user1", entity.URL{ShortURL: "0042", OriginalURL: "https://example.com"}).
+		Return("0042", nil)
+	got, err := usecase.Add(t.Context(), "user1", entity.URL{OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	require.Equal(t, "0042", got)
+
+	urlRepositoryMock.EXPECT().
+		GetByOriginalURL(gomock.Any(), gomock.Any(), "https://example1.com").
+		Return("", entity.ErrURLNotFound)
+	urlRepositoryMock.EXPECT().
+		AddBatch(gomock.Any(), "user1", []entity.URL{{OriginalURL: "https://example1.com", ShortURL: "0043"}}).
+		Return(nil)
+	urls, err := usecase.AddBatch(t.Context(), "user1", []entity.URL{{OriginalURL: "https://example1.com"}})
+	require.NoError(t, err)
+	require.Equal(t, []entity.URL{{OriginalURL: "https://example1.com", ShortURL: "0043"}}, urls)
+}
+
+func TestURLUsecase_ShortURLLengthGrowth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	urlRepositoryMock := mocks.NewMockURLRepository(ctrl)
+	_, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(urlRepositoryMock),
+		usecase.WithURLUsecaseLogger(zap.NewNop()),
+		usecase.WithShortURLAttempts(0),
+	)
+	require.Error(t, err)
+
+	usecase, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(urlRepositoryMock),
+		usecase.WithURLUsecaseLogger(zap.NewNop()),
+		usecase.WithShortURLAttempts(2),
+	)
+	require.NoError(t, err)
+
+	ofLength := func(length int) gomock.Matcher {
+		return gomock.Cond(func(url entity.URL) bool { return len(url.ShortURL) == length })
+	}
+
+	t.Run("add", func(t *testing.T) {
+		gomock.InOrder(
+			urlRepositoryMock.EXPECT().
+				Add(gomock.Any(), "user1", ofLength(shorneter.LENGTH)).
+				Times(2).
+				Return("", entity.ErrShortURLTaken),
+			urlRepositoryMock.EXPECT().
+				Add(gomock.Any(), "user1", ofLength(shorneter.LENGTH+1)).
+				DoAndReturn(func(_ context.Context, _ string, url entity.URL) (string, error) {
+					return url.ShortURL, nil
+				}),
+		)
+
+		got, errAdd := usecase.Add(t.Context(), "user1", entity.URL{OriginalURL: "https://example.com"})
+		require.NoError(t, errAdd)
+		require.Len(t, got, shorneter.LENGTH+1)
+	})
+
+	t.Run("add batch keeps the grown length", func(t *testing.T) {
+		urlRepositoryMock.EXPECT().
+			GetByOriginalURL(gomock.Any(), gomock.Any(), "https://example1.com").
+			Return("", entity.ErrURLNotFound)
+		batchOfLength := func(length int) gomock.Matcher {
+			return gomock.Cond(func(urls []entity.URL) bool {
+				return len(urls) == 1 && len(urls[0].ShortURL) == length
+			})
+		}
+		gomock.InOrder(
+			urlRepositoryMock.EXPECT().
+				AddBatch(gomock.Any(), "user1", batchOfLength(shorneter.LENGTH+1)).
+				Times(2).
+				Return(entity.ErrShortURLTaken),
+			urlRepositoryMock.EXPECT().
+				AddBatch(gomock.Any(), "user1", batchOfLength(shorneter.LENGTH+2)).
+				Return(nil),
+		)
+
+		got, errAdd := usecase.AddBatch(t.Context(), "user1", []entity.URL{{OriginalURL: "https://example1.com"}})
+		require.NoError(t, errAdd)
+		require.Len(t, got, 1)
+		require.Len(t, got[0].ShortURL, shorneter.LENGTH+2)
+	})
+}
+
 func TestURLUsecase_GetByOriginalURL(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -176,7 +285,7 @@ func TestURLUsecase_GetByOriginalURL(t *testing.T) {
 			originalURL: "https://example.com",
 			setup: func() {
 				urlRepositoryMock.EXPECT().
-					GetByOriginalURL(gomock.Any(), "https://example.com").
+					GetByOriginalURL(gomock.Any(), "user1", "https://example.com").
 					Return("shortURL", nil)
 			},
 			want:    "shortURL",
@@ -187,8 +296,8 @@ func TestURLUsecase_GetByOriginalURL(t *testing.T) {
 			originalURL: "https://example.com",
 			setup: func() {
 				urlRepositoryMock.EXPECT().
-					GetByOriginalURL(gomock.Any(), "https://example.com").
-					Return("", sql.ErrNoRows)
+					GetByOriginalURL(gomock.Any(), "user1", "https://example.com").
+					Return("", entity.ErrURLNotFound)
 			},
 			want:    "",
 			wantErr: true,
@@ -199,7 +308,7 @@ func TestURLUsecase_GetByOriginalURL(t *testing.T) {
 			originalURL: "https://example.com",
 			setup: func() {
 				urlRepositoryMock.EXPECT().
-					GetByOriginalURL(gomock.Any(), "https://example.com").
+					GetByOriginalURL(gomock.Any(), "user1", "https://example.com").
 					Return("", errors.New("repository error"))
 			},
 			want:    "",
@@ -213,7 +322,7 @@ func TestURLUsecase_GetByOriginalURL(t *testing.T) {
 			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
 			defer cancel()
 
-			got, errGet := usecase.GetByOriginalURL(ctx, tt.originalURL)
+			got, errGet := usecase.GetByOriginalURL(ctx, "user1", tt.originalURL)
 			if tt.wantErr {
 				require.Error(t, errGet)
 				if tt.errType != nil {
@@ -253,7 +362,7 @@ func TestURLUsecase_GetByShortURL(t *testing.T) {
 			shortURL: "abc123",
 			setup: func() {
 				urlRepositoryMock.EXPECT().
-					GetByShortURL(gomock.Any(), "abc123").
+					Visit(gomock.Any(), "abc123").
 					Return("https://example.com", nil)
 			},
 			want:    "https://example.com",
@@ -264,8 +373,8 @@ func TestURLUsecase_GetByShortURL(t *testing.T) {
 			shortURL: "abc123",
 			setup: func() {
 				urlRepositoryMock.EXPECT().
-					GetByShortURL(gomock.Any(), "abc123").
-					Return("", sql.ErrNoRows)
+					Visit(gomock.Any(), "abc123").
+					Return("", entity.ErrURLNotFound)
 			},
 			want:    "",
 			wantErr: true,
@@ -320,10 +429,10 @@ func TestURLUsecase_AddBatch(t *testing.T) {
 			},
 			setup: func() {
 				urlRepositoryMock.EXPECT().
-					GetByOriginalURL(gomock.Any(), "https://example1.com").
+					GetByOriginalURL(gomock.Any(), gomock.Any(), "https://example1.com").
 					Return("", entity.ErrURLNotFound)
 				urlRepositoryMock.EXPECT().
-					GetByOriginalURL(gomock.Any(), "https://example2.com").
+					GetByOriginalURL(gomock.Any(), gomock.Any(), "https://example2.com").
 					Return("", entity.ErrURLNotFound)
 				urlRepositoryMock.EXPECT().
 					AddBatch(gomock.Any(), "user1", gomock.Any()).
@@ -331,6 +440,23 @@ func TestURLUsecase_AddBatch(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:   "repeated urls in batch",
+			userID: "user1",
+			urls: []entity.URL{
+				{OriginalURL: "https://example1.com"},
+				{OriginalURL: "https://example1.com"},
+			},
+			setup: func() {
+				urlRepositoryMock.EXPECT().
+					GetByOriginalURL(gomock.Any(), gomock.Any(), "https://example1.com").
+					Return("", entity.ErrURLNotFound)
+				urlRepositoryMock.EXPECT().
+					AddBatch(gomock.Any(), "user1", gomock.Len(1)).
+					Return(nil)
+			},
+			wantErr: false,
+		},
 		{
 			name:   "partial existing urls",
 			userID: "user1",
@@ -340,10 +466,10 @@ func TestURLUsecase_AddBatch(t *testing.T) {
 			},
 			setup: func() {
 				urlRepositoryMock.EXPECT().
-					GetByOriginalURL(gomock.Any(), "https://example1.com").
+					GetByOriginalURL(gomock.Any(), gomock.Any(), "https://example1.com").
 					Return("existing1", nil)
 				urlRepositoryMock.EXPECT().
-					GetByOriginalURL(gomock.Any(), "https://example2.com").
+					GetByOriginalURL(gomock.Any(), gomock.Any(), "https://example2.com").
 					Return("", entity.ErrURLNotFound)
 				urlRepositoryMock.EXPECT().
 					AddBatch(gomock.Any(), "user1", gomock.Any()).
@@ -385,11 +511,12 @@ func TestURLUsecase_GetUserURLs(t *testing.T) {
 	require.NoError(t, err)
 
 	tests := []struct {
-		name    string
-		userID  string
-		setup   func()
-		want    []entity.URL
-		wantErr bool
+		name           string
+		userID         string
+		setup          func()
+		want           []entity.URL
+		includeDeleted bool
+		wantErr        bool
 	}{
 		{
 			name:   "success get user urls",
@@ -408,6 +535,38 @@ func TestURLUsecase_GetUserURLs(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:   "deleted urls left out",
+			userID: "user1",
+			setup: func() {
+				urlRepositoryMock.EXPECT().
+					GetUserURLs(gomock.Any(), "user1").
+					Return([]entity.URL{
+						{ShortURL: "abc123", OriginalURL: "https://example1.com", DeletedFlag: true},
+						{ShortURL: "def456", OriginalURL: "https://example2.com"},
+					}, nil)
+			},
+			want: []entity.URL{
+				{ShortURL: "def456", OriginalURL: "https://example2.com"},
+			},
+		},
+		{
+			name:           "deleted urls included",
+			userID:         "user1",
+			includeDeleted: true,
+			setup: func() {
+				urlRepositoryMock.EXPECT().
+					GetUserURLs(gomock.Any(), "user1").
+					Return([]entity.URL{
+						{ShortURL: "abc123", OriginalURL: "https://example1.com", DeletedFlag: true},
+						{ShortURL: "def456", OriginalURL: "https://example2.com"},
+					}, nil)
+			},
+			want: []entity.URL{
+				{ShortURL: "abc123", OriginalURL: "https://example1.com", DeletedFlag: true},
+				{ShortURL: "def456", OriginalURL: "https://example2.com"},
+			},
+		},
 		{
 			name:   "repository error",
 			userID: "user1",
@@ -427,7 +586,7 @@ func TestURLUsecase_GetUserURLs(t *testing.T) {
 			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
 			defer cancel()
 
-			got, errGet := usecase.GetUserURLs(ctx, tt.userID)
+			got, errGet := usecase.GetUserURLs(ctx, tt.userID, tt.includeDeleted)
 			if tt.wantErr {
 				require.Error(t, errGet)
 			} else {
@@ -445,57 +604,133 @@ func TestURLUsecase_DeleteUserURLs(t *testing.T) {
 	urlRepositoryMock := mocks.NewMockURLRepository(ctrl)
 	logger, err := zap.NewDevelopment()
 	require.NoError(t, err)
-	deleteWorker := worker.NewDeleteWorker(urlRepositoryMock, logger)
+	// a single attempt moves the failed job to the dead letters right away
+	deleteWorker := worker.NewDeleteWorker(
+		urlRepositoryMock,
+		worker.NewMemoryDeleteQueue(),
+		logger,
+		worker.WithMaxAttempts(1),
+	)
+	// the jobs are processed when the workers are drained on shutdown
+	workers := worker.NewManager(logger)
+	require.NoError(t, workers.Register(
+		worker.DeleteJobType,
+		deleteWorker,
+		worker.WithFlushInterval(time.Hour),
+		worker.WithDrainOnShutdown(),
+	))
 
 	usecase, err := usecase.NewURLUsecase(
 		usecase.WithURLUsecaseRepository(urlRepositoryMock),
 		usecase.WithURLUsecaseLogger(logger),
 		usecase.WithDeleteWorker(deleteWorker),
+		usecase.WithWorkers(workers),
 	)
 	require.NoError(t, err)
 
-	tests := []struct {
-		setup     func()
-		name      string
-		userID    string
-		shortURLs []string
-		wantErr   bool
-	}{
-		{
-			name:      "success delete urls",
-			userID:    "user1",
-			shortURLs: []string{"abc123", "def456"},
-			setup: func() {
-				urlRepositoryMock.EXPECT().
-					MarkDeletedBatch(gomock.Any(), "user1", []string{"abc123", "def456"}).
-					Return(nil)
-				urlRepositoryMock.EXPECT().
-					Close().
-					Return(nil)
-			},
-			wantErr: false,
-		},
-	}
+	urlRepositoryMock.EXPECT().
+		MarkDeletedBatch(gomock.Any(), "user1", []string{"abc123", "def456", "ghi789"}).
+		Return([]string{"abc123", "ghi789"}, nil)
+	urlRepositoryMock.EXPECT().
+		MarkDeletedBatch(gomock.Any(), "user2", []string{"abc123"}).
+		Return(nil, errors.New("connection refused"))
+	urlRepositoryMock.EXPECT().Close().Return(nil)
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if tt.setup != nil {
-				tt.setup()
-			}
-			ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
-			defer cancel()
+	// the requests of one user are batched, each job reports its own short URLs
+	first, err := usecase.DeleteUserURLs(t.Context(), "user1", []string{"abc123", "def456"})
+	require.NoError(t, err)
+	require.Equal(t, entity.JobPending, first.Status)
+	second, err := usecase.DeleteUserURLs(t.Context(), "user1", []string{"ghi789"})
+	require.NoError(t, err)
+	failed, err := usecase.DeleteUserURLs(t.Context(), "user2", []string{"abc123"})
+	require.NoError(t, err)
+	require.NotEqual(t, first.ID, second.ID)
 
-			errDelete := usecase.DeleteUserURLs(ctx, tt.userID, tt.shortURLs)
-			if tt.wantErr {
-				require.Error(t, errDelete)
-			} else {
-				require.NoError(t, errDelete)
-			}
-			time.Sleep(100 * time.Millisecond)
-		})
-	}
+	require.NoError(t, usecase.Shutdown(t.Context()))
+
+	job, err := usecase.GetUserJob(t.Context(), "user1", first.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.JobPartiallyFailed, job.Status)
+	require.Equal(t, entity.JobDelete, job.Operation)
+	require.Equal(t, []string{"def456"}, job.FailedURLs)
+	require.False(t, job.FinishedAt.IsZero())
+
+	job, err = usecase.GetUserJob(t.Context(), "user1", second.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.JobSucceeded, job.Status)
+	require.Empty(t, job.FailedURLs)
+
+	job, err = usecase.GetUserJob(t.Context(), "user2", failed.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.JobFailed, job.Status)
+	require.Equal(t, []string{"abc123"}, job.FailedURLs)
 
-	usecase.Shutdown()
+	deadLetters, err := usecase.GetDeadLetters(t.Context())
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	require.Equal(t, failed.ID, deadLetters[0].Job.ID)
+	require.Equal(t, "connection refused", deadLetters[0].LastError)
+	require.Equal(t, 1, deadLetters[0].Attempts)
+
+	// jobs of other users are not found
+	_, err = usecase.GetUserJob(t.Context(), "user2", first.ID)
+	require.ErrorIs(t, err, entity.ErrJobNotFound)
+	_, err = usecase.GetUserJob(t.Context(), "user1", "unknown")
+	require.ErrorIs(t, err, entity.ErrJobNotFound)
+}
+
+func TestURLUsecase_RestoreUserURLs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	urlRepositoryMock := mocks.NewMockURLRepository(ctrl)
+	logger, err := zap.NewDevelopment()
+	require.NoError(t, err)
+	deleteWorker := worker.NewDeleteWorker(urlRepositoryMock, worker.NewMemoryDeleteQueue(), logger)
+	workers := worker.NewManager(logger)
+	require.NoError(t, workers.Register(
+		worker.DeleteJobType,
+		deleteWorker,
+		worker.WithFlushInterval(time.Hour),
+		worker.WithDrainOnShutdown(),
+	))
+
+	usecase, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(urlRepositoryMock),
+		usecase.WithURLUsecaseLogger(logger),
+		usecase.WithDeleteWorker(deleteWorker),
+		usecase.WithWorkers(workers),
+	)
+	require.NoError(t, err)
+
+	gomock.InOrder(
+		urlRepositoryMock.EXPECT().
+			MarkDeletedBatch(gomock.Any(), "user1", []string{"abc123", "def456"}).
+			Return([]string{"abc123", "def456"}, nil),
+		urlRepositoryMock.EXPECT().
+			MarkRestoredBatch(gomock.Any(), "user1", []string{"abc123"}).
+			Return([]string{"abc123"}, nil),
+		urlRepositoryMock.EXPECT().
+			MarkDeletedBatch(gomock.Any(), "user1", []string{"def456"}).
+			Return([]string{"def456"}, nil),
+	)
+	urlRepositoryMock.EXPECT().Close().Return(nil)
+
+	_, err = usecase.DeleteUserURLs(t.Context(), "user1", []string{"abc123"})
+	require.NoError(t, err)
+	_, err = usecase.DeleteUserURLs(t.Context(), "user1", []string{"def456"})
+	require.NoError(t, err)
+	restore, err := usecase.RestoreUserURLs(t.Context(), "user1", []string{"abc123"})
+	require.NoError(t, err)
+	require.Equal(t, entity.JobRestore, restore.Operation)
+	_, err = usecase.DeleteUserURLs(t.Context(), "user1", []string{"def456"})
+	require.NoError(t, err)
+
+	require.NoError(t, usecase.Shutdown(t.Context()))
+
+	job, err := usecase.GetUserJob(t.Context(), "user1", restore.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.JobSucceeded, job.Status)
 }
 
 func TestURLUsecase_Ping(t *testing.T) {
@@ -553,3 +788,298 @@ func TestURLUsecase_Ping(t *testing.T) {
 		})
 	}
 }
+
+func TestURLUsecase_Alias(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	urlRepositoryMock := mocks.NewMockURLRepository(ctrl)
+	_, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(urlRepositoryMock),
+		usecase.WithURLUsecaseLogger(zap.NewNop()),
+		usecase.WithAliasPattern("("),
+	)
+	require.Error(t, err)
+
+	usecase, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(urlRepositoryMock),
+		usecase.WithURLUsecaseLogger(zap.NewNop()),
+		usecase.WithReservedAliases([]string{"api", "ping", "debug", "admin"}),
+	)
+	require.NoError(t, err)
+	springSale := entity.URL{ShortURL: "spring-sale", OriginalURL: "https://example.com"}
+
+	t.Run("add", func(t *testing.T) {
+		urlRepositoryMock.EXPECT().
+			Add(gomock.Any(), "user1", springSale).
+			Return("spring-sale", nil)
+		got, errAdd := usecase.Add(t.Context(), "user1", springSale)
+		require.NoError(t, errAdd)
+		require.Equal(t, "spring-sale", got)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, alias := range []string{"ab", "-sale", "spring sale", "API", "Admin"} {
+			url := entity.URL{ShortURL: alias, OriginalURL: "https://example.com"}
+			_, errAdd := usecase.Add(t.Context(), "user1", url)
+			require.ErrorIs(t, errAdd, entity.ErrInvalidAlias, alias)
+		}
+	})
+
+	t.Run("taken", func(t *testing.T) {
+		urlRepositoryMock.EXPECT().
+			Add(gomock.Any(), "user1", springSale).
+			Return("", entity.ErrShortURLTaken)
+		_, errAdd := usecase.Add(t.Context(), "user1", springSale)
+		require.ErrorIs(t, errAdd, entity.ErrAliasTaken)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		urlRepositoryMock.EXPECT().
+			GetByOriginalURL(gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			Return("", entity.ErrURLNotFound)
+		urlRepositoryMock.EXPECT().
+			GetByShortURL(gomock.Any(), "spring-sale").
+			Return("", entity.ErrURLNotFound)
+		urlRepositoryMock.EXPECT().
+			AddBatch(gomock.Any(), "user1", gomock.Cond(func(urls []entity.URL) bool {
+				return len(urls) == 2 && urls[0].ShortURL == "spring-sale" && urls[1].ShortURL != ""
+			})).
+			Return(nil)
+
+		got, errAdd := usecase.AddBatch(t.Context(), "user1", []entity.URL{
+			{OriginalURL: "https://example1.com", ShortURL: "spring-sale"},
+			{OriginalURL: "https://example2.com"},
+		})
+		require.NoError(t, errAdd)
+		require.Len(t, got, 2)
+		require.Equal(t, "spring-sale", got[0].ShortURL)
+	})
+
+	t.Run("batch alias taken", func(t *testing.T) {
+		urlRepositoryMock.EXPECT().
+			GetByOriginalURL(gomock.Any(), gomock.Any(), "https://example1.com").
+			Return("", entity.ErrURLNotFound)
+		urlRepositoryMock.EXPECT().
+			GetByShortURL(gomock.Any(), "spring-sale").
+			Return("", entity.ErrURLDeleted)
+
+		_, errAdd := usecase.AddBatch(t.Context(), "user1", []entity.URL{
+			{OriginalURL: "https://example1.com", ShortURL: "spring-sale"},
+		})
+		require.ErrorIs(t, errAdd, entity.ErrAliasTaken)
+	})
+
+	t.Run("batch alias of existing url", func(t *testing.T) {
+		urlRepositoryMock.EXPECT().
+			GetByOriginalURL(gomock.Any(), "user1", "https://example1.com").
+			Times(2).
+			Return("abc123", nil)
+
+		_, errAdd := usecase.AddBatch(t.Context(), "user1", []entity.URL{
+			{OriginalURL: "https://example1.com", ShortURL: "spring-sale"},
+		})
+		require.ErrorIs(t, errAdd, entity.ErrAliasTaken)
+
+		// the alias matching the stored short URL is not a conflict
+		got, errAdd := usecase.AddBatch(t.Context(), "user1", []entity.URL{
+			{OriginalURL: "https://example1.com", ShortURL: "abc123"},
+		})
+		require.NoError(t, errAdd)
+		require.Equal(t, []entity.URL{{OriginalURL: "https://example1.com", ShortURL: "abc123"}}, got)
+	})
+
+	t.Run("batch repeats url with another alias", func(t *testing.T) {
+		for _, first := range []string{"spring-sale", ""} {
+			urlRepositoryMock.EXPECT().
+				GetByOriginalURL(gomock.Any(), gomock.Any(), "https://example1.com").
+				Return("", entity.ErrURLNotFound)
+
+			_, errAdd := usecase.AddBatch(t.Context(), "user1", []entity.URL{
+				{OriginalURL: "https://example1.com", ShortURL: first},
+				{OriginalURL: "https://example1.com", ShortURL: "summer-sale"},
+			})
+			require.ErrorIs(t, errAdd, entity.ErrAliasTaken, first)
+		}
+	})
+
+	t.Run("batch repeats url with the same alias", func(t *testing.T) {
+		urlRepositoryMock.EXPECT().
+			GetByOriginalURL(gomock.Any(), gomock.Any(), "https://example1.com").
+			Return("", entity.ErrURLNotFound)
+		urlRepositoryMock.EXPECT().
+			GetByShortURL(gomock.Any(), "spring-sale").
+			Return("", entity.ErrURLNotFound)
+		urlRepositoryMock.EXPECT().
+			AddBatch(gomock.Any(), "user1", []entity.URL{{OriginalURL: "https://example1.com", ShortURL: "spring-sale"}}).
+			Return(nil)
+
+		got, errAdd := usecase.AddBatch(t.Context(), "user1", []entity.URL{
+			{OriginalURL: "https://example1.com", ShortURL: "spring-sale"},
+			{OriginalURL: "https://example1.com", ShortURL: "spring-sale"},
+			{OriginalURL: "https://example1.com"},
+		})
+		require.NoError(t, errAdd)
+		require.Len(t, got, 3)
+		for _, url := range got {
+			require.Equal(t, "spring-sale", url.ShortURL)
+		}
+	})
+
+	t.Run("batch repeats alias", func(t *testing.T) {
+		urlRepositoryMock.EXPECT().
+			GetByOriginalURL(gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			Return("", entity.ErrURLNotFound)
+
+		_, errAdd := usecase.AddBatch(t.Context(), "user1", []entity.URL{
+			{OriginalURL: "https://example1.com", ShortURL: "spring-sale"},
+			{OriginalURL: "https://example2.com", ShortURL: "spring-sale"},
+		})
+		require.ErrorIs(t, errAdd, entity.ErrAliasTaken)
+	})
+}
+
+func TestURLUsecase_Limits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	urlRepositoryMock := mocks.NewMockURLRepository(ctrl)
+	usecase, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(urlRepositoryMock),
+		usecase.WithURLUsecaseLogger(zap.NewNop()),
+	)
+	require.NoError(t, err)
+
+	t.Run("add keeps the limits", func(t *testing.T) {
+		url := entity.URL{
+			ExpiresAt:   time.Now().Add(time.Hour),
+			ShortURL:    "spring-sale",
+			OriginalURL: "https://example.com",
+			MaxClicks:   10,
+		}
+		urlRepositoryMock.EXPECT().
+			Add(gomock.Any(), "user1", url).
+			Return("spring-sale", nil)
+
+		got, errAdd := usecase.Add(t.Context(), "user1", url)
+		require.NoError(t, errAdd)
+		require.Equal(t, "spring-sale", got)
+	})
+
+	t.Run("invalid limits", func(t *testing.T) {
+		for _, url := range []entity.URL{
+			{OriginalURL: "https://example.com", ExpiresAt: time.Now().Add(-time.Minute)},
+			{OriginalURL: "https://example.com", MaxClicks: -1},
+		} {
+			_, errAdd := usecase.Add(t.Context(), "user1", url)
+			require.ErrorIs(t, errAdd, entity.ErrInvalidLimits)
+
+			_, errAdd = usecase.AddBatch(t.Context(), "user1", []entity.URL{url})
+			require.ErrorIs(t, errAdd, entity.ErrInvalidLimits)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		urlRepositoryMock.EXPECT().
+			Visit(gomock.Any(), "spring-sale").
+			Return("", entity.ErrURLExpired)
+
+		_, errGet := usecase.GetByShortURL(t.Context(), "spring-sale")
+		require.ErrorIs(t, errGet, entity.ErrURLExpired)
+	})
+}
+
+func TestURLUsecase_Clicks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	urlRepositoryMock := mocks.NewMockURLRepository(ctrl)
+	logger := zap.NewNop()
+	clickWorker := worker.NewClickWorker(urlRepositoryMock, logger)
+	workers := worker.NewManager(logger)
+	require.NoError(t, workers.Register(
+		worker.ClickJobType,
+		clickWorker,
+		worker.WithFlushInterval(time.Hour),
+		worker.WithDrainOnShutdown(),
+	))
+
+	usecase, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(urlRepositoryMock),
+		usecase.WithURLUsecaseLogger(logger),
+		usecase.WithClickWorker(clickWorker),
+		usecase.WithWorkers(workers),
+	)
+	require.NoError(t, err)
+
+	t.Run("stats", func(t *testing.T) {
+		stats := entity.URLStats{ShortURL: "abc123", Total: 1}
+		urlRepositoryMock.EXPECT().
+			GetClickStats(gomock.Any(), "user1", "abc123").
+			Return(stats, nil)
+
+		got, errStats := usecase.GetURLStats(t.Context(), "user1", "abc123")
+		require.NoError(t, errStats)
+		require.Equal(t, stats, got)
+	})
+
+	t.Run("clicks are saved on shutdown", func(t *testing.T) {
+		clicks := []entity.Click{
+			{Time: time.Now(), ShortURL: "abc123"},
+			{Time: time.Now(), ShortURL: "def456", Gzip: true},
+		}
+		for _, click := range clicks {
+			usecase.RecordClick(t.Context(), click)
+		}
+		urlRepositoryMock.EXPECT().AddClicks(gomock.Any(), clicks).Return(nil)
+		urlRepositoryMock.EXPECT().Close().Return(nil)
+
+		require.NoError(t, usecase.Shutdown(t.Context()))
+	})
+}
+
+func TestURLUsecase_APIKeys(t *testing.T) {
+	logger := zap.NewNop()
+	usecase, err := usecase.NewURLUsecase(
+		usecase.WithURLUsecaseRepository(memory.NewMemStorage(logger)),
+		usecase.WithURLUsecaseLogger(logger),
+	)
+	require.NoError(t, err)
+
+	_, _, err = usecase.CreateAPIKey(t.Context(), "user1", "ci", nil)
+	require.ErrorIs(t, err, entity.ErrInvalidScope)
+	_, _, err = usecase.CreateAPIKey(t.Context(), "user1", "ci", []string{"admin"})
+	require.ErrorIs(t, err, entity.ErrInvalidScope)
+
+	key, plain, err := usecase.CreateAPIKey(t.Context(), "user1", "ci", []string{"shorten", "read", "shorten"})
+	require.NoError(t, err)
+	require.Equal(t, []entity.APIKeyScope{entity.ScopeShorten, entity.ScopeRead}, key.Scopes)
+	require.True(t, strings.HasPrefix(plain, key.Prefix))
+	// only the hash of the key is stored
+	require.Equal(t, entity.HashAPIKey(plain), key.Hash)
+
+	resolved, err := usecase.ResolveAPIKey(t.Context(), plain)
+	require.NoError(t, err)
+	require.Equal(t, "user1", resolved.UserID)
+	require.Equal(t, key.ID, resolved.ID)
+	_, err = usecase.ResolveAPIKey(t.Context(), plain+"x")
+	require.ErrorIs(t, err, entity.ErrAPIKeyNotFound)
+
+	keys, err := usecase.GetUserAPIKeys(t.Context(), "user1")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	keys, err = usecase.GetUserAPIKeys(t.Context(), "user2")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	deleted, err := usecase.DeleteAPIKeys(t.Context(), "user2", []string{key.ID})
+	require.NoError(t, err)
+	require.Empty(t, deleted)
+	deleted, err = usecase.DeleteAPIKeys(t.Context(), "user1", []string{key.ID})
+	require.NoError(t, err)
+	require.Equal(t, []string{key.ID}, deleted)
+	_, err = usecase.ResolveAPIKey(t.Context(), plain)
+	require.ErrorIs(t, err, entity.ErrAPIKeyNotFound)
+}
@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+const (
+	defaultClickChannelSize = 1000
+	clickSaveTimeout        = 5 * time.Second
+)
+
+// ClickSaver describes the behavior for saving click events in batch.
+type ClickSaver interface {
+	AddClicks(ctx context.Context, clicks []entity.Click) error
+}
+
+// ClickWorker writes click events in batches off the redirect path.
+// Run by the Manager, it writes the queued clicks every flush interval and as soon as a batch fills up.
+// Clicks arriving while the queue is full are dropped and counted, so the redirects never wait for the storage.
+type ClickWorker struct {
+	repository ClickSaver
+	logger     *zap.Logger
+	clicks     chan entity.Click
+	flush      func()
+	dropped    atomic.Int64
+	batchSize  int
+}
+
+// NewClickWorker creates a new worker for writing click events.
+// The clicks are written once the worker is registered with a Manager.
+func NewClickWorker(repo ClickSaver, logger *zap.Logger) *ClickWorker {
+	logger = logger.With(zap.String("component", "ClickWorker"))
+
+	return &ClickWorker{
+		repository: repo,
+		logger:     logger,
+		clicks:     make(chan entity.Click, defaultClickChannelSize),
+		batchSize:  defaultBatchSize,
+	}
+}
+
+// BindFlush sets the batch size of the worker and the function writing a full batch right away.
+// It is called by Manager.Register before the first click is queued.
+func (w *ClickWorker) BindFlush(batchSize int, flush func()) {
+	w.batchSize = batchSize
+	w.flush = flush
+}
+
+// EnqueueClick adds a click to the processing queue without blocking.
+// It returns false and drops the click if the queue is full.
+func (w *ClickWorker) EnqueueClick(click entity.Click) bool {
+	select {
+	case w.clicks <- click:
+		if w.flush != nil && len(w.clicks) >= w.batchSize {
+			w.flush()
+		}
+		return true
+	default:
+		w.dropped.Add(1)
+		return false
+	}
+}
+
+// Dropped returns the number of clicks dropped because the queue was full.
+func (w *ClickWorker) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Handle writes up to limit queued clicks as one batch and returns the number of written clicks.
+func (w *ClickWorker) Handle(ctx context.Context, limit int) (int, error) {
+	batch := make([]entity.Click, 0, limit)
+collect:
+	for len(batch) < limit {
+		select {
+		case click := <-w.clicks:
+			batch = append(batch, click)
+		default:
+			break collect
+		}
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clickSaveTimeout)
+	defer cancel()
+
+	if err := w.repository.AddClicks(ctx, batch); err != nil {
+		return 0, fmt.Errorf("failed to save %d clicks: %w", len(batch), err)
+	}
+	w.logger.Debug("successfully saved clicks", zap.Int("count", len(batch)))
+	return len(batch), nil
+}
@@ -0,0 +1,93 @@
+package worker_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+type clickSaverFunc func(ctx context.Context, clicks []entity.Click) error
+
+func (f clickSaverFunc) AddClicks(ctx context.Context, clicks []entity.Click) error {
+	return f(ctx, clicks)
+}
+
+func TestClickWorker(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]entity.Click
+	)
+	clickWorker := worker.NewClickWorker(
+		clickSaverFunc(func(_ context.Context, clicks []entity.Click) error {
+			mu.Lock()
+			defer mu.Unlock()
+			batches = append(batches, clicks)
+			return nil
+		}),
+		zap.NewNop(),
+	)
+	workers := worker.NewManager(zap.NewNop())
+	require.NoError(t, workers.Register(
+		worker.ClickJobType,
+		clickWorker,
+		worker.WithBatchSize(2),
+		worker.WithFlushInterval(time.Hour),
+		worker.WithDrainOnShutdown(),
+	))
+
+	for _, shortURL := range []string{"a", "b", "c"} {
+		assert.True(t, clickWorker.EnqueueClick(entity.Click{ShortURL: shortURL}))
+	}
+	// a full batch is saved without waiting for the flush interval
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(batches) >= 1
+	}, time.Second, 5*time.Millisecond)
+
+	// the rest is saved on shutdown at the latest
+	require.NoError(t, workers.Shutdown(t.Context()))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, [][]entity.Click{
+		{{ShortURL: "a"}, {ShortURL: "b"}},
+		{{ShortURL: "c"}},
+	}, batches)
+}
+
+func TestClickWorkerDropsWhenFull(t *testing.T) {
+	var saves atomic.Int64
+	clickWorker := worker.NewClickWorker(
+		clickSaverFunc(func(_ context.Context, clicks []entity.Click) error {
+			saves.Add(int64(len(clicks)))
+			return nil
+		}),
+		zap.NewNop(),
+	)
+
+	// without a manager nothing drains the queue
+	queued := 0
+	for clickWorker.EnqueueClick(entity.Click{ShortURL: "a"}) {
+		queued++
+	}
+	for range 10 {
+		assert.False(t, clickWorker.EnqueueClick(entity.Click{ShortURL: "b"}))
+	}
+	assert.Positive(t, queued)
+	assert.Equal(t, int64(11), clickWorker.Dropped())
+	assert.Zero(t, saves.Load(), "dropped clicks must not be saved out of band")
+
+	saved, err := clickWorker.Handle(t.Context(), queued)
+	require.NoError(t, err)
+	assert.Equal(t, queued, saved)
+	assert.True(t, clickWorker.EnqueueClick(entity.Click{ShortURL: "c"}))
+}
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+const (
+	defaultJobRetention        = 24 * time.Hour
+	defaultDeadLetterRetention = 7 * 24 * time.Hour
+	jobPruneInterval           = time.Minute
+)
+
+// newJob creates the pending job for the request.
+func newJob(req DeleteRequest) entity.Job {
+	operation := entity.JobDelete
+	if req.Restore {
+		operation = entity.JobRestore
+	}
+	return entity.Job{
+		CreatedAt: time.Now(),
+		ID:        uuid.NewString(),
+		UserID:    req.UserID,
+		Operation: operation,
+		Status:    entity.JobPending,
+		ShortURLs: slices.Clone(req.ShortURLs),
+	}
+}
+
+// finishJobs returns the jobs of the tasks finished at the moment now given the short URLs the repository processed.
+func finishJobs(tasks []entity.DeleteTask, now time.Time, processed []string) []entity.Job {
+	done := make(map[string]struct{}, len(processed))
+	for _, shortURL := range processed {
+		done[shortURL] = struct{}{}
+	}
+	jobs := make([]entity.Job, 0, len(tasks))
+	for _, task := range tasks {
+		jobs = append(jobs, task.Job.Finish(now, done, nil))
+	}
+	return jobs
+}
+
+// prune drops the jobs finished before the job retention and the dead letters older than their retention
+// from the queue, at most once a jobPruneInterval. Pending jobs are kept until they finish.
+func (w *DeleteWorker) prune(ctx context.Context, now time.Time) {
+	w.pruneMu.Lock()
+	if now.Sub(w.lastPrune) < jobPruneInterval {
+		w.pruneMu.Unlock()
+		return
+	}
+	w.lastPrune = now
+	w.pruneMu.Unlock()
+
+	// the zero time keeps every dead letter
+	var deadBefore time.Time
+	if w.deadLetterRetention > 0 {
+		deadBefore = now.Add(-w.deadLetterRetention)
+	}
+	ctx, cancel := context.WithTimeout(ctx, processTimeout)
+	defer cancel()
+	if err := w.queue.Prune(ctx, now.Add(-w.jobRetention), deadBefore); err != nil {
+		w.logger.Warn("failed to prune delete jobs", zap.Error(err))
+	}
+}
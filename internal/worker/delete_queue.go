@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+// DeleteQueue is the persistent queue the DeleteWorker takes its tasks from.
+// It also keeps the jobs of the tasks, so a job can be looked up after a restart: a queued task is
+// a pending job, an acknowledged task leaves its finished job and a dead letter is a failed job.
+//
+// A claimed task is leased: other claims skip it until it is acknowledged, retried, buried
+// or its lease expires, so the task of a process that crashed is claimed again and every task
+// is processed at least once. Claim returns the tasks ordered by Seq and never returns a task
+// while an earlier queued task of the same user is leased or waits for its retry,
+// so the deletes and restores of one user are applied in the order they were pushed.
+type DeleteQueue interface {
+	// Push appends the task to the queue.
+	Push(ctx context.Context, task entity.DeleteTask) error
+	// Claim leases up to limit tasks due at the moment now for the lease duration.
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]entity.DeleteTask, error)
+	// Ack removes the processed task of the job from the queue and keeps the finished job.
+	Ack(ctx context.Context, job entity.Job) error
+	// Retry stores the attempts, the next attempt time and the last error of the task and drops its lease.
+	Retry(ctx context.Context, task entity.DeleteTask) error
+	// Bury moves the task that ran out of attempts to the dead letters.
+	Bury(ctx context.Context, task entity.DeleteTask) error
+	// DeadLetters returns up to limit dead tasks, the latest first. A zero limit returns all of them.
+	DeadLetters(ctx context.Context, limit int) ([]entity.DeleteTask, error)
+	// Job returns the job by its ID, entity.ErrJobNotFound if it is neither queued, finished nor dead.
+	Job(ctx context.Context, jobID string) (entity.Job, error)
+	// Prune drops the jobs finished before finishedBefore and the dead tasks buried before deadBefore.
+	Prune(ctx context.Context, finishedBefore, deadBefore time.Time) error
+}
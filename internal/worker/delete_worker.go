@@ -2,51 +2,76 @@ package worker
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
 )
 
 const (
-	defaultFlushInterval = 500 * time.Millisecond
-	defaultBatchSize     = 50
-	defaultChannelSize   = 100
+	defaultConcurrency     = 4
+	defaultMaxAttempts     = 5
+	defaultRetryBackoff    = time.Second
+	defaultMaxRetryBackoff = 5 * time.Minute
+	defaultLease           = time.Minute
+	processTimeout         = 5 * time.Second
 )
 
-// URLDeleter describes the behavior for marking URLs as deleted in batch.
+// URLDeleter describes the behavior for marking URLs as deleted and restoring them in batch.
+// Both methods return the short URLs of the user they processed.
 type URLDeleter interface {
-	MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) error
+	MarkDeletedBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error)
+	MarkRestoredBatch(ctx context.Context, userID string, shortURLs []string) ([]string, error)
 }
 
-// DeleteRequest represents a request to delete URLs.
+// DeleteRequest represents a request to delete URLs or, when Restore is set, to restore deleted ones.
 type DeleteRequest struct {
 	UserID    string
 	ShortURLs []string
+	Restore   bool
 }
 
 // DeleteWorker handles batch processing of URL deletion requests.
+// Every request is pushed to the DeleteQueue as a task tracked by a job that reports which of its short URLs
+// were processed. Run by the Manager, the worker claims a batch of due tasks every flush interval and processes
+// the tasks of up to concurrency users at once; consecutive tasks of one user with the same operation are applied
+// with one repository call. A failed task is retried with an exponential backoff and is moved to the dead letters
+// once it runs out of attempts.
 type DeleteWorker struct {
-	repository     URLDeleter
-	logger         *zap.Logger
-	deleteRequests chan DeleteRequest
-	done           chan struct{}
-	wg             sync.WaitGroup
-	batchSize      int
-	flushInterval  time.Duration
+	lastPrune           time.Time
+	repository          URLDeleter
+	queue               DeleteQueue
+	logger              *zap.Logger
+	concurrency         int
+	maxAttempts         int
+	retryBackoff        time.Duration
+	maxRetryBackoff     time.Duration
+	lease               time.Duration
+	jobRetention        time.Duration
+	deadLetterRetention time.Duration
+	pruneMu             sync.Mutex
 }
 
-// NewDeleteWorker creates a new worker for processing delete requests.
-func NewDeleteWorker(repo URLDeleter, logger *zap.Logger, opts ...Option) *DeleteWorker {
+// NewDeleteWorker creates a new worker for processing the delete requests pushed to the queue.
+// The requests are processed once the worker is registered with a Manager.
+func NewDeleteWorker(repo URLDeleter, queue DeleteQueue, logger *zap.Logger, opts ...Option) *DeleteWorker {
 	logger = logger.With(zap.String("component", "DeleteWorker"))
 
 	w := &DeleteWorker{
-		repository:     repo,
-		logger:         logger,
-		deleteRequests: make(chan DeleteRequest, defaultChannelSize),
-		batchSize:      defaultBatchSize,
-		flushInterval:  defaultFlushInterval,
-		done:           make(chan struct{}),
+		repository:          repo,
+		queue:               queue,
+		logger:              logger,
+		concurrency:         defaultConcurrency,
+		maxAttempts:         defaultMaxAttempts,
+		retryBackoff:        defaultRetryBackoff,
+		maxRetryBackoff:     defaultMaxRetryBackoff,
+		lease:               defaultLease,
+		jobRetention:        defaultJobRetention,
+		deadLetterRetention: defaultDeadLetterRetention,
 	}
 
 	// Apply options
@@ -54,122 +79,246 @@ func NewDeleteWorker(repo URLDeleter, logger *zap.Logger, opts ...Option) *Delet
 		opt(w)
 	}
 
-	w.wg.Add(1)
-	go w.processDeleteRequests()
-
 	return w
 }
 
 // Option is a function that configures DeleteWorker.
 type Option func(*DeleteWorker)
 
-// WithBatchSize sets the batch size for the worker.
-func WithBatchSize(size int) Option {
+// WithJobRetention sets how long a finished job can be looked up.
+func WithJobRetention(retention time.Duration) Option {
 	return func(w *DeleteWorker) {
-		w.batchSize = size
+		w.jobRetention = retention
 	}
 }
 
-// WithFlushInterval sets the flush interval for the worker.
-func WithFlushInterval(interval time.Duration) Option {
+// WithDeadLetterRetention sets how long a task that ran out of attempts is kept in the dead letters.
+// Zero keeps the dead letters forever.
+func WithDeadLetterRetention(retention time.Duration) Option {
 	return func(w *DeleteWorker) {
-		w.flushInterval = interval
-	}
-}
-
-// EnqueueDelete adds a delete request to the processing queue.
-func (w *DeleteWorker) EnqueueDelete(req DeleteRequest) bool {
-	select {
-	case w.deleteRequests <- req:
-		return true
-	default:
-		// Process immediately if channel is full
-		go func(userID string, ids []string) {
-			w.logger.Warn("delete requests channel is full, processing immediately",
-				zap.String("userID", userID),
-				zap.Int("urlCount", len(ids)))
-			w.processBatch(userID, ids)
-		}(req.UserID, req.ShortURLs)
-		return false
+		w.deadLetterRetention = retention
 	}
 }
 
-// processDeleteRequests implements the fanIn pattern for processing delete requests.
-func (w *DeleteWorker) processDeleteRequests() {
-	defer w.wg.Done()
+// WithConcurrency sets the number of users whose tasks are processed at once.
+func WithConcurrency(concurrency int) Option {
+	return func(w *DeleteWorker) {
+		w.concurrency = max(concurrency, 1)
+	}
+}
 
-	batches := make(map[string][]string)
-	lastFlush := time.Now()
+// WithMaxAttempts sets the number of attempts after which a failing task is moved to the dead letters.
+func WithMaxAttempts(attempts int) Option {
+	return func(w *DeleteWorker) {
+		w.maxAttempts = max(attempts, 1)
+	}
+}
 
-	const tickerInterval = 5
-	ticker := time.NewTicker(w.flushInterval / tickerInterval)
-	defer ticker.Stop()
+// WithRetryBackoff sets the delay before the first retry of a failed task and the longest delay.
+// The delay doubles with every failed attempt.
+func WithRetryBackoff(backoff, maxBackoff time.Duration) Option {
+	return func(w *DeleteWorker) {
+		w.retryBackoff = backoff
+		w.maxRetryBackoff = maxBackoff
+	}
+}
 
-	for {
-		select {
-		case req, ok := <-w.deleteRequests:
-			if !ok {
-				w.flushAllBatches(batches)
-				return
-			}
+// WithLease sets how long a claimed task is hidden from other claims.
+// It must outlast the processing of a batch, otherwise a task may be processed twice at once.
+func WithLease(lease time.Duration) Option {
+	return func(w *DeleteWorker) {
+		w.lease = lease
+	}
+}
+
+// EnqueueDelete pushes a delete request to the queue and returns the pending job tracking it.
+func (w *DeleteWorker) EnqueueDelete(ctx context.Context, req DeleteRequest) (entity.Job, error) {
+	job := newJob(req)
+	task := entity.DeleteTask{
+		NextAttemptAt: job.CreatedAt,
+		Job:           job,
+	}
+	if err := w.queue.Push(ctx, task); err != nil {
+		return entity.Job{}, fmt.Errorf("failed to enqueue %s job: %w", job.Operation, err)
+	}
+	return job, nil
+}
+
+// Job returns the job by its ID, entity.ErrJobNotFound if it is unknown.
+// Finished jobs are kept for the job retention and failed jobs as long as their dead letters.
+func (w *DeleteWorker) Job(ctx context.Context, id string) (entity.Job, error) {
+	return w.queue.Job(ctx, id)
+}
 
-			batches[req.UserID] = append(batches[req.UserID], req.ShortURLs...)
+// DeadLetters returns up to limit tasks that ran out of attempts, the latest first.
+func (w *DeleteWorker) DeadLetters(ctx context.Context, limit int) ([]entity.DeleteTask, error) {
+	return w.queue.DeadLetters(ctx, limit)
+}
+
+// Handle claims up to limit due tasks and processes them, the tasks of one user in order.
+// It returns the number of claimed tasks. The jobs past their retention are pruned on the way.
+func (w *DeleteWorker) Handle(ctx context.Context, limit int) (int, error) {
+	w.prune(ctx, time.Now())
 
-			for userID, shortURLs := range batches {
-				if len(shortURLs) >= w.batchSize {
-					w.processBatch(userID, shortURLs)
-					delete(batches, userID)
-					lastFlush = time.Now()
+	claimCtx, cancel := context.WithTimeout(ctx, processTimeout)
+	tasks, err := w.queue.Claim(claimCtx, time.Now(), limit, w.lease)
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim delete tasks: %w", err)
+	}
+
+	sem := make(chan struct{}, w.concurrency)
+	var wg sync.WaitGroup
+	for _, userTasks := range groupByUser(tasks) {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer func() {
+				// the leased tasks are claimed again once their lease expires
+				if recovered := recover(); recovered != nil {
+					w.logger.Error("delete tasks panicked",
+						zap.String("userID", userTasks[0].Job.UserID),
+						zap.Any("panic", recovered),
+						zap.Stack("stack"))
 				}
-			}
+			}()
+			w.processUserTasks(ctx, userTasks)
+		}()
+	}
+	wg.Wait()
+	return len(tasks), nil
+}
 
-		case <-ticker.C:
-			if time.Since(lastFlush) >= w.flushInterval && len(batches) > 0 {
-				w.flushAllBatches(batches)
-				batches = make(map[string][]string)
-				lastFlush = time.Now()
-			}
+// Close closes the queue if it is an io.Closer.
+func (w *DeleteWorker) Close() error {
+	if closer, ok := w.queue.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// groupByUser splits the tasks by user keeping their order.
+func groupByUser(tasks []entity.DeleteTask) [][]entity.DeleteTask {
+	groups := make([][]entity.DeleteTask, 0)
+	positions := make(map[string]int)
+	for _, task := range tasks {
+		i, ok := positions[task.Job.UserID]
+		if !ok {
+			i = len(groups)
+			positions[task.Job.UserID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], task)
+	}
+	return groups
+}
 
-		case <-w.done:
-			w.logger.Info("Stopping URL delete processor")
-			w.flushAllBatches(batches)
+// processUserTasks applies the tasks of one user in order, consecutive tasks with the same operation at once.
+// Once a batch fails the following tasks are released untouched, they wait for the failed one.
+func (w *DeleteWorker) processUserTasks(ctx context.Context, tasks []entity.DeleteTask) {
+	for start := 0; start < len(tasks); {
+		end := start + 1
+		for end < len(tasks) && tasks[end].Job.Operation == tasks[start].Job.Operation {
+			end++
+		}
+		if !w.processBatch(ctx, tasks[start:end]) {
+			w.release(ctx, tasks[end:])
 			return
 		}
+		start = end
 	}
 }
 
-// processBatch processes one batch of delete requests.
-func (w *DeleteWorker) processBatch(userID string, shortURLs []string) {
-	const timeout = 5 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// processBatch processes the tasks of one user with the same operation, finishes their jobs
+// and acknowledges them. It reports whether the repository call succeeded.
+func (w *DeleteWorker) processBatch(ctx context.Context, tasks []entity.DeleteTask) bool {
+	ctx, cancel := context.WithTimeout(ctx, processTimeout)
 	defer cancel()
 
-	err := w.repository.MarkDeletedBatch(ctx, userID, shortURLs)
+	userID := tasks[0].Job.UserID
+	shortURLs := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		shortURLs = append(shortURLs, task.Job.ShortURLs...)
+	}
+
+	mark, state := w.repository.MarkDeletedBatch, "deleted"
+	if tasks[0].Job.Operation == entity.JobRestore {
+		mark, state = w.repository.MarkRestoredBatch, "restored"
+	}
+	processed, err := mark(ctx, userID, shortURLs)
 	if err != nil {
-		w.logger.Error("failed to mark URLs as deleted",
+		w.logger.Error("failed to mark URLs as "+state,
 			zap.String("userID", userID),
 			zap.Int("count", len(shortURLs)),
 			zap.Error(err))
-	} else {
-		w.logger.Info("successfully marked URLs as deleted",
-			zap.String("userID", userID),
-			zap.Int("count", len(shortURLs)))
+		w.fail(ctx, tasks, err)
+		return false
+	}
+
+	for _, job := range finishJobs(tasks, time.Now(), processed) {
+		if errAck := w.queue.Ack(ctx, job); errAck != nil {
+			// the task is claimed again once its lease expires, applying it twice is harmless
+			w.logger.Warn("failed to acknowledge delete task", zap.String("job_id", job.ID), zap.Error(errAck))
+		}
+	}
+	w.logger.Info("successfully marked URLs as "+state,
+		zap.String("userID", userID),
+		zap.Int("count", len(processed)),
+		zap.Int("skipped", len(shortURLs)-len(processed)))
+	return true
+}
+
+// fail schedules the retry of the failed tasks, those that ran out of attempts are buried, which fails their jobs.
+func (w *DeleteWorker) fail(ctx context.Context, tasks []entity.DeleteTask, err error) {
+	now := time.Now()
+	for _, task := range tasks {
+		task.Attempts++
+		task.LastError = err.Error()
+		if task.Attempts < w.maxAttempts {
+			task.NextAttemptAt = now.Add(w.backoff(task.Attempts))
+			if errRetry := w.queue.Retry(ctx, task); errRetry != nil {
+				w.logger.Error("failed to schedule delete task retry", zap.String("job_id", task.Job.ID),
+					zap.Error(errRetry))
+			}
+			continue
+		}
+
+		task.DeadAt = now
+		if errBury := w.queue.Bury(ctx, task); errBury != nil {
+			w.logger.Error("failed to bury delete task", zap.String("job_id", task.Job.ID), zap.Error(errBury))
+			continue
+		}
+		w.logger.Error("delete task moved to the dead letters",
+			zap.String("job_id", task.Job.ID),
+			zap.Int("attempts", task.Attempts),
+			zap.Error(err))
 	}
 }
 
-// flushAllBatches processes all remaining batches.
-func (w *DeleteWorker) flushAllBatches(batches map[string][]string) {
-	for userID, shortURLs := range batches {
-		if len(shortURLs) > 0 {
-			w.processBatch(userID, shortURLs)
+// release returns the claimed tasks to the queue without counting an attempt.
+func (w *DeleteWorker) release(ctx context.Context, tasks []entity.DeleteTask) {
+	ctx, cancel := context.WithTimeout(ctx, processTimeout)
+	defer cancel()
+
+	now := time.Now()
+	for _, task := range tasks {
+		task.NextAttemptAt = now
+		if err := w.queue.Retry(ctx, task); err != nil {
+			w.logger.Error("failed to release delete task", zap.String("job_id", task.Job.ID), zap.Error(err))
 		}
 	}
 }
 
-// Shutdown correctly stops the worker.
-func (w *DeleteWorker) Shutdown() {
-	w.logger.Info("Shutting down DeleteWorker")
-	close(w.done)
-	w.wg.Wait()
-	w.logger.Info("DeleteWorker shutdown complete")
+// backoff returns the delay before the next attempt of a task that failed the given number of times.
+func (w *DeleteWorker) backoff(attempts int) time.Duration {
+	delay := w.retryBackoff
+	for range attempts - 1 {
+		if delay >= w.maxRetryBackoff/2 {
+			return w.maxRetryBackoff
+		}
+		delay *= 2
+	}
+	return min(delay, w.maxRetryBackoff)
 }
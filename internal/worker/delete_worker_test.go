@@ -0,0 +1,200 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+// flakyDeleter fails the first failures calls and records the calls in order.
+type flakyDeleter struct {
+	calls    []string
+	failures int
+	mu       sync.Mutex
+}
+
+func (d *flakyDeleter) MarkDeletedBatch(_ context.Context, _ string, shortURLs []string) ([]string, error) {
+	return d.call("delete", shortURLs)
+}
+
+func (d *flakyDeleter) MarkRestoredBatch(_ context.Context, _ string, shortURLs []string) ([]string, error) {
+	return d.call("restore", shortURLs)
+}
+
+func (d *flakyDeleter) call(operation string, shortURLs []string) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls = append(d.calls, operation)
+	if d.failures > 0 {
+		d.failures--
+		return nil, errors.New("connection refused")
+	}
+	return shortURLs, nil
+}
+
+func (d *flakyDeleter) recorded() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.calls...)
+}
+
+func TestDeleteWorker_Retry(t *testing.T) {
+	deleter := &flakyDeleter{failures: 2}
+	deleteWorker := worker.NewDeleteWorker(
+		deleter,
+		worker.NewMemoryDeleteQueue(),
+		zap.NewNop(),
+		worker.WithRetryBackoff(10*time.Millisecond, 20*time.Millisecond),
+	)
+	workers := worker.NewManager(zap.NewNop())
+	require.NoError(t, workers.Register(
+		worker.DeleteJobType,
+		deleteWorker,
+		worker.WithFlushInterval(5*time.Millisecond),
+	))
+	defer func() {
+		assert.NoError(t, workers.Shutdown(context.Background()))
+	}()
+
+	deleteJob, err := deleteWorker.EnqueueDelete(t.Context(), worker.DeleteRequest{
+		UserID:    "user1",
+		ShortURLs: []string{"abc123"},
+	})
+	require.NoError(t, err)
+	restoreJob, err := deleteWorker.EnqueueDelete(t.Context(), worker.DeleteRequest{
+		UserID:    "user1",
+		ShortURLs: []string{"abc123"},
+		Restore:   true,
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		job, err := deleteWorker.Job(t.Context(), restoreJob.ID)
+		return err == nil && job.Status == entity.JobSucceeded
+	}, time.Second, 5*time.Millisecond)
+	job, err := deleteWorker.Job(t.Context(), deleteJob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.JobSucceeded, job.Status)
+
+	// the restore waits for the delete requested before it to succeed
+	assert.Equal(t, []string{"delete", "delete", "delete", "restore"}, deleter.recorded())
+
+	letters, err := deleteWorker.DeadLetters(t.Context(), 0)
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+}
+
+func TestDeleteWorker_DeadLetters(t *testing.T) {
+	deleter := &flakyDeleter{failures: 3}
+	deleteWorker := worker.NewDeleteWorker(
+		deleter,
+		worker.NewMemoryDeleteQueue(),
+		zap.NewNop(),
+		worker.WithRetryBackoff(time.Millisecond, time.Millisecond),
+		worker.WithMaxAttempts(2),
+	)
+	workers := worker.NewManager(zap.NewNop())
+	require.NoError(t, workers.Register(
+		worker.DeleteJobType,
+		deleteWorker,
+		worker.WithFlushInterval(5*time.Millisecond),
+	))
+	defer func() {
+		assert.NoError(t, workers.Shutdown(context.Background()))
+	}()
+
+	failed, err := deleteWorker.EnqueueDelete(t.Context(), worker.DeleteRequest{
+		UserID:    "user1",
+		ShortURLs: []string{"abc123", "def456"},
+	})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		job, err := deleteWorker.Job(t.Context(), failed.ID)
+		return err == nil && job.Status == entity.JobFailed
+	}, time.Second, 5*time.Millisecond)
+
+	letters, err := deleteWorker.DeadLetters(t.Context(), 0)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, failed.ID, letters[0].Job.ID)
+	assert.Equal(t, 2, letters[0].Attempts)
+	assert.Equal(t, "connection refused", letters[0].LastError)
+	assert.False(t, letters[0].DeadAt.IsZero())
+
+	// the next job of the user is processed once the failed one is buried
+	next, err := deleteWorker.EnqueueDelete(t.Context(), worker.DeleteRequest{
+		UserID:    "user1",
+		ShortURLs: []string{"abc123"},
+	})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		job, err := deleteWorker.Job(t.Context(), next.ID)
+		return err == nil && job.Status == entity.JobSucceeded
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"delete", "delete", "delete", "delete"}, deleter.recorded())
+}
+
+func TestDeleteWorker_Prune(t *testing.T) {
+	queue := worker.NewMemoryDeleteQueue()
+	now := time.Now()
+	task := func(id string, at time.Time) entity.DeleteTask {
+		return entity.DeleteTask{
+			NextAttemptAt: at,
+			Job: entity.Job{
+				CreatedAt: at,
+				ID:        id,
+				UserID:    "user1",
+				Operation: entity.JobDelete,
+				Status:    entity.JobPending,
+				ShortURLs: []string{"abc123"},
+			},
+		}
+	}
+	for _, id := range []string{"old-finished", "new-finished", "old-dead", "new-dead"} {
+		require.NoError(t, queue.Push(t.Context(), task(id, now)))
+	}
+	_, err := queue.Claim(t.Context(), now, 10, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, queue.Ack(t.Context(), task("old-finished", now).Job.Finish(now.Add(-2*time.Hour), nil, nil)))
+	require.NoError(t, queue.Ack(t.Context(), task("new-finished", now).Job.Finish(now, nil, nil)))
+	oldDead := task("old-dead", now)
+	oldDead.DeadAt = now.Add(-3 * time.Hour)
+	require.NoError(t, queue.Bury(t.Context(), oldDead))
+	newDead := task("new-dead", now)
+	newDead.DeadAt = now
+	require.NoError(t, queue.Bury(t.Context(), newDead))
+
+	deleteWorker := worker.NewDeleteWorker(
+		&flakyDeleter{},
+		queue,
+		zap.NewNop(),
+		worker.WithJobRetention(time.Hour),
+		worker.WithDeadLetterRetention(2*time.Hour),
+	)
+	_, err = deleteWorker.Handle(t.Context(), 10)
+	require.NoError(t, err)
+
+	for id, wantErr := range map[string]error{
+		"old-finished": entity.ErrJobNotFound,
+		"new-finished": nil,
+		"old-dead":     entity.ErrJobNotFound,
+		"new-dead":     nil,
+	} {
+		_, err = deleteWorker.Job(t.Context(), id)
+		assert.ErrorIs(t, err, wantErr, id)
+	}
+	letters, err := deleteWorker.DeadLetters(t.Context(), 0)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, "new-dead", letters[0].Job.ID)
+}
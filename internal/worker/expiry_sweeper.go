@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultSweepTimeout = 30 * time.Second
+
+// URLExpirer describes the behavior for marking expired URLs as deleted.
+type URLExpirer interface {
+	MarkExpiredDeleted(ctx context.Context, now time.Time) (int64, error)
+}
+
+// ExpirySweeper marks URLs that have expired or used up their clicks as deleted.
+// Run by the Manager, it sweeps once every flush interval.
+type ExpirySweeper struct {
+	repository URLExpirer
+	logger     *zap.Logger
+}
+
+// NewExpirySweeper creates a new sweeper of expired URLs.
+func NewExpirySweeper(repo URLExpirer, logger *zap.Logger) *ExpirySweeper {
+	return &ExpirySweeper{
+		repository: repo,
+		logger:     logger.With(zap.String("component", "ExpirySweeper")),
+	}
+}
+
+// Handle marks all URLs expired by now as deleted and returns their number, the limit is ignored.
+func (s *ExpirySweeper) Handle(ctx context.Context, _ int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultSweepTimeout)
+	defer cancel()
+
+	count, err := s.repository.MarkExpiredDeleted(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark expired URLs as deleted: %w", err)
+	}
+	if count > 0 {
+		s.logger.Info("successfully marked expired URLs as deleted", zap.Int64("count", count))
+	}
+	return int(count), nil
+}
@@ -0,0 +1,40 @@
+package worker_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+type expirerFunc func(ctx context.Context, now time.Time) (int64, error)
+
+func (f expirerFunc) MarkExpiredDeleted(ctx context.Context, now time.Time) (int64, error) {
+	return f(ctx, now)
+}
+
+func TestExpirySweeper(t *testing.T) {
+	var sweeps atomic.Int64
+	sweeper := worker.NewExpirySweeper(
+		expirerFunc(func(_ context.Context, _ time.Time) (int64, error) {
+			sweeps.Add(1)
+			return 1, nil
+		}),
+		zap.NewNop(),
+	)
+	workers := worker.NewManager(zap.NewNop())
+	require.NoError(t, workers.Register(worker.ExpiryJobType, sweeper, worker.WithFlushInterval(10*time.Millisecond)))
+
+	assert.Eventually(t, func() bool { return sweeps.Load() >= 2 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, workers.Shutdown(t.Context()))
+
+	stopped := sweeps.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, sweeps.Load())
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultPingTimeout = 5 * time.Second
+
+// Pinger describes the behavior for checking that the storage is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// HealthChecker pings the storage and logs when it becomes unavailable and when it recovers.
+// Run by the Manager, it pings once every flush interval; a failed ping is reported as a failed run.
+type HealthChecker struct {
+	pinger Pinger
+	logger *zap.Logger
+	// failing is only touched by the runs of the job type, which never overlap.
+	failing bool
+}
+
+// NewHealthChecker creates a new health checker of the storage.
+func NewHealthChecker(pinger Pinger, logger *zap.Logger) *HealthChecker {
+	return &HealthChecker{
+		pinger: pinger,
+		logger: logger.With(zap.String("component", "HealthChecker")),
+	}
+}
+
+// Handle pings the storage once, the limit is ignored.
+func (h *HealthChecker) Handle(ctx context.Context, _ int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultPingTimeout)
+	defer cancel()
+
+	if err := h.pinger.Ping(ctx); err != nil {
+		if !h.failing {
+			h.failing = true
+			h.logger.Warn("storage is unavailable", zap.Error(err))
+		}
+		return 0, fmt.Errorf("failed to ping storage: %w", err)
+	}
+	if h.failing {
+		h.failing = false
+		h.logger.Info("storage is available again")
+	}
+	return 1, nil
+}
@@ -0,0 +1,40 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestHealthChecker(t *testing.T) {
+	var pingErr error
+	checker := worker.NewHealthChecker(
+		pingerFunc(func(_ context.Context) error { return pingErr }),
+		zap.NewNop(),
+	)
+
+	processed, err := checker.Handle(t.Context(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+
+	pingErr = errors.New("connection refused")
+	processed, err = checker.Handle(t.Context(), 1)
+	require.ErrorIs(t, err, pingErr)
+	assert.Zero(t, processed)
+
+	pingErr = nil
+	_, err = checker.Handle(t.Context(), 1)
+	require.NoError(t, err)
+}
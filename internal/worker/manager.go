@@ -0,0 +1,330 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Names of the job types run by the application.
+const (
+	DeleteJobType = "delete"
+	ClickJobType  = "clicks"
+	ExpiryJobType = "expiry_sweep"
+	PurgeJobType  = "retention_purge"
+	HealthJobType = "health_check"
+)
+
+const (
+	defaultPoolSize      = 4
+	defaultFlushInterval = 500 * time.Millisecond
+	defaultBatchSize     = 50
+)
+
+// errPanicked is returned for the runs of a handler that panicked.
+var errPanicked = errors.New("job panicked")
+
+// Handler processes the work of one job type.
+// Handle processes up to limit items and returns how many it processed. The Manager calls it again right away
+// while it returns full batches, so a backlog is not throttled by the flush interval.
+type Handler interface {
+	Handle(ctx context.Context, limit int) (int, error)
+}
+
+// HandlerFunc is an adapter to use an ordinary function as a Handler.
+type HandlerFunc func(ctx context.Context, limit int) (int, error)
+
+// Handle calls f(ctx, limit).
+func (f HandlerFunc) Handle(ctx context.Context, limit int) (int, error) {
+	return f(ctx, limit)
+}
+
+// Flusher is implemented by the handlers that buffer the items pushed to them, like the ClickWorker.
+// Register hands them the batch size of their job type and a function that runs the job type
+// without waiting for the flush interval, so a full batch is processed right away.
+type Flusher interface {
+	BindFlush(batchSize int, flush func())
+}
+
+// Metrics receives the events of the job types run by the Manager.
+// The methods are called from the worker goroutines and must be safe for concurrent use.
+type Metrics interface {
+	// ObserveRun is called after every run of a handler with the number of processed items.
+	ObserveRun(jobType string, processed int, elapsed time.Duration, err error)
+	// ObservePanic is called with the recovered value when a handler panics.
+	ObservePanic(jobType string, recovered any)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRun(string, int, time.Duration, error) {}
+
+func (nopMetrics) ObservePanic(string, any) {}
+
+// jobType is a registered job type, it is run by its own goroutine so its runs never overlap.
+type jobType struct {
+	handler       Handler
+	wake          chan struct{}
+	name          string
+	flushInterval time.Duration
+	batchSize     int
+	drain         bool
+}
+
+// Manager runs the registered job types in the background.
+// Every job type runs once per flush interval, the runs of all types share a bounded pool,
+// so a slow type cannot starve the storage of connections. Handler panics are recovered and reported
+// to the metrics as failed runs.
+type Manager struct {
+	logger  *zap.Logger
+	metrics Metrics
+	// ctx is passed to the handlers, it is canceled once the shutdown deadline passes.
+	ctx    context.Context
+	cancel context.CancelFunc
+	pool   chan struct{}
+	done   chan struct{}
+	types  map[string]*jobType
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewManager creates a new manager without job types.
+func NewManager(logger *zap.Logger, opts ...ManagerOption) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		logger:  logger.With(zap.String("component", "WorkerManager")),
+		metrics: nopMetrics{},
+		ctx:     ctx,
+		cancel:  cancel,
+		pool:    make(chan struct{}, defaultPoolSize),
+		done:    make(chan struct{}),
+		types:   make(map[string]*jobType),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// ManagerOption is a function that configures Manager.
+type ManagerOption func(*Manager)
+
+// WithPoolSize sets the number of handler runs going on at once across all job types.
+func WithPoolSize(size int) ManagerOption {
+	return func(m *Manager) {
+		m.pool = make(chan struct{}, max(size, 1))
+	}
+}
+
+// WithMetrics sets the metrics receiving the runs and panics of the handlers.
+func WithMetrics(metrics Metrics) ManagerOption {
+	return func(m *Manager) {
+		m.metrics = metrics
+	}
+}
+
+// TypeOption is a function that configures a job type.
+type TypeOption func(*jobType)
+
+// WithBatchSize sets the number of items a handler processes in one run.
+func WithBatchSize(size int) TypeOption {
+	return func(t *jobType) {
+		t.batchSize = max(size, 1)
+	}
+}
+
+// WithFlushInterval sets the interval between two runs of a handler.
+func WithFlushInterval(interval time.Duration) TypeOption {
+	return func(t *jobType) {
+		if interval > 0 {
+			t.flushInterval = interval
+		}
+	}
+}
+
+// WithDrainOnShutdown makes the shutdown run the handler until it finds nothing left to process.
+// Without it the pending work of the job type waits for the next start.
+func WithDrainOnShutdown() TypeOption {
+	return func(t *jobType) {
+		t.drain = true
+	}
+}
+
+// Register starts running the handler as the job type name.
+// A handler implementing io.Closer is closed once its job type stops.
+func (m *Manager) Register(name string, handler Handler, opts ...TypeOption) error {
+	if name == "" || handler == nil {
+		return errors.New("job type name and handler are required")
+	}
+	t := &jobType{
+		handler:       handler,
+		wake:          make(chan struct{}, 1),
+		name:          name,
+		flushInterval: defaultFlushInterval,
+		batchSize:     defaultBatchSize,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return fmt.Errorf("failed to register job type %q: manager is shut down", name)
+	}
+	if _, ok := m.types[name]; ok {
+		return fmt.Errorf("job type %q is already registered", name)
+	}
+	m.types[name] = t
+	if flusher, ok := handler.(Flusher); ok {
+		flusher.BindFlush(t.batchSize, func() {
+			select {
+			case t.wake <- struct{}{}:
+			default:
+			}
+		})
+	}
+
+	m.wg.Add(1)
+	go m.loop(t)
+	m.logger.Info("job type registered",
+		zap.String("job_type", name),
+		zap.Int("batch_size", t.batchSize),
+		zap.Duration("flush_interval", t.flushInterval))
+	return nil
+}
+
+// Shutdown stops all job types and waits for them, draining those registered with WithDrainOnShutdown.
+// Once ctx is done the running handlers are canceled and Shutdown returns without waiting any longer.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	m.mu.Unlock()
+
+	m.logger.Info("Shutting down workers")
+	close(m.done)
+	stopped := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		m.cancel()
+		m.logger.Info("Workers shutdown complete")
+		return nil
+	case <-ctx.Done():
+		m.cancel()
+		return fmt.Errorf("workers did not stop in time: %w", ctx.Err())
+	}
+}
+
+// loop runs the job type every flush interval and whenever it is flushed until the manager is shut down.
+func (m *Manager) loop(t *jobType) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.runBacklog(t)
+		case <-t.wake:
+			m.runBacklog(t)
+		case <-m.done:
+			if t.drain {
+				m.drain(t)
+			}
+			m.close(t)
+			return
+		}
+	}
+}
+
+// runBacklog runs the job type until a run fails or comes out short of a full batch.
+func (m *Manager) runBacklog(t *jobType) {
+	for {
+		processed, err := m.run(t)
+		if err != nil || processed < t.batchSize {
+			return
+		}
+		select {
+		case <-m.done:
+			return
+		default:
+		}
+	}
+}
+
+// drain runs the job type until a run fails or finds nothing to process.
+func (m *Manager) drain(t *jobType) {
+	for {
+		processed, err := m.run(t)
+		if err != nil || processed == 0 {
+			return
+		}
+	}
+}
+
+// run runs the handler of the job type once it gets a slot of the pool.
+func (m *Manager) run(t *jobType) (int, error) {
+	select {
+	case m.pool <- struct{}{}:
+	case <-m.ctx.Done():
+		return 0, m.ctx.Err()
+	}
+	defer func() { <-m.pool }()
+
+	started := time.Now()
+	processed, err := m.handle(t)
+	m.metrics.ObserveRun(t.name, processed, time.Since(started), err)
+	if err != nil {
+		m.logger.Error("job failed", zap.String("job_type", t.name), zap.Error(err))
+	}
+	return processed, err
+}
+
+// handle calls the handler of the job type, a panic is recovered and returned as an error.
+func (m *Manager) handle(t *jobType) (int, error) {
+	var (
+		processed int
+		err       error
+	)
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				m.metrics.ObservePanic(t.name, recovered)
+				m.logger.Error("job panicked",
+					zap.String("job_type", t.name),
+					zap.Any("panic", recovered),
+					zap.Stack("stack"))
+				processed, err = 0, fmt.Errorf("%w: %v", errPanicked, recovered)
+			}
+		}()
+		processed, err = t.handler.Handle(m.ctx, t.batchSize)
+	}()
+	return processed, err
+}
+
+// close closes the handler of the stopped job type if it is an io.Closer.
+func (m *Manager) close(t *jobType) {
+	closer, ok := t.handler.(io.Closer)
+	if !ok {
+		return
+	}
+	if err := closer.Close(); err != nil {
+		m.logger.Error("failed to close job type", zap.String("job_type", t.name), zap.Error(err))
+	}
+}
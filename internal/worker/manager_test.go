@@ -0,0 +1,203 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+// recordingMetrics counts the runs, failed runs and panics of every job type.
+type recordingMetrics struct {
+	runs   map[string]int
+	failed map[string]int
+	panics map[string]int
+	mu     sync.Mutex
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		runs:   make(map[string]int),
+		failed: make(map[string]int),
+		panics: make(map[string]int),
+	}
+}
+
+func (m *recordingMetrics) ObserveRun(jobType string, _ int, _ time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs[jobType]++
+	if err != nil {
+		m.failed[jobType]++
+	}
+}
+
+func (m *recordingMetrics) ObservePanic(jobType string, _ any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.panics[jobType]++
+}
+
+func (m *recordingMetrics) counts(jobType string) (int, int, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.runs[jobType], m.failed[jobType], m.panics[jobType]
+}
+
+// closerHandler counts its runs and reports whether it was closed.
+type closerHandler struct {
+	runs   atomic.Int64
+	closed atomic.Bool
+}
+
+func (h *closerHandler) Handle(_ context.Context, _ int) (int, error) {
+	h.runs.Add(1)
+	return 0, nil
+}
+
+func (h *closerHandler) Close() error {
+	h.closed.Store(true)
+	return nil
+}
+
+func TestManager_PanicRecovery(t *testing.T) {
+	metrics := newRecordingMetrics()
+	workers := worker.NewManager(zap.NewNop(), worker.WithMetrics(metrics))
+
+	var calls atomic.Int64
+	require.NoError(t, workers.Register("flaky", worker.HandlerFunc(func(_ context.Context, _ int) (int, error) {
+		switch calls.Add(1) {
+		case 1:
+			panic("boom")
+		case 2:
+			return 0, errors.New("connection refused")
+		default:
+			return 1, nil
+		}
+	}), worker.WithFlushInterval(5*time.Millisecond)))
+
+	// the job type keeps running after a panic and a failure
+	assert.Eventually(t, func() bool {
+		runs, _, _ := metrics.counts("flaky")
+		return runs >= 3
+	}, time.Second, 5*time.Millisecond)
+	require.NoError(t, workers.Shutdown(t.Context()))
+
+	_, failed, panics := metrics.counts("flaky")
+	assert.Equal(t, 2, failed)
+	assert.Equal(t, 1, panics)
+}
+
+func TestManager_Backlog(t *testing.T) {
+	workers := worker.NewManager(zap.NewNop())
+
+	var (
+		mu      sync.Mutex
+		pending = 5
+		batches []int
+	)
+	require.NoError(t, workers.Register("backlog", worker.HandlerFunc(func(_ context.Context, limit int) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		count := min(pending, limit)
+		pending -= count
+		batches = append(batches, count)
+		return count, nil
+	}), worker.WithBatchSize(2), worker.WithFlushInterval(time.Hour), worker.WithDrainOnShutdown()))
+
+	// the drain goes on until a run finds nothing left
+	require.NoError(t, workers.Shutdown(t.Context()))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int{2, 2, 1, 0}, batches)
+}
+
+func TestManager_PoolSize(t *testing.T) {
+	workers := worker.NewManager(zap.NewNop(), worker.WithPoolSize(1))
+
+	var running, peak, runs atomic.Int64
+	handler := worker.HandlerFunc(func(_ context.Context, _ int) (int, error) {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			seen := peak.Load()
+			if current <= seen || peak.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		runs.Add(1)
+		return 0, nil
+	})
+	for _, name := range []string{"first", "second", "third"} {
+		require.NoError(t, workers.Register(name, handler, worker.WithFlushInterval(time.Millisecond)))
+	}
+
+	assert.Eventually(t, func() bool { return runs.Load() >= 6 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, workers.Shutdown(t.Context()))
+	assert.Equal(t, int64(1), peak.Load())
+}
+
+func TestManager_Shutdown(t *testing.T) {
+	t.Run("closes the handlers", func(t *testing.T) {
+		workers := worker.NewManager(zap.NewNop())
+		handler := &closerHandler{}
+		require.NoError(t, workers.Register("closer", handler, worker.WithFlushInterval(5*time.Millisecond)))
+		assert.Eventually(t, func() bool { return handler.runs.Load() > 0 }, time.Second, 5*time.Millisecond)
+
+		require.NoError(t, workers.Shutdown(t.Context()))
+		assert.True(t, handler.closed.Load())
+		// a second shutdown is a no-op
+		require.NoError(t, workers.Shutdown(t.Context()))
+
+		err := workers.Register("late", handler)
+		require.Error(t, err)
+	})
+
+	t.Run("cancels the handlers after the deadline", func(t *testing.T) {
+		workers := worker.NewManager(zap.NewNop())
+		started := make(chan struct{})
+		canceled := make(chan struct{})
+		var once sync.Once
+		require.NoError(t, workers.Register("stuck", worker.HandlerFunc(func(ctx context.Context, _ int) (int, error) {
+			once.Do(func() {
+				close(started)
+				<-ctx.Done()
+				close(canceled)
+			})
+			return 0, ctx.Err()
+		}), worker.WithFlushInterval(time.Millisecond)))
+		<-started
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		err := workers.Shutdown(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("handler was not canceled")
+		}
+	})
+
+	t.Run("rejects invalid job types", func(t *testing.T) {
+		workers := worker.NewManager(zap.NewNop())
+		defer func() {
+			assert.NoError(t, workers.Shutdown(context.Background()))
+		}()
+		handler := &closerHandler{}
+
+		require.Error(t, workers.Register("", handler))
+		require.Error(t, workers.Register("nil", nil))
+		require.NoError(t, workers.Register("twice", handler))
+		require.Error(t, workers.Register("twice", handler))
+	})
+}
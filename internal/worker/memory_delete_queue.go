@@ -0,0 +1,180 @@
+package worker
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/AGENT3128/shortener-url/internal/entity"
+)
+
+// queuedTask is a task of the MemoryDeleteQueue with its lease.
+type queuedTask struct {
+	leasedUntil time.Time
+	task        entity.DeleteTask
+}
+
+// MemoryDeleteQueue is the in-process DeleteQueue.
+// Its tasks are lost when the process exits, it backs the memory storage whose URLs are lost as well,
+// and it keeps the queue of the file storage that records its changes in a spool.
+// Retrying or burying a task that is not queued is a no-op; acknowledging it still keeps its finished job.
+type MemoryDeleteQueue struct {
+	finished map[string]entity.Job
+	tasks    []queuedTask
+	dead     []entity.DeleteTask
+	seq      int64
+	mu       sync.Mutex
+}
+
+// NewMemoryDeleteQueue creates an empty MemoryDeleteQueue.
+func NewMemoryDeleteQueue() *MemoryDeleteQueue {
+	return &MemoryDeleteQueue{
+		finished: make(map[string]entity.Job),
+		tasks:    make([]queuedTask, 0),
+		dead:     make([]entity.DeleteTask, 0),
+	}
+}
+
+// Push appends the task to the queue.
+func (q *MemoryDeleteQueue) Push(_ context.Context, task entity.DeleteTask) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	task.Seq = q.seq
+	q.tasks = append(q.tasks, queuedTask{task: task})
+	return nil
+}
+
+// Claim leases up to limit tasks due at the moment now.
+// The tasks of a user that follow a leased task or a task waiting for its retry are skipped.
+func (q *MemoryDeleteQueue) Claim(
+	_ context.Context,
+	now time.Time,
+	limit int,
+	lease time.Duration,
+) ([]entity.DeleteTask, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	claimed := make([]entity.DeleteTask, 0, min(limit, len(q.tasks)))
+	blocked := make(map[string]struct{})
+	for i := range q.tasks {
+		if len(claimed) == limit {
+			break
+		}
+		queued := &q.tasks[i]
+		userID := queued.task.Job.UserID
+		if _, ok := blocked[userID]; ok {
+			continue
+		}
+		if queued.leasedUntil.After(now) || queued.task.NextAttemptAt.After(now) {
+			blocked[userID] = struct{}{}
+			continue
+		}
+		queued.leasedUntil = now.Add(lease)
+		claimed = append(claimed, queued.task)
+	}
+	return claimed, nil
+}
+
+// Ack removes the task of the job from the queue and keeps the finished job.
+func (q *MemoryDeleteQueue) Ack(_ context.Context, job entity.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.tasks = slices.DeleteFunc(q.tasks, func(queued queuedTask) bool { return queued.task.Job.ID == job.ID })
+	q.finished[job.ID] = job
+	return nil
+}
+
+// Retry stores the attempts, the next attempt time and the last error of the task and drops its lease.
+func (q *MemoryDeleteQueue) Retry(_ context.Context, task entity.DeleteTask) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if i := q.find(task.Job.ID); i >= 0 {
+		queued := &q.tasks[i]
+		queued.leasedUntil = time.Time{}
+		queued.task.Attempts = task.Attempts
+		queued.task.NextAttemptAt = task.NextAttemptAt
+		queued.task.LastError = task.LastError
+	}
+	return nil
+}
+
+// Bury moves the task to the dead letters.
+func (q *MemoryDeleteQueue) Bury(_ context.Context, task entity.DeleteTask) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.find(task.Job.ID)
+	if i < 0 {
+		return nil
+	}
+	dead := q.tasks[i].task
+	dead.Attempts = task.Attempts
+	dead.DeadAt = task.DeadAt
+	dead.LastError = task.LastError
+	q.tasks = slices.Delete(q.tasks, i, i+1)
+	q.dead = append(q.dead, dead)
+	return nil
+}
+
+// DeadLetters returns up to limit dead tasks, the latest first. A zero limit returns all of them.
+func (q *MemoryDeleteQueue) DeadLetters(_ context.Context, limit int) ([]entity.DeleteTask, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	dead := slices.Clone(q.dead)
+	slices.Reverse(dead)
+	if limit > 0 && len(dead) > limit {
+		dead = dead[:limit]
+	}
+	return dead, nil
+}
+
+// Job returns the job by its ID, entity.ErrJobNotFound if it is neither queued, finished nor dead.
+func (q *MemoryDeleteQueue) Job(_ context.Context, jobID string) (entity.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if i := q.find(jobID); i >= 0 {
+		return q.tasks[i].task.Job, nil
+	}
+	if job, ok := q.finished[jobID]; ok {
+		return job, nil
+	}
+	if i := slices.IndexFunc(q.dead, func(dead entity.DeleteTask) bool { return dead.Job.ID == jobID }); i >= 0 {
+		return q.dead[i].DeadJob(), nil
+	}
+	return entity.Job{}, entity.ErrJobNotFound
+}
+
+// Prune drops the jobs finished before finishedBefore and the dead tasks buried before deadBefore.
+func (q *MemoryDeleteQueue) Prune(_ context.Context, finishedBefore, deadBefore time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	maps.DeleteFunc(q.finished, func(_ string, job entity.Job) bool { return job.FinishedAt.Before(finishedBefore) })
+	q.dead = slices.DeleteFunc(q.dead, func(dead entity.DeleteTask) bool { return dead.DeadAt.Before(deadBefore) })
+	return nil
+}
+
+// Finished returns the finished jobs in no particular order.
+func (q *MemoryDeleteQueue) Finished() []entity.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Collect(maps.Values(q.finished))
+}
+
+// Queued returns the tasks waiting in the queue ordered by Seq, leased ones included.
+func (q *MemoryDeleteQueue) Queued() []entity.DeleteTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	tasks := make([]entity.DeleteTask, 0, len(q.tasks))
+	for _, queued := range q.tasks {
+		tasks = append(tasks, queued.task)
+	}
+	return tasks
+}
+
+// find returns the position of the task of the job, -1 if it is not queued. The caller must hold the lock.
+func (q *MemoryDeleteQueue) find(jobID string) int {
+	return slices.IndexFunc(q.tasks, func(queued queuedTask) bool { return queued.task.Job.ID == jobID })
+}
@@ -0,0 +1,14 @@
+package worker_test
+
+import (
+	"testing"
+
+	"github.com/AGENT3128/shortener-url/internal/repository/repositorytest"
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+func TestMemoryDeleteQueue_Contract(t *testing.T) {
+	repositorytest.RunDeleteQueue(t, func(_ *testing.T) worker.DeleteQueue {
+		return worker.NewMemoryDeleteQueue()
+	})
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultPurgeTimeout = 30 * time.Second
+
+// URLPurger describes the behavior for permanently removing deleted URLs in batches.
+type URLPurger interface {
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int64, error)
+}
+
+// RetentionPurger removes URLs that have been deleted for longer than the retention.
+// Run by the Manager, every pass removes the URLs batch by batch until a batch comes out short,
+// so the storage is never locked for the whole pass.
+type RetentionPurger struct {
+	repository URLPurger
+	logger     *zap.Logger
+	retention  time.Duration
+}
+
+// NewRetentionPurger creates a new purger of URLs deleted longer than retention ago.
+func NewRetentionPurger(repo URLPurger, logger *zap.Logger, retention time.Duration) *RetentionPurger {
+	return &RetentionPurger{
+		repository: repo,
+		logger:     logger.With(zap.String("component", "RetentionPurger")),
+		retention:  retention,
+	}
+}
+
+// Handle removes up to limit URLs deleted before the retention and returns their number.
+func (p *RetentionPurger) Handle(ctx context.Context, limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultPurgeTimeout)
+	defer cancel()
+
+	count, err := p.repository.PurgeDeleted(ctx, time.Now().Add(-p.retention), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted URLs: %w", err)
+	}
+	if count > 0 {
+		p.logger.Info("successfully purged deleted URLs", zap.Int64("count", count))
+	}
+	return int(count), nil
+}
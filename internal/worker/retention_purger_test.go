@@ -0,0 +1,63 @@
+package worker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/AGENT3128/shortener-url/internal/worker"
+)
+
+type purgerFunc func(ctx context.Context, deletedBefore time.Time, limit int) (int64, error)
+
+func (f purgerFunc) PurgeDeleted(ctx context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	return f(ctx, deletedBefore, limit)
+}
+
+func TestRetentionPurger(t *testing.T) {
+	const retention = time.Hour
+	var (
+		mu      sync.Mutex
+		batches []int
+		pending int64 = 5
+	)
+	started := time.Now()
+	purger := worker.NewRetentionPurger(
+		purgerFunc(func(_ context.Context, deletedBefore time.Time, limit int) (int64, error) {
+			assert.WithinDuration(t, started.Add(-retention), deletedBefore, time.Second)
+			mu.Lock()
+			defer mu.Unlock()
+			count := min(pending, int64(limit))
+			pending -= count
+			batches = append(batches, int(count))
+			return count, nil
+		}),
+		zap.NewNop(),
+		retention,
+	)
+	workers := worker.NewManager(zap.NewNop())
+	require.NoError(t, workers.Register(
+		worker.PurgeJobType,
+		purger,
+		worker.WithBatchSize(2),
+		worker.WithFlushInterval(10*time.Millisecond),
+	))
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(batches) >= 4
+	}, time.Second, 5*time.Millisecond)
+	require.NoError(t, workers.Shutdown(t.Context()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	// the first pass goes on while the batches come out full, the next one finds nothing left
+	require.GreaterOrEqual(t, len(batches), 4)
+	assert.Equal(t, []int{2, 2, 1, 0}, batches[:4])
+}
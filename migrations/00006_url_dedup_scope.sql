@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls
+    DROP CONSTRAINT IF EXISTS urls_original_url_key;
+
+-- the scope the URL was deduplicated in when it was shortened
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS dedup_scope TEXT NOT NULL DEFAULT 'global'
+        CHECK (dedup_scope IN ('global', 'user', 'none'));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_user_id_original_url
+    ON urls(user_id, original_url) WHERE dedup_scope <> 'none';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_urls_original_url_global
+    ON urls(original_url) WHERE dedup_scope = 'global';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_urls_original_url_global;
+DROP INDEX IF EXISTS idx_urls_user_id_original_url;
+
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS dedup_scope;
+
+ALTER TABLE urls
+    ADD CONSTRAINT urls_original_url_key UNIQUE (original_url);
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the outbox of the delete worker: a row lives until its job is processed or moved to the dead letters
+CREATE TABLE IF NOT EXISTS delete_queue (
+    id BIGSERIAL PRIMARY KEY,
+    job_id TEXT NOT NULL UNIQUE,
+    user_id TEXT NOT NULL,
+    operation TEXT NOT NULL CHECK (operation IN ('delete', 'restore')),
+    short_urls TEXT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    leased_until TIMESTAMP WITH TIME ZONE,
+    dead_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_delete_queue_next_attempt_at
+    ON delete_queue(next_attempt_at) WHERE dead_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_delete_queue_user_id
+    ON delete_queue(user_id, id) WHERE dead_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_delete_queue_dead_at
+    ON delete_queue(dead_at) WHERE dead_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS delete_queue;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the jobs of the acknowledged delete_queue rows, kept for the job retention so they can be looked up
+CREATE TABLE IF NOT EXISTS delete_jobs (
+    job_id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    operation TEXT NOT NULL CHECK (operation IN ('delete', 'restore')),
+    status TEXT NOT NULL,
+    short_urls TEXT[] NOT NULL,
+    failed_urls TEXT[],
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_delete_jobs_finished_at ON delete_jobs(finished_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS delete_jobs;
+-- +goose StatementEnd
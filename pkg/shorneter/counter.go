@@ -0,0 +1,41 @@
+package shorneter
+
+import (
+	"sync/atomic"
+)
+
+// CounterGenerator encodes an increasing counter in its alphabet.
+// The counter is not persisted: after a restart the caller seeds it with WithCounterStart
+// past the values already issued, or its IDs collide with the stored ones.
+type CounterGenerator struct {
+	alphabet string
+	counter  atomic.Uint64
+}
+
+func newCounterGenerator(alphabet string, start uint64) *CounterGenerator {
+	g := &CounterGenerator{alphabet: alphabet}
+	g.counter.Store(start)
+	return g
+}
+
+// Generate returns the next counter value left-padded to the given length.
+func (g *CounterGenerator) Generate(length int) (string, error) {
+	return encode(g.alphabet, g.counter.Add(1)-1, length), nil
+}
+
+// encode encodes n in the alphabet left-padded with its first character to the given length.
+func encode(alphabet string, n uint64, length int) string {
+	base := uint64(len(alphabet))
+	digits := make([]byte, 0, length)
+	for n > 0 {
+		digits = append(digits, alphabet[n%base])
+		n /= base
+	}
+	for len(digits) < length {
+		digits = append(digits, alphabet[0])
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
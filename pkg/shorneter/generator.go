@@ -0,0 +1,136 @@
+package shorneter
+
+import (
+	"errors"
+	"fmt"
+)
+
+const minAlphabetLength = 2
+
+// HumanAlphabet is the alphabet without the look-alike characters 0/O/o, 1/l/I.
+const HumanAlphabet = "23456789abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// Generator generates short IDs.
+type Generator interface {
+	// Generate returns a short ID of at least the given length.
+	Generate(length int) (string, error)
+}
+
+// Strategy is the short ID generation strategy.
+type Strategy string
+
+// Available strategies.
+const (
+	// StrategyRandom generates uniformly random IDs.
+	StrategyRandom Strategy = "random"
+	// StrategyCounter encodes an increasing counter.
+	StrategyCounter Strategy = "counter"
+	// StrategyHashids encodes an increasing counter obfuscated by a salt, reversible with Decode.
+	StrategyHashids Strategy = "hashids"
+	// StrategyHuman generates random IDs from HumanAlphabet.
+	StrategyHuman Strategy = "human"
+)
+
+type options struct {
+	alphabet string
+	salt     string
+	start    uint64
+}
+
+// Option is the option for the generator.
+type Option func(options *options) error
+
+// WithAlphabet is the option for the generator to set the alphabet.
+// The alphabet must hold at least 2 distinct characters allowed unescaped in a URL path.
+func WithAlphabet(alphabet string) Option {
+	return func(options *options) error {
+		if err := validateAlphabet(alphabet); err != nil {
+			return err
+		}
+		options.alphabet = alphabet
+		return nil
+	}
+}
+
+// WithSalt is the option for the hashids generator to set the salt.
+func WithSalt(salt string) Option {
+	return func(options *options) error {
+		options.salt = salt
+		return nil
+	}
+}
+
+// WithCounterStart is the option for the counter based generators to set the first counter value.
+func WithCounterStart(start uint64) Option {
+	return func(options *options) error {
+		options.start = start
+		return nil
+	}
+}
+
+// NewGenerator creates a generator of the strategy.
+// The alphabet defaults to CHARSET, or HumanAlphabet for StrategyHuman.
+func NewGenerator(strategy Strategy, opts ...Option) (Generator, error) {
+	options := &options{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.alphabet == "" {
+		options.alphabet = CHARSET
+		if strategy == StrategyHuman {
+			options.alphabet = HumanAlphabet
+		}
+	}
+
+	switch strategy {
+	case StrategyRandom, StrategyHuman:
+		return &RandomGenerator{alphabet: options.alphabet}, nil
+	case StrategyCounter:
+		return newCounterGenerator(options.alphabet, options.start), nil
+	case StrategyHashids:
+		return newHashidsGenerator(options.alphabet, options.salt, options.start), nil
+	default:
+		return nil, fmt.Errorf("unknown generator strategy %q", strategy)
+	}
+}
+
+func validateAlphabet(alphabet string) error {
+	if len(alphabet) < minAlphabetLength {
+		return errors.New("alphabet must hold at least 2 characters")
+	}
+	seen := make(map[byte]struct{}, len(alphabet))
+	for i := range len(alphabet) {
+		c := alphabet[i]
+		if !isUnreserved(c) {
+			return fmt.Errorf("alphabet character %q is not allowed in a URL path", c)
+		}
+		if _, ok := seen[c]; ok {
+			return fmt.Errorf("alphabet repeats %q", c)
+		}
+		seen[c] = struct{}{}
+	}
+	return nil
+}
+
+// isUnreserved reports whether c may appear in a URL path without escaping.
+// The dot is left out so that no ID resolves to a relative path segment.
+func isUnreserved(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	default:
+		return c == '-' || c == '_' || c == '~'
+	}
+}
+
+// RandomGenerator generates uniformly random short IDs from its alphabet.
+type RandomGenerator struct {
+	alphabet string
+}
+
+// Generate returns a random short ID of the given length.
+func (g *RandomGenerator) Generate(length int) (string, error) {
+	return randomString(g.alphabet, length)
+}
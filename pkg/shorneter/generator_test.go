@@ -0,0 +1,124 @@
+package shorneter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AGENT3128/shortener-url/pkg/shorneter"
+)
+
+func TestNewGenerator(t *testing.T) {
+	strategies := []shorneter.Strategy{
+		shorneter.StrategyRandom,
+		shorneter.StrategyCounter,
+		shorneter.StrategyHashids,
+		shorneter.StrategyHuman,
+	}
+	for _, strategy := range strategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			generator, err := shorneter.NewGenerator(strategy)
+			require.NoError(t, err)
+
+			ids := make(map[string]struct{})
+			for range 1000 {
+				id, errGenerate := generator.Generate(shorneter.LENGTH)
+				require.NoError(t, errGenerate)
+				require.Len(t, id, shorneter.LENGTH)
+				assert.NotContains(t, ids, id)
+				ids[id] = struct{}{}
+			}
+		})
+	}
+
+	_, err := shorneter.NewGenerator("unknown")
+	require.Error(t, err)
+}
+
+func TestWithAlphabet(t *testing.T) {
+	for _, alphabet := range []string{"", "a", "abca", "ab/c", "ab.c", "abcé"} {
+		_, err := shorneter.NewGenerator(shorneter.StrategyRandom, shorneter.WithAlphabet(alphabet))
+		require.Error(t, err, alphabet)
+	}
+
+	generator, err := shorneter.NewGenerator(shorneter.StrategyRandom, shorneter.WithAlphabet("ab"))
+	require.NoError(t, err)
+	id, err := generator.Generate(32)
+	require.NoError(t, err)
+	assert.Empty(t, strings.Trim(id, "ab"))
+}
+
+func TestHumanGenerator(t *testing.T) {
+	generator, err := shorneter.NewGenerator(shorneter.StrategyHuman)
+	require.NoError(t, err)
+
+	for range 100 {
+		id, errGenerate := generator.Generate(shorneter.LENGTH)
+		require.NoError(t, errGenerate)
+		assert.False(t, strings.ContainsAny(id, "0Oo1lI"), id)
+	}
+}
+
+func TestCounterGenerator(t *testing.T) {
+	generator, err := shorneter.NewGenerator(
+		shorneter.StrategyCounter,
+		shorneter.WithAlphabet("0123456789"),
+		shorneter.WithCounterStart(99),
+	)
+	require.NoError(t, err)
+
+	for _, want := range []string{"0099", "0100", "0101"} {
+		id, errGenerate := generator.Generate(4)
+		require.NoError(t, errGenerate)
+		assert.Equal(t, want, id)
+	}
+	// the counter outgrows the length
+	id, err := generator.Generate(2)
+	require.NoError(t, err)
+	assert.Equal(t, "102", id)
+}
+
+func TestHashidsGenerator(t *testing.T) {
+	generator, err := shorneter.NewGenerator(
+		shorneter.StrategyHashids,
+		shorneter.WithSalt("salt"),
+		shorneter.WithCounterStart(1000),
+	)
+	require.NoError(t, err)
+	hashids, ok := generator.(*shorneter.HashidsGenerator)
+	require.True(t, ok)
+
+	previous, first := "", ""
+	for want := uint64(1000); want < 1100; want++ {
+		id, errGenerate := hashids.Generate(shorneter.LENGTH)
+		require.NoError(t, errGenerate)
+		require.Len(t, id, shorneter.LENGTH)
+		// consecutive IDs share no common prefix the way plain counters do
+		assert.NotEqual(t, previous[:min(len(previous), 4)], id[:4])
+		previous = id
+		if first == "" {
+			first = id
+		}
+
+		got, errDecode := hashids.Decode(id)
+		require.NoError(t, errDecode)
+		assert.Equal(t, want, got)
+	}
+
+	_, err = hashids.Decode("")
+	require.ErrorIs(t, err, shorneter.ErrInvalidID)
+	_, err = hashids.Decode("!!!")
+	require.ErrorIs(t, err, shorneter.ErrInvalidID)
+
+	other, err := shorneter.NewGenerator(
+		shorneter.StrategyHashids,
+		shorneter.WithSalt("other"),
+		shorneter.WithCounterStart(1000),
+	)
+	require.NoError(t, err)
+	id, err := other.Generate(shorneter.LENGTH)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, id)
+}
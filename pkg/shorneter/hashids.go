@@ -0,0 +1,123 @@
+package shorneter
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/big"
+	"math/bits"
+	"strings"
+	"sync/atomic"
+)
+
+const offsetRotation = 32
+
+// ErrInvalidID is returned when an ID was not produced by the generator.
+var ErrInvalidID = errors.New("invalid short id")
+
+// HashidsGenerator encodes an increasing counter so that consecutive IDs do not look sequential.
+// The counter is permuted by an affine map over all IDs of the requested length and written
+// with the alphabet shuffled by the salt, so Decode recovers the counter value from an ID.
+type HashidsGenerator struct {
+	alphabet   string
+	multiplier uint64
+	offset     uint64
+	counter    atomic.Uint64
+}
+
+func newHashidsGenerator(alphabet, salt string, start uint64) *HashidsGenerator {
+	hash := fnv.New64a()
+	hash.Write([]byte(salt))
+	sum := hash.Sum64()
+
+	g := &HashidsGenerator{
+		alphabet:   shuffle(alphabet, salt),
+		multiplier: sum | 1,
+		offset:     bits.RotateLeft64(sum, offsetRotation),
+	}
+	g.counter.Store(start)
+	return g
+}
+
+// Generate returns the obfuscated next counter value.
+// The ID is longer than length only once the counter no longer fits into length characters.
+func (g *HashidsGenerator) Generate(length int) (string, error) {
+	n := new(big.Int).SetUint64(g.counter.Add(1) - 1)
+	length = max(length, len(encode(g.alphabet, n.Uint64(), 0)))
+
+	space, multiplier, offset := g.permutation(length)
+	// x = (multiplier * n + offset) mod space
+	x := n.Mul(n, multiplier)
+	x.Add(x, offset).Mod(x, space)
+	return g.format(x, length), nil
+}
+
+// Decode returns the counter value encoded in the ID.
+func (g *HashidsGenerator) Decode(id string) (uint64, error) {
+	if id == "" {
+		return 0, ErrInvalidID
+	}
+	base := big.NewInt(int64(len(g.alphabet)))
+	x := new(big.Int)
+	for _, c := range []byte(id) {
+		digit := strings.IndexByte(g.alphabet, c)
+		if digit < 0 {
+			return 0, ErrInvalidID
+		}
+		x.Mul(x, base).Add(x, big.NewInt(int64(digit)))
+	}
+
+	space, multiplier, offset := g.permutation(len(id))
+	// n = multiplier^-1 * (x - offset) mod space
+	inverse := new(big.Int).ModInverse(multiplier, space)
+	n := x.Sub(x, offset)
+	n.Mul(n, inverse).Mod(n, space)
+	if !n.IsUint64() {
+		return 0, ErrInvalidID
+	}
+	return n.Uint64(), nil
+}
+
+// permutation returns the number of IDs of the length and the coefficients of the affine map over them.
+// The multiplier is coprime with the space, so the map is a bijection.
+func (g *HashidsGenerator) permutation(length int) (*big.Int, *big.Int, *big.Int) {
+	base := big.NewInt(int64(len(g.alphabet)))
+	space := new(big.Int).Exp(base, big.NewInt(int64(length)), nil)
+
+	multiplier := new(big.Int).SetUint64(g.multiplier)
+	multiplier.Mod(multiplier, space)
+	one := big.NewInt(1)
+	for new(big.Int).GCD(nil, nil, multiplier, space).Cmp(one) != 0 {
+		multiplier.Add(multiplier, one).Mod(multiplier, space)
+	}
+
+	offset := new(big.Int).SetUint64(g.offset)
+	offset.Mod(offset, space)
+	return space, multiplier, offset
+}
+
+// format writes x in the alphabet left-padded to the length.
+func (g *HashidsGenerator) format(x *big.Int, length int) string {
+	base := big.NewInt(int64(len(g.alphabet)))
+	digits := make([]byte, length)
+	digit := new(big.Int)
+	for i := length - 1; i >= 0; i-- {
+		x.DivMod(x, base, digit)
+		digits[i] = g.alphabet[digit.Int64()]
+	}
+	return string(digits)
+}
+
+// shuffle reorders the alphabet by the salt the way hashids does, so different salts give different IDs.
+func shuffle(alphabet, salt string) string {
+	if salt == "" {
+		return alphabet
+	}
+	shuffled := []byte(alphabet)
+	for i, v, p := len(shuffled)-1, 0, 0; i > 0; i, v = i-1, v+1 {
+		v %= len(salt)
+		p += int(salt[v])
+		j := (int(salt[v]) + v + p) % i
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	return string(shuffled)
+}
@@ -31,33 +31,38 @@ func GenerateShortID() (string, error) {
 
 // GenerateShortIDOptimized generates a short ID optimized for performance.
 func GenerateShortIDOptimized() (string, error) {
-	result := make([]byte, LENGTH)
-	randomBytes := make([]byte, LENGTH)
+	return randomString(CHARSET, LENGTH)
+}
+
+// randomString generates a uniformly random string of the given length from the alphabet.
+func randomString(alphabet string, length int) (string, error) {
+	result := make([]byte, length)
+	randomBytes := make([]byte, length)
 
 	// Read all random bytes
 	if _, err := rand.Read(randomBytes); err != nil {
 		return "", err
 	}
 
-	charsetLen := byte(len(CHARSET))
-	for i := range LENGTH {
-		// Ensure uniform distribution by rejecting bytes that would create modulo bias
-		// Maximum value that can be used without bias
-		const maxByteValue = 256
-		maxAcceptable := maxByteValue - (maxByteValue % uint16(charsetLen))
-		b := randomBytes[i]
+	alphabetLen := len(alphabet)
+	// Ensure uniform distribution by rejecting bytes that would create modulo bias
+	// Maximum value that can be used without bias
+	const maxByteValue = 256
+	maxAcceptable := maxByteValue - (maxByteValue % alphabetLen)
+	for i := range length {
+		b := int(randomBytes[i])
 
 		// Reject values that would create bias
-		for b >= byte(maxAcceptable) {
+		for b >= maxAcceptable {
 			newByte := make([]byte, 1)
 			if _, err := rand.Read(newByte); err != nil {
 				return "", err
 			}
-			b = newByte[0]
+			b = int(newByte[0])
 		}
 
-		// Map the random byte to our charset
-		result[i] = CHARSET[b%charsetLen]
+		// Map the random byte to our alphabet
+		result[i] = alphabet[b%alphabetLen]
 	}
 
 	return string(result), nil